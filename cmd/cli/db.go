@@ -164,12 +164,39 @@ func confirmAction(c *console.Console, message string) bool {
 func dropAllTables(db *gorm.DB) error {
 	// Drop tables in correct order (due to foreign key constraints)
 	return db.Migrator().DropTable(
+		&models.NotificationMute{},
+		&models.Notification{},
+		&models.CommentMention{},
+		&models.CommentReport{},
+		&models.CommentRevision{},
 		&models.Comment{},
 		&models.Attendee{},
 		&models.Profile{},
 		&models.Event{},
+		&models.CouponRedemption{},
+		&models.PaymentEvent{},
+		&models.Payment{},
+		&models.OrderStatusChange{},
+		&models.OrderItem{},
+		&models.Order{},
+		&models.MediaVariant{},
+		&models.Media{},
+		&models.ProductImport{},
+		&models.ProductView{},
+		&models.ProductPriceChange{},
+		&models.ProductPriceSchedule{},
+		&models.InventoryAdjustment{},
+		&models.Review{},
+		&models.WishlistItem{},
+		&models.Wishlist{},
+		&models.StockReservation{},
 		&models.BasketItem{},
 		&models.Basket{},
+		"coupon_products",
+		"coupon_categories",
+		&models.Coupon{},
+		&models.ProductVariant{},
+		&models.ProductOption{},
 		&models.Product{},
 		&models.Category{},
 		&models.User{},
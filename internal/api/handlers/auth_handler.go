@@ -40,15 +40,16 @@ type UpdatePasswordRequest struct {
 
 // Login handles user authentication
 // @Summary      User login
-// @Description  Authenticate user and return JWT token
+// @Description  Authenticate user and return JWT token. A guest basket sent with the request is merged into the user's basket.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
-// @Param        credentials  body      LoginRequest   true  "Login credentials"
-// @Success      200          {object}  LoginResponse
-// @Failure      400          {object}  helpers.ErrorResponse
-// @Failure      401          {object}  helpers.ErrorResponse
-// @Failure      500          {object}  helpers.ErrorResponse
+// @Param        X-Basket-Token  header    string        false  "Guest basket token"
+// @Param        credentials     body      LoginRequest  true   "Login credentials"
+// @Success      200             {object}  LoginResponse
+// @Failure      400             {object}  helpers.ErrorResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
 // @Router       /api/v1/auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -101,6 +102,9 @@ func (h *Handler) Login(c *gin.Context) {
 	// Update last login time
 	_ = h.Repos.Users.UpdateLastLogin(ctx, user.ID)
 
+	// Carry over anything the user added to a basket before signing in
+	h.mergeGuestBasket(c, user.ID)
+
 	// Don't expose password in response
 	user.Password = ""
 
@@ -123,14 +127,15 @@ func (h *Handler) Logout(c *gin.Context) {
 
 // Register handles user registration
 // @Summary      User registration
-// @Description  Register a new user account
+// @Description  Register a new user account. A guest basket sent with the request becomes the user's basket.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
-// @Param        user  body      RegisterRequest  true  "User registration details"
-// @Success      201   {object}  models.User
-// @Failure      400   {object}  helpers.ErrorResponse
-// @Failure      500   {object}  helpers.ErrorResponse
+// @Param        X-Basket-Token  header    string           false  "Guest basket token"
+// @Param        user            body      RegisterRequest  true   "User registration details"
+// @Success      201             {object}  models.User
+// @Failure      400             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
 // @Router       /api/v1/auth/register [post]
 func (h *Handler) Register(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -174,6 +179,8 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	h.mergeGuestBasket(c, createdUser.ID)
+
 	// Don't expose password in response
 	createdUser.Password = ""
 
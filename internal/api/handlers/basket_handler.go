@@ -1,85 +1,95 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	"github.com/alireza-akbarzadeh/ginflow/internal/baskettoken"
 	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
 	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
 	"github.com/gin-gonic/gin"
 )
 
-// GetBasket retrieves the current user's basket
-// @Summary      Get user basket
-// @Description  Get the active basket for the authenticated user
+const (
+	// BasketTokenHeader carries the token of a guest basket
+	BasketTokenHeader = "X-Basket-Token"
+	// basketTokenCookie carries the token of a guest basket for browsers
+	basketTokenCookie = "basket_token"
+)
+
+// BasketResponse is a basket with its price breakdown. CouponError explains why
+// the basket's coupon is no longer taken into account and PriceChanged tells
+// whether any item was re-priced since it was added.
+type BasketResponse struct {
+	*models.Basket
+	Totals       *pricing.Totals `json:"totals"`
+	CouponError  string          `json:"couponError,omitempty"`
+	PriceChanged bool            `json:"priceChanged"`
+}
+
+// GetBasket retrieves the current basket
+// @Summary      Get basket
+// @Description  Get the active basket of the authenticated user, or of the guest identified by the X-Basket-Token header or basket_token cookie, with its subtotal, discounts, tax and total. Tax follows the country in the user's profile. Items whose product price changed are re-priced and flagged with priceChanged.
 // @Tags         Basket
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  models.Basket
-// @Failure      401  {object}  helpers.ErrorResponse
-// @Failure      500  {object}  helpers.ErrorResponse
+// @Param        X-Basket-Token  header    string  false  "Guest basket token"
+// @Success      200             {object}  BasketResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
 // @Security     BearerAuth
 // @Router       /api/v1/basket [get]
 func (h *Handler) GetBasket(c *gin.Context) {
-	ctx := c.Request.Context()
-
 	user := helpers.GetUserFromContext(c)
-	if user == nil {
-		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrUnauthorized, "Authentication required"), "Authentication required")
-		return
-	}
 
-	basket, err := h.Repos.Baskets.GetActiveBasket(ctx, user.ID)
-	if err != nil {
-		logging.Error(ctx, "Failed to retrieve basket", err, "userID", user.ID)
-		helpers.HandleError(c, err, "Failed to retrieve basket")
+	// Guests get a basket once they add to it
+	basket, ok := h.currentBasket(c, user, user != nil)
+	if !ok {
 		return
 	}
 
-	if basket == nil {
-		// Create a new basket if none exists
-		basket = &models.Basket{
-			UserID: &user.ID,
-			Status: "active",
-		}
-		if err := h.Repos.Baskets.CreateBasket(ctx, basket); err != nil {
-			logging.Error(ctx, "Failed to create basket", err, "userID", user.ID)
-			helpers.HandleError(c, err, "Failed to create basket")
-			return
-		}
-	}
-
-	c.JSON(http.StatusOK, basket)
+	h.respondWithBasket(c, basket, user)
 }
 
+// AddItemRequest represents the add to basket payload. VariantID is required
+// for products sold in variants.
 type AddItemRequest struct {
-	ProductID int `json:"productId" binding:"required"`
-	Quantity  int `json:"quantity" binding:"required,min=1"`
+	ProductID int  `json:"productId" binding:"required"`
+	VariantID *int `json:"variantId"`
+	Quantity  int  `json:"quantity" binding:"required,min=1"`
+}
+
+// UpdateItemRequest represents the basket item update payload. A quantity of
+// zero removes the item.
+type UpdateItemRequest struct {
+	Quantity *int `json:"quantity" binding:"required,min=0"`
 }
 
 // AddItemToBasket adds an item to the basket
 // @Summary      Add item to basket
-// @Description  Add a product to the user's active basket
+// @Description  Add a product, or one of its variants, to the active basket. Products sold in variants need the variant chosen. The item's stock is reserved for a limited time; products without enough available stock are rejected. Guests without a basket get one, and its token in the X-Basket-Token header and basket_token cookie.
 // @Tags         Basket
 // @Accept       json
 // @Produce      json
-// @Param        item  body      AddItemRequest  true  "Item to add"
-// @Success      200   {object}  models.Basket
-// @Failure      400   {object}  helpers.ErrorResponse
-// @Failure      401   {object}  helpers.ErrorResponse
-// @Failure      404   {object}  helpers.ErrorResponse
-// @Failure      500   {object}  helpers.ErrorResponse
+// @Param        X-Basket-Token  header    string          false  "Guest basket token"
+// @Param        item            body      AddItemRequest  true   "Item to add"
+// @Success      200             {object}  BasketResponse
+// @Failure      400             {object}  helpers.ErrorResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      404             {object}  helpers.ErrorResponse
+// @Failure      409             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
 // @Security     BearerAuth
 // @Router       /api/v1/basket/items [post]
 func (h *Handler) AddItemToBasket(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	user := helpers.GetUserFromContext(c)
-	if user == nil {
-		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrUnauthorized, "Authentication required"), "Authentication required")
-		return
-	}
 
 	var req AddItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -87,64 +97,84 @@ func (h *Handler) AddItemToBasket(c *gin.Context) {
 		return
 	}
 
-	// Get or create basket
-	basket, err := h.Repos.Baskets.GetActiveBasket(ctx, user.ID)
-	if err != nil {
-		logging.Error(ctx, "Failed to retrieve basket", err, "userID", user.ID)
-		helpers.HandleError(c, err, "Failed to retrieve basket")
+	basket, ok := h.addToBasket(c, user, req.ProductID, req.VariantID, req.Quantity)
+	if !ok {
 		return
 	}
-	if basket == nil {
-		basket = &models.Basket{
-			UserID: &user.ID,
-			Status: "active",
-		}
-		if err := h.Repos.Baskets.CreateBasket(ctx, basket); err != nil {
-			logging.Error(ctx, "Failed to create basket", err, "userID", user.ID)
-			helpers.HandleError(c, err, "Failed to create basket")
-			return
-		}
+
+	// Return updated basket
+	h.respondWithBasket(c, h.reloadBasket(ctx, basket, user), user)
+}
+
+// addToBasket adds a product, or one of its variants, at its current price to
+// the current basket, creating the basket when needed. It sends the error
+// response and returns false on failure.
+func (h *Handler) addToBasket(c *gin.Context, user *models.User, productID int, variantID *int, quantity int) (*models.Basket, bool) {
+	ctx := c.Request.Context()
+
+	// Get or create basket
+	basket, ok := h.currentBasket(c, user, true)
+	if !ok {
+		return nil, false
 	}
 
 	// Get product to check price and existence
-	product, err := h.Repos.Products.Get(ctx, req.ProductID)
+	product, err := h.Repos.Products.Get(ctx, productID)
 	if err != nil {
-		logging.Error(ctx, "Failed to retrieve product", err, "productID", req.ProductID)
+		logging.Error(ctx, "Failed to retrieve product", err, "productID", productID)
 		helpers.HandleError(c, err, "Failed to retrieve product")
-		return
+		return nil, false
 	}
 	if product == nil {
 		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrNotFound, "Product not found"), "Product not found")
-		return
+		return nil, false
+	}
+
+	var variant *models.ProductVariant
+	switch {
+	case variantID != nil:
+		variant = product.Variant(*variantID)
+		if variant == nil {
+			helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "variant with ID %d of %s not found", *variantID, product.Name), "")
+			return nil, false
+		}
+	case product.HasVariants():
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrInvalidInput, "Choose a variant of %s", product.Name).
+			WithDetail("productId", product.ID), "")
+		return nil, false
 	}
 
 	item := &models.BasketItem{
-		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
-		UnitPrice: product.Price, // Use current price
+		ProductID:    productID,
+		VariantID:    variantID,
+		Quantity:     quantity,
+		UnitPrice:    product.VariantPrice(variant), // Use current price
+		UnitDiscount: product.Discount,
+		Currency:     product.Currency,
 	}
 
-	if err := h.Repos.Baskets.AddItem(ctx, basket.ID, item); err != nil {
-		logging.Error(ctx, "Failed to add item to basket", err, "basketID", basket.ID, "productID", req.ProductID)
+	if err := h.Repos.Baskets.AddItem(ctx, basket.ID, item, time.Now().Add(h.StockReservationTTL)); err != nil {
+		logging.Error(ctx, "Failed to add item to basket", err, "basketID", basket.ID, "productID", productID)
 		helpers.HandleError(c, err, "Failed to add item to basket")
-		return
+		return nil, false
 	}
 
-	// Return updated basket
-	updatedBasket, _ := h.Repos.Baskets.GetActiveBasket(ctx, user.ID)
-	c.JSON(http.StatusOK, updatedBasket)
+	return basket, true
 }
 
 // RemoveItemFromBasket removes an item from the basket
 // @Summary      Remove item from basket
-// @Description  Remove an item from the basket by Item ID
+// @Description  Remove an item from the active basket by Item ID and release its reserved stock
 // @Tags         Basket
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Item ID"
-// @Success      200  {object}  models.Basket
-// @Failure      401  {object}  helpers.ErrorResponse
-// @Failure      500  {object}  helpers.ErrorResponse
+// @Param        X-Basket-Token  header    string  false  "Guest basket token"
+// @Param        id              path      int     true   "Item ID"
+// @Success      200             {object}  BasketResponse
+// @Failure      400             {object}  helpers.ErrorResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      404             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
 // @Security     BearerAuth
 // @Router       /api/v1/basket/items/{id} [delete]
 func (h *Handler) RemoveItemFromBasket(c *gin.Context) {
@@ -152,51 +182,99 @@ func (h *Handler) RemoveItemFromBasket(c *gin.Context) {
 
 	itemID, err := helpers.ParseIDParam(c, "id")
 	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid item ID")
 		return
 	}
 
 	user := helpers.GetUserFromContext(c)
-	if user == nil {
-		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrUnauthorized, "Authentication required"), "Authentication required")
+
+	basket, ok := h.currentBasket(c, user, false)
+	if !ok {
+		return
+	}
+	if basket == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID), "")
 		return
 	}
 
-	// Verify ownership (optional but good practice, though item ID is unique)
-	// For simplicity, just delete
-	if err := h.Repos.Baskets.RemoveItem(ctx, itemID); err != nil {
+	if err := h.Repos.Baskets.RemoveItem(ctx, basket.ID, itemID); err != nil {
 		logging.Error(ctx, "Failed to remove item from basket", err, "itemID", itemID)
 		helpers.HandleError(c, err, "Failed to remove item")
 		return
 	}
 
-	updatedBasket, _ := h.Repos.Baskets.GetActiveBasket(ctx, user.ID)
-	c.JSON(http.StatusOK, updatedBasket)
+	h.respondWithBasket(c, h.reloadBasket(ctx, basket, user), user)
+}
+
+// UpdateBasketItem changes the quantity of a basket item
+// @Summary      Update basket item
+// @Description  Set the quantity of an item in the active basket, reserving or releasing stock. A quantity of zero removes the item.
+// @Tags         Basket
+// @Accept       json
+// @Produce      json
+// @Param        X-Basket-Token  header    string             false  "Guest basket token"
+// @Param        id              path      int                true   "Item ID"
+// @Param        item            body      UpdateItemRequest  true   "New quantity"
+// @Success      200             {object}  BasketResponse
+// @Failure      400             {object}  helpers.ErrorResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      404             {object}  helpers.ErrorResponse
+// @Failure      409             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/basket/items/{id} [patch]
+func (h *Handler) UpdateBasketItem(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	itemID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid item ID")
+		return
+	}
+
+	var req UpdateItemRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user := helpers.GetUserFromContext(c)
+
+	basket, ok := h.currentBasket(c, user, false)
+	if !ok {
+		return
+	}
+	if basket == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID), "")
+		return
+	}
+
+	err = h.Repos.Baskets.UpdateItemQuantity(ctx, basket.ID, itemID, *req.Quantity, time.Now().Add(h.StockReservationTTL))
+	if helpers.HandleError(c, err, "Failed to update basket item") {
+		return
+	}
+
+	h.respondWithBasket(c, h.reloadBasket(ctx, basket, user), user)
 }
 
 // ClearBasket clears the basket
 // @Summary      Clear basket
-// @Description  Remove all items from the active basket
+// @Description  Remove all items from the active basket and release their reserved stock
 // @Tags         Basket
 // @Accept       json
 // @Produce      json
-// @Success      204  {object}  nil
-// @Failure      401  {object}  helpers.ErrorResponse
-// @Failure      500  {object}  helpers.ErrorResponse
+// @Param        X-Basket-Token  header    string  false  "Guest basket token"
+// @Success      200             {object}  BasketResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
 // @Security     BearerAuth
 // @Router       /api/v1/basket [delete]
 func (h *Handler) ClearBasket(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	user := helpers.GetUserFromContext(c)
-	if user == nil {
-		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrUnauthorized, "Authentication required"), "Authentication required")
-		return
-	}
 
-	basket, err := h.Repos.Baskets.GetActiveBasket(ctx, user.ID)
-	if err != nil {
-		logging.Error(ctx, "Failed to retrieve basket", err, "userID", user.ID)
-		helpers.HandleError(c, err, "Failed to retrieve basket")
+	basket, ok := h.currentBasket(c, user, false)
+	if !ok {
 		return
 	}
 
@@ -206,7 +284,186 @@ func (h *Handler) ClearBasket(c *gin.Context) {
 			helpers.HandleError(c, err, "Failed to clear basket")
 			return
 		}
+		basket.Items = nil
+	}
+
+	h.respondWithBasket(c, basket, user)
+}
+
+// respondWithBasket sends a basket with its totals for the user's country.
+// Guests, with a nil user, pay the default tax rate.
+func (h *Handler) respondWithBasket(c *gin.Context, basket *models.Basket, user *models.User) {
+	ctx := c.Request.Context()
+
+	if basket == nil {
+		basket = &models.Basket{Status: models.BasketStatusActive}
+		if user != nil {
+			basket.UserID = &user.ID
+		}
+	}
+
+	var country string
+	if user != nil {
+		var err error
+		country, err = h.buyerCountry(ctx, user.ID)
+		if helpers.HandleError(c, err, "Failed to retrieve profile") {
+			return
+		}
+	}
+
+	// A coupon that stopped applying is shown as such rather than failing the basket
+	var couponError string
+	if basket.Coupon != nil {
+		if err := h.checkCoupon(ctx, basket.Coupon, user); err != nil {
+			if !appErrors.IsType(err, appErrors.ErrInvalidInput) {
+				helpers.HandleError(c, err, "Failed to check coupon")
+				return
+			}
+			couponError = err.Error()
+		}
+	}
+
+	var totals *pricing.Totals
+	var err error
+	if basket.Coupon != nil && couponError == "" {
+		totals, err = h.Pricing.Quote(pricing.BasketLines(basket), country, basket.Coupon)
+		if errors.Is(err, pricing.ErrCouponNotApplicable) {
+			couponError = err.Error()
+		}
+	}
+	if totals == nil {
+		totals, err = h.Pricing.Quote(pricing.BasketLines(basket), country, nil)
+	}
+	if err != nil {
+		logging.Error(ctx, "failed to price basket", err, "basket_id", basket.ID)
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrConflict, "Your basket cannot be priced"), "")
+		return
+	}
+
+	priceChanged := false
+	for _, item := range basket.Items {
+		priceChanged = priceChanged || item.PriceChanged
+	}
+
+	c.JSON(http.StatusOK, BasketResponse{Basket: basket, Totals: totals, CouponError: couponError, PriceChanged: priceChanged})
+}
+
+// buyerCountry returns the country in the user's profile, used for tax. Users
+// without a profile are taxed at the default rate.
+func (h *Handler) buyerCountry(ctx context.Context, userID int) (string, error) {
+	profile, err := h.Repos.Profiles.GetByUserID(ctx, userID)
+	if err != nil {
+		if appErrors.IsType(err, appErrors.ErrNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if profile == nil {
+		return "", nil
+	}
+	return profile.Country, nil
+}
+
+// currentBasket returns the active basket of the user or, for guests with a nil
+// user, of the basket token sent with the request. It returns a nil basket when
+// there is none, unless create is set. Guests are sent the token of their
+// basket. It sends the error response and returns false on failure.
+func (h *Handler) currentBasket(c *gin.Context, user *models.User, create bool) (*models.Basket, bool) {
+	ctx := c.Request.Context()
+
+	var basket *models.Basket
+	var err error
+	if user != nil {
+		basket, err = h.Repos.Baskets.GetActiveBasket(ctx, user.ID)
+	} else if basketID, ok := h.guestBasketID(c); ok {
+		basket, err = h.Repos.Baskets.GetGuestBasket(ctx, basketID)
+	}
+	if err != nil {
+		if !appErrors.IsType(err, appErrors.ErrNotFound) {
+			helpers.HandleError(c, err, "Failed to retrieve basket")
+			return nil, false
+		}
+		basket = nil
+	}
+
+	if basket == nil && create {
+		basket = &models.Basket{Status: models.BasketStatusActive}
+		if user != nil {
+			basket.UserID = &user.ID
+		}
+		if err := h.Repos.Baskets.CreateBasket(ctx, basket); err != nil {
+			helpers.HandleError(c, err, "Failed to create basket")
+			return nil, false
+		}
+	}
+
+	if basket != nil && user == nil {
+		h.setBasketToken(c, basket.ID)
+	}
+	return basket, true
+}
+
+// reloadBasket reads a basket again after a change, falling back to the
+// basket as it was when it cannot be read
+func (h *Handler) reloadBasket(ctx context.Context, basket *models.Basket, user *models.User) *models.Basket {
+	var updated *models.Basket
+	var err error
+	if user != nil {
+		updated, err = h.Repos.Baskets.GetActiveBasket(ctx, user.ID)
+	} else {
+		updated, err = h.Repos.Baskets.GetGuestBasket(ctx, basket.ID)
+	}
+	if err != nil || updated == nil {
+		logging.Error(ctx, "failed to reload basket", err, "basket_id", basket.ID)
+		return basket
+	}
+	return updated
+}
+
+// guestBasketID returns the basket of a valid token in the request's basket
+// token header or cookie
+func (h *Handler) guestBasketID(c *gin.Context) (int, bool) {
+	token := c.GetHeader(BasketTokenHeader)
+	if token == "" {
+		token, _ = c.Cookie(basketTokenCookie)
+	}
+	if token == "" {
+		return 0, false
+	}
+
+	basketID, err := baskettoken.Verify(h.JWTSecret, token)
+	if err != nil {
+		logging.Debug(c.Request.Context(), "ignoring invalid basket token")
+		return 0, false
+	}
+	return basketID, true
+}
+
+// setBasketToken sends a guest the token of their basket
+func (h *Handler) setBasketToken(c *gin.Context, basketID int) {
+	token := baskettoken.Sign(h.JWTSecret, basketID)
+	c.Header(BasketTokenHeader, token)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(basketTokenCookie, token, int(h.GuestBasketTTL.Seconds()), "/", "", c.Request.TLS != nil, true)
+}
+
+// mergeGuestBasket moves the guest basket sent with a sign-in request into the
+// user's basket. Failures are logged and never fail the sign-in.
+func (h *Handler) mergeGuestBasket(c *gin.Context, userID int) {
+	ctx := c.Request.Context()
+
+	basketID, ok := h.guestBasketID(c)
+	if !ok {
+		return
+	}
+
+	err := h.Repos.Baskets.MergeGuestBasket(ctx, basketID, userID, time.Now().Add(h.StockReservationTTL))
+	if err != nil && !appErrors.IsType(err, appErrors.ErrNotFound) {
+		logging.Error(ctx, "failed to merge guest basket", err, "basket_id", basketID, "user_id", userID)
+		return
 	}
 
-	c.Status(http.StatusNoContent)
+	// The guest basket is gone either way
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(basketTokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
 }
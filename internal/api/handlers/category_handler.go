@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
 	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
@@ -11,9 +12,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// MoveCategoryRequest represents the move category payload. A null parentId
+// makes the category a top-level one.
+type MoveCategoryRequest struct {
+	ParentID *int `json:"parentId"`
+}
+
 // CreateCategory handles category creation
 // @Summary      Create a new category
-// @Description  Create a new event category (requires authentication)
+// @Description  Create a new event category, under an existing parent if given (requires authentication)
 // @Tags         Categories
 // @Accept       json
 // @Produce      json
@@ -21,6 +28,8 @@ import (
 // @Success      201       {object}  models.Category
 // @Failure      400       {object}  helpers.ErrorResponse
 // @Failure      401       {object}  helpers.ErrorResponse
+// @Failure      404       {object}  helpers.ErrorResponse
+// @Failure      409       {object}  helpers.ErrorResponse
 // @Failure      500       {object}  helpers.ErrorResponse
 // @Security     BearerAuth
 // @Router       /api/v1/categories [post]
@@ -31,6 +40,9 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 	if !helpers.BindJSON(c, &category) {
 		return
 	}
+	if !h.checkMediaLinks(c, category.Image) {
+		return
+	}
 
 	// Generate slug if not provided
 	if category.Slug == "" {
@@ -97,3 +109,201 @@ func (h *Handler) GetCategoryBySlug(c *gin.Context) {
 
 	c.JSON(http.StatusOK, category)
 }
+
+// GetCategoryTree retrieves the category hierarchy
+// @Summary      Get the category tree
+// @Description  Get all categories nested under their parents as children, with the top-level categories first and each level sorted by name
+// @Tags         Categories
+// @Produce      json
+// @Success      200  {array}   models.Category
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/categories/tree [get]
+func (h *Handler) GetCategoryTree(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tree, err := h.Repos.Categories.Tree(ctx)
+	if helpers.HandleError(c, err, "Failed to retrieve category tree") {
+		return
+	}
+	if tree == nil {
+		tree = []models.Category{}
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+// UpdateCategory updates a category
+// @Summary      Update a category
+// @Description  Update the name, slug, description, image, status and meta fields of a category (Admin only). Its parent changes by moving it.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int              true  "Category ID"
+// @Param        category  body      models.Category  true  "Category object"
+// @Success      200       {object}  models.Category
+// @Failure      400       {object}  helpers.ErrorResponse
+// @Failure      401       {object}  helpers.ErrorResponse
+// @Failure      403       {object}  helpers.ErrorResponse
+// @Failure      404       {object}  helpers.ErrorResponse
+// @Failure      409       {object}  helpers.ErrorResponse
+// @Failure      500       {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/categories/{id} [put]
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, ok := h.adminCategoryID(c)
+	if !ok {
+		return
+	}
+
+	var req models.Category
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+	if !h.checkMediaLinks(c, req.Image) {
+		return
+	}
+
+	category, err := h.Repos.Categories.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve category") {
+		return
+	}
+
+	category.Name = req.Name
+	category.Slug = req.Slug
+	if category.Slug == "" {
+		category.Slug = utils.GenerateSlug(req.Name)
+	}
+	category.Description = req.Description
+	category.Image = req.Image
+	if req.Status != "" {
+		category.Status = req.Status
+	}
+	category.MetaTitle = req.MetaTitle
+	category.MetaDescription = req.MetaDescription
+
+	if err := h.Repos.Categories.Update(ctx, category); err != nil {
+		helpers.HandleError(c, err, "Failed to update category")
+		return
+	}
+
+	logging.Info(ctx, "category updated", "category_id", id)
+	c.JSON(http.StatusOK, category)
+}
+
+// MoveCategory moves a category and its subcategories under another parent
+// @Summary      Move a category
+// @Description  Make a category, with its subcategories, a subcategory of another one or, for a null parentId, a top-level category (Admin only). A category cannot move under itself or its subcategories.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                  true  "Category ID"
+// @Param        move  body      MoveCategoryRequest  true  "New parent"
+// @Success      200   {object}  models.Category
+// @Failure      400   {object}  helpers.ErrorResponse
+// @Failure      401   {object}  helpers.ErrorResponse
+// @Failure      403   {object}  helpers.ErrorResponse
+// @Failure      404   {object}  helpers.ErrorResponse
+// @Failure      500   {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/categories/{id}/move [post]
+func (h *Handler) MoveCategory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, ok := h.adminCategoryID(c)
+	if !ok {
+		return
+	}
+
+	var req MoveCategoryRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	category, err := h.Repos.Categories.Move(ctx, id, req.ParentID)
+	if helpers.HandleError(c, err, "Failed to move category") {
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategoryResponse reports the products a category deletion left
+// without any category
+type DeleteCategoryResponse struct {
+	UncategorizedProducts int `json:"uncategorizedProducts"`
+}
+
+// DeleteCategory deletes a category
+// @Summary      Delete a category
+// @Description  Delete a category (Admin only). By default its subcategories and products move to its parent, or to the category given by target_id; with strategy=cascade its subcategories are deleted too and the products taken out of them. The response counts the products left without any category, as happens with a cascade or a top-level category.
+// @Tags         Categories
+// @Produce      json
+// @Param        id         path      int     true   "Category ID"
+// @Param        strategy   query     string  false  "What happens to subcategories and products: reassign (default) or cascade"
+// @Param        target_id  query     int     false  "Category to reassign subcategories and products to (default: the parent)"
+// @Success      200        {object}  DeleteCategoryResponse
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      403        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/categories/{id} [delete]
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, ok := h.adminCategoryID(c)
+	if !ok {
+		return
+	}
+
+	strategy := c.DefaultQuery("strategy", models.CategoryDeleteReassign)
+	if strategy != models.CategoryDeleteReassign && strategy != models.CategoryDeleteCascade {
+		helpers.RespondWithError(c, http.StatusBadRequest, "strategy must be reassign or cascade")
+		return
+	}
+
+	var targetID *int
+	if raw := c.Query("target_id"); raw != "" {
+		if strategy != models.CategoryDeleteReassign {
+			helpers.RespondWithError(c, http.StatusBadRequest, "target_id only applies to the reassign strategy")
+			return
+		}
+		target, err := strconv.Atoi(raw)
+		if err != nil || target <= 0 {
+			helpers.RespondWithError(c, http.StatusBadRequest, "Invalid target category ID")
+			return
+		}
+		targetID = &target
+	}
+
+	uncategorized, err := h.Repos.Categories.Delete(ctx, id, strategy, targetID)
+	if err != nil {
+		helpers.HandleError(c, err, "Failed to delete category")
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteCategoryResponse{UncategorizedProducts: uncategorized})
+}
+
+// adminCategoryID parses the category ID parameter if the authenticated user
+// is an admin, responding with an error otherwise
+func (h *Handler) adminCategoryID(c *gin.Context) (int, bool) {
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid category ID")
+		return 0, false
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return 0, false
+	}
+	if !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only admins can manage categories"), "")
+		return 0, false
+	}
+	return id, true
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -8,21 +9,26 @@ import (
 	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
 	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
 	"github.com/gin-gonic/gin"
 )
 
 // CreateComment handles comment creation
 // @Summary      Add a comment to an event
-// @Description  Add a comment to an event (requires authentication)
+// @Description  Add a comment to an event, or a reply to another comment when parentId is set (requires authentication).
+// @Description  Comments matching the configured word filter are held for review and returned with 202 Accepted.
+// @Description  @name and @email mentions are returned as character-offset spans and notify the mentioned users.
 // @Tags         Comments
 // @Accept       json
 // @Produce      json
 // @Param        id     path      int                 true  "Event ID"
 // @Param        comment body      models.Comment  true  "Comment object"
 // @Success      201    {object}  models.Comment
+// @Success      202    {object}  models.Comment
 // @Failure      400    {object}  helpers.ErrorResponse
 // @Failure      401    {object}  helpers.ErrorResponse
 // @Failure      404    {object}  helpers.ErrorResponse
+// @Failure      429    {object}  helpers.ErrorResponse
 // @Failure      500    {object}  helpers.ErrorResponse
 // @Security     BearerAuth
 // @Router       /api/v1/events/{id}/comments [post]
@@ -38,10 +44,14 @@ func (h *Handler) CreateComment(c *gin.Context) {
 	logging.Debug(ctx, "creating comment for event", "event_id", eventID)
 
 	// Check if event exists
-	_, err = h.Repos.Events.Get(ctx, eventID)
+	event, err := h.Repos.Events.Get(ctx, eventID)
 	if helpers.HandleError(c, err, "Failed to retrieve event") {
 		return
 	}
+	if event == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "event with ID %d not found", eventID), "")
+		return
+	}
 
 	var comment models.Comment
 	if !helpers.BindJSON(c, &comment) {
@@ -56,24 +66,70 @@ func (h *Handler) CreateComment(c *gin.Context) {
 
 	comment.EventID = eventID
 	comment.UserID = user.ID
+	comment.Depth = 0
+	comment.IsDeleted = false
+	comment.Status = h.commentStatusFor(ctx, comment.Content)
 	comment.CreatedAt = time.Now()
 
+	if comment.ParentID != nil {
+		parent, err := h.Repos.Comments.Get(ctx, *comment.ParentID)
+		if helpers.HandleError(c, err, "Failed to retrieve parent comment") {
+			return
+		}
+		if parent == nil || parent.EventID != eventID || !parent.IsVisible() {
+			helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", *comment.ParentID), "")
+			return
+		}
+		if parent.IsDeleted {
+			helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "Cannot reply to a deleted comment"), "")
+			return
+		}
+		if parent.Depth+1 > h.CommentMaxDepth {
+			helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrInvalidInput, "Replies cannot be nested more than %d levels deep", h.CommentMaxDepth), "")
+			return
+		}
+		comment.Depth = parent.Depth + 1
+	}
+
+	comment.Mentions, err = h.resolveMentions(ctx, comment.Content)
+	if helpers.HandleError(c, err, "Failed to resolve mentions") {
+		return
+	}
+
 	createdComment, err := h.Repos.Comments.Insert(ctx, &comment)
 	if helpers.HandleError(c, err, "Failed to create comment") {
 		return
 	}
 
-	logging.Info(ctx, "comment created successfully", "comment_id", createdComment.ID, "event_id", eventID, "user_id", user.ID)
+	logging.Info(ctx, "comment created successfully", "comment_id", createdComment.ID, "event_id", eventID, "user_id", user.ID, "status", createdComment.Status)
+	if !createdComment.IsVisible() {
+		c.JSON(http.StatusAccepted, createdComment)
+		return
+	}
+
+	h.notifyMentions(ctx, createdComment, user, nil)
 	c.JSON(http.StatusCreated, createdComment)
 }
 
-// GetEventComments retrieves all comments for an event
+// commentStatusFor returns the moderation status for new comment content,
+// holding content that matches the comment filter for review
+func (h *Handler) commentStatusFor(ctx context.Context, content string) string {
+	if term, ok := h.CommentFilter.Match(content); ok {
+		logging.Info(ctx, "comment held for review by filter", "term", term)
+		return models.CommentStatusPending
+	}
+	return models.CommentStatusApproved
+}
+
+// GetEventComments retrieves the top-level comments for an event
 // @Summary      Get comments for an event
-// @Description  Get all comments for a specific event
+// @Description  Get cursor-paginated top-level comments for an event, oldest first, with reply counts
 // @Tags         Comments
 // @Produce      json
-// @Param        id   path      int  true  "Event ID"
-// @Success      200  {array}   models.Comment
+// @Param        id         path      int     true   "Event ID"
+// @Param        cursor     query     string  false  "Cursor returned as end_cursor by the previous page"
+// @Param        page_size  query     int     false  "Page size (default: 20, max: 100)"
+// @Success      200  {object}  query.PaginatedList{data=[]models.Comment}
 // @Failure      400  {object}  helpers.ErrorResponse
 // @Failure      500  {object}  helpers.ErrorResponse
 // @Router       /api/v1/events/{id}/comments [get]
@@ -86,20 +142,238 @@ func (h *Handler) GetEventComments(c *gin.Context) {
 		return
 	}
 
-	logging.Debug(ctx, "retrieving comments for event", "event_id", eventID)
+	// Top-level comments are always cursor-paginated in creation order
+	params := query.ParseFromContext(c)
+	params.Type = query.CursorPagination
+	params.Sort = nil
+	params.SortRaw = ""
 
-	comments, err := h.Repos.Comments.GetByEvent(ctx, eventID)
+	logging.Debug(ctx, "retrieving comments for event", "event_id", eventID, "cursor", params.Cursor)
+
+	comments, result, err := h.Repos.Comments.ListTopLevelByEvent(ctx, eventID, params)
 	if helpers.HandleError(c, err, "Failed to fetch comments") {
 		return
 	}
 
 	logging.Debug(ctx, "comments retrieved successfully", "event_id", eventID, "count", len(comments))
-	c.JSON(http.StatusOK, comments)
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCommentThread retrieves a comment with all of its nested replies
+// @Summary      Get a comment thread
+// @Description  Get a comment and its nested replies as a tree
+// @Tags         Comments
+// @Produce      json
+// @Param        id         path      int  true  "Event ID"
+// @Param        commentId  path      int  true  "Comment ID"
+// @Success      200        {object}  models.Comment
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Router       /api/v1/events/{id}/comments/{commentId}/thread [get]
+func (h *Handler) GetCommentThread(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	commentID, err := helpers.ParseIDParam(c, "commentId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	logging.Debug(ctx, "retrieving comment thread", "event_id", eventID, "comment_id", commentID)
+
+	thread, err := h.Repos.Comments.GetThread(ctx, commentID)
+	if helpers.HandleError(c, err, "Failed to fetch comment thread") {
+		return
+	}
+	if thread == nil || thread.EventID != eventID {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", commentID), "")
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
+}
+
+// UpdateCommentRequest represents the comment edit payload
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required,min=1"`
+}
+
+// UpdateComment edits a comment
+// @Summary      Edit a comment
+// @Description  Edit a comment within the edit window (author only). The previous version is kept as a revision.
+// @Description  Edits matching the configured word filter are held for review.
+// @Tags         Comments
+// @Accept       json
+// @Produce      json
+// @Param        id         path      int                   true  "Event ID"
+// @Param        commentId  path      int                   true  "Comment ID"
+// @Param        comment    body      UpdateCommentRequest  true  "New comment content"
+// @Success      200        {object}  models.Comment
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      403        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/events/{id}/comments/{commentId} [put]
+func (h *Handler) UpdateComment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	commentID, err := helpers.ParseIDParam(c, "commentId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	var req UpdateCommentRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	logging.Debug(ctx, "updating comment", "comment_id", commentID, "user_id", user.ID)
+
+	comment, err := h.Repos.Comments.Get(ctx, commentID)
+	if helpers.HandleError(c, err, "Failed to retrieve comment") {
+		return
+	}
+	if comment == nil || comment.IsDeleted || comment.EventID != eventID {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", commentID), "")
+		return
+	}
+
+	// Only the author can edit, and only within the edit window
+	if comment.UserID != user.ID {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "You are not allowed to edit this comment"), "")
+		return
+	}
+	if time.Since(comment.CreatedAt) > h.CommentEditWindow {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "The edit window for this comment has expired"), "")
+		return
+	}
+
+	if req.Content == comment.Content {
+		c.JSON(http.StatusOK, comment)
+		return
+	}
+
+	// Edited content is filtered again; comments already held or hidden keep their status
+	status := comment.Status
+	if comment.IsVisible() {
+		status = h.commentStatusFor(ctx, req.Content)
+	}
+
+	mentionSpans, err := h.resolveMentions(ctx, req.Content)
+	if helpers.HandleError(c, err, "Failed to resolve mentions") {
+		return
+	}
+
+	updatedComment, err := h.Repos.Comments.Update(ctx, commentID, req.Content, status, mentionSpans, user.ID)
+	if helpers.HandleError(c, err, "Failed to update comment") {
+		return
+	}
+
+	// Only users newly mentioned by the edit are notified
+	if updatedComment.IsVisible() {
+		alreadyMentioned := make(map[int]bool)
+		if comment.IsVisible() {
+			for _, mention := range comment.Mentions {
+				alreadyMentioned[mention.UserID] = true
+			}
+		}
+		h.notifyMentions(ctx, updatedComment, user, alreadyMentioned)
+	}
+
+	logging.Info(ctx, "comment updated successfully", "comment_id", commentID, "user_id", user.ID)
+	c.JSON(http.StatusOK, updatedComment)
+}
+
+// GetCommentRevisions lists the previous versions of a comment
+// @Summary      List comment revisions
+// @Description  List the previous versions of an edited comment, newest first (event owner only)
+// @Tags         Comments
+// @Produce      json
+// @Param        id         path      int  true  "Event ID"
+// @Param        commentId  path      int  true  "Comment ID"
+// @Success      200        {array}   models.CommentRevision
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      403        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/events/{id}/comments/{commentId}/revisions [get]
+func (h *Handler) GetCommentRevisions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	commentID, err := helpers.ParseIDParam(c, "commentId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	event, err := h.Repos.Events.Get(ctx, eventID)
+	if helpers.HandleError(c, err, "Failed to retrieve event") {
+		return
+	}
+	if event == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "event with ID %d not found", eventID), "")
+		return
+	}
+	if event.OwnerID != user.ID {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only the event owner can view comment revisions"), "")
+		return
+	}
+
+	comment, err := h.Repos.Comments.Get(ctx, commentID)
+	if helpers.HandleError(c, err, "Failed to retrieve comment") {
+		return
+	}
+	if comment == nil || comment.EventID != eventID {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", commentID), "")
+		return
+	}
+
+	revisions, err := h.Repos.Comments.GetRevisions(ctx, commentID)
+	if helpers.HandleError(c, err, "Failed to fetch comment revisions") {
+		return
+	}
+
+	logging.Debug(ctx, "comment revisions retrieved successfully", "comment_id", commentID, "count", len(revisions))
+	c.JSON(http.StatusOK, revisions)
 }
 
 // DeleteComment removes a comment
 // @Summary      Delete a comment
-// @Description  Delete a comment by ID (requires authentication)
+// @Description  Delete a comment by ID (requires authentication). Comments with replies are replaced by a "[deleted]" tombstone.
 // @Tags         Comments
 // @Param        id         path      int  true  "Event ID"
 // @Param        commentId  path      int  true  "Comment ID"
@@ -133,7 +407,7 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 	if helpers.HandleError(c, err, "Failed to retrieve comment") {
 		return
 	}
-	if comment == nil {
+	if comment == nil || comment.IsDeleted {
 		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", commentID), "")
 		return
 	}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// moderationQueueStatuses are the statuses accepted by the moderation queue
+var moderationQueueStatuses = map[string]bool{
+	models.CommentStatusPending:  true,
+	models.CommentStatusRejected: true,
+	models.CommentStatusHidden:   true,
+	models.CommentStatusReported: true,
+}
+
+// moderationActions maps moderation actions to the resulting comment status
+var moderationActions = map[string]string{
+	"approve": models.CommentStatusApproved,
+	"reject":  models.CommentStatusRejected,
+	"hide":    models.CommentStatusHidden,
+}
+
+// ReportCommentRequest represents the comment report payload
+type ReportCommentRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ModerateCommentRequest represents the moderation decision payload
+type ModerateCommentRequest struct {
+	Action string `json:"action" binding:"required,oneof=approve reject hide"`
+}
+
+// ReportComment reports a comment for moderation
+// @Summary      Report a comment
+// @Description  Report a comment. Comments reaching the report threshold are held for review.
+// @Tags         Comments
+// @Accept       json
+// @Produce      json
+// @Param        id         path      int                   true  "Event ID"
+// @Param        commentId  path      int                   true  "Comment ID"
+// @Param        report     body      ReportCommentRequest  true  "Report reason"
+// @Success      201        {object}  models.CommentReport
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      409        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/events/{id}/comments/{commentId}/report [post]
+func (h *Handler) ReportComment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	commentID, err := helpers.ParseIDParam(c, "commentId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	var req ReportCommentRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	logging.Debug(ctx, "reporting comment", "comment_id", commentID, "user_id", user.ID)
+
+	comment, err := h.Repos.Comments.Get(ctx, commentID)
+	if helpers.HandleError(c, err, "Failed to retrieve comment") {
+		return
+	}
+	if comment == nil || comment.IsDeleted || comment.EventID != eventID || !comment.IsVisible() {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", commentID), "")
+		return
+	}
+	if comment.UserID == user.ID {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "You cannot report your own comment"), "")
+		return
+	}
+
+	report, err := h.Repos.CommentReports.Insert(ctx, &models.CommentReport{
+		CommentID:  commentID,
+		ReporterID: user.ID,
+		Reason:     req.Reason,
+		Status:     models.ReportStatusOpen,
+	})
+	if helpers.HandleError(c, err, "Failed to report comment") {
+		return
+	}
+
+	// Hold the comment for review once enough users have reported it
+	openReports, err := h.Repos.CommentReports.CountOpenByComment(ctx, commentID)
+	if helpers.HandleError(c, err, "Failed to count comment reports") {
+		return
+	}
+	if openReports >= int64(h.CommentReportThreshold) {
+		if err := h.Repos.Comments.SetStatus(ctx, commentID, models.CommentStatusPending); err != nil {
+			helpers.HandleError(c, err, "Failed to hold comment for review")
+			return
+		}
+		logging.Info(ctx, "comment held for review after reports", "comment_id", commentID, "reports", openReports)
+	}
+
+	logging.Info(ctx, "comment reported successfully", "comment_id", commentID, "user_id", user.ID)
+	c.JSON(http.StatusCreated, report)
+}
+
+// GetModerationQueue lists an event's comments awaiting moderation
+// @Summary      Get the comment moderation queue
+// @Description  List an event's comments by moderation status (event owner or admin only)
+// @Tags         Comments
+// @Produce      json
+// @Param        id         path      int     true   "Event ID"
+// @Param        status     query     string  false  "pending, reported, hidden or rejected (default: pending)"
+// @Param        page       query     int     false  "Page number (default: 1)"
+// @Param        page_size  query     int     false  "Page size (default: 20, max: 100)"
+// @Success      200        {object}  query.PaginatedList{data=[]models.Comment}
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      403        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/events/{id}/comments/moderation [get]
+func (h *Handler) GetModerationQueue(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	status := c.DefaultQuery("status", models.CommentStatusPending)
+	if !moderationQueueStatuses[status] {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid moderation status")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeModerator(c, ctx, eventID, user) {
+		return
+	}
+
+	params := query.ParseFromContext(c)
+
+	comments, result, err := h.Repos.Comments.ListForModeration(ctx, eventID, status, params)
+	if helpers.HandleError(c, err, "Failed to fetch moderation queue") {
+		return
+	}
+
+	logging.Debug(ctx, "moderation queue retrieved successfully", "event_id", eventID, "status", status, "count", len(comments))
+	c.JSON(http.StatusOK, result)
+}
+
+// ModerateComment approves, rejects or hides a comment
+// @Summary      Moderate a comment
+// @Description  Approve, reject or hide a comment and resolve its open reports (event owner or admin only)
+// @Tags         Comments
+// @Accept       json
+// @Produce      json
+// @Param        id         path      int                     true  "Event ID"
+// @Param        commentId  path      int                     true  "Comment ID"
+// @Param        decision   body      ModerateCommentRequest  true  "Moderation action"
+// @Success      200        {object}  models.Comment
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      403        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/events/{id}/comments/{commentId}/moderate [post]
+func (h *Handler) ModerateComment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	commentID, err := helpers.ParseIDParam(c, "commentId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	var req ModerateCommentRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeModerator(c, ctx, eventID, user) {
+		return
+	}
+
+	comment, err := h.Repos.Comments.Get(ctx, commentID)
+	if helpers.HandleError(c, err, "Failed to retrieve comment") {
+		return
+	}
+	if comment == nil || comment.IsDeleted || comment.EventID != eventID {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", commentID), "")
+		return
+	}
+
+	status := moderationActions[req.Action]
+	if err := h.Repos.Comments.SetStatus(ctx, commentID, status); err != nil {
+		helpers.HandleError(c, err, "Failed to moderate comment")
+		return
+	}
+	if err := h.Repos.CommentReports.ResolveByComment(ctx, commentID, user.ID); err != nil {
+		helpers.HandleError(c, err, "Failed to resolve comment reports")
+		return
+	}
+
+	// Mentions in a comment that was held for review are delivered once it is
+	// approved, except to users notified before, as when a reported comment
+	// is approved again
+	wasVisible := comment.IsVisible()
+	comment.Status = status
+	if !wasVisible && comment.IsVisible() && len(comment.Mentions) > 0 {
+		h.notifyApprovedMentions(ctx, comment)
+	}
+
+	logging.Info(ctx, "comment moderated successfully", "comment_id", commentID, "action", req.Action, "moderator_id", user.ID)
+	c.JSON(http.StatusOK, comment)
+}
+
+// notifyApprovedMentions notifies the users mentioned in an approved comment
+// who were not notified of it yet. Failures are logged.
+func (h *Handler) notifyApprovedMentions(ctx context.Context, comment *models.Comment) {
+	notified, err := h.Repos.Notifications.MentionedUserIDs(ctx, comment.ID)
+	if err != nil {
+		logging.Error(ctx, "failed to check mention notifications, skipping them", err, "comment_id", comment.ID)
+		return
+	}
+	author, err := h.Repos.Users.Get(ctx, comment.UserID)
+	if err != nil {
+		logging.Error(ctx, "failed to load comment author, skipping mention notifications", err, "comment_id", comment.ID)
+		return
+	}
+
+	skip := make(map[int]bool, len(notified))
+	for _, userID := range notified {
+		skip[userID] = true
+	}
+	h.notifyMentions(ctx, comment, author, skip)
+}
+
+// authorizeModerator checks that the user may moderate the event's comments.
+// It sends the error response and returns false when they may not.
+func (h *Handler) authorizeModerator(c *gin.Context, ctx context.Context, eventID int, user *models.User) bool {
+	event, err := h.Repos.Events.Get(ctx, eventID)
+	if helpers.HandleError(c, err, "Failed to retrieve event") {
+		return false
+	}
+	if event == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "event with ID %d not found", eventID), "")
+		return false
+	}
+	if event.OwnerID != user.ID && !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only the event owner or an admin can moderate comments"), "")
+		return false
+	}
+	return true
+}
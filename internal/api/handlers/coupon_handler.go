@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// couponCodePattern is the shape of a coupon code
+var couponCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,50}$`)
+
+// ApplyCouponRequest represents the apply coupon payload
+type ApplyCouponRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// CreateCouponRequest represents the create coupon payload. Amounts are in minor
+// units of their currency.
+type CreateCouponRequest struct {
+	Code           string       `json:"code" binding:"required"`
+	Type           string       `json:"type" binding:"required,oneof=percentage fixed"`
+	PercentOff     int          `json:"percentOff" binding:"min=0,max=100"`
+	AmountOff      *money.Money `json:"amountOff"`
+	MinSpend       *money.Money `json:"minSpend"`
+	MaxRedemptions int          `json:"maxRedemptions" binding:"min=0"`
+	MaxPerUser     int          `json:"maxPerUser" binding:"min=0"`
+	StartsAt       *time.Time   `json:"startsAt"`
+	EndsAt         *time.Time   `json:"endsAt"`
+	ProductIDs     []int        `json:"productIds"`
+	CategoryIDs    []int        `json:"categoryIds"`
+}
+
+// ApplyCoupon applies a coupon code to the basket
+// @Summary      Apply a coupon
+// @Description  Apply a coupon code to the active basket, replacing any coupon already applied. The coupon is checked again at checkout.
+// @Tags         Basket
+// @Accept       json
+// @Produce      json
+// @Param        X-Basket-Token  header    string              false  "Guest basket token"
+// @Param        coupon          body      ApplyCouponRequest  true   "Coupon code"
+// @Success      200             {object}  BasketResponse
+// @Failure      400             {object}  helpers.ErrorResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      404             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/basket/coupon [post]
+func (h *Handler) ApplyCoupon(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req ApplyCouponRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user := helpers.GetUserFromContext(c)
+
+	logging.Debug(ctx, "applying coupon", "code", req.Code)
+
+	basket, ok := h.currentBasket(c, user, false)
+	if !ok {
+		return
+	}
+	if basket == nil || len(basket.Items) == 0 {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "Your basket is empty"), "")
+		return
+	}
+
+	coupon, err := h.Repos.Coupons.GetByCode(ctx, req.Code)
+	if helpers.HandleError(c, err, "Failed to retrieve coupon") {
+		return
+	}
+
+	if err := h.checkCoupon(ctx, coupon, user); err != nil {
+		helpers.HandleError(c, err, "Failed to check coupon")
+		return
+	}
+
+	var country string
+	if user != nil {
+		country, err = h.buyerCountry(ctx, user.ID)
+		if helpers.HandleError(c, err, "Failed to retrieve profile") {
+			return
+		}
+	}
+	if _, err := h.Pricing.Quote(pricing.BasketLines(basket), country, coupon); err != nil {
+		if errors.Is(err, pricing.ErrCouponNotApplicable) {
+			helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+			return
+		}
+		logging.Error(ctx, "failed to price basket", err, "basket_id", basket.ID)
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrConflict, "Your basket cannot be priced"), "")
+		return
+	}
+
+	if err := h.Repos.Baskets.SetCoupon(ctx, basket.ID, &coupon.ID); err != nil {
+		helpers.HandleError(c, err, "Failed to apply coupon")
+		return
+	}
+	basket.CouponID = &coupon.ID
+	basket.Coupon = coupon
+
+	logging.Info(ctx, "coupon applied", "basket_id", basket.ID, "coupon_id", coupon.ID)
+	h.respondWithBasket(c, basket, user)
+}
+
+// RemoveCoupon removes the coupon from the basket
+// @Summary      Remove the coupon
+// @Description  Remove the coupon applied to the active basket
+// @Tags         Basket
+// @Produce      json
+// @Param        X-Basket-Token  header    string  false  "Guest basket token"
+// @Success      200             {object}  BasketResponse
+// @Failure      401             {object}  helpers.ErrorResponse
+// @Failure      404             {object}  helpers.ErrorResponse
+// @Failure      500             {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/basket/coupon [delete]
+func (h *Handler) RemoveCoupon(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user := helpers.GetUserFromContext(c)
+
+	basket, ok := h.currentBasket(c, user, false)
+	if !ok {
+		return
+	}
+
+	if basket != nil && basket.CouponID != nil {
+		if err := h.Repos.Baskets.SetCoupon(ctx, basket.ID, nil); err != nil {
+			helpers.HandleError(c, err, "Failed to remove coupon")
+			return
+		}
+		basket.CouponID = nil
+		basket.Coupon = nil
+		logging.Info(ctx, "coupon removed", "basket_id", basket.ID)
+	}
+
+	h.respondWithBasket(c, basket, user)
+}
+
+// CreateCoupon creates a coupon
+// @Summary      Create a coupon
+// @Description  Create a percentage or fixed amount coupon with optional minimum spend, usage limits, validity window and product or category restrictions (admin only)
+// @Tags         Coupons
+// @Accept       json
+// @Produce      json
+// @Param        coupon  body      CreateCouponRequest  true  "Coupon"
+// @Success      201     {object}  models.Coupon
+// @Failure      400     {object}  helpers.ErrorResponse
+// @Failure      401     {object}  helpers.ErrorResponse
+// @Failure      403     {object}  helpers.ErrorResponse
+// @Failure      409     {object}  helpers.ErrorResponse
+// @Failure      500     {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/coupons [post]
+func (h *Handler) CreateCoupon(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateCouponRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+	if !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only admins can manage coupons"), "")
+		return
+	}
+
+	coupon, err := req.toCoupon()
+	if err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+		return
+	}
+
+	coupon, err = h.Repos.Coupons.Insert(ctx, coupon, req.ProductIDs, req.CategoryIDs)
+	if helpers.HandleError(c, err, "Failed to create coupon") {
+		return
+	}
+
+	logging.Info(ctx, "coupon created", "coupon_id", coupon.ID, "code", coupon.Code, "admin_id", user.ID)
+	c.JSON(http.StatusCreated, coupon)
+}
+
+// GetCoupons lists coupons
+// @Summary      List coupons
+// @Description  List coupons, newest first (admin only)
+// @Tags         Coupons
+// @Produce      json
+// @Param        page       query     int     false  "Page number (default: 1)"
+// @Param        page_size  query     int     false  "Page size (default: 20, max: 100)"
+// @Param        active     query     bool    false  "Filter by active flag"
+// @Param        type       query     string  false  "Filter by coupon type"
+// @Success      200        {object}  query.PaginatedList{data=[]models.Coupon}
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      403        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/coupons [get]
+func (h *Handler) GetCoupons(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+	if !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only admins can manage coupons"), "")
+		return
+	}
+
+	params := query.ParseFromContext(c)
+
+	coupons, result, err := h.Repos.Coupons.List(ctx, params)
+	if helpers.HandleError(c, err, "Failed to fetch coupons") {
+		return
+	}
+
+	logging.Debug(ctx, "coupons retrieved successfully", "count", len(coupons))
+	c.JSON(http.StatusOK, result)
+}
+
+// DeactivateCoupon deactivates a coupon
+// @Summary      Deactivate a coupon
+// @Description  Stop a coupon from being applied or redeemed. Baskets holding it show it as no longer valid (admin only).
+// @Tags         Coupons
+// @Param        id   path      int  true  "Coupon ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      403  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/coupons/{id} [delete]
+func (h *Handler) DeactivateCoupon(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+	if !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only admins can manage coupons"), "")
+		return
+	}
+
+	if err := h.Repos.Coupons.Deactivate(ctx, id); err != nil {
+		helpers.HandleError(c, err, "Failed to deactivate coupon")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkCoupon checks that the user may use the coupon now. The order transaction
+// checks again under lock, so this only gives early feedback.
+func (h *Handler) checkCoupon(ctx context.Context, coupon *models.Coupon, user *models.User) error {
+	// Guests have not redeemed anything yet; the per-user limit applies at checkout
+	var redemptions int64
+	if user != nil {
+		var err error
+		redemptions, err = h.Repos.Coupons.CountUserRedemptions(ctx, coupon.ID, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if err := coupon.CheckUsable(time.Now(), redemptions); err != nil {
+		return appErrors.New(appErrors.ErrInvalidInput, err.Error()).WithDetail("coupon", coupon.Code)
+	}
+	return nil
+}
+
+// toCoupon validates the request and builds the coupon it describes
+func (req *CreateCouponRequest) toCoupon() (*models.Coupon, error) {
+	if !couponCodePattern.MatchString(req.Code) {
+		return nil, errors.New("code must be 3 to 50 letters, digits, dashes or underscores")
+	}
+
+	coupon := &models.Coupon{
+		Code:           strings.ToUpper(req.Code),
+		Type:           req.Type,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+		Active:         true,
+	}
+
+	switch req.Type {
+	case models.CouponTypePercentage:
+		if req.PercentOff < 1 {
+			return nil, errors.New("percentage coupons need a percentOff between 1 and 100")
+		}
+		if req.AmountOff != nil {
+			return nil, errors.New("percentage coupons cannot have an amountOff")
+		}
+		coupon.PercentOff = req.PercentOff
+	case models.CouponTypeFixed:
+		if req.AmountOff == nil || !req.AmountOff.IsPositive() {
+			return nil, errors.New("fixed coupons need a positive amountOff")
+		}
+		if req.PercentOff != 0 {
+			return nil, errors.New("fixed coupons cannot have a percentOff")
+		}
+		coupon.AmountOff = *req.AmountOff
+		coupon.Currency = req.AmountOff.Currency
+	}
+
+	if req.MinSpend != nil && !req.MinSpend.IsZero() {
+		if req.MinSpend.IsNegative() {
+			return nil, errors.New("minSpend cannot be negative")
+		}
+		if coupon.Currency != "" && req.MinSpend.Currency != coupon.Currency {
+			return nil, errors.New("minSpend and amountOff must use the same currency")
+		}
+		coupon.MinSpend = *req.MinSpend
+		coupon.Currency = req.MinSpend.Currency
+	}
+
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return nil, errors.New("endsAt must be after startsAt")
+	}
+	if req.MaxPerUser > 0 && req.MaxRedemptions > 0 && req.MaxPerUser > req.MaxRedemptions {
+		return nil, errors.New("maxPerUser cannot exceed maxRedemptions")
+	}
+
+	return coupon, nil
+}
@@ -93,10 +93,10 @@ func (h *Handler) GetEvent(c *gin.Context) {
 // @Produce      json
 // @Param        page        query     int     false  "Page number (default: 1)"
 // @Param        page_size   query     int     false  "Page size (default: 20, max: 100)"
-// @Param        type        query     string  false  "Pagination type: 'offset' or 'cursor' (default: offset)"
+// @Param        type        query     string  false  "Pagination type: 'offset' or 'cursor' (default: offset); searches are paginated by offset"
 // @Param        cursor      query     string  false  "Cursor for cursor-based pagination"
 // @Param        sort        query     string  false  "Sort fields (e.g., '-created_at,name:asc')"
-// @Param        search      query     string  false  "Search term for name, description, location"
+// @Param        search      query     string  false  "Full-text search of name, location and description in web search syntax; matches are ordered by rank unless sorted"
 // @Param        name[eq]    query     string  false  "Filter by exact name"
 // @Param        name[like]  query     string  false  "Filter by name (partial match)"
 // @Param        location[eq] query    string  false  "Filter by location"
@@ -1,19 +1,109 @@
 package handlers
 
 import (
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/constants"
+	"github.com/alireza-akbarzadeh/ginflow/internal/imaging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/moderation"
+	"github.com/alireza-akbarzadeh/ginflow/internal/notifications"
+	"github.com/alireza-akbarzadeh/ginflow/internal/payments"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
 	"github.com/alireza-akbarzadeh/ginflow/internal/repository"
+	"github.com/alireza-akbarzadeh/ginflow/internal/storage"
+	"github.com/alireza-akbarzadeh/ginflow/internal/views"
+	"golang.org/x/sync/singleflight"
 )
 
 // Handler holds all dependencies for HTTP handlers
 type Handler struct {
 	Repos     *repository.Models
 	JWTSecret string
+
+	// CommentMaxDepth is the deepest reply level allowed (top-level comments have depth 0)
+	CommentMaxDepth int
+	// CommentEditWindow is how long after posting an author may still edit a comment
+	CommentEditWindow time.Duration
+	// CommentRateLimit is the number of comments a user may post per minute
+	CommentRateLimit int
+	// CommentReportThreshold is the number of open reports that holds a comment for review
+	CommentReportThreshold int
+	// CommentFilter holds matching comments for review
+	CommentFilter *moderation.Filter
+	// Notifier delivers notifications over email and push
+	Notifier notifications.Sender
+	// Payments collects and refunds order payments
+	Payments payments.PaymentProvider
+	// Currency is the ISO 4217 code of prices given without a currency
+	Currency string
+	// Pricing computes basket and order totals, discounts and tax
+	Pricing *pricing.Engine
+	// StockReservationTTL is how long basket items hold their stock
+	StockReservationTTL time.Duration
+	// GuestBasketTTL is how long an unchanged guest basket is kept
+	GuestBasketTTL time.Duration
+	// ReviewsVerifiedOnly limits product reviews to users who bought the product
+	ReviewsVerifiedOnly bool
+	// ProductImportMaxSize is the largest file a product import accepts, in bytes
+	ProductImportMaxSize int64
+	// Storage keeps uploaded files
+	Storage storage.Storage
+	// MediaMaxSize is the largest file an upload accepts, in bytes
+	MediaMaxSize int64
+	// MediaURLTTL is how long signed URLs of stored files stay valid
+	MediaURLTTL time.Duration
+	// ImageSizes are the resized variants made of uploaded images
+	ImageSizes []imaging.Size
+	// ImageFormat is the format variants are encoded in
+	ImageFormat string
+	// variantFlights makes each missing image variant once however many
+	// requests ask for it at the same time
+	variantFlights singleflight.Group
+	// Views counts product views until they are flushed to the database
+	Views *views.Counter
+	// TrendingHalfLife is how long until views and sales weigh half as much
+	// towards trending products
+	TrendingHalfLife time.Duration
+	// TrendingSaleWeight is how many views a sale counts as towards trending products
+	TrendingSaleWeight int
 }
 
 // NewHandler creates a new Handler instance
-func NewHandler(repos *repository.Models, jwtSecret string) *Handler {
-	return &Handler{
-		Repos:     repos,
-		JWTSecret: jwtSecret,
+func NewHandler(repos *repository.Models, jwtSecret string, opts ...Option) *Handler {
+	h := &Handler{
+		Repos:                  repos,
+		JWTSecret:              jwtSecret,
+		CommentMaxDepth:        constants.DEFAULT_COMMENT_MAX_DEPTH,
+		CommentEditWindow:      time.Duration(constants.DEFAULT_COMMENT_EDIT_WINDOW) * time.Second,
+		CommentRateLimit:       constants.DEFAULT_COMMENT_RATE_LIMIT,
+		CommentReportThreshold: constants.DEFAULT_COMMENT_REPORT_THRESHOLD,
+		Notifier:               notifications.LogSender{},
+		// Without a webhook secret the fake provider rejects every webhook
+		Payments: payments.NewFakeProvider(""),
+		Currency: constants.DEFAULT_CURRENCY,
+		Pricing:  &pricing.Engine{},
+
+		StockReservationTTL: time.Duration(constants.DEFAULT_STOCK_RESERVATION_TTL) * time.Second,
+		GuestBasketTTL:      time.Duration(constants.DEFAULT_GUEST_BASKET_TTL) * time.Second,
+		ReviewsVerifiedOnly: constants.DEFAULT_REVIEWS_VERIFIED_ONLY,
+
+		ProductImportMaxSize: int64(constants.DEFAULT_PRODUCT_IMPORT_MAX_SIZE),
+		// Files are kept in memory unless a storage is configured
+		Storage:      storage.NewMemory(models.MediaFilesPath, jwtSecret),
+		MediaMaxSize: int64(constants.DEFAULT_MEDIA_MAX_SIZE),
+		MediaURLTTL:  time.Duration(constants.DEFAULT_MEDIA_URL_TTL) * time.Second,
+		ImageSizes:   defaultImageSizes,
+		ImageFormat:  constants.DEFAULT_IMAGE_FORMAT,
+
+		Views:              views.NewCounter(time.Duration(constants.DEFAULT_VIEW_WINDOW) * time.Second),
+		TrendingHalfLife:   time.Duration(constants.DEFAULT_TRENDING_HALF_LIFE) * time.Second,
+		TrendingSaleWeight: constants.DEFAULT_TRENDING_SALE_WEIGHT,
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// AdjustInventoryRequest represents the adjust inventory payload. Restocks and
+// returns add stock; corrections may also take it away.
+type AdjustInventoryRequest struct {
+	VariantID *int   `json:"variantId"`
+	Delta     int    `json:"delta" binding:"required"`
+	Reason    string `json:"reason" binding:"required,oneof=restock return correction"`
+	Note      string `json:"note" binding:"max=500"`
+}
+
+// GetProductInventory lists the inventory ledger of a product
+// @Summary      Get a product's inventory ledger
+// @Description  List the adjustments of a product's stock, latest first, with their reason and who made them (Owner only)
+// @Tags         Inventory
+// @Produce      json
+// @Param        id          path      int     true   "Product ID"
+// @Param        page        query     int     false  "Page number (default: 1)"
+// @Param        page_size   query     int     false  "Page size (default: 20, max: 100)"
+// @Param        reason      query     string  false  "Filter by reason"
+// @Param        variant_id  query     int     false  "Filter by variant ID"
+// @Success      200         {object}  query.PaginatedList{data=[]models.InventoryAdjustment}
+// @Failure      400         {object}  helpers.ErrorResponse
+// @Failure      401         {object}  helpers.ErrorResponse
+// @Failure      403         {object}  helpers.ErrorResponse
+// @Failure      404         {object}  helpers.ErrorResponse
+// @Failure      500         {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/inventory [get]
+func (h *Handler) GetProductInventory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	product, _, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	params := query.ParseFromContext(c)
+
+	adjustments, result, err := h.Repos.Inventory.List(ctx, product.ID, params)
+	if helpers.HandleError(c, err, "Failed to retrieve inventory") {
+		return
+	}
+
+	logging.Debug(ctx, "inventory retrieved", "product_id", product.ID, "count", len(adjustments))
+	c.JSON(http.StatusOK, result)
+}
+
+// AdjustProductInventory adjusts the stock of a product
+// @Summary      Adjust a product's stock
+// @Description  Restock, take back returns or correct the stock of a product, or of one of its variants for products sold in variants, and record why in the inventory ledger (Owner only). Stock falling to or below the product's low-stock threshold notifies the seller, and a product coming back in stock notifies the users watching it on their wishlists.
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                     true  "Product ID"
+// @Param        adjustment  body      AdjustInventoryRequest  true  "Adjustment"
+// @Success      201         {object}  models.InventoryAdjustment
+// @Failure      400         {object}  helpers.ErrorResponse
+// @Failure      401         {object}  helpers.ErrorResponse
+// @Failure      403         {object}  helpers.ErrorResponse
+// @Failure      404         {object}  helpers.ErrorResponse
+// @Failure      409         {object}  helpers.ErrorResponse
+// @Failure      500         {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/inventory [post]
+func (h *Handler) AdjustProductInventory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	product, user, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var req AdjustInventoryRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+	if req.Reason != models.InventoryReasonCorrection && req.Delta < 0 {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrInvalidInput, "a %s must add stock", req.Reason), "")
+		return
+	}
+
+	wasAvailable := product.Available > 0
+	adjustment := &models.InventoryAdjustment{
+		ProductID: product.ID,
+		VariantID: req.VariantID,
+		Delta:     req.Delta,
+		Reason:    req.Reason,
+		ActorID:   &user.ID,
+		Note:      req.Note,
+	}
+	if err := h.Repos.Inventory.Adjust(ctx, adjustment); err != nil {
+		helpers.HandleError(c, err, "Failed to adjust inventory")
+		return
+	}
+
+	if adjustment.LowStock {
+		product.Stock += adjustment.Delta
+		h.notifyLowStock(ctx, product)
+	}
+	// Restocks and returns can bring a product back in stock, which only the
+	// reloaded product's availability tells
+	if !wasAvailable && adjustment.Delta > 0 {
+		restocked, err := h.Repos.Products.Get(ctx, product.ID)
+		if err != nil {
+			logging.Error(ctx, "failed to load restocked product", err, "product_id", product.ID)
+		} else {
+			previousPrice, _ := pricing.FinalPrice(product.Price, product.Discount)
+			h.notifyWishlistWatchers(ctx, restocked, previousPrice, wasAvailable)
+		}
+	}
+	c.JSON(http.StatusCreated, adjustment)
+}
+
+// ReconcileProductInventory reconciles the inventory ledger of a product with its stock
+// @Summary      Reconcile a product's inventory ledger
+// @Description  Add corrections to the inventory ledger of a product where it does not add up to the stock of the product or its variants, e.g. for stock from before the ledger (Owner only)
+// @Tags         Inventory
+// @Produce      json
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  []models.InventoryAdjustment
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      403  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/inventory/reconcile [post]
+func (h *Handler) ReconcileProductInventory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	product, user, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	corrections, err := h.Repos.Inventory.Reconcile(ctx, product.ID, &user.ID)
+	if helpers.HandleError(c, err, "Failed to reconcile inventory") {
+		return
+	}
+	if corrections == nil {
+		corrections = []models.InventoryAdjustment{}
+	}
+
+	c.JSON(http.StatusOK, corrections)
+}
+
+// notifyOrderLowStock notifies the sellers of the products an order took to or
+// below their low-stock threshold
+func (h *Handler) notifyOrderLowStock(ctx context.Context, orderID int) {
+	adjustments, err := h.Repos.Inventory.ListByOrder(ctx, orderID)
+	if err != nil {
+		return
+	}
+	for _, adjustment := range adjustments {
+		if adjustment.LowStock && adjustment.Product != nil {
+			h.notifyLowStock(ctx, adjustment.Product)
+		}
+	}
+}
+
+// notifyLowStock notifies the seller of a product its stock fell to or below
+// the low-stock threshold
+func (h *Handler) notifyLowStock(ctx context.Context, product *models.Product) {
+	h.deliverNotification(ctx, &models.Notification{
+		UserID:    product.UserID,
+		Type:      models.NotificationTypeLowStock,
+		ProductID: &product.ID,
+		Message:   fmt.Sprintf("%s is low on stock: %d left", product.Name, product.Stock),
+	})
+	logging.Info(ctx, "seller notified of low stock", "product_id", product.ID, "stock", product.Stock)
+}
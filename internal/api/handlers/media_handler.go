@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// mediaFormOverhead is how much of an upload request the multipart form
+// itself may take besides the file
+const mediaFormOverhead = 64 << 10
+
+// mediaTypes are the content types uploads may have, by the extension their
+// files are stored with
+var mediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// UploadMedia stores an uploaded file
+// @Summary      Upload a file
+// @Description  Upload an image (JPEG, PNG, GIF or WebP) as the file field of a multipart form. The content type is sniffed from the file itself. Files are stored once by content: uploading a file that is already stored returns it with status 200. Use the returned url for product images, category images and avatars.
+// @Tags         Media
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file  true  "Image file"
+// @Success      200   {object}  models.Media
+// @Success      201   {object}  models.Media
+// @Failure      400   {object}  helpers.ErrorResponse
+// @Failure      401   {object}  helpers.ErrorResponse
+// @Failure      413   {object}  helpers.ErrorResponse
+// @Failure      415   {object}  helpers.ErrorResponse
+// @Failure      500   {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/media [post]
+func (h *Handler) UploadMedia(c *gin.Context) {
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	media, created, ok := h.storeUpload(c, user.ID)
+	if !ok {
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, media)
+}
+
+// GetMedia redirects to a stored file
+// @Summary      Get a file
+// @Description  Redirect to a signed URL of a stored file, valid for a short time. This is the stable link to the file; its resized variants are linked from the srcset of the file.
+// @Tags         Media
+// @Param        id   path      int  true  "Media ID"
+// @Success      302
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/media/{id} [get]
+func (h *Handler) GetMedia(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid media ID")
+		return
+	}
+
+	media, err := h.Repos.Media.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve media") {
+		return
+	}
+
+	h.redirectToFile(c, media.Key)
+}
+
+// ServeMediaFile serves a stored file from a signed URL
+// @Summary      Download a file
+// @Description  Serve a file kept by the local storage. The URL must be signed and not expired; get one from the media link.
+// @Tags         Media
+// @Produce      octet-stream
+// @Param        key        path      string  true  "Storage key"
+// @Param        expires    query     int     true  "Expiry time of the URL, in Unix seconds"
+// @Param        signature  query     string  true  "Signature of the URL"
+// @Success      200
+// @Failure      403  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/media/files/{key} [get]
+func (h *Handler) ServeMediaFile(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Backends such as S3 serve their signed URLs themselves
+	verifier, ok := h.Storage.(storage.Verifier)
+	if !ok {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrNotFound, "File not found"), "")
+		return
+	}
+
+	key := c.Param("key")
+	if len(key) > 0 && key[0] == '/' {
+		key = key[1:]
+	}
+	if err := verifier.Verify(key, c.Query("expires"), c.Query("signature"), time.Now()); err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "The link is invalid or has expired"), "")
+		return
+	}
+
+	file, err := h.Storage.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrNotFound, "File not found"), "")
+		return
+	}
+	if err != nil {
+		logging.Error(ctx, "failed to open media file", err, "key", key)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve file")
+		return
+	}
+	defer file.Close()
+
+	contentType := "application/octet-stream"
+	for mediaType, ext := range mediaTypes {
+		if path.Ext(key) == ext {
+			contentType = mediaType
+		}
+	}
+
+	// Stored files never change, as their keys are their content hashes
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
+
+// redirectToFile redirects to a signed URL of a stored file
+func (h *Handler) redirectToFile(c *gin.Context, key string) {
+	signedURL, err := h.Storage.SignedURL(key, h.MediaURLTTL)
+	if err != nil {
+		logging.Error(c.Request.Context(), "failed to sign media URL", err, "key", key)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve media")
+		return
+	}
+
+	// The signed URL expires, so the redirect must not be cached for longer
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(h.MediaURLTTL.Seconds())/2))
+	c.Redirect(http.StatusFound, signedURL)
+}
+
+// UploadProductImage adds an uploaded image to a product
+// @Summary      Upload a product image
+// @Description  Upload an image as the file field of a multipart form and add it to the product's images (Owner only). It also becomes the main image if the product has none.
+// @Tags         Products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id    path      int   true  "Product ID"
+// @Param        file  formData  file  true  "Image file"
+// @Success      200   {object}  models.Product
+// @Failure      400   {object}  helpers.ErrorResponse
+// @Failure      401   {object}  helpers.ErrorResponse
+// @Failure      403   {object}  helpers.ErrorResponse
+// @Failure      404   {object}  helpers.ErrorResponse
+// @Failure      413   {object}  helpers.ErrorResponse
+// @Failure      415   {object}  helpers.ErrorResponse
+// @Failure      500   {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/images [post]
+func (h *Handler) UploadProductImage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.Repos.Products.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve product") {
+		return
+	}
+	if product == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "product with ID %d not found", id), "")
+		return
+	}
+	if product.UserID != user.ID {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "You do not have permission to update this product"), "")
+		return
+	}
+
+	media, _, ok := h.storeUpload(c, user.ID)
+	if !ok {
+		return
+	}
+
+	for _, image := range product.Images {
+		if image == media.URL {
+			c.JSON(http.StatusOK, product)
+			return
+		}
+	}
+	product.Images = append(product.Images, media.URL)
+	if product.Image == "" {
+		product.Image = media.URL
+	}
+
+	if err := h.Repos.Products.Update(ctx, product); err != nil {
+		helpers.HandleError(c, err, "Failed to update product")
+		return
+	}
+
+	logging.Info(ctx, "product image uploaded", "product_id", id, "media_id", media.ID)
+	c.JSON(http.StatusOK, product)
+}
+
+// UploadCategoryImage sets a category's image to an uploaded one
+// @Summary      Upload a category image
+// @Description  Upload an image as the file field of a multipart form and make it the category's image (Admin only)
+// @Tags         Categories
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id    path      int   true  "Category ID"
+// @Param        file  formData  file  true  "Image file"
+// @Success      200   {object}  models.Category
+// @Failure      400   {object}  helpers.ErrorResponse
+// @Failure      401   {object}  helpers.ErrorResponse
+// @Failure      403   {object}  helpers.ErrorResponse
+// @Failure      404   {object}  helpers.ErrorResponse
+// @Failure      413   {object}  helpers.ErrorResponse
+// @Failure      415   {object}  helpers.ErrorResponse
+// @Failure      500   {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/categories/{id}/image [put]
+func (h *Handler) UploadCategoryImage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+	if !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only admins can change category images"), "")
+		return
+	}
+
+	category, err := h.Repos.Categories.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve category") {
+		return
+	}
+
+	media, _, ok := h.storeUpload(c, user.ID)
+	if !ok {
+		return
+	}
+
+	category.Image = media.URL
+	if err := h.Repos.Categories.Update(ctx, category); err != nil {
+		helpers.HandleError(c, err, "Failed to update category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// UploadAvatar sets the authenticated user's avatar to an uploaded image
+// @Summary      Upload an avatar
+// @Description  Upload an image as the file field of a multipart form and make it the authenticated user's avatar. A profile is created if the user has none.
+// @Tags         Profile
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file  formData  file  true  "Image file"
+// @Success      200   {object}  models.Profile
+// @Failure      400   {object}  helpers.ErrorResponse
+// @Failure      401   {object}  helpers.ErrorResponse
+// @Failure      413   {object}  helpers.ErrorResponse
+// @Failure      415   {object}  helpers.ErrorResponse
+// @Failure      500   {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/profile/avatar [put]
+func (h *Handler) UploadAvatar(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	profile, err := h.Repos.Profiles.GetByUserID(ctx, user.ID)
+	if helpers.HandleError(c, err, "Failed to retrieve profile") {
+		return
+	}
+
+	media, _, ok := h.storeUpload(c, user.ID)
+	if !ok {
+		return
+	}
+
+	if profile == nil {
+		profile, err = h.Repos.Profiles.Insert(ctx, &models.Profile{UserID: user.ID, AvatarURL: media.URL})
+		if helpers.HandleError(c, err, "Failed to create profile") {
+			return
+		}
+	} else {
+		if err := h.Repos.Profiles.UpdateByUserID(ctx, user.ID, map[string]interface{}{"avatar_url": media.URL}); err != nil {
+			helpers.HandleError(c, err, "Failed to update profile")
+			return
+		}
+		profile.AvatarURL = media.URL
+		profile.AvatarSrcset = models.Srcset(media.URL)
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// storeUpload stores the file field of a multipart upload, or returns the
+// stored file with the same content. It responds with an error and returns
+// false when the file is missing, too large or not an image.
+func (h *Handler) storeUpload(c *gin.Context, userID int) (*models.Media, bool, bool) {
+	ctx := c.Request.Context()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MediaMaxSize+mediaFormOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			helpers.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("The file is larger than %d bytes", h.MediaMaxSize))
+			return nil, false, false
+		}
+		helpers.RespondWithError(c, http.StatusBadRequest, "A file is required in the file field of a multipart form")
+		return nil, false, false
+	}
+	if header.Size > h.MediaMaxSize {
+		helpers.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("The file is larger than %d bytes", h.MediaMaxSize))
+		return nil, false, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logging.Error(ctx, "failed to open upload", err)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to read the file")
+		return nil, false, false
+	}
+	defer file.Close()
+
+	// The declared content type is not trusted; the file's own bytes decide
+	contentType, hash, err := sniffUpload(file)
+	if err != nil {
+		logging.Error(ctx, "failed to read upload", err)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to read the file")
+		return nil, false, false
+	}
+	ext, ok := mediaTypes[contentType]
+	if !ok {
+		helpers.RespondWithError(c, http.StatusUnsupportedMediaType, "Only JPEG, PNG, GIF and WebP images can be uploaded")
+		return nil, false, false
+	}
+
+	existing, err := h.Repos.Media.GetByHash(ctx, hash)
+	if helpers.HandleError(c, err, "Failed to store the file") {
+		return nil, false, false
+	}
+	if existing != nil {
+		logging.Debug(ctx, "upload already stored", "media_id", existing.ID, "user_id", userID)
+		return existing, false, true
+	}
+
+	key := "media/" + hash[:2] + "/" + hash + ext
+	if err := h.Storage.Put(ctx, key, file, header.Size, contentType); err != nil {
+		logging.Error(ctx, "failed to store upload", err, "key", key)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to store the file")
+		return nil, false, false
+	}
+
+	media, err := h.Repos.Media.Insert(ctx, &models.Media{
+		UserID:      userID,
+		Key:         key,
+		Hash:        hash,
+		ContentType: contentType,
+		Size:        header.Size,
+		Filename:    path.Base(header.Filename),
+	})
+	if helpers.HandleError(c, err, "Failed to store the file") {
+		return nil, false, false
+	}
+
+	// Resized variants are made in the background
+	go h.generateVariants(context.WithoutCancel(ctx), *media)
+	return media, true, true
+}
+
+// sniffUpload detects the content type of a file and hashes its content,
+// leaving it rewound for storing
+func sniffUpload(file multipart.File) (string, string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", "", err
+	}
+	contentType := http.DetectContentType(head[:n])
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+	return contentType, hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// checkMediaLinks responds with an error and returns false when a link to a
+// stored file points to one that does not exist. Other URLs are left alone.
+func (h *Handler) checkMediaLinks(c *gin.Context, links ...string) bool {
+	ctx := c.Request.Context()
+
+	for _, link := range links {
+		id, ok := models.MediaIDFromURL(link)
+		if !ok {
+			continue
+		}
+		if _, err := h.Repos.Media.Get(ctx, id); err != nil {
+			if appErrors.IsType(err, appErrors.ErrNotFound) {
+				helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "No stored file with ID "+strconv.Itoa(id)), "")
+				return false
+			}
+			helpers.HandleError(c, err, "Failed to retrieve media")
+			return false
+		}
+	}
+	return true
+}
+
+// productImages lists the images of a product and its variants
+func productImages(product *models.Product) []string {
+	images := append([]string{product.Image}, product.Images...)
+	for _, variant := range product.Variants {
+		images = append(images, variant.Images...)
+	}
+	return images
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	"github.com/alireza-akbarzadeh/ginflow/internal/constants"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/imaging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultImageSizes are the variants made of uploaded images unless configured otherwise
+var defaultImageSizes, _ = imaging.ParseSizes(constants.DEFAULT_IMAGE_SIZES)
+
+// GetMediaVariant redirects to a resized variant of a stored image
+// @Summary      Get a resized image
+// @Description  Redirect to a signed URL of a resized variant of a stored image, e.g. its thumbnail. Variants are made in the background after upload; a variant of a size that was configured since is made on demand, once for all the requests waiting for it.
+// @Tags         Media
+// @Param        id       path      int     true  "Media ID"
+// @Param        variant  path      string  true  "Size name, e.g. thumbnail"
+// @Success      302
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/media/{id}/{variant} [get]
+func (h *Handler) GetMediaVariant(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid media ID")
+		return
+	}
+	size, ok := h.imageSize(c.Param("variant"))
+	if !ok {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "Unknown image size %q", c.Param("variant")), "")
+		return
+	}
+
+	media, err := h.Repos.Media.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve media") {
+		return
+	}
+	variant, err := h.Repos.Media.GetVariant(ctx, media.ID, size.Name)
+	if helpers.HandleError(c, err, "Failed to retrieve media") {
+		return
+	}
+
+	// Sizes configured after the upload, or changed since, are made now
+	if variant == nil || variant.MaxSize != size.Max {
+		if variant, err = h.variantOnDemand(ctx, media, size); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrNotFound, "File not found"), "")
+				return
+			}
+			helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to resize the image")
+			return
+		}
+	}
+
+	h.redirectToFile(c, variant.Key)
+}
+
+// variantOnDemand makes the variant of a size missing for an image. Requests
+// for the same variant while it is being made wait for it rather than each
+// decoding the image again, and the variant is made to the end even if the
+// request that started it goes away.
+func (h *Handler) variantOnDemand(ctx context.Context, media *models.Media, size imaging.Size) (*models.MediaVariant, error) {
+	key := fmt.Sprintf("%d/%s", media.ID, size.Name)
+	made, err, _ := h.variantFlights.Do(key, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+
+		// A request before may have made it since this one looked
+		variant, err := h.Repos.Media.GetVariant(ctx, media.ID, size.Name)
+		if err != nil {
+			return nil, err
+		}
+		if variant != nil && variant.MaxSize == size.Max {
+			return variant, nil
+		}
+
+		source, err := h.openImage(ctx, media)
+		if err != nil {
+			logging.Error(ctx, "failed to open image", err, "media_id", media.ID)
+			return nil, err
+		}
+		if variant, err = h.makeVariant(ctx, media, source, size, variant); err != nil {
+			logging.Error(ctx, "failed to make image variant", err, "media_id", media.ID, "size", size.Name)
+			return nil, err
+		}
+		return variant, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return made.(*models.MediaVariant), nil
+}
+
+// generateVariants makes the variants of every configured size of a newly
+// stored image. It runs in the background after upload; failed variants are
+// made on demand later.
+func (h *Handler) generateVariants(ctx context.Context, media models.Media) {
+	source, err := h.openImage(ctx, &media)
+	if err != nil {
+		logging.Error(ctx, "failed to open image", err, "media_id", media.ID)
+		return
+	}
+	for _, size := range h.ImageSizes {
+		if _, err := h.makeVariant(ctx, &media, source, size, nil); err != nil {
+			logging.Error(ctx, "failed to make image variant", err, "media_id", media.ID, "size", size.Name)
+		}
+	}
+	logging.Info(ctx, "image variants generated", "media_id", media.ID, "sizes", len(h.ImageSizes))
+}
+
+// openImage reads and decodes a stored image
+func (h *Handler) openImage(ctx context.Context, media *models.Media) (*imaging.Source, error) {
+	file, err := h.Storage.Open(ctx, media.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return imaging.Decode(data)
+}
+
+// makeVariant resizes an image to a size, stores and records the result. The
+// file of the previous variant of the size, if any, is removed.
+func (h *Handler) makeVariant(ctx context.Context, media *models.Media, source *imaging.Source, size imaging.Size, previous *models.MediaVariant) (*models.MediaVariant, error) {
+	img := source.Resize(size.Max)
+	format := imaging.OutputFormat(h.ImageFormat, img)
+	var encoded bytes.Buffer
+	if err := imaging.Encode(&encoded, img, format); err != nil {
+		return nil, err
+	}
+
+	variant := &models.MediaVariant{
+		MediaID:     media.ID,
+		Name:        size.Name,
+		MaxSize:     size.Max,
+		Key:         fmt.Sprintf("variants/%s/%s-%s-%d%s", media.Hash[:2], media.Hash, size.Name, size.Max, imaging.Extension(format)),
+		ContentType: imaging.ContentType(format),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Size:        int64(encoded.Len()),
+	}
+	if err := h.Storage.Put(ctx, variant.Key, &encoded, variant.Size, variant.ContentType); err != nil {
+		return nil, err
+	}
+	if err := h.Repos.Media.SaveVariant(ctx, variant); err != nil {
+		return nil, err
+	}
+
+	if previous != nil && previous.Key != variant.Key {
+		if err := h.Storage.Delete(ctx, previous.Key); err != nil {
+			logging.Error(ctx, "failed to delete previous image variant", err, "key", previous.Key)
+		}
+	}
+	return variant, nil
+}
+
+// imageSize returns the configured variant size with a name
+func (h *Handler) imageSize(name string) (imaging.Size, bool) {
+	for _, size := range h.ImageSizes {
+		if size.Name == name {
+			return size, true
+		}
+	}
+	return imaging.Size{}, false
+}
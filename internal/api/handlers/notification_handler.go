@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/mentions"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/notifications"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// maxMentionsPerComment caps how many mentions in one comment are resolved
+const maxMentionsPerComment = 20
+
+// GetNotifications lists the authenticated user's notifications
+// @Summary      List notifications
+// @Description  List the authenticated user's notifications, newest first
+// @Tags         Notifications
+// @Produce      json
+// @Param        unread     query     bool  false  "Only unread notifications"
+// @Param        page       query     int   false  "Page number (default: 1)"
+// @Param        page_size  query     int   false  "Page size (default: 20, max: 100)"
+// @Success      200        {object}  query.PaginatedList{data=[]models.Notification}
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/notifications [get]
+func (h *Handler) GetNotifications(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	params := query.ParseFromContext(c)
+
+	notifications, result, err := h.Repos.Notifications.ListByUser(ctx, user.ID, unreadOnly, params)
+	if helpers.HandleError(c, err, "Failed to fetch notifications") {
+		return
+	}
+
+	logging.Debug(ctx, "notifications retrieved successfully", "user_id", user.ID, "count", len(notifications))
+	c.JSON(http.StatusOK, result)
+}
+
+// MarkNotificationRead marks a notification as read
+// @Summary      Mark a notification as read
+// @Description  Mark one of the authenticated user's notifications as read
+// @Tags         Notifications
+// @Param        id   path      int  true  "Notification ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/notifications/{id}/read [post]
+func (h *Handler) MarkNotificationRead(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repos.Notifications.MarkRead(ctx, user.ID, id); err != nil {
+		helpers.HandleError(c, err, "Failed to update notification")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllNotificationsRead marks all notifications as read
+// @Summary      Mark all notifications as read
+// @Description  Mark all of the authenticated user's notifications as read
+// @Tags         Notifications
+// @Produce      json
+// @Success      200  {object}  map[string]int64
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/notifications/read-all [post]
+func (h *Handler) MarkAllNotificationsRead(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.Repos.Notifications.MarkAllRead(ctx, user.ID)
+	if helpers.HandleError(c, err, "Failed to update notifications") {
+		return
+	}
+
+	logging.Info(ctx, "notifications marked read", "user_id", user.ID, "count", updated)
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// MuteEventMentions stops mention notifications from an event
+// @Summary      Mute mentions from an event
+// @Description  Stop notifying the authenticated user about mentions in an event's comments
+// @Tags         Notifications
+// @Param        id   path      int  true  "Event ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/events/{id}/mentions/mute [post]
+func (h *Handler) MuteEventMentions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	event, err := h.Repos.Events.Get(ctx, eventID)
+	if helpers.HandleError(c, err, "Failed to retrieve event") {
+		return
+	}
+	if event == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "event with ID %d not found", eventID), "")
+		return
+	}
+
+	if err := h.Repos.Notifications.Mute(ctx, user.ID, eventID); err != nil {
+		helpers.HandleError(c, err, "Failed to mute event")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnmuteEventMentions restores mention notifications from an event
+// @Summary      Unmute mentions from an event
+// @Description  Resume notifying the authenticated user about mentions in an event's comments
+// @Tags         Notifications
+// @Param        id   path      int  true  "Event ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/events/{id}/mentions/mute [delete]
+func (h *Handler) UnmuteEventMentions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	eventID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repos.Notifications.Unmute(ctx, user.ID, eventID); err != nil {
+		helpers.HandleError(c, err, "Failed to unmute event")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// resolveMentions finds @name and @email mentions in content and resolves them to users
+func (h *Handler) resolveMentions(ctx context.Context, content string) ([]models.CommentMention, error) {
+	candidates := mentions.Parse(content)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if len(candidates) > maxMentionsPerComment {
+		candidates = candidates[:maxMentionsPerComment]
+	}
+
+	names, emails := mentions.Handles(candidates)
+	users, err := h.Repos.Users.FindByMentions(ctx, names, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	return mentions.Resolve(candidates, users), nil
+}
+
+// notifyMentions notifies the users mentioned in a visible comment, except the
+// author, users in skip and users who muted the event. Failures are logged and
+// never fail the request that triggered them.
+func (h *Handler) notifyMentions(ctx context.Context, comment *models.Comment, author *models.User, skip map[int]bool) {
+	var recipients []int
+	for _, userID := range mentions.UserIDs(comment.Mentions) {
+		if userID != author.ID && !skip[userID] {
+			recipients = append(recipients, userID)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	muted, err := h.Repos.Notifications.MutedUserIDs(ctx, comment.EventID, recipients)
+	if err != nil {
+		logging.Error(ctx, "failed to check event mutes, skipping mention notifications", err, "comment_id", comment.ID)
+		return
+	}
+	mutedSet := make(map[int]bool, len(muted))
+	for _, userID := range muted {
+		mutedSet[userID] = true
+	}
+
+	for _, userID := range recipients {
+		if mutedSet[userID] {
+			logging.Debug(ctx, "mention notification muted", "user_id", userID, "event_id", comment.EventID)
+			continue
+		}
+
+		h.deliverNotification(ctx, &models.Notification{
+			UserID:    userID,
+			Type:      models.NotificationTypeMention,
+			ActorID:   &author.ID,
+			EventID:   &comment.EventID,
+			CommentID: &comment.ID,
+			Message:   fmt.Sprintf("%s mentioned you in a comment", author.Name),
+		})
+	}
+}
+
+// deliverNotification stores a notification and sends it over the channels its
+// recipient enabled. Failures are logged.
+func (h *Handler) deliverNotification(ctx context.Context, notification *models.Notification) {
+	notification, err := h.Repos.Notifications.Insert(ctx, notification)
+	if err != nil {
+		return
+	}
+
+	// A missing profile means the user never changed the defaults
+	profile, err := h.Repos.Profiles.GetByUserID(ctx, notification.UserID)
+	if err != nil && !appErrors.IsType(err, appErrors.ErrNotFound) {
+		logging.Error(ctx, "failed to load notification preferences", err, "user_id", notification.UserID)
+		return
+	}
+
+	for _, channel := range notifications.Channels(profile) {
+		if err := h.Notifier.Send(ctx, channel, notification); err != nil {
+			logging.Error(ctx, "failed to deliver notification", err, "channel", string(channel), "notification_id", notification.ID)
+		}
+	}
+}
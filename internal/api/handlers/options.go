@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/imaging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/moderation"
+	"github.com/alireza-akbarzadeh/ginflow/internal/notifications"
+	"github.com/alireza-akbarzadeh/ginflow/internal/payments"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/storage"
+	"github.com/alireza-akbarzadeh/ginflow/internal/views"
+)
+
+// Option is a functional option for configuring the Handler
+type Option func(*Handler)
+
+// WithCommentMaxDepth sets the maximum nesting depth for comment replies
+func WithCommentMaxDepth(depth int) Option {
+	return func(h *Handler) {
+		if depth >= 0 {
+			h.CommentMaxDepth = depth
+		}
+	}
+}
+
+// WithCommentEditWindow sets how long after posting a comment may be edited
+func WithCommentEditWindow(d time.Duration) Option {
+	return func(h *Handler) {
+		if d >= 0 {
+			h.CommentEditWindow = d
+		}
+	}
+}
+
+// WithCommentRateLimit sets how many comments a user may post per minute
+func WithCommentRateLimit(perMinute int) Option {
+	return func(h *Handler) {
+		if perMinute > 0 {
+			h.CommentRateLimit = perMinute
+		}
+	}
+}
+
+// WithCommentReportThreshold sets how many open reports hold a comment for review
+func WithCommentReportThreshold(threshold int) Option {
+	return func(h *Handler) {
+		if threshold > 0 {
+			h.CommentReportThreshold = threshold
+		}
+	}
+}
+
+// WithCommentFilter sets the filter that holds matching comments for review
+func WithCommentFilter(filter *moderation.Filter) Option {
+	return func(h *Handler) {
+		h.CommentFilter = filter
+	}
+}
+
+// WithNotifier sets the sender used for email and push notifications
+func WithNotifier(sender notifications.Sender) Option {
+	return func(h *Handler) {
+		if sender != nil {
+			h.Notifier = sender
+		}
+	}
+}
+
+// WithPaymentProvider sets the provider used to collect and refund payments
+func WithPaymentProvider(provider payments.PaymentProvider) Option {
+	return func(h *Handler) {
+		if provider != nil {
+			h.Payments = provider
+		}
+	}
+}
+
+// WithCurrency sets the currency of prices given without a currency
+func WithCurrency(currency string) Option {
+	return func(h *Handler) {
+		if currency != "" {
+			h.Currency = strings.ToUpper(currency)
+		}
+	}
+}
+
+// WithPricing sets the engine that prices baskets and orders
+func WithPricing(engine *pricing.Engine) Option {
+	return func(h *Handler) {
+		if engine != nil {
+			h.Pricing = engine
+		}
+	}
+}
+
+// WithStockReservationTTL sets how long basket items hold their stock
+func WithStockReservationTTL(d time.Duration) Option {
+	return func(h *Handler) {
+		if d > 0 {
+			h.StockReservationTTL = d
+		}
+	}
+}
+
+// WithGuestBasketTTL sets how long an unchanged guest basket is kept
+func WithGuestBasketTTL(d time.Duration) Option {
+	return func(h *Handler) {
+		if d > 0 {
+			h.GuestBasketTTL = d
+		}
+	}
+}
+
+// WithReviewsVerifiedOnly limits product reviews to users who bought the product
+func WithReviewsVerifiedOnly(verifiedOnly bool) Option {
+	return func(h *Handler) {
+		h.ReviewsVerifiedOnly = verifiedOnly
+	}
+}
+
+// WithProductImportMaxSize sets the largest file a product import accepts, in bytes
+func WithProductImportMaxSize(size int64) Option {
+	return func(h *Handler) {
+		if size > 0 {
+			h.ProductImportMaxSize = size
+		}
+	}
+}
+
+// WithStorage sets the storage uploaded files are kept in
+func WithStorage(store storage.Storage) Option {
+	return func(h *Handler) {
+		if store != nil {
+			h.Storage = store
+		}
+	}
+}
+
+// WithMediaMaxSize sets the largest file an upload accepts, in bytes
+func WithMediaMaxSize(size int64) Option {
+	return func(h *Handler) {
+		if size > 0 {
+			h.MediaMaxSize = size
+		}
+	}
+}
+
+// WithMediaURLTTL sets how long signed URLs of stored files stay valid
+func WithMediaURLTTL(d time.Duration) Option {
+	return func(h *Handler) {
+		if d > 0 {
+			h.MediaURLTTL = d
+		}
+	}
+}
+
+// WithImageSizes sets the resized variants made of uploaded images
+func WithImageSizes(sizes []imaging.Size) Option {
+	return func(h *Handler) {
+		h.ImageSizes = sizes
+	}
+}
+
+// WithImageFormat sets the format image variants are encoded in
+func WithImageFormat(format string) Option {
+	return func(h *Handler) {
+		if format != "" {
+			h.ImageFormat = format
+		}
+	}
+}
+
+// WithViewWindow sets the window a viewer's views of a product count once in
+func WithViewWindow(d time.Duration) Option {
+	return func(h *Handler) {
+		if d > 0 {
+			h.Views = views.NewCounter(d)
+		}
+	}
+}
+
+// WithTrendingHalfLife sets how long until views and sales weigh half as much
+// towards trending products
+func WithTrendingHalfLife(d time.Duration) Option {
+	return func(h *Handler) {
+		if d > 0 {
+			h.TrendingHalfLife = d
+		}
+	}
+}
+
+// WithTrendingSaleWeight sets how many views a sale counts as towards trending products
+func WithTrendingSaleWeight(weight int) Option {
+	return func(h *Handler) {
+		if weight >= 0 {
+			h.TrendingSaleWeight = weight
+		}
+	}
+}
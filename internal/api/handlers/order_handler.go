@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// Checkout places an order for the active basket
+// @Summary      Check out basket
+// @Description  Place an order for the items in the active basket at their basket prices, with tax for the country in the buyer's profile. Stock is reserved, the basket is completed and a payment intent is created; its client secret is returned in the order's payments.
+// @Tags         Orders
+// @Produce      json
+// @Success      201  {object}  models.Order
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      409  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/basket/checkout [post]
+func (h *Handler) Checkout(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	logging.Debug(ctx, "checking out basket", "user_id", user.ID)
+
+	country, err := h.buyerCountry(ctx, user.ID)
+	if helpers.HandleError(c, err, "Failed to retrieve profile") {
+		return
+	}
+
+	order, err := h.Repos.Orders.Checkout(ctx, user.ID, h.Pricing.QuoteFor(country))
+	if helpers.HandleError(c, err, "Failed to check out basket") {
+		return
+	}
+
+	h.notifyOrderLowStock(ctx, order.ID)
+
+	// The order stands even if the payment cannot be started; the buyer can retry it
+	payment, err := h.startPayment(ctx, order)
+	if err != nil {
+		logging.Error(ctx, "failed to start payment after checkout", err, "order_id", order.ID)
+	} else {
+		order.Payments = append(order.Payments, *payment)
+	}
+
+	logging.Info(ctx, "checkout completed", "order_id", order.ID, "user_id", user.ID)
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetMyOrders lists the authenticated user's orders
+// @Summary      List my orders
+// @Description  List the authenticated user's orders, newest first
+// @Tags         Orders
+// @Produce      json
+// @Param        page       query     int     false  "Page number (default: 1)"
+// @Param        page_size  query     int     false  "Page size (default: 20, max: 100)"
+// @Param        status     query     string  false  "Filter by order status"
+// @Success      200        {object}  query.PaginatedList{data=[]models.Order}
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/orders [get]
+func (h *Handler) GetMyOrders(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	params := query.ParseFromContext(c)
+
+	orders, result, err := h.Repos.Orders.ListByUser(ctx, user.ID, params)
+	if helpers.HandleError(c, err, "Failed to fetch orders") {
+		return
+	}
+
+	logging.Debug(ctx, "orders retrieved successfully", "user_id", user.ID, "count", len(orders))
+	c.JSON(http.StatusOK, result)
+}
+
+// GetOrder retrieves an order
+// @Summary      Get an order
+// @Description  Get an order with its items and status history (buyer, sellers of its products, or admin). Sellers see only their own items and amounts.
+// @Tags         Orders
+// @Produce      json
+// @Param        id   path      int  true  "Order ID"
+// @Success      200  {object}  models.Order
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/orders/{id} [get]
+func (h *Handler) GetOrder(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.Repos.Orders.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve order") {
+		return
+	}
+
+	// Other users' orders are reported as missing rather than forbidden
+	if order.UserID != user.ID && !order.HasSeller(user.ID) && !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "order with ID %d not found", id), "")
+		return
+	}
+
+	c.JSON(http.StatusOK, orderView(order, user))
+}
+
+// UpdateOrderStatusRequest represents an order status change
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=paid processing shipped delivered cancelled refunded"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+// buyerOrderStatuses and sellerOrderStatuses are the statuses buyers and sellers
+// may move an order to; admins may make any valid transition
+var (
+	buyerOrderStatuses  = map[string]bool{models.OrderStatusCancelled: true}
+	sellerOrderStatuses = map[string]bool{
+		models.OrderStatusProcessing: true,
+		models.OrderStatusShipped:    true,
+		models.OrderStatusDelivered:  true,
+		models.OrderStatusCancelled:  true,
+	}
+)
+
+// UpdateOrderStatus moves an order to a new status
+// @Summary      Update order status
+// @Description  Move an order to a new status. Buyers may cancel; the seller of all its products may process, ship, deliver or cancel; admins may make any valid transition. Cancelling or refunding restores stock, then refunds captured payments with the provider; refunds the provider fails stay refund_pending and are retried.
+// @Tags         Orders
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                       true  "Order ID"
+// @Param        status  body      UpdateOrderStatusRequest  true  "New status"
+// @Success      200     {object}  models.Order
+// @Failure      400     {object}  helpers.ErrorResponse
+// @Failure      401     {object}  helpers.ErrorResponse
+// @Failure      403     {object}  helpers.ErrorResponse
+// @Failure      404     {object}  helpers.ErrorResponse
+// @Failure      409     {object}  helpers.ErrorResponse
+// @Failure      500     {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/orders/{id}/status [post]
+func (h *Handler) UpdateOrderStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req UpdateOrderStatusRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.Repos.Orders.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve order") {
+		return
+	}
+
+	isBuyer := order.UserID == user.ID
+	isSeller := order.HasSeller(user.ID)
+	if !isBuyer && !isSeller && !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "order with ID %d not found", id), "")
+		return
+	}
+
+	// Order-wide changes by a seller would affect other sellers' items too
+	if isSeller && !isBuyer && !user.IsAdmin() && sellerOrderStatuses[req.Status] && !order.IsSoleSeller(user.ID) {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Orders with other sellers' items can only be updated by the buyer or an admin"), "")
+		return
+	}
+
+	allowed := user.IsAdmin() ||
+		(isBuyer && buyerOrderStatuses[req.Status]) ||
+		(isSeller && sellerOrderStatuses[req.Status])
+	if !allowed {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrForbidden, "You are not allowed to mark this order as %s", req.Status), "")
+		return
+	}
+
+	updatedOrder, err := h.Repos.Orders.Transition(ctx, id, req.Status, &user.ID, req.Note)
+	if helpers.HandleError(c, err, "Failed to update order status") {
+		return
+	}
+
+	// Cancelling a paid order gives the buyer their money back, as refunding
+	// does, once the order has changed
+	if models.RestoresStock(req.Status) {
+		h.refundPayments(ctx, updatedOrder)
+		h.notifyRestocked(ctx, updatedOrder)
+	}
+
+	logging.Info(ctx, "order status updated", "order_id", id, "status", req.Status, "user_id", user.ID)
+	c.JSON(http.StatusOK, orderView(updatedOrder, user))
+}
+
+// GetSellerOrders lists orders containing the authenticated user's products
+// @Summary      List seller orders
+// @Description  List orders that contain products sold by the authenticated user, newest first, with only the user's items and amounts
+// @Tags         Orders
+// @Produce      json
+// @Param        page       query     int     false  "Page number (default: 1)"
+// @Param        page_size  query     int     false  "Page size (default: 20, max: 100)"
+// @Param        status     query     string  false  "Filter by order status"
+// @Success      200        {object}  query.PaginatedList{data=[]models.Order}
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/seller/orders [get]
+func (h *Handler) GetSellerOrders(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	params := query.ParseFromContext(c)
+
+	orders, result, err := h.Repos.Orders.ListBySeller(ctx, user.ID, params)
+	if helpers.HandleError(c, err, "Failed to fetch orders") {
+		return
+	}
+
+	logging.Debug(ctx, "seller orders retrieved successfully", "seller_id", user.ID, "count", len(orders))
+	c.JSON(http.StatusOK, result)
+}
+
+// orderView returns the order as the user may see it: in full for its buyer
+// and admins, and scoped to their own items for its sellers
+func orderView(order *models.Order, user *models.User) *models.Order {
+	if order.UserID == user.ID || user.IsAdmin() {
+		return order
+	}
+	return order.ForSeller(user.ID)
+}
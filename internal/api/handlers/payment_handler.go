@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/payments"
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentSignatureHeader carries the provider's webhook signature
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+// maxWebhookBytes caps the size of webhook payloads
+const maxWebhookBytes = 64 << 10
+
+// PayOrder starts a new payment attempt for a pending order
+// @Summary      Pay for an order
+// @Description  Create a new payment intent for a pending order, e.g. after a failed attempt (buyer only)
+// @Tags         Payments
+// @Produce      json
+// @Param        id   path      int  true  "Order ID"
+// @Success      201  {object}  models.Payment
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      409  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/orders/{id}/pay [post]
+func (h *Handler) PayOrder(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.Repos.Orders.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve order") {
+		return
+	}
+	if order.UserID != user.ID {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "order with ID %d not found", id), "")
+		return
+	}
+	if order.Status != models.OrderStatusPending {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrConflict, "order is already %s", order.Status), "")
+		return
+	}
+
+	payment, err := h.startPayment(ctx, order)
+	if helpers.HandleError(c, err, "Failed to start payment") {
+		return
+	}
+	if payment.Status == models.PaymentStatusFailed {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInternalServer, "Payment provider rejected the payment"), "")
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// PaymentWebhook processes a payment provider webhook
+// @Summary      Payment provider webhook
+// @Description  Receive a signed payment provider event. Events are processed once; redelivered events are acknowledged without effect.
+// @Tags         Payments
+// @Accept       json
+// @Produce      json
+// @Param        X-Payment-Signature  header    string  true  "Webhook signature"
+// @Success      200                  {object}  map[string]string
+// @Failure      400                  {object}  helpers.ErrorResponse
+// @Failure      401                  {object}  helpers.ErrorResponse
+// @Failure      500                  {object}  helpers.ErrorResponse
+// @Router       /api/v1/payments/webhook [post]
+func (h *Handler) PaymentWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBytes))
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Failed to read webhook payload")
+		return
+	}
+
+	if err := h.Payments.VerifyWebhook(payload, c.GetHeader(PaymentSignatureHeader)); err != nil {
+		logging.Info(ctx, "rejected payment webhook", "provider", h.Payments.Name(), "reason", err.Error())
+		helpers.RespondWithError(c, http.StatusUnauthorized, "Invalid webhook signature")
+		return
+	}
+
+	event, err := h.Payments.ParseWebhook(payload)
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	provider := h.Payments.Name()
+	seen, err := h.Repos.Payments.HasEvent(ctx, provider, event.ID)
+	if helpers.HandleError(c, err, "Failed to process webhook") {
+		return
+	}
+	if seen {
+		logging.Debug(ctx, "duplicate payment webhook", "event_id", event.ID)
+		c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+		return
+	}
+
+	payment, err := h.Repos.Payments.GetByIntent(ctx, provider, event.IntentID)
+	if err != nil {
+		if appErrors.IsType(err, appErrors.ErrNotFound) {
+			// Unknown intents are acknowledged so the provider stops retrying
+			logging.Info(ctx, "payment webhook for unknown intent", "event_id", event.ID, "intent_id", event.IntentID)
+			c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+			return
+		}
+		helpers.HandleError(c, err, "Failed to process webhook")
+		return
+	}
+
+	// Every step below is idempotent, so a delivery that fails part-way is
+	// safely reprocessed when the provider retries it
+	if err := h.applyPaymentEvent(ctx, payment, event); err != nil {
+		helpers.HandleError(c, err, "Failed to process webhook")
+		return
+	}
+
+	err = h.Repos.Payments.RecordEvent(ctx, &models.PaymentEvent{
+		Provider:  provider,
+		EventID:   event.ID,
+		Type:      event.Type,
+		PaymentID: &payment.ID,
+	})
+	if helpers.HandleError(c, err, "Failed to process webhook") {
+		return
+	}
+
+	logging.Info(ctx, "payment webhook processed", "event_id", event.ID, "type", event.Type, "payment_id", payment.ID)
+	c.JSON(http.StatusOK, gin.H{"status": "processed"})
+}
+
+// startPayment creates a provider intent for an order and records the attempt.
+// A provider failure is recorded as a failed attempt rather than returned.
+func (h *Handler) startPayment(ctx context.Context, order *models.Order) (*models.Payment, error) {
+	payment := &models.Payment{
+		OrderID:  order.ID,
+		Provider: h.Payments.Name(),
+		Amount:   order.Total,
+		Currency: order.Total.Currency,
+		Status:   models.PaymentStatusPending,
+	}
+
+	intent, err := h.Payments.CreateIntent(ctx, payments.IntentRequest{
+		OrderID: order.ID,
+		Amount:  order.Total,
+	})
+	if err != nil {
+		logging.Error(ctx, "payment provider failed to create intent", err, "order_id", order.ID)
+		payment.Status = models.PaymentStatusFailed
+		payment.Error = err.Error()
+	} else {
+		payment.IntentID = intent.ID
+	}
+
+	payment, err = h.Repos.Payments.Insert(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	if intent != nil {
+		payment.ClientSecret = intent.ClientSecret
+	}
+	return payment, nil
+}
+
+// refundPayments refunds the captured payments of an order that was cancelled
+// or refunded. Failures are logged and never undo the order's change: the
+// payments stay refund_pending until RetryRefunds gets them refunded.
+func (h *Handler) refundPayments(ctx context.Context, order *models.Order) {
+	for i := range order.Payments {
+		payment := &order.Payments[i]
+		if payment.Status != models.PaymentStatusSucceeded {
+			continue
+		}
+		// The refund is owed before the provider is asked, so that it is
+		// retried even if the request ends half way
+		if err := h.Repos.Payments.UpdateStatus(ctx, payment.ID, models.PaymentStatusRefundPending, ""); err != nil {
+			continue
+		}
+		payment.Status = models.PaymentStatusRefundPending
+		h.refundPayment(ctx, payment)
+	}
+}
+
+// RetryRefunds asks the provider again for the refunds pending since before.
+// Failures are logged and retried on the next call.
+func (h *Handler) RetryRefunds(ctx context.Context, before time.Time) error {
+	pending, err := h.Repos.Payments.ListPendingRefunds(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, payment := range pending {
+		h.refundPayment(ctx, payment)
+	}
+	return nil
+}
+
+// refundPayment refunds a refund_pending payment with the provider and marks
+// it refunded. A provider failure is recorded on the payment, which stays
+// pending.
+func (h *Handler) refundPayment(ctx context.Context, payment *models.Payment) {
+	status, errMsg := models.PaymentStatusRefunded, ""
+	if _, err := h.Payments.Refund(ctx, payment.IntentID, payment.Amount); err != nil {
+		logging.Error(ctx, "payment provider failed to refund, retrying later", err, "payment_id", payment.ID, "order_id", payment.OrderID)
+		status, errMsg = models.PaymentStatusRefundPending, err.Error()
+	}
+	if err := h.Repos.Payments.UpdateStatus(ctx, payment.ID, status, errMsg); err != nil {
+		return
+	}
+	payment.Status, payment.Error = status, errMsg
+}
+
+// applyPaymentEvent updates a payment and its order for a provider event
+func (h *Handler) applyPaymentEvent(ctx context.Context, payment *models.Payment, event *payments.WebhookEvent) error {
+	switch event.Type {
+	case payments.EventPaymentAuthorized:
+		if payment.Status != models.PaymentStatusPending {
+			return nil
+		}
+		order, err := h.Repos.Orders.Get(ctx, payment.OrderID)
+		if err != nil {
+			return err
+		}
+		// Orders cancelled or paid by another attempt in the meantime are not charged
+		if order.Status != models.OrderStatusPending {
+			return h.cancelPayment(ctx, payment, order)
+		}
+		intent, err := h.Payments.Capture(ctx, payment.IntentID)
+		if err != nil {
+			logging.Error(ctx, "failed to capture payment", err, "payment_id", payment.ID)
+			return appErrors.New(appErrors.ErrInternalServer, "failed to capture payment")
+		}
+		if intent.Status != payments.IntentStatusSucceeded {
+			return nil
+		}
+		return h.completePayment(ctx, payment)
+
+	case payments.EventPaymentSucceeded:
+		return h.completePayment(ctx, payment)
+
+	case payments.EventPaymentFailed:
+		if payment.Status != models.PaymentStatusPending {
+			return nil
+		}
+		return h.Repos.Payments.UpdateStatus(ctx, payment.ID, models.PaymentStatusFailed, event.Reason)
+
+	case payments.EventRefundSucceeded:
+		// Refunds made here settle their order themselves; only refunds made
+		// with the provider directly refund the order
+		if payment.Status == models.PaymentStatusRefunded {
+			return nil
+		}
+		if err := h.transitionOrder(ctx, payment.OrderID, models.OrderStatusRefunded, "Payment refunded"); err != nil {
+			return err
+		}
+		return h.Repos.Payments.UpdateStatus(ctx, payment.ID, models.PaymentStatusRefunded, "")
+
+	default:
+		logging.Debug(ctx, "ignoring payment webhook type", "type", event.Type)
+		return nil
+	}
+}
+
+// completePayment marks a captured payment succeeded and its pending order
+// paid. A payment captured for an order no longer pending, e.g. cancelled while
+// the buyer paid or paid by another attempt, is refunded.
+func (h *Handler) completePayment(ctx context.Context, payment *models.Payment) error {
+	order, err := h.Repos.Orders.Get(ctx, payment.OrderID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case payment.Status == models.PaymentStatusRefunded:
+		return nil
+	case order.Status == models.OrderStatusPending:
+		if payment.Status != models.PaymentStatusSucceeded {
+			if err := h.Repos.Payments.UpdateStatus(ctx, payment.ID, models.PaymentStatusSucceeded, ""); err != nil {
+				return err
+			}
+		}
+		// A conflict means the order moved on since it was loaded; the
+		// provider retries the event, which then refunds the payment if the
+		// order was cancelled
+		_, err := h.Repos.Orders.Transition(ctx, order.ID, models.OrderStatusPaid, nil, fmt.Sprintf("Payment %s succeeded", payment.IntentID))
+		return err
+	case payment.Status == models.PaymentStatusSucceeded && !models.RestoresStock(order.Status):
+		// An earlier delivery of the event already paid the order
+		return nil
+	default:
+		return h.refundStrayPayment(ctx, payment, order)
+	}
+}
+
+// cancelPayment releases an authorized payment of an order that no longer
+// takes it instead of capturing it
+func (h *Handler) cancelPayment(ctx context.Context, payment *models.Payment, order *models.Order) error {
+	logging.Warn(ctx, "cancelling payment authorized for an order that is no longer pending",
+		"payment_id", payment.ID, "order_id", order.ID, "order_status", order.Status)
+	if _, err := h.Payments.Cancel(ctx, payment.IntentID); err != nil {
+		logging.Error(ctx, "payment provider failed to cancel", err, "payment_id", payment.ID)
+		return appErrors.New(appErrors.ErrInternalServer, "payment provider failed to cancel the payment")
+	}
+	return h.Repos.Payments.UpdateStatus(ctx, payment.ID, models.PaymentStatusCancelled, "order is "+order.Status)
+}
+
+// refundStrayPayment refunds a payment captured for an order that no longer
+// takes it
+func (h *Handler) refundStrayPayment(ctx context.Context, payment *models.Payment, order *models.Order) error {
+	logging.Warn(ctx, "refunding payment captured for an order that is no longer pending",
+		"payment_id", payment.ID, "order_id", order.ID, "order_status", order.Status)
+	if _, err := h.Payments.Refund(ctx, payment.IntentID, payment.Amount); err != nil {
+		logging.Error(ctx, "payment provider failed to refund", err, "payment_id", payment.ID)
+		return appErrors.New(appErrors.ErrInternalServer, "payment provider failed to refund the payment")
+	}
+	return h.Repos.Payments.UpdateStatus(ctx, payment.ID, models.PaymentStatusRefunded, "order is "+order.Status)
+}
+
+// transitionOrder moves an order to a status on behalf of the system. Orders
+// already in the status, e.g. through an earlier delivery of the same event,
+// are left unchanged, and orders that cannot move to it are left for an admin
+// with a warning.
+func (h *Handler) transitionOrder(ctx context.Context, orderID int, status, note string) error {
+	order, err := h.Repos.Orders.Get(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status == status {
+		return nil
+	}
+	if !models.CanTransitionOrder(order.Status, status) {
+		logging.Warn(ctx, "payment event does not apply to order", "order_id", orderID, "status", order.Status, "target", status)
+		return nil
+	}
+
+	order, err = h.Repos.Orders.Transition(ctx, orderID, status, nil, note)
+	if err != nil {
+		return err
+	}
+	if models.RestoresStock(status) {
+		h.notifyRestocked(ctx, order)
+	}
+	return nil
+}
@@ -8,14 +8,16 @@ import (
 	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
 	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
 	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/alireza-akbarzadeh/ginflow/internal/repository"
 	"github.com/alireza-akbarzadeh/ginflow/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
 // CreateProduct handles product creation
 // @Summary      Create a new product
-// @Description  Create a new product (requires authentication)
+// @Description  Create a new product (requires authentication). The final price is derived from the price and discount. Products sold in variants list their options, e.g. sizes, and variants with a SKU, a value for each option, their own stock and images and optionally their own price; the product's stock is then the sum of its variants'.
 // @Tags         Products
 // @Accept       json
 // @Produce      json
@@ -41,6 +43,21 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 	}
 	product.UserID = user.ID
 
+	// Options and variants are new with the product
+	for i := range product.Options {
+		product.Options[i].ID = 0
+	}
+	for i := range product.Variants {
+		product.Variants[i].ID = 0
+	}
+
+	if !h.validateProductPrices(c, &product) {
+		return
+	}
+	if !h.checkMediaLinks(c, productImages(&product)...) {
+		return
+	}
+
 	logging.Debug(ctx, "creating new product", "name", product.Name, "user_id", user.ID)
 
 	// Generate slug if not provided
@@ -65,16 +82,22 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 // @Produce      json
 // @Param        page        query     int     false  "Page number (default: 1)"
 // @Param        page_size   query     int     false  "Page size (default: 20, max: 100)"
-// @Param        type        query     string  false  "Pagination type: 'offset' or 'cursor' (default: offset)"
+// @Param        type        query     string  false  "Pagination type: 'offset' or 'cursor' (default: offset); searches are paginated by offset"
 // @Param        cursor      query     string  false  "Cursor for cursor-based pagination"
 // @Param        sort        query     string  false  "Sort fields (e.g., '-created_at,name:asc,price:desc')"
-// @Param        search      query     string  false  "Search term for name, slug, description"
+// @Param        search      query     string  false  "Full-text search of name, brand, tags and description in web search syntax, e.g. \"red shoes\" -leather; matches are ordered by rank unless sorted"
+// @Param        brand[eq]   query     string  false  "Filter by brand"
+// @Param        category[in] query    int     false  "Filter by category ID; repeat for any of several"
+// @Param        tags[in]    query     string  false  "Filter by tag; repeat for any of several"
+// @Param        status[eq]  query     string  false  "Filter by status"
 // @Param        name[eq]    query     string  false  "Filter by exact name"
 // @Param        name[like]  query     string  false  "Filter by name (partial match)"
-// @Param        price[gte]  query     number  false  "Filter by minimum price"
-// @Param        price[lte]  query     number  false  "Filter by maximum price"
+// @Param        price[gte]  query     int     false  "Filter by minimum price in minor units"
+// @Param        price[lte]  query     int     false  "Filter by maximum price in minor units"
 // @Param        user_id[eq] query     int     false  "Filter by user ID"
+// @Param        option[size] query    string  false  "Filter by a variant option value, e.g. option[size]=M; only matching variants are listed"
 // @Success      200         {object}  query.PaginatedList{data=[]models.Product}
+// @Failure      400         {object}  helpers.ErrorResponse
 // @Failure      500         {object}  helpers.ErrorResponse
 // @Router       /api/v1/products [get]
 func (h *Handler) GetAllProducts(c *gin.Context) {
@@ -103,13 +126,42 @@ func (h *Handler) GetAllProducts(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetProductFacets counts the products matching a search by facet
+// @Summary      Get product facets
+// @Description  Count the products matching a search by category, brand, tag, price bucket and status, e.g. for filter sidebars. It takes the filters of the product list; each facet is counted with every filter applied but its own, so choosing a brand keeps the other brands listed.
+// @Tags         Products
+// @Produce      json
+// @Param        search      query     string  false  "Full-text search of name, brand, tags and description"
+// @Param        brand[in]   query     string  false  "Filter by brand; repeat for any of several"
+// @Param        category[in] query    int     false  "Filter by category ID; repeat for any of several"
+// @Param        tags[in]    query     string  false  "Filter by tag; repeat for any of several"
+// @Param        price[gte]  query     int     false  "Filter by minimum price in minor units"
+// @Param        price[lt]   query     int     false  "Filter by price below, in minor units"
+// @Param        status[eq]  query     string  false  "Filter by status"
+// @Param        option[size] query    string  false  "Filter by a variant option value"
+// @Success      200  {object}  models.ProductFacets
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/products/facets [get]
+func (h *Handler) GetProductFacets(c *gin.Context) {
+	ctx := c.Request.Context()
+	req := query.ParseFromContext(c)
+
+	facets, err := h.Repos.Products.Facets(ctx, req)
+	if helpers.HandleError(c, err, "Failed to count product facets") {
+		return
+	}
+
+	c.JSON(http.StatusOK, facets)
+}
+
 // GetProduct retrieves a product by ID or Slug
 // @Summary      Get a product
-// @Description  Get a product by ID or Slug
+// @Description  Get a product by ID or Slug with its options and variants. Option filters, e.g. option[size]=M, narrow down the variants listed.
 // @Tags         Products
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "Product ID or Slug"
+// @Param        id            path      string  true   "Product ID or Slug"
+// @Param        option[size]  query     string  false  "Filter variants by an option value"
 // @Success      200  {object}  models.Product
 // @Failure      404  {object}  helpers.ErrorResponse
 // @Failure      500  {object}  helpers.ErrorResponse
@@ -136,12 +188,14 @@ func (h *Handler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	h.recordView(c, product.ID)
+	product.Variants = product.VariantsMatching(variantOptionFilters(c))
 	c.JSON(http.StatusOK, product)
 }
 
 // UpdateProduct updates a product
 // @Summary      Update a product
-// @Description  Update a product by ID (Owner only)
+// @Description  Update a product by ID (Owner only). Variants are matched by ID: variants left out are deleted, along with basket items holding them, and variants without an ID are created. Leaving out options and variants altogether keeps them.
 // @Tags         Products
 // @Accept       json
 // @Produce      json
@@ -189,11 +243,34 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	// Prices without a currency keep the product's current one
+	if updateData.Currency == "" {
+		updateData.Currency = existingProduct.Currency
+	}
+	if updateData.Options == nil && updateData.Variants == nil {
+		updateData.Options = existingProduct.Options
+		updateData.Variants = existingProduct.Variants
+	}
+	if !h.validateProductPrices(c, &updateData) {
+		return
+	}
+	if !h.checkMediaLinks(c, productImages(&updateData)...) {
+		return
+	}
+
+	// Wishlist watchers hear about price drops and restocks, and the seller
+	// about low stock
+	previousPrice, _ := pricing.FinalPrice(existingProduct.Price, existingProduct.Discount)
+	wasAvailable := existingProduct.Available > 0
+	previousStock := existingProduct.Stock
+
 	// Update fields
 	existingProduct.Name = updateData.Name
 	existingProduct.Description = updateData.Description
 	existingProduct.Price = updateData.Price
+	existingProduct.Currency = updateData.Currency
 	existingProduct.Stock = updateData.Stock
+	existingProduct.LowStockThreshold = updateData.LowStockThreshold
 	existingProduct.SKU = updateData.SKU
 	existingProduct.Status = updateData.Status
 	existingProduct.Image = updateData.Image
@@ -206,6 +283,8 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	existingProduct.Brand = updateData.Brand
 	existingProduct.Weight = updateData.Weight
 	existingProduct.Dimensions = updateData.Dimensions
+	existingProduct.Options = updateData.Options
+	existingProduct.Variants = updateData.Variants
 
 	if err := h.Repos.Products.Update(ctx, existingProduct); err != nil {
 		helpers.HandleError(c, err, "Failed to update product")
@@ -213,6 +292,10 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	}
 
 	logging.Info(ctx, "product updated successfully", "product_id", id, "name", existingProduct.Name)
+	h.notifyWishlistWatchers(ctx, existingProduct, previousPrice, wasAvailable)
+	if existingProduct.CrossedLowStock(previousStock) {
+		h.notifyLowStock(ctx, existingProduct)
+	}
 	c.JSON(http.StatusOK, existingProduct)
 }
 
@@ -271,11 +354,12 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 
 // GetProductBySlug retrieves a product by Slug
 // @Summary      Get a product by Slug
-// @Description  Get a product by Slug
+// @Description  Get a product by Slug with its options and variants. Option filters, e.g. option[size]=M, narrow down the variants listed.
 // @Tags         Products
 // @Accept       json
 // @Produce      json
-// @Param        slug   path      string  true  "Product Slug"
+// @Param        slug          path      string  true   "Product Slug"
+// @Param        option[size]  query     string  false  "Filter variants by an option value"
 // @Success      200  {object}  models.Product
 // @Failure      404  {object}  helpers.ErrorResponse
 // @Failure      500  {object}  helpers.ErrorResponse
@@ -295,6 +379,8 @@ func (h *Handler) GetProductBySlug(c *gin.Context) {
 		return
 	}
 
+	h.recordView(c, product.ID)
+	product.Variants = product.VariantsMatching(variantOptionFilters(c))
 	c.JSON(http.StatusOK, product)
 }
 
@@ -326,3 +412,51 @@ func (h *Handler) GetProductsByCategory(c *gin.Context) {
 	logging.Debug(ctx, "products retrieved by category", "category_id", id, "count", len(products))
 	c.JSON(http.StatusOK, products)
 }
+
+// validateProductPrices settles a product's currency, checks its prices and
+// variants and derives its final price.
+// It sends the error response and returns false when they are invalid.
+func (h *Handler) validateProductPrices(c *gin.Context, product *models.Product) bool {
+	if err := product.ValidateVariants(); err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+		return false
+	}
+	if err := product.ApplyCurrency(h.Currency); err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+		return false
+	}
+	if !product.Price.IsPositive() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "Price must be greater than zero"), "")
+		return false
+	}
+	if product.Discount.IsNegative() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "Discount cannot be negative"), "")
+		return false
+	}
+
+	// The final price is always derived, never taken from the client
+	finalPrice, err := pricing.FinalPrice(product.Price, product.Discount)
+	if err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "Discount cannot exceed the price"), "")
+		return false
+	}
+	product.FinalPrice = finalPrice
+
+	// The product's discount applies to its variants' own prices too
+	for _, variant := range product.Variants {
+		if variant.Price == nil {
+			continue
+		}
+		if _, err := pricing.FinalPrice(*variant.Price, product.Discount); err != nil || !variant.Price.IsPositive() {
+			helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrInvalidInput, "Price of variant %s must be greater than zero and the discount", variant.SKU), "")
+			return false
+		}
+	}
+	return true
+}
+
+// variantOptionFilters returns the variant option values asked for in the
+// query, e.g. option[size]=M
+func variantOptionFilters(c *gin.Context) map[string]string {
+	return query.KeyedFilters(query.ParseFromContext(c).Filters, repository.VariantOptionFilter)
+}
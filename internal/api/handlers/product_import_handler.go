@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	"github.com/alireza-akbarzadeh/ginflow/internal/catalog"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// importProgressEvery is how many rows an import reads between saving its progress
+const importProgressEvery = 100
+
+// exportPageSize is how many products an export reads at a time
+const exportPageSize = 100
+
+// ImportProducts starts a product import
+// @Summary      Import products
+// @Description  Upload a CSV or NDJSON file of products, given by the format query parameter or the content type. Each row creates a product or updates the caller's product with its SKU; categories are given by slug and, when given, replace the product's. CSV files start with a header naming their columns (sku, name and price are required) and separate list values with "|". Prices are decimal amounts, e.g. 19.99. The file is imported in the background: poll the returned job for its progress and the errors of rows that were not imported.
+// @Tags         Products
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        format  query     string  false  "File format: csv or ndjson (default: from the content type)"
+// @Success      202     {object}  models.ProductImport
+// @Failure      400     {object}  helpers.ErrorResponse
+// @Failure      401     {object}  helpers.ErrorResponse
+// @Failure      413     {object}  helpers.ErrorResponse
+// @Failure      500     {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/import [post]
+func (h *Handler) ImportProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	format, err := catalog.ParseFormat(c.DefaultQuery("format", c.ContentType()))
+	if err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+		return
+	}
+
+	// The upload is kept in a temporary file the job streams its rows from
+	file, err := os.CreateTemp("", "product-import-*")
+	if err != nil {
+		logging.Error(ctx, "failed to create import file", err)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to store the file")
+		return
+	}
+	if _, err := io.Copy(file, http.MaxBytesReader(c.Writer, c.Request.Body, h.ProductImportMaxSize)); err != nil {
+		removeImportFile(ctx, file)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			helpers.RespondWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("The file is larger than %d bytes", h.ProductImportMaxSize))
+			return
+		}
+		helpers.RespondWithError(c, http.StatusBadRequest, "Failed to read the file")
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		removeImportFile(ctx, file)
+		logging.Error(ctx, "failed to rewind import file", err)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to store the file")
+		return
+	}
+
+	reader, err := catalog.NewReader(format, file)
+	if err != nil {
+		removeImportFile(ctx, file)
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+		return
+	}
+
+	job, err := h.Repos.ProductImports.Insert(ctx, &models.ProductImport{
+		UserID: user.ID,
+		Format: string(format),
+		Status: models.ImportStatusPending,
+	})
+	if err != nil {
+		removeImportFile(ctx, file)
+		helpers.HandleError(c, err, "Failed to start the import")
+		return
+	}
+
+	logging.Info(ctx, "product import started", "import_id", job.ID, "user_id", user.ID, "format", format)
+	c.JSON(http.StatusAccepted, job)
+
+	// The job outlives the request
+	go h.importProducts(context.WithoutCancel(ctx), job, reader, file)
+}
+
+// GetProductImport retrieves a product import
+// @Summary      Get a product import
+// @Description  Get the status and progress of a product import with the errors of rows that were not imported (owner or admin)
+// @Tags         Products
+// @Produce      json
+// @Param        id   path      int  true  "Import ID"
+// @Success      200  {object}  models.ProductImport
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      403  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/imports/{id} [get]
+func (h *Handler) GetProductImport(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid import ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.Repos.ProductImports.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve the import") {
+		return
+	}
+	if job.UserID != user.ID && !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "You are not allowed to view this import"), "")
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ExportProducts streams the product list as a file
+// @Summary      Export products
+// @Description  Download the products matching the same filters, search and sorting as the product list, as CSV or NDJSON in the columns accepted by the import. All matching products are exported; pagination parameters are ignored.
+// @Tags         Products
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format      query     string  false  "File format: csv or ndjson (default: csv)"
+// @Param        sort        query     string  false  "Sort fields (e.g., '-created_at,name:asc,price:desc')"
+// @Param        search      query     string  false  "Search term for name, slug, description"
+// @Param        name[like]  query     string  false  "Filter by name (partial match)"
+// @Param        price[gte]  query     int     false  "Filter by minimum price in minor units"
+// @Param        price[lte]  query     int     false  "Filter by maximum price in minor units"
+// @Param        user_id[eq] query     int     false  "Filter by user ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/export [get]
+func (h *Handler) ExportProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	format, err := catalog.ParseFormat(c.DefaultQuery("format", string(catalog.CSV)))
+	if err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+		return
+	}
+
+	params := query.ParseFromContext(c)
+	params.Type = query.OffsetPagination
+	params.Page = 1
+	params.PageSize = exportPageSize
+	params.IncludeTotal = false
+
+	logging.Debug(ctx, "exporting products", "format", format, "search", params.Search)
+
+	// Errors can be reported until the first page is written
+	var writer catalog.Writer
+	exported := 0
+	for {
+		products, _, err := h.Repos.Products.ListWithAdvancedPagination(ctx, params)
+		if err != nil {
+			if writer == nil {
+				helpers.HandleError(c, err, "Failed to export products")
+				return
+			}
+			logging.Error(ctx, "product export interrupted", err, "exported", exported)
+			return
+		}
+
+		if writer == nil {
+			c.Header("Content-Type", format.ContentType())
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+			c.Status(http.StatusOK)
+			writer = catalog.NewWriter(format, c.Writer)
+		}
+		for i := range products {
+			if err := writer.Write(catalog.RowOf(&products[i])); err != nil {
+				logging.Error(ctx, "product export interrupted", err, "exported", exported)
+				return
+			}
+			exported++
+		}
+		if err := writer.Flush(); err != nil {
+			logging.Error(ctx, "product export interrupted", err, "exported", exported)
+			return
+		}
+		c.Writer.Flush()
+
+		if len(products) < params.PageSize {
+			break
+		}
+		params.Page++
+	}
+
+	logging.Info(ctx, "products exported", "format", format, "count", exported)
+}
+
+// importProducts runs an import job over the rows of its file, saving its
+// progress every importProgressEvery rows, and removes the file when done
+func (h *Handler) importProducts(ctx context.Context, job *models.ProductImport, reader catalog.Reader, file *os.File) {
+	defer removeImportFile(ctx, file)
+
+	job.Status = models.ImportStatusRunning
+	h.saveImport(ctx, job)
+
+	var failure error
+	for {
+		row, line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *catalog.RowError
+		if errors.As(err, &rowErr) {
+			job.RowFailed(line, "", rowErr.Err)
+		} else if err != nil {
+			failure = fmt.Errorf("reading stopped after line %d: %w", line, err)
+			break
+		} else {
+			h.importRow(ctx, job, row, line)
+		}
+
+		if job.Rows%importProgressEvery == 0 {
+			h.saveImport(ctx, job)
+		}
+	}
+
+	job.Finish(failure, time.Now())
+	h.saveImport(ctx, job)
+
+	logging.Info(ctx, "product import finished", "import_id", job.ID, "status", job.Status,
+		"created", job.Created, "updated", job.Updated, "failed", job.Failed)
+}
+
+// importRow creates or updates the product of a row, recording the outcome in the job
+func (h *Handler) importRow(ctx context.Context, job *models.ProductImport, row catalog.Row, line int) {
+	product, err := row.Product(h.Currency)
+	if err != nil {
+		job.RowFailed(line, row.SKU, err)
+		return
+	}
+	product.UserID = job.UserID
+
+	previous, err := h.Repos.Products.UpsertBySKU(ctx, product, row.Categories)
+	if err != nil {
+		var appErr *appErrors.AppError
+		if !errors.As(err, &appErr) {
+			logging.Error(ctx, "failed to import product", err, "import_id", job.ID, "sku", product.SKU)
+			err = errors.New("failed to save the product")
+		}
+		job.RowFailed(line, product.SKU, err)
+		return
+	}
+	job.RowImported(previous == nil)
+
+	if previous != nil {
+		previousPrice, _ := pricing.FinalPrice(previous.Price, previous.Discount)
+		h.notifyWishlistWatchers(ctx, product, previousPrice, previous.Available > 0)
+	}
+}
+
+// saveImport saves the progress of an import job. Failures are logged by the
+// repository and the next save catches up.
+func (h *Handler) saveImport(ctx context.Context, job *models.ProductImport) {
+	_ = h.Repos.ProductImports.Update(ctx, job)
+}
+
+// removeImportFile closes and deletes the temporary file of an import
+func removeImportFile(ctx context.Context, file *os.File) {
+	_ = file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		logging.Error(ctx, "failed to remove import file", err, "file", file.Name())
+	}
+}
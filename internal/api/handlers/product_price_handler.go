@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePriceScheduleRequest represents the create price schedule payload.
+// Amounts are in minor units of the product's currency. Schedules with an end
+// are sales, after which the product's price is restored.
+type CreatePriceScheduleRequest struct {
+	Price    money.Money  `json:"price"`
+	Discount *money.Money `json:"discount"`
+	StartsAt time.Time    `json:"startsAt" binding:"required"`
+	EndsAt   *time.Time   `json:"endsAt"`
+}
+
+// GetProductPriceHistory lists the price changes of a product
+// @Summary      Get a product's price history
+// @Description  List the prices and discounts a product had, latest first, with why and when they changed
+// @Tags         Products
+// @Produce      json
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  []models.ProductPriceChange
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/products/{id}/prices [get]
+func (h *Handler) GetProductPriceHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	product, err := h.Repos.Products.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve product") {
+		return
+	}
+	if product == nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrNotFound, "Product not found"), "")
+		return
+	}
+
+	changes, err := h.Repos.Products.PriceHistory(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve price history") {
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// GetProductPriceSchedules lists the price schedules of a product
+// @Summary      Get a product's price schedules
+// @Description  List the scheduled price changes and sales of a product in the order they start (Owner only)
+// @Tags         Products
+// @Produce      json
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  []models.ProductPriceSchedule
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      403  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/price-schedules [get]
+func (h *Handler) GetProductPriceSchedules(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	product, _, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.Repos.Products.PriceSchedules(ctx, product.ID)
+	if helpers.HandleError(c, err, "Failed to retrieve price schedules") {
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateProductPriceSchedule schedules a price change or sale of a product
+// @Summary      Schedule a price change
+// @Description  Schedule a product's price and discount to change at a start time (Owner only). With an end time the change is a sale, after which the product gets back its price from before unless it was changed during the sale. Pending schedules of a product cannot overlap.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                         true  "Product ID"
+// @Param        schedule  body      CreatePriceScheduleRequest  true  "Price schedule"
+// @Success      201       {object}  models.ProductPriceSchedule
+// @Failure      400       {object}  helpers.ErrorResponse
+// @Failure      401       {object}  helpers.ErrorResponse
+// @Failure      403       {object}  helpers.ErrorResponse
+// @Failure      404       {object}  helpers.ErrorResponse
+// @Failure      409       {object}  helpers.ErrorResponse
+// @Failure      500       {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/price-schedules [post]
+func (h *Handler) CreateProductPriceSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	product, user, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var req CreatePriceScheduleRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	schedule, err := req.toSchedule(product, time.Now())
+	if err != nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "")
+		return
+	}
+	schedule.CreatedByID = user.ID
+
+	if err := h.Repos.Products.CreatePriceSchedule(ctx, schedule); err != nil {
+		helpers.HandleError(c, err, "Failed to create price schedule")
+		return
+	}
+
+	logging.Info(ctx, "price scheduled", "product_id", product.ID, "schedule_id", schedule.ID, "user_id", user.ID)
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// CancelProductPriceSchedule cancels a pending price schedule of a product
+// @Summary      Cancel a price schedule
+// @Description  Cancel a price change or sale yet to start, or end a sale under way at once, restoring the product's price from before unless it was changed during the sale (Owner only)
+// @Tags         Products
+// @Produce      json
+// @Param        id          path      int  true  "Product ID"
+// @Param        scheduleId  path      int  true  "Price schedule ID"
+// @Success      200         {object}  models.ProductPriceSchedule
+// @Failure      400         {object}  helpers.ErrorResponse
+// @Failure      401         {object}  helpers.ErrorResponse
+// @Failure      403         {object}  helpers.ErrorResponse
+// @Failure      404         {object}  helpers.ErrorResponse
+// @Failure      409         {object}  helpers.ErrorResponse
+// @Failure      500         {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/price-schedules/{scheduleId} [delete]
+func (h *Handler) CancelProductPriceSchedule(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	scheduleID, err := helpers.ParseIDParam(c, "scheduleId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid price schedule ID")
+		return
+	}
+
+	product, user, ok := h.ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	previousPrice, _ := pricing.FinalPrice(product.Price, product.Discount)
+	schedule, err := h.Repos.Products.CancelPriceSchedule(ctx, product.ID, scheduleID, &user.ID)
+	if helpers.HandleError(c, err, "Failed to cancel price schedule") {
+		return
+	}
+
+	// Ending a schedule under way may have lowered the price
+	if schedule.PreviousPrice != nil {
+		if repriced, err := h.Repos.Products.Get(ctx, product.ID); err == nil && repriced != nil {
+			h.notifyWishlistWatchers(ctx, repriced, previousPrice, true)
+		}
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// ApplyPriceSchedules starts the price schedules due by now and ends the sales
+// over by then, telling wishlist watchers of the products whose price dropped.
+// Schedules that fail to apply are logged by the repository and stay due.
+func (h *Handler) ApplyPriceSchedules(ctx context.Context, now time.Time) error {
+	repricings, err := h.Repos.Products.ApplyPriceSchedules(ctx, now)
+	for i := range repricings {
+		h.notifyWishlistWatchers(ctx, &repricings[i].Product, repricings[i].PreviousFinalPrice, true)
+	}
+	return err
+}
+
+// ownedProduct loads the product of the id parameter if the authenticated user
+// owns it, responding with an error otherwise
+func (h *Handler) ownedProduct(c *gin.Context) (*models.Product, *models.User, bool) {
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return nil, nil, false
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	product, err := h.Repos.Products.Get(c.Request.Context(), id)
+	if helpers.HandleError(c, err, "Failed to retrieve product") {
+		return nil, nil, false
+	}
+	if product == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "product with ID %d not found", id), "")
+		return nil, nil, false
+	}
+	if product.UserID != user.ID {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "You do not have permission to update this product"), "")
+		return nil, nil, false
+	}
+	return product, user, true
+}
+
+// toSchedule validates the request against the product and converts it to a
+// schedule. Amounts without a currency are in the product's.
+func (req *CreatePriceScheduleRequest) toSchedule(product *models.Product, now time.Time) (*models.ProductPriceSchedule, error) {
+	schedule := &models.ProductPriceSchedule{
+		ProductID: product.ID,
+		Price:     req.Price,
+		Discount:  money.Zero(product.Currency),
+		Currency:  product.Currency,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+	}
+	if req.Discount != nil {
+		schedule.Discount = *req.Discount
+	}
+
+	for _, amount := range []*money.Money{&schedule.Price, &schedule.Discount} {
+		if amount.Currency == "" {
+			amount.Currency = product.Currency
+		}
+		if amount.Currency != product.Currency {
+			return nil, errors.New("price and discount must be in the product's currency")
+		}
+	}
+	if !schedule.Price.IsPositive() {
+		return nil, errors.New("price must be greater than zero")
+	}
+	if schedule.Discount.IsNegative() {
+		return nil, errors.New("discount cannot be negative")
+	}
+	if _, err := pricing.FinalPrice(schedule.Price, schedule.Discount); err != nil {
+		return nil, errors.New("discount cannot exceed the price")
+	}
+	// The product's discount applies to its variants' own prices too
+	for _, variant := range product.Variants {
+		if variant.Price != nil && variant.Price.Amount < schedule.Discount.Amount {
+			return nil, errors.New("discount cannot exceed the price of variant " + variant.SKU)
+		}
+	}
+
+	if req.EndsAt != nil {
+		if !req.EndsAt.After(req.StartsAt) {
+			return nil, errors.New("endsAt must be after startsAt")
+		}
+		if !req.EndsAt.After(now) {
+			return nil, errors.New("endsAt must be in the future")
+		}
+	}
+	return schedule, nil
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Sizes of trending and related product lists
+const (
+	defaultProductListLimit = 10
+	maxProductListLimit     = 50
+)
+
+// GetTrendingProducts lists the products trending now
+// @Summary      Get trending products
+// @Description  List the active products with the most recent views and sales. Views and sales weigh half as much after every configured half-life, and a sale counts as several views.
+// @Tags         Products
+// @Produce      json
+// @Param        limit  query     int  false  "Number of products (default: 10, max: 50)"
+// @Success      200  {object}  []models.Product
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/products/trending [get]
+func (h *Handler) GetTrendingProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	products, err := h.Repos.Products.Trending(ctx, time.Now(), h.TrendingHalfLife, h.TrendingSaleWeight, productListLimit(c))
+	if helpers.HandleError(c, err, "Failed to retrieve trending products") {
+		return
+	}
+
+	logging.Debug(ctx, "trending products retrieved", "count", len(products))
+	c.JSON(http.StatusOK, products)
+}
+
+// GetRelatedProducts lists the products related to a product
+// @Summary      Get related products
+// @Description  List the active products sharing the most categories and tags with a product, a shared category counting as much as two shared tags
+// @Tags         Products
+// @Produce      json
+// @Param        id     path      int  true   "Product ID"
+// @Param        limit  query     int  false  "Number of products (default: 10, max: 50)"
+// @Success      200  {object}  []models.Product
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/products/{id}/related [get]
+func (h *Handler) GetRelatedProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	product, err := h.Repos.Products.Get(ctx, id)
+	if helpers.HandleError(c, err, "Failed to retrieve product") {
+		return
+	}
+	if product == nil {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrNotFound, "Product not found"), "")
+		return
+	}
+
+	products, err := h.Repos.Products.Related(ctx, product, productListLimit(c))
+	if helpers.HandleError(c, err, "Failed to retrieve related products") {
+		return
+	}
+
+	logging.Debug(ctx, "related products retrieved", "product_id", id, "count", len(products))
+	c.JSON(http.StatusOK, products)
+}
+
+// recordView counts a view of a product by the authenticated user, or by the
+// client IP of anonymous requests. Views are written in batches later.
+func (h *Handler) recordView(c *gin.Context, productID int) {
+	viewer := "ip:" + c.ClientIP()
+	if user := helpers.GetUserFromContext(c); user != nil {
+		viewer = fmt.Sprintf("user:%d", user.ID)
+	}
+	h.Views.Record(productID, viewer, time.Now())
+}
+
+// productListLimit reads the limit query parameter of product lists
+func productListLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return defaultProductListLimit
+	}
+	return min(limit, maxProductListLimit)
+}
@@ -91,6 +91,9 @@ func (h *Handler) CreateProfile(c *gin.Context) {
 		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "Invalid request body")
 		return
 	}
+	if !h.checkMediaLinks(c, profile.AvatarURL) {
+		return
+	}
 
 	// Set user ID and create a profile
 	profile.UserID = authUser.ID
@@ -148,6 +151,9 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, err.Error()), "Invalid request body")
 		return
 	}
+	if !h.checkMediaLinks(c, updateData.AvatarURL) {
+		return
+	}
 
 	// If a profile doesn't exist, create it
 	if existingProfile == nil {
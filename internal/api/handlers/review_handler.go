@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewRequest represents the create and update review payload
+type ReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"max=200"`
+	Body   string `json:"body" binding:"max=5000"`
+}
+
+// GetProductReviews lists the reviews of a product
+// @Summary      List product reviews
+// @Description  Get the paginated reviews of a product, newest first by default
+// @Tags         Reviews
+// @Produce      json
+// @Param        id                      path      int     true   "Product ID"
+// @Param        page                    query     int     false  "Page number (default: 1)"
+// @Param        page_size               query     int     false  "Page size (default: 20, max: 100)"
+// @Param        sort                    query     string  false  "Sort fields: id, rating, created_at (e.g., '-rating')"
+// @Param        rating[eq]              query     int     false  "Filter by rating"
+// @Param        verified_purchase[eq]   query     bool    false  "Filter by verified purchase"
+// @Success      200  {object}  query.PaginatedList{data=[]models.Review}
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Router       /api/v1/products/{id}/reviews [get]
+func (h *Handler) GetProductReviews(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	productID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	params := query.ParseFromContext(c)
+
+	logging.Debug(ctx, "retrieving product reviews", "product_id", productID, "page", params.Page)
+
+	reviews, result, err := h.Repos.Reviews.ListByProduct(ctx, productID, params)
+	if helpers.HandleError(c, err, "Failed to retrieve reviews") {
+		return
+	}
+
+	logging.Debug(ctx, "product reviews retrieved successfully", "product_id", productID, "count", len(reviews))
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateReview reviews a product
+// @Summary      Review a product
+// @Description  Rate a product from 1 to 5 with an optional title and body. A user reviews a product once. Reviews of users who bought the product are marked as verified purchases; when reviews are limited to verified purchasers, other users are refused. The product's rating and review count are updated.
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int            true  "Product ID"
+// @Param        review  body      ReviewRequest  true  "Review"
+// @Success      201     {object}  models.Review
+// @Failure      400     {object}  helpers.ErrorResponse
+// @Failure      401     {object}  helpers.ErrorResponse
+// @Failure      403     {object}  helpers.ErrorResponse
+// @Failure      404     {object}  helpers.ErrorResponse
+// @Failure      409     {object}  helpers.ErrorResponse
+// @Failure      500     {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/reviews [post]
+func (h *Handler) CreateReview(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	productID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req ReviewRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.Repos.Products.Get(ctx, productID)
+	if helpers.HandleError(c, err, "Failed to retrieve product") {
+		return
+	}
+	if product == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "product with ID %d not found", productID), "")
+		return
+	}
+
+	purchased, err := h.Repos.Orders.HasPurchased(ctx, user.ID, productID)
+	if helpers.HandleError(c, err, "Failed to check purchase") {
+		return
+	}
+	if h.ReviewsVerifiedOnly && !purchased {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "Only customers who bought this product can review it"), "")
+		return
+	}
+
+	logging.Debug(ctx, "creating review", "product_id", productID, "user_id", user.ID)
+
+	review, err := h.Repos.Reviews.Insert(ctx, &models.Review{
+		ProductID:        productID,
+		UserID:           user.ID,
+		Rating:           req.Rating,
+		Title:            req.Title,
+		Body:             req.Body,
+		VerifiedPurchase: purchased,
+	})
+	if helpers.HandleError(c, err, "Failed to create review") {
+		return
+	}
+
+	logging.Info(ctx, "review created successfully", "review_id", review.ID, "product_id", productID)
+	c.JSON(http.StatusCreated, review)
+}
+
+// UpdateReview edits a review
+// @Summary      Edit a review
+// @Description  Change the rating, title and body of a review (author only). The product's rating is updated.
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int            true  "Product ID"
+// @Param        reviewId  path      int            true  "Review ID"
+// @Param        review    body      ReviewRequest  true  "Review"
+// @Success      200       {object}  models.Review
+// @Failure      400       {object}  helpers.ErrorResponse
+// @Failure      401       {object}  helpers.ErrorResponse
+// @Failure      403       {object}  helpers.ErrorResponse
+// @Failure      404       {object}  helpers.ErrorResponse
+// @Failure      500       {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/reviews/{reviewId} [put]
+func (h *Handler) UpdateReview(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req ReviewRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	review, user, ok := h.productReview(c)
+	if !ok {
+		return
+	}
+	if review.UserID != user.ID {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "You are not allowed to edit this review"), "")
+		return
+	}
+
+	review.Rating = req.Rating
+	review.Title = req.Title
+	review.Body = req.Body
+	if err := h.Repos.Reviews.Update(ctx, review); err != nil {
+		helpers.HandleError(c, err, "Failed to update review")
+		return
+	}
+
+	logging.Info(ctx, "review updated successfully", "review_id", review.ID, "user_id", user.ID)
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview deletes a review
+// @Summary      Delete a review
+// @Description  Delete a review (author or admin). The product's rating is updated.
+// @Tags         Reviews
+// @Produce      json
+// @Param        id        path      int  true  "Product ID"
+// @Param        reviewId  path      int  true  "Review ID"
+// @Success      204       {object}  nil
+// @Failure      400       {object}  helpers.ErrorResponse
+// @Failure      401       {object}  helpers.ErrorResponse
+// @Failure      403       {object}  helpers.ErrorResponse
+// @Failure      404       {object}  helpers.ErrorResponse
+// @Failure      500       {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/products/{id}/reviews/{reviewId} [delete]
+func (h *Handler) DeleteReview(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	review, user, ok := h.productReview(c)
+	if !ok {
+		return
+	}
+	if review.UserID != user.ID && !user.IsAdmin() {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrForbidden, "You are not allowed to delete this review"), "")
+		return
+	}
+
+	if err := h.Repos.Reviews.Delete(ctx, review.ID); err != nil {
+		helpers.HandleError(c, err, "Failed to delete review")
+		return
+	}
+
+	logging.Info(ctx, "review deleted successfully", "review_id", review.ID, "user_id", user.ID)
+	c.Status(http.StatusNoContent)
+}
+
+// productReview loads the review in the path along with the authenticated
+// user. Reviews of other products are not found. It sends the error response
+// and returns false on failure.
+func (h *Handler) productReview(c *gin.Context) (*models.Review, *models.User, bool) {
+	ctx := c.Request.Context()
+
+	productID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return nil, nil, false
+	}
+	reviewID, err := helpers.ParseIDParam(c, "reviewId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid review ID")
+		return nil, nil, false
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	review, err := h.Repos.Reviews.Get(ctx, reviewID)
+	if helpers.HandleError(c, err, "Failed to retrieve review") {
+		return nil, nil, false
+	}
+	if review.ProductID != productID {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "review with ID %d not found", reviewID), "")
+		return nil, nil, false
+	}
+	return review, user, true
+}
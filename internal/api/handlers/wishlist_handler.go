@@ -0,0 +1,529 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWishlistRequest represents the create wishlist payload
+type CreateWishlistRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// AddWishlistItemRequest represents the add to wishlist payload
+type AddWishlistItemRequest struct {
+	ProductID int `json:"productId" binding:"required"`
+}
+
+// MoveToBasketRequest represents the move to basket payload. VariantID is
+// required for products sold in variants.
+type MoveToBasketRequest struct {
+	VariantID *int `json:"variantId"`
+	Quantity  int  `json:"quantity" binding:"omitempty,min=1"`
+}
+
+// GetWishlists lists the authenticated user's wishlists
+// @Summary      List wishlists
+// @Description  List the authenticated user's wishlists with their products
+// @Tags         Wishlists
+// @Produce      json
+// @Success      200  {array}   models.Wishlist
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists [get]
+func (h *Handler) GetWishlists(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	wishlists, err := h.Repos.Wishlists.ListByUser(ctx, user.ID)
+	if helpers.HandleError(c, err, "Failed to fetch wishlists") {
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlists)
+}
+
+// CreateWishlist creates a wishlist
+// @Summary      Create a wishlist
+// @Description  Create a named wishlist for the authenticated user
+// @Tags         Wishlists
+// @Accept       json
+// @Produce      json
+// @Param        wishlist  body      CreateWishlistRequest  true  "Wishlist"
+// @Success      201       {object}  models.Wishlist
+// @Failure      400       {object}  helpers.ErrorResponse
+// @Failure      401       {object}  helpers.ErrorResponse
+// @Failure      409       {object}  helpers.ErrorResponse
+// @Failure      500       {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists [post]
+func (h *Handler) CreateWishlist(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateWishlistRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		helpers.RespondWithAppError(c, appErrors.New(appErrors.ErrInvalidInput, "Wishlist name is required"), "")
+		return
+	}
+
+	wishlist, err := h.Repos.Wishlists.Insert(ctx, &models.Wishlist{UserID: user.ID, Name: name})
+	if helpers.HandleError(c, err, "Failed to create wishlist") {
+		return
+	}
+	wishlist.Items = []models.WishlistItem{}
+
+	c.JSON(http.StatusCreated, wishlist)
+}
+
+// GetWishlist retrieves one of the authenticated user's wishlists
+// @Summary      Get a wishlist
+// @Description  Get one of the authenticated user's wishlists with its products
+// @Tags         Wishlists
+// @Produce      json
+// @Param        id   path      int  true  "Wishlist ID"
+// @Success      200  {object}  models.Wishlist
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists/{id} [get]
+func (h *Handler) GetWishlist(c *gin.Context) {
+	wishlist, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// DeleteWishlist deletes a wishlist
+// @Summary      Delete a wishlist
+// @Description  Delete one of the authenticated user's wishlists
+// @Tags         Wishlists
+// @Param        id   path      int  true  "Wishlist ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists/{id} [delete]
+func (h *Handler) DeleteWishlist(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	wishlist, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repos.Wishlists.Delete(ctx, wishlist.ID); err != nil {
+		helpers.HandleError(c, err, "Failed to delete wishlist")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddWishlistItem adds a product to a wishlist
+// @Summary      Add a product to a wishlist
+// @Description  Add a product to one of the authenticated user's wishlists. Its stock is not reserved.
+// @Tags         Wishlists
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                     true  "Wishlist ID"
+// @Param        item  body      AddWishlistItemRequest  true  "Product"
+// @Success      200   {object}  models.Wishlist
+// @Failure      400   {object}  helpers.ErrorResponse
+// @Failure      401   {object}  helpers.ErrorResponse
+// @Failure      404   {object}  helpers.ErrorResponse
+// @Failure      500   {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists/{id}/items [post]
+func (h *Handler) AddWishlistItem(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req AddWishlistItemRequest
+	if !helpers.BindJSON(c, &req) {
+		return
+	}
+
+	wishlist, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.Repos.Products.Get(ctx, req.ProductID)
+	if helpers.HandleError(c, err, "Failed to retrieve product") {
+		return
+	}
+	if product == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "product with ID %d not found", req.ProductID), "")
+		return
+	}
+
+	if err := h.Repos.Wishlists.AddItem(ctx, wishlist.ID, product.ID); err != nil {
+		helpers.HandleError(c, err, "Failed to add product to wishlist")
+		return
+	}
+
+	h.respondWithWishlist(c, wishlist.ID)
+}
+
+// RemoveWishlistItem removes a product from a wishlist
+// @Summary      Remove a product from a wishlist
+// @Description  Remove a product from one of the authenticated user's wishlists
+// @Tags         Wishlists
+// @Produce      json
+// @Param        id         path      int  true  "Wishlist ID"
+// @Param        productId  path      int  true  "Product ID"
+// @Success      200        {object}  models.Wishlist
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists/{id}/items/{productId} [delete]
+func (h *Handler) RemoveWishlistItem(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	productID, err := helpers.ParseIDParam(c, "productId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	wishlist, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repos.Wishlists.RemoveItem(ctx, wishlist.ID, productID); err != nil {
+		helpers.HandleError(c, err, "Failed to remove product from wishlist")
+		return
+	}
+
+	h.respondWithWishlist(c, wishlist.ID)
+}
+
+// MoveWishlistItemToBasket moves a product from a wishlist to the basket
+// @Summary      Move a product to the basket
+// @Description  Add a wishlist product to the active basket, reserving its stock, and remove it from the wishlist
+// @Tags         Wishlists
+// @Accept       json
+// @Produce      json
+// @Param        id         path      int                  true   "Wishlist ID"
+// @Param        productId  path      int                  true   "Product ID"
+// @Param        item       body      MoveToBasketRequest  false  "Quantity (default: 1) and variant"
+// @Success      200        {object}  BasketResponse
+// @Failure      400        {object}  helpers.ErrorResponse
+// @Failure      401        {object}  helpers.ErrorResponse
+// @Failure      404        {object}  helpers.ErrorResponse
+// @Failure      409        {object}  helpers.ErrorResponse
+// @Failure      500        {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists/{id}/items/{productId}/move-to-basket [post]
+func (h *Handler) MoveWishlistItemToBasket(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	productID, err := helpers.ParseIDParam(c, "productId")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	req := MoveToBasketRequest{Quantity: 1}
+	if c.Request.ContentLength > 0 && !helpers.BindJSON(c, &req) {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	wishlist, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+	if !wishlist.HasProduct(productID) {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "product with ID %d is not on the wishlist", productID), "")
+		return
+	}
+
+	user := helpers.GetUserFromContext(c)
+	basket, ok := h.addToBasket(c, user, productID, req.VariantID, req.Quantity)
+	if !ok {
+		return
+	}
+
+	// The product is in the basket either way; a leftover wishlist entry is harmless
+	if err := h.Repos.Wishlists.RemoveItem(ctx, wishlist.ID, productID); err != nil {
+		logging.Error(ctx, "failed to remove moved product from wishlist", err, "wishlist_id", wishlist.ID, "product_id", productID)
+	}
+
+	logging.Info(ctx, "wishlist product moved to basket", "wishlist_id", wishlist.ID, "product_id", productID, "basket_id", basket.ID)
+	h.respondWithBasket(c, h.reloadBasket(ctx, basket, user), user)
+}
+
+// SaveBasketItemForLater moves a basket item to the save-for-later wishlist
+// @Summary      Save a basket item for later
+// @Description  Move an item out of the active basket, releasing its reserved stock, onto the "Saved for later" wishlist
+// @Tags         Basket
+// @Produce      json
+// @Param        id   path      int  true  "Item ID"
+// @Success      200  {object}  BasketResponse
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/basket/items/{id}/save-for-later [post]
+func (h *Handler) SaveBasketItemForLater(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	itemID, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid item ID")
+		return
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	basket, ok := h.currentBasket(c, user, false)
+	if !ok {
+		return
+	}
+	if basket == nil {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID), "")
+		return
+	}
+
+	wishlist, err := h.Repos.Wishlists.SaveForLater(ctx, user.ID, basket.ID, itemID)
+	if helpers.HandleError(c, err, "Failed to save item for later") {
+		return
+	}
+
+	logging.Info(ctx, "basket item saved for later", "item_id", itemID, "wishlist_id", wishlist.ID)
+	h.respondWithBasket(c, h.reloadBasket(ctx, basket, user), user)
+}
+
+// ShareWishlist creates a share link for a wishlist
+// @Summary      Share a wishlist
+// @Description  Create a share token anyone can view the wishlist with, replacing any previous one
+// @Tags         Wishlists
+// @Produce      json
+// @Param        id   path      int  true  "Wishlist ID"
+// @Success      200  {object}  models.Wishlist
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists/{id}/share [post]
+func (h *Handler) ShareWishlist(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	wishlist, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		logging.Error(ctx, "failed to generate share token", err, "wishlist_id", wishlist.ID)
+		helpers.RespondWithError(c, http.StatusInternalServerError, "Failed to share wishlist")
+		return
+	}
+
+	if err := h.Repos.Wishlists.SetShareToken(ctx, wishlist.ID, &token); err != nil {
+		helpers.HandleError(c, err, "Failed to share wishlist")
+		return
+	}
+	wishlist.ShareToken = &token
+
+	logging.Info(ctx, "wishlist shared", "wishlist_id", wishlist.ID)
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// UnshareWishlist revokes a wishlist's share link
+// @Summary      Stop sharing a wishlist
+// @Description  Revoke the wishlist's share token
+// @Tags         Wishlists
+// @Param        id   path      int  true  "Wishlist ID"
+// @Success      204  {object}  nil
+// @Failure      400  {object}  helpers.ErrorResponse
+// @Failure      401  {object}  helpers.ErrorResponse
+// @Failure      404  {object}  helpers.ErrorResponse
+// @Failure      500  {object}  helpers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /api/v1/wishlists/{id}/share [delete]
+func (h *Handler) UnshareWishlist(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	wishlist, ok := h.ownWishlist(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Repos.Wishlists.SetShareToken(ctx, wishlist.ID, nil); err != nil {
+		helpers.HandleError(c, err, "Failed to stop sharing wishlist")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSharedWishlist retrieves a wishlist by its share token
+// @Summary      Get a shared wishlist
+// @Description  Get a wishlist shared through its share token
+// @Tags         Wishlists
+// @Produce      json
+// @Param        token  path      string  true  "Share token"
+// @Success      200    {object}  models.Wishlist
+// @Failure      404    {object}  helpers.ErrorResponse
+// @Failure      500    {object}  helpers.ErrorResponse
+// @Router       /api/v1/wishlists/shared/{token} [get]
+func (h *Handler) GetSharedWishlist(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	wishlist, err := h.Repos.Wishlists.GetByShareToken(ctx, c.Param("token"))
+	if helpers.HandleError(c, err, "Failed to retrieve wishlist") {
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// ownWishlist loads the wishlist in the id parameter when it belongs to the
+// authenticated user. Other users' wishlists are not found. It sends the error
+// response and returns false on failure.
+func (h *Handler) ownWishlist(c *gin.Context) (*models.Wishlist, bool) {
+	id, err := helpers.ParseIDParam(c, "id")
+	if err != nil {
+		helpers.RespondWithError(c, http.StatusBadRequest, "Invalid wishlist ID")
+		return nil, false
+	}
+
+	user, ok := helpers.GetAuthenticatedUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	wishlist, err := h.Repos.Wishlists.Get(c.Request.Context(), id)
+	if helpers.HandleError(c, err, "Failed to retrieve wishlist") {
+		return nil, false
+	}
+	if wishlist == nil || wishlist.UserID != user.ID {
+		helpers.RespondWithAppError(c, appErrors.Newf(appErrors.ErrNotFound, "wishlist with ID %d not found", id), "")
+		return nil, false
+	}
+	return wishlist, true
+}
+
+// respondWithWishlist sends a wishlist as it is after a change
+func (h *Handler) respondWithWishlist(c *gin.Context, id int) {
+	wishlist, err := h.Repos.Wishlists.Get(c.Request.Context(), id)
+	if helpers.HandleError(c, err, "Failed to retrieve wishlist") {
+		return
+	}
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// newShareToken returns a random URL-safe wishlist share token
+func newShareToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// notifyWishlistWatchers tells users with the product on a wishlist that it
+// came back in stock or dropped in price. Failures are logged and never fail
+// the request that changed the product.
+func (h *Handler) notifyWishlistWatchers(ctx context.Context, product *models.Product, previousPrice money.Money, wasAvailable bool) {
+	var notificationType, message string
+	price, err := pricing.FinalPrice(product.Price, product.Discount)
+	switch {
+	case !wasAvailable && product.Available > 0:
+		notificationType = models.NotificationTypeBackInStock
+		message = fmt.Sprintf("%s is back in stock", product.Name)
+	case err == nil && price.SameCurrency(previousPrice) && price.Amount < previousPrice.Amount:
+		notificationType = models.NotificationTypePriceDrop
+		message = fmt.Sprintf("%s dropped in price from %s to %s", product.Name, previousPrice, price)
+	default:
+		return
+	}
+
+	userIDs, err := h.Repos.Wishlists.WatcherIDs(ctx, product.ID)
+	if err != nil {
+		return
+	}
+
+	for _, userID := range userIDs {
+		h.deliverNotification(ctx, &models.Notification{
+			UserID:    userID,
+			Type:      notificationType,
+			ProductID: &product.ID,
+			Message:   message,
+		})
+	}
+	if len(userIDs) > 0 {
+		logging.Info(ctx, "wishlist watchers notified", "product_id", product.ID, "type", notificationType, "count", len(userIDs))
+	}
+}
+
+// notifyRestocked tells wishlist watchers of the products an order returned to
+// stock, when cancelled or refunded, that they are back in stock. A product
+// was out of stock before if its availability less the returned quantity is
+// none.
+func (h *Handler) notifyRestocked(ctx context.Context, order *models.Order) {
+	returned := make(map[int]int)
+	var productIDs []int
+	for _, item := range order.Items {
+		if returned[item.ProductID] == 0 {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		returned[item.ProductID] += item.Quantity
+	}
+
+	for _, productID := range productIDs {
+		product, err := h.Repos.Products.Get(ctx, productID)
+		if err != nil {
+			logging.Error(ctx, "failed to load restocked product", err, "order_id", order.ID, "product_id", productID)
+			continue
+		}
+		price, _ := pricing.FinalPrice(product.Price, product.Discount)
+		h.notifyWishlistWatchers(ctx, product, price, product.Available-returned[productID] > 0)
+	}
+}
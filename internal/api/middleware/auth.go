@@ -106,3 +106,16 @@ func AuthMiddleware(jwtSecret string, userRepo interfaces.UserRepositoryInterfac
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware authenticates requests that carry an Authorization
+// header and lets anonymous requests through without a user
+func OptionalAuthMiddleware(jwtSecret string, userRepo interfaces.UserRepositoryInterface) gin.HandlerFunc {
+	authenticate := AuthMiddleware(jwtSecret, userRepo)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
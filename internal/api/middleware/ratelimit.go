@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/helpers"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
@@ -93,3 +95,21 @@ func RateLimitMiddleware(limit rate.Limit, burst int) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// UserRateLimitMiddleware creates middleware for rate limiting based on the authenticated user.
+// It must run after AuthMiddleware; requests without a user fall back to the client IP.
+func UserRateLimitMiddleware(limit rate.Limit, burst int) gin.HandlerFunc {
+	limiter := NewIPRateLimiter(limit, burst)
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if user := helpers.GetUserFromContext(c); user != nil {
+			key = "user:" + strconv.Itoa(user.ID)
+		}
+		if !limiter.GetLimiter(key).Allow() {
+			helpers.RespondWithError(c, http.StatusTooManyRequests, "Too many requests, please slow down")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
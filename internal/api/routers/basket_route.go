@@ -5,13 +5,25 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// SetupProtectedBasketRoutes registers protected basket routes
-func SetupProtectedBasketRoutes(rg *gin.RouterGroup, h *handlers.Handler) {
+// SetupBasketRoutes registers basket routes open to guests and users
+func SetupBasketRoutes(rg *gin.RouterGroup, h *handlers.Handler) {
 	basket := rg.Group("/basket")
 	{
 		basket.GET("", h.GetBasket)
 		basket.DELETE("", h.ClearBasket)
 		basket.POST("/items", h.AddItemToBasket)
+		basket.PATCH("/items/:id", h.UpdateBasketItem)
 		basket.DELETE("/items/:id", h.RemoveItemFromBasket)
+		basket.POST("/coupon", h.ApplyCoupon)
+		basket.DELETE("/coupon", h.RemoveCoupon)
+	}
+}
+
+// SetupProtectedBasketRoutes registers protected basket routes
+func SetupProtectedBasketRoutes(rg *gin.RouterGroup, h *handlers.Handler) {
+	basket := rg.Group("/basket")
+	{
+		basket.POST("/checkout", h.Checkout)
+		basket.POST("/items/:id/save-for-later", h.SaveBasketItemForLater)
 	}
 }
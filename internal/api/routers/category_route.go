@@ -10,6 +10,7 @@ func SetupCategoryRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 	categories := router.Group("/categories")
 	{
 		categories.GET("", h.GetAllCategories)
+		categories.GET("/tree", h.GetCategoryTree)
 		categories.GET("/:slug", h.GetCategoryBySlug)
 	}
 }
@@ -17,4 +18,8 @@ func SetupCategoryRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 // SetupProtectedCategoryRoutes configures protected category routes
 func SetupProtectedCategoryRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 	router.POST("/categories", h.CreateCategory)
+	router.PUT("/categories/:id", h.UpdateCategory)
+	router.DELETE("/categories/:id", h.DeleteCategory)
+	router.POST("/categories/:id/move", h.MoveCategory)
+	router.PUT("/categories/:id/image", h.UploadCategoryImage)
 }
@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupProtectedCouponRoutes registers protected coupon management routes
+func SetupProtectedCouponRoutes(rg *gin.RouterGroup, h *handlers.Handler) {
+	coupons := rg.Group("/coupons")
+	{
+		coupons.GET("", h.GetCoupons)
+		coupons.POST("", h.CreateCoupon)
+		coupons.DELETE("/:id", h.DeactivateCoupon)
+	}
+}
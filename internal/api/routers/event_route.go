@@ -1,8 +1,12 @@
 package routers
 
 import (
+	"time"
+
 	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/middleware"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
 )
 
 // SetupEventRoutes configures public event routes
@@ -13,6 +17,7 @@ func SetupEventRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 		events.GET("/:id", h.GetEvent)
 		events.GET("/:id/attendees", h.GetAttendees)
 		events.GET("/:id/comments", h.GetEventComments)
+		events.GET("/:id/comments/:commentId/thread", h.GetCommentThread)
 	}
 }
 
@@ -24,8 +29,20 @@ func SetupProtectedEventRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 	router.DELETE("/events/:id", h.DeleteEvent)
 
 	// Comment management
-	router.POST("/events/:id/comments", h.CreateComment)
+	commentLimit := rate.Every(time.Minute / time.Duration(h.CommentRateLimit))
+	router.POST("/events/:id/comments", middleware.UserRateLimitMiddleware(commentLimit, h.CommentRateLimit), h.CreateComment)
+	router.PUT("/events/:id/comments/:commentId", h.UpdateComment)
 	router.DELETE("/events/:id/comments/:commentId", h.DeleteComment)
+	router.GET("/events/:id/comments/:commentId/revisions", h.GetCommentRevisions)
+	router.POST("/events/:id/comments/:commentId/report", h.ReportComment)
+
+	// Comment moderation
+	router.GET("/events/:id/comments/moderation", h.GetModerationQueue)
+	router.POST("/events/:id/comments/:commentId/moderate", h.ModerateComment)
+
+	// Mention notifications
+	router.POST("/events/:id/mentions/mute", h.MuteEventMentions)
+	router.DELETE("/events/:id/mentions/mute", h.UnmuteEventMentions)
 
 	// Attendee management
 	router.POST("/events/:id/attendees/:userId", h.AddAttendee)
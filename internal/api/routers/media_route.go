@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMediaRoutes configures public media routes
+func SetupMediaRoutes(router *gin.RouterGroup, h *handlers.Handler) {
+	media := router.Group("/media")
+	{
+		media.GET("/:id", h.GetMedia)
+		media.GET("/:id/:variant", h.GetMediaVariant)
+		media.GET("/files/*key", h.ServeMediaFile)
+	}
+}
+
+// SetupProtectedMediaRoutes configures protected media routes
+func SetupProtectedMediaRoutes(router *gin.RouterGroup, h *handlers.Handler) {
+	router.POST("/media", h.UploadMedia)
+}
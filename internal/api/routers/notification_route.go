@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupProtectedNotificationRoutes configures protected notification routes
+func SetupProtectedNotificationRoutes(router *gin.RouterGroup, h *handlers.Handler) {
+	notifications := router.Group("/notifications")
+	{
+		notifications.GET("", h.GetNotifications)
+		notifications.POST("/read-all", h.MarkAllNotificationsRead)
+		notifications.POST("/:id/read", h.MarkNotificationRead)
+	}
+}
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupProtectedOrderRoutes registers protected order routes
+func SetupProtectedOrderRoutes(rg *gin.RouterGroup, h *handlers.Handler) {
+	orders := rg.Group("/orders")
+	{
+		orders.GET("", h.GetMyOrders)
+		orders.GET("/:id", h.GetOrder)
+		orders.POST("/:id/status", h.UpdateOrderStatus)
+		orders.POST("/:id/pay", h.PayOrder)
+	}
+
+	rg.GET("/seller/orders", h.GetSellerOrders)
+}
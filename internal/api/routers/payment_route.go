@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupPaymentRoutes configures public payment routes. The webhook is
+// authenticated by its provider signature rather than a user token.
+func SetupPaymentRoutes(router *gin.RouterGroup, h *handlers.Handler) {
+	payments := router.Group("/payments")
+	{
+		payments.POST("/webhook", h.PaymentWebhook)
+	}
+}
@@ -10,9 +10,14 @@ func SetupProductRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 	products := router.Group("/products")
 	{
 		products.GET("", h.GetAllProducts)
+		products.GET("/facets", h.GetProductFacets)
+		products.GET("/trending", h.GetTrendingProducts)
 		products.GET("/:id", h.GetProduct)
 		products.GET("/slug/:slug", h.GetProductBySlug)
 		products.GET("/category/:id", h.GetProductsByCategory)
+		products.GET("/:id/reviews", h.GetProductReviews)
+		products.GET("/:id/related", h.GetRelatedProducts)
+		products.GET("/:id/prices", h.GetProductPriceHistory)
 	}
 }
 
@@ -23,5 +28,26 @@ func SetupProtectedProductRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 		products.POST("", h.CreateProduct)
 		products.PUT("/:id", h.UpdateProduct)
 		products.DELETE("/:id", h.DeleteProduct)
+		products.POST("/:id/images", h.UploadProductImage)
+
+		// Scheduled price changes and sales
+		products.GET("/:id/price-schedules", h.GetProductPriceSchedules)
+		products.POST("/:id/price-schedules", h.CreateProductPriceSchedule)
+		products.DELETE("/:id/price-schedules/:scheduleId", h.CancelProductPriceSchedule)
+
+		// Inventory ledger
+		products.GET("/:id/inventory", h.GetProductInventory)
+		products.POST("/:id/inventory", h.AdjustProductInventory)
+		products.POST("/:id/inventory/reconcile", h.ReconcileProductInventory)
+
+		// Bulk import and export
+		products.POST("/import", h.ImportProducts)
+		products.GET("/imports/:id", h.GetProductImport)
+		products.GET("/export", h.ExportProducts)
+
+		// Reviews
+		products.POST("/:id/reviews", h.CreateReview)
+		products.PUT("/:id/reviews/:reviewId", h.UpdateReview)
+		products.DELETE("/:id/reviews/:reviewId", h.DeleteReview)
 	}
 }
@@ -11,4 +11,5 @@ func SetupProtectedProfileRoutes(router *gin.RouterGroup, h *handlers.Handler) {
 	router.POST("/profile", h.CreateProfile)
 	router.PUT("/profile", h.UpdateProfile)
 	router.DELETE("/profile", h.DeleteProfile)
+	router.PUT("/profile/avatar", h.UploadAvatar)
 }
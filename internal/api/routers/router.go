@@ -56,8 +56,23 @@ func SetupRouter(handler *handlers.Handler, jwtSecret string, userRepo interface
 		// Category Routes
 		SetupCategoryRoutes(v1, handler)
 
-		// Product Routes
-		SetupProductRoutes(v1, handler)
+		// Payment Routes
+		SetupPaymentRoutes(v1, handler)
+
+		// Wishlist Routes
+		SetupWishlistRoutes(v1, handler)
+
+		// Media Routes
+		SetupMediaRoutes(v1, handler)
+
+		// Basket routes (guests identified by basket token), and product
+		// routes, which count views once per user or IP
+		guest := v1.Group("")
+		guest.Use(middleware.OptionalAuthMiddleware(jwtSecret, userRepo))
+		{
+			SetupBasketRoutes(guest, handler)
+			SetupProductRoutes(guest, handler)
+		}
 
 		// Protected routes (require authentication)
 		protected := v1.Group("")
@@ -70,6 +85,11 @@ func SetupRouter(handler *handlers.Handler, jwtSecret string, userRepo interface
 			SetupProtectedProfileRoutes(protected, handler)
 			SetupProtectedProductRoutes(protected, handler)
 			SetupProtectedBasketRoutes(protected, handler)
+			SetupProtectedOrderRoutes(protected, handler)
+			SetupProtectedCouponRoutes(protected, handler)
+			SetupProtectedWishlistRoutes(protected, handler)
+			SetupProtectedNotificationRoutes(protected, handler)
+			SetupProtectedMediaRoutes(protected, handler)
 
 		}
 	}
@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWishlistRoutes registers public wishlist routes
+func SetupWishlistRoutes(rg *gin.RouterGroup, h *handlers.Handler) {
+	rg.GET("/wishlists/shared/:token", h.GetSharedWishlist)
+}
+
+// SetupProtectedWishlistRoutes registers protected wishlist routes
+func SetupProtectedWishlistRoutes(rg *gin.RouterGroup, h *handlers.Handler) {
+	wishlists := rg.Group("/wishlists")
+	{
+		wishlists.GET("", h.GetWishlists)
+		wishlists.POST("", h.CreateWishlist)
+		wishlists.GET("/:id", h.GetWishlist)
+		wishlists.DELETE("/:id", h.DeleteWishlist)
+		wishlists.POST("/:id/items", h.AddWishlistItem)
+		wishlists.DELETE("/:id/items/:productId", h.RemoveWishlistItem)
+		wishlists.POST("/:id/items/:productId/move-to-basket", h.MoveWishlistItemToBasket)
+		wishlists.POST("/:id/share", h.ShareWishlist)
+		wishlists.DELETE("/:id/share", h.UnshareWishlist)
+	}
+}
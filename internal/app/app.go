@@ -11,7 +11,13 @@ import (
 	"github.com/alireza-akbarzadeh/ginflow/internal/api/routers"
 	"github.com/alireza-akbarzadeh/ginflow/internal/console"
 	"github.com/alireza-akbarzadeh/ginflow/internal/database"
+	"github.com/alireza-akbarzadeh/ginflow/internal/imaging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/moderation"
+	"github.com/alireza-akbarzadeh/ginflow/internal/payments"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
 	"github.com/alireza-akbarzadeh/ginflow/internal/repository"
+	"github.com/alireza-akbarzadeh/ginflow/internal/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -82,7 +88,77 @@ func (a *App) initialize() error {
 	a.repos = repository.NewModels(a.db)
 
 	// 4. Initialize Handlers
-	a.handler = handlers.NewHandler(a.repos, a.config.JWTSecret)
+	commentFilter, err := moderation.NewFilter(a.config.CommentFilterWords, a.config.CommentFilterPatterns)
+	if err != nil {
+		return fmt.Errorf("comment filter configuration invalid: %w", err)
+	}
+
+	paymentProvider, err := payments.NewProvider(a.config.PaymentProvider, a.config.PaymentWebhookSecret)
+	if err != nil {
+		return fmt.Errorf("payment configuration invalid: %w", err)
+	}
+
+	pricingEngine, err := pricing.NewEngine(a.config.TaxRates, a.config.DefaultTaxRate)
+	if err != nil {
+		return fmt.Errorf("tax configuration invalid: %w", err)
+	}
+
+	signingSecret := a.config.StorageSigningSecret
+	if signingSecret == "" {
+		signingSecret = a.config.JWTSecret
+	}
+	mediaStorage, err := storage.New(storage.Config{
+		Driver:        a.config.StorageDriver,
+		SigningSecret: signingSecret,
+		BaseURL:       models.MediaFilesPath,
+		LocalDir:      a.config.StorageLocalDir,
+		S3: storage.S3Config{
+			Endpoint:        a.config.S3Endpoint,
+			Region:          a.config.S3Region,
+			Bucket:          a.config.S3Bucket,
+			AccessKeyID:     a.config.S3AccessKeyID,
+			SecretAccessKey: a.config.S3SecretAccessKey,
+			PathStyle:       a.config.S3PathStyle,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("storage configuration invalid: %w", err)
+	}
+	imageSizes, err := imaging.ParseSizes(a.config.ImageSizes)
+	if err != nil {
+		return fmt.Errorf("image sizes configuration invalid: %w", err)
+	}
+	if err := imaging.CheckFormat(a.config.ImageFormat); err != nil {
+		return fmt.Errorf("image format configuration invalid: %w", err)
+	}
+	imageVariants := make([]string, len(imageSizes))
+	for i, size := range imageSizes {
+		imageVariants[i] = size.Name
+	}
+	models.SetImageVariants(imageVariants)
+
+	a.handler = handlers.NewHandler(a.repos, a.config.JWTSecret,
+		handlers.WithCommentMaxDepth(a.config.CommentMaxDepth),
+		handlers.WithCommentEditWindow(a.config.CommentEditWindow),
+		handlers.WithCommentRateLimit(a.config.CommentRateLimit),
+		handlers.WithCommentReportThreshold(a.config.CommentReportThreshold),
+		handlers.WithCommentFilter(commentFilter),
+		handlers.WithPaymentProvider(paymentProvider),
+		handlers.WithCurrency(a.config.Currency),
+		handlers.WithPricing(pricingEngine),
+		handlers.WithStockReservationTTL(a.config.StockReservationTTL),
+		handlers.WithGuestBasketTTL(a.config.GuestBasketTTL),
+		handlers.WithReviewsVerifiedOnly(a.config.ReviewsVerifiedOnly),
+		handlers.WithProductImportMaxSize(int64(a.config.ProductImportMaxSize)),
+		handlers.WithStorage(mediaStorage),
+		handlers.WithMediaMaxSize(int64(a.config.MediaMaxSize)),
+		handlers.WithMediaURLTTL(a.config.MediaURLTTL),
+		handlers.WithImageSizes(imageSizes),
+		handlers.WithImageFormat(a.config.ImageFormat),
+		handlers.WithViewWindow(a.config.ViewWindow),
+		handlers.WithTrendingHalfLife(a.config.TrendingHalfLife),
+		handlers.WithTrendingSaleWeight(a.config.TrendingSaleWeight),
+	)
 
 	// 5. Initialize Router
 	a.router = routers.SetupRouter(a.handler, a.config.JWTSecret, a.repos.Users)
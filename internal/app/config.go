@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/config"
+	"github.com/alireza-akbarzadeh/ginflow/internal/constants"
 )
 
 // Config holds all application configuration
@@ -15,17 +16,109 @@ type Config struct {
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+
+	// Comments
+	CommentMaxDepth        int
+	CommentEditWindow      time.Duration
+	CommentRateLimit       int
+	CommentReportThreshold int
+	CommentFilterWords     []string
+	CommentFilterPatterns  []string
+
+	// Payments
+	PaymentProvider      string
+	PaymentWebhookSecret string
+	RefundRetryEvery     time.Duration
+	Currency             string
+
+	// Pricing
+	TaxRates       []string
+	DefaultTaxRate string
+
+	// Stock
+	StockReservationTTL   time.Duration
+	ReservationSweepEvery time.Duration
+
+	// Guest baskets
+	GuestBasketTTL        time.Duration
+	GuestBasketSweepEvery time.Duration
+
+	// Reviews
+	ReviewsVerifiedOnly bool
+
+	// Product import
+	ProductImportMaxSize int
+
+	// Media storage
+	StorageDriver        string
+	StorageLocalDir      string
+	StorageSigningSecret string
+	S3Endpoint           string
+	S3Region             string
+	S3Bucket             string
+	S3AccessKeyID        string
+	S3SecretAccessKey    string
+	S3PathStyle          bool
+	MediaMaxSize         int
+	MediaURLTTL          time.Duration
+	ImageSizes           string
+	ImageFormat          string
+
+	// Product views and trending
+	ViewWindow         time.Duration
+	ViewFlushEvery     time.Duration
+	TrendingHalfLife   time.Duration
+	TrendingSaleWeight int
+
+	// Scheduled price changes
+	PriceScheduleEvery time.Duration
 }
 
 // DefaultConfig returns the default configuration loaded from environment
 func DefaultConfig() *Config {
 	return &Config{
-		Port:            config.GetEnvInt("PORT", 8080),
-		JWTSecret:       config.GetEnvString("JWT_SECRET", "some-secret-123456"),
-		DatabaseURL:     config.GetEnvString("DATABASE_URL", ""),
-		IdleTimeout:     time.Minute,
-		ReadTimeout:     10 * time.Second,
-		WriteTimeout:    30 * time.Second,
-		ShutdownTimeout: 5 * time.Second,
+		Port:                   config.GetEnvInt("PORT", 8080),
+		JWTSecret:              config.GetEnvString("JWT_SECRET", "some-secret-123456"),
+		DatabaseURL:            config.GetEnvString("DATABASE_URL", ""),
+		IdleTimeout:            time.Minute,
+		ReadTimeout:            10 * time.Second,
+		WriteTimeout:           30 * time.Second,
+		ShutdownTimeout:        5 * time.Second,
+		CommentMaxDepth:        config.GetEnvInt("COMMENT_MAX_DEPTH", constants.DEFAULT_COMMENT_MAX_DEPTH),
+		CommentEditWindow:      config.GetEnvDuration("COMMENT_EDIT_WINDOW", time.Duration(constants.DEFAULT_COMMENT_EDIT_WINDOW)*time.Second),
+		CommentRateLimit:       config.GetEnvInt("COMMENT_RATE_LIMIT", constants.DEFAULT_COMMENT_RATE_LIMIT),
+		CommentReportThreshold: config.GetEnvInt("COMMENT_REPORT_THRESHOLD", constants.DEFAULT_COMMENT_REPORT_THRESHOLD),
+		CommentFilterWords:     config.GetEnvList("COMMENT_FILTER_WORDS", ",", nil),
+		CommentFilterPatterns:  config.GetEnvList("COMMENT_FILTER_PATTERNS", ";", nil),
+		PaymentProvider:        config.GetEnvString("PAYMENT_PROVIDER", constants.DEFAULT_PAYMENT_PROVIDER),
+		PaymentWebhookSecret:   config.GetEnvString("PAYMENT_WEBHOOK_SECRET", ""),
+		RefundRetryEvery:       config.GetEnvDuration("REFUND_RETRY_INTERVAL", time.Duration(constants.DEFAULT_REFUND_RETRY_INTERVAL)*time.Second),
+		Currency:               config.GetEnvString("CURRENCY", constants.DEFAULT_CURRENCY),
+		TaxRates:               config.GetEnvList("TAX_RATES", ",", nil),
+		DefaultTaxRate:         config.GetEnvString("DEFAULT_TAX_RATE", constants.DEFAULT_TAX_RATE),
+		StockReservationTTL:    config.GetEnvDuration("STOCK_RESERVATION_TTL", time.Duration(constants.DEFAULT_STOCK_RESERVATION_TTL)*time.Second),
+		ReservationSweepEvery:  config.GetEnvDuration("RESERVATION_SWEEP_INTERVAL", time.Duration(constants.DEFAULT_RESERVATION_SWEEP)*time.Second),
+		GuestBasketTTL:         config.GetEnvDuration("GUEST_BASKET_TTL", time.Duration(constants.DEFAULT_GUEST_BASKET_TTL)*time.Second),
+		GuestBasketSweepEvery:  config.GetEnvDuration("GUEST_BASKET_SWEEP_INTERVAL", time.Duration(constants.DEFAULT_GUEST_BASKET_SWEEP)*time.Second),
+		ReviewsVerifiedOnly:    config.GetEnvBool("REVIEWS_VERIFIED_ONLY", constants.DEFAULT_REVIEWS_VERIFIED_ONLY),
+		ProductImportMaxSize:   config.GetEnvInt("PRODUCT_IMPORT_MAX_SIZE", constants.DEFAULT_PRODUCT_IMPORT_MAX_SIZE),
+		StorageDriver:          config.GetEnvString("STORAGE_DRIVER", constants.DEFAULT_STORAGE_DRIVER),
+		StorageLocalDir:        config.GetEnvString("STORAGE_LOCAL_DIR", constants.DEFAULT_STORAGE_LOCAL_DIR),
+		StorageSigningSecret:   config.GetEnvString("STORAGE_SIGNING_SECRET", ""),
+		S3Endpoint:             config.GetEnvString("S3_ENDPOINT", ""),
+		S3Region:               config.GetEnvString("S3_REGION", ""),
+		S3Bucket:               config.GetEnvString("S3_BUCKET", ""),
+		S3AccessKeyID:          config.GetEnvString("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:      config.GetEnvString("S3_SECRET_ACCESS_KEY", ""),
+		S3PathStyle:            config.GetEnvBool("S3_PATH_STYLE", false),
+		MediaMaxSize:           config.GetEnvInt("MEDIA_MAX_SIZE", constants.DEFAULT_MEDIA_MAX_SIZE),
+		MediaURLTTL:            config.GetEnvDuration("MEDIA_URL_TTL", time.Duration(constants.DEFAULT_MEDIA_URL_TTL)*time.Second),
+		ImageSizes:             config.GetEnvString("IMAGE_SIZES", constants.DEFAULT_IMAGE_SIZES),
+		ImageFormat:            config.GetEnvString("IMAGE_FORMAT", constants.DEFAULT_IMAGE_FORMAT),
+		ViewWindow:             config.GetEnvDuration("VIEW_WINDOW", time.Duration(constants.DEFAULT_VIEW_WINDOW)*time.Second),
+		ViewFlushEvery:         config.GetEnvDuration("VIEW_FLUSH_INTERVAL", time.Duration(constants.DEFAULT_VIEW_FLUSH)*time.Second),
+		TrendingHalfLife:       config.GetEnvDuration("TRENDING_HALF_LIFE", time.Duration(constants.DEFAULT_TRENDING_HALF_LIFE)*time.Second),
+		TrendingSaleWeight:     config.GetEnvInt("TRENDING_SALE_WEIGHT", constants.DEFAULT_TRENDING_SALE_WEIGHT),
+		PriceScheduleEvery:     config.GetEnvDuration("PRICE_SCHEDULE_INTERVAL", time.Duration(constants.DEFAULT_PRICE_SCHEDULE_INTERVAL)*time.Second),
 	}
 }
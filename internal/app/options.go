@@ -53,3 +53,25 @@ func WithShutdownTimeout(d time.Duration) Option {
 		a.config.ShutdownTimeout = d
 	}
 }
+
+// WithCommentMaxDepth sets the maximum nesting depth for comment replies
+func WithCommentMaxDepth(depth int) Option {
+	return func(a *App) {
+		a.config.CommentMaxDepth = depth
+	}
+}
+
+// WithCommentEditWindow sets how long after posting a comment may be edited
+func WithCommentEditWindow(d time.Duration) Option {
+	return func(a *App) {
+		a.config.CommentEditWindow = d
+	}
+}
+
+// WithCommentFilter sets the blocked words and regular expressions that hold comments for review
+func WithCommentFilter(words, patterns []string) Option {
+	return func(a *App) {
+		a.config.CommentFilterWords = words
+		a.config.CommentFilterPatterns = patterns
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 // Run starts the HTTP server and blocks until shutdown signal is received
@@ -14,6 +15,11 @@ func (a *App) Run() error {
 	// Channel to capture server errors
 	serverErr := make(chan error, 1)
 
+	// Background jobs stop with the server
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	a.startSweepers(jobs)
+
 	// Start server in goroutine
 	go func() {
 		a.printStartupBanner()
@@ -76,6 +82,11 @@ func (a *App) gracefulShutdown() error {
 		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
 
+	// Views counted since the last flush would be lost otherwise
+	if err := a.handler.Views.Flush(ctx, a.repos.Products, time.Now()); err != nil {
+		a.console.Error("❌", fmt.Sprintf("Error saving product views: %v", err))
+	}
+
 	a.console.Line()
 	a.console.Success("👋", "Server stopped gracefully. Goodbye!")
 	a.console.Line()
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// startSweepers starts the periodic clean-up, flush and scheduling jobs. They stop when ctx is done.
+func (a *App) startSweepers(ctx context.Context) {
+	// Failures are logged by the repositories and retried on the next tick
+	a.every(ctx, a.config.ReservationSweepEvery, func(now time.Time) {
+		_, _ = a.repos.Reservations.ReleaseExpired(ctx, now)
+	})
+	a.every(ctx, a.config.GuestBasketSweepEvery, func(now time.Time) {
+		_, _ = a.repos.Baskets.DeleteStaleGuestBaskets(ctx, now.Add(-a.config.GuestBasketTTL))
+	})
+	// Views the database fails to take are kept for the next flush
+	a.every(ctx, a.config.ViewFlushEvery, func(now time.Time) {
+		_ = a.handler.Views.Flush(ctx, a.repos.Products, now)
+	})
+	// Refunds get a full interval to go through before they are retried
+	a.every(ctx, a.config.RefundRetryEvery, func(now time.Time) {
+		_ = a.handler.RetryRefunds(ctx, now.Add(-a.config.RefundRetryEvery))
+	})
+	// Schedules that fail to apply stay due for the next tick
+	a.every(ctx, a.config.PriceScheduleEvery, func(now time.Time) {
+		_ = a.handler.ApplyPriceSchedules(ctx, now)
+	})
+}
+
+// every runs job at each interval until ctx is done. A non-positive interval
+// disables the job.
+func (a *App) every(ctx context.Context, interval time.Duration, job func(now time.Time)) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				job(now)
+			}
+		}
+	}()
+}
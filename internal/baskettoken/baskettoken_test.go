@@ -0,0 +1,29 @@
+package baskettoken
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	token := Sign("secret", 42)
+
+	id, err := Verify("secret", token)
+	require.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	_, err = Verify("other-secret", token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = Verify("", token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// The signature of one basket does not unlock another
+	_, sig, _ := strings.Cut(token, ".")
+	for _, forged := range []string{"43." + sig, "42", "42.", "", "abc." + sig, "-1." + sig} {
+		_, err := Verify("secret", forged)
+		assert.ErrorIs(t, err, ErrInvalidToken, forged)
+	}
+}
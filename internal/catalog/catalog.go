@@ -0,0 +1,187 @@
+// Package catalog reads and writes products as flat rows for bulk import and
+// export in CSV and NDJSON.
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/utils"
+)
+
+// Format is a bulk product file format
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// ParseFormat reads a format name, e.g. "csv", or a content type, e.g.
+// "application/x-ndjson"
+func ParseFormat(s string) (Format, error) {
+	name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ";")
+	switch strings.TrimSpace(name) {
+	case "csv", "text/csv", "application/csv":
+		return CSV, nil
+	case "ndjson", "jsonl", "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return NDJSON, nil
+	}
+	return "", fmt.Errorf("unsupported format %q: use csv or ndjson", s)
+}
+
+// ContentType returns the MIME type of files in the format
+func (f Format) ContentType() string {
+	if f == NDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// Amount is a decimal amount in major units, e.g. "19.99". In NDJSON it may
+// also be given as a number.
+type Amount string
+
+// UnmarshalJSON reads an amount from a JSON string or number
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Amount(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return errors.New("amounts must be decimal strings or numbers")
+	}
+	*a = Amount(n.String())
+	return nil
+}
+
+// Row is a product as a flat record. Prices are decimal amounts in the row's
+// currency and categories are given by slug.
+type Row struct {
+	SKU             string   `json:"sku"`
+	Name            string   `json:"name"`
+	Slug            string   `json:"slug,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	Price           Amount   `json:"price"`
+	Discount        Amount   `json:"discount,omitempty"`
+	Currency        string   `json:"currency,omitempty"`
+	Stock           int      `json:"stock"`
+	Status          string   `json:"status,omitempty"`
+	Brand           string   `json:"brand,omitempty"`
+	Image           string   `json:"image,omitempty"`
+	Images          []string `json:"images,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Categories      []string `json:"categories,omitempty"`
+	Weight          float64  `json:"weight,omitempty"`
+	Dimensions      string   `json:"dimensions,omitempty"`
+	MetaTitle       string   `json:"metaTitle,omitempty"`
+	MetaDescription string   `json:"metaDescription,omitempty"`
+}
+
+// RowOf flattens a product into a row
+func RowOf(product *models.Product) Row {
+	row := Row{
+		SKU:             product.SKU,
+		Name:            product.Name,
+		Slug:            product.Slug,
+		Description:     product.Description,
+		Price:           Amount(product.Price.Decimal()),
+		Currency:        product.Currency,
+		Stock:           product.Stock,
+		Status:          product.Status,
+		Brand:           product.Brand,
+		Image:           product.Image,
+		Images:          product.Images,
+		Tags:            product.Tags,
+		Weight:          product.Weight,
+		Dimensions:      product.Dimensions,
+		MetaTitle:       product.MetaTitle,
+		MetaDescription: product.MetaDescription,
+	}
+	if !product.Discount.IsZero() {
+		row.Discount = Amount(product.Discount.Decimal())
+	}
+	for _, category := range product.Categories {
+		row.Categories = append(row.Categories, category.Slug)
+	}
+	return row
+}
+
+// Product validates the row and builds the product it describes, priced in
+// fallback when the row has no currency. The slug defaults to one made from
+// the name and the final price is derived from the price and discount.
+func (r Row) Product(fallback string) (*models.Product, error) {
+	sku := strings.TrimSpace(r.SKU)
+	if sku == "" {
+		return nil, errors.New("sku is required")
+	}
+	name := strings.TrimSpace(r.Name)
+	if len(name) < 3 {
+		return nil, errors.New("name must be at least 3 characters")
+	}
+	if r.Stock < 0 {
+		return nil, errors.New("stock cannot be negative")
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(r.Currency))
+	if currency == "" {
+		currency = fallback
+	}
+	price, err := money.Parse(string(r.Price), currency)
+	if err != nil {
+		return nil, fmt.Errorf("price: %w", err)
+	}
+	if !price.IsPositive() {
+		return nil, errors.New("price must be greater than zero")
+	}
+	discount := money.Zero(currency)
+	if strings.TrimSpace(string(r.Discount)) != "" {
+		if discount, err = money.Parse(string(r.Discount), currency); err != nil {
+			return nil, fmt.Errorf("discount: %w", err)
+		}
+	}
+	if discount.IsNegative() {
+		return nil, errors.New("discount cannot be negative")
+	}
+	finalPrice, err := pricing.FinalPrice(price, discount)
+	if err != nil {
+		return nil, errors.New("discount cannot exceed the price")
+	}
+
+	slug := strings.TrimSpace(r.Slug)
+	if slug == "" {
+		slug = utils.GenerateSlug(name)
+	}
+	status := strings.TrimSpace(r.Status)
+	if status == "" {
+		status = "active"
+	}
+
+	return &models.Product{
+		Name:            name,
+		Description:     r.Description,
+		Price:           price,
+		Currency:        currency,
+		Stock:           r.Stock,
+		SKU:             sku,
+		Status:          status,
+		Slug:            slug,
+		Image:           r.Image,
+		Images:          r.Images,
+		Tags:            r.Tags,
+		MetaTitle:       r.MetaTitle,
+		MetaDescription: r.MetaDescription,
+		Discount:        discount,
+		FinalPrice:      finalPrice,
+		Brand:           r.Brand,
+		Weight:          r.Weight,
+		Dimensions:      r.Dimensions,
+	}, nil
+}
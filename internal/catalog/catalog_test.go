@@ -0,0 +1,184 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll reads every row, collecting the lines of rows that failed to decode
+func readAll(t *testing.T, reader Reader) ([]Row, []int) {
+	t.Helper()
+	var rows []Row
+	var failed []int
+	for {
+		row, line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, failed
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			failed = append(failed, line)
+			continue
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]Format{
+		"csv":                        CSV,
+		"text/csv; charset=utf-8":    CSV,
+		"NDJSON":                     NDJSON,
+		"application/x-ndjson":       NDJSON,
+		"application/jsonl":          NDJSON,
+		" application/x-ndjson ; q ": NDJSON,
+	} {
+		format, err := ParseFormat(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, format, input)
+	}
+
+	_, err := ParseFormat("application/json")
+	assert.Error(t, err)
+}
+
+func TestCSVReader(t *testing.T) {
+	t.Run("reads rows by header name", func(t *testing.T) {
+		file := "\ufeffSKU,name,price,stock,categories,tags\n" +
+			"LAMP-1,Desk Lamp,19.99,4,lighting|home,\n" +
+			"\n" +
+			"CHAIR-1,\"Chair, oak\",120,,,wood\n"
+
+		reader, err := NewReader(CSV, strings.NewReader(file))
+		require.NoError(t, err)
+		rows, failed := readAll(t, reader)
+
+		assert.Empty(t, failed)
+		require.Len(t, rows, 2)
+		assert.Equal(t, Row{SKU: "LAMP-1", Name: "Desk Lamp", Price: "19.99", Stock: 4, Categories: []string{"lighting", "home"}}, rows[0])
+		assert.Equal(t, Row{SKU: "CHAIR-1", Name: "Chair, oak", Price: "120", Tags: []string{"wood"}}, rows[1])
+	})
+
+	t.Run("reports bad rows and carries on", func(t *testing.T) {
+		file := "sku,name,price,stock\n" +
+			"A-1,Alpha,1.00,many\n" +
+			"A-2,Beta,2.00\n" +
+			"A-3,Gamma,3.00,1\n"
+
+		reader, err := NewReader(CSV, strings.NewReader(file))
+		require.NoError(t, err)
+		rows, failed := readAll(t, reader)
+
+		assert.Equal(t, []int{2, 3}, failed)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "A-3", rows[0].SKU)
+	})
+
+	t.Run("checks the header", func(t *testing.T) {
+		for _, header := range []string{"", "sku,name\n", "sku,name,price,colour\n", "sku,name,price,sku\n"} {
+			_, err := NewReader(CSV, strings.NewReader(header))
+			assert.Error(t, err, header)
+		}
+	})
+}
+
+func TestNDJSONReader(t *testing.T) {
+	file := `{"sku":"A-1","name":"Alpha","price":19.99,"stock":2,"categories":["home"]}` + "\n" +
+		"\n" +
+		`{"sku":"A-2","name":"Beta","price":"5","colour":"red"}` + "\n" +
+		`{"sku":"A-3",` + "\n" +
+		`{"sku":"A-4","name":"Delta","price":"7.50","discount":"0.50"}`
+
+	reader, err := NewReader(NDJSON, strings.NewReader(file))
+	require.NoError(t, err)
+	rows, failed := readAll(t, reader)
+
+	assert.Equal(t, []int{3, 4}, failed)
+	require.Len(t, rows, 2)
+	assert.Equal(t, Row{SKU: "A-1", Name: "Alpha", Price: "19.99", Stock: 2, Categories: []string{"home"}}, rows[0])
+	assert.Equal(t, Row{SKU: "A-4", Name: "Delta", Price: "7.50", Discount: "0.50"}, rows[1])
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	product := &models.Product{
+		SKU:        "LAMP-1",
+		Name:       "Desk Lamp",
+		Slug:       "desk-lamp",
+		Price:      money.New(1999, "EUR"),
+		Discount:   money.New(500, "EUR"),
+		Currency:   "EUR",
+		Stock:      3,
+		Status:     "active",
+		Tags:       []string{"desk", "light"},
+		Weight:     1.25,
+		Categories: []models.Category{{Slug: "lighting"}},
+	}
+	want := RowOf(product)
+	assert.Equal(t, Amount("19.99"), want.Price)
+	assert.Equal(t, Amount("5.00"), want.Discount)
+	assert.Equal(t, []string{"lighting"}, want.Categories)
+
+	for _, format := range []Format{CSV, NDJSON} {
+		var buf bytes.Buffer
+		writer := NewWriter(format, &buf)
+		require.NoError(t, writer.Write(want))
+		require.NoError(t, writer.Flush())
+
+		reader, err := NewReader(format, &buf)
+		require.NoError(t, err, format)
+		rows, failed := readAll(t, reader)
+		assert.Empty(t, failed, format)
+		assert.Equal(t, []Row{want}, rows, format)
+	}
+
+	t.Run("an empty CSV export still has its header", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, NewWriter(CSV, &buf).Flush())
+		assert.Equal(t, strings.Join(Columns, ",")+"\n", buf.String())
+	})
+}
+
+func TestRowProduct(t *testing.T) {
+	t.Run("builds a priced product", func(t *testing.T) {
+		product, err := Row{SKU: " LAMP-1 ", Name: "Desk Lamp", Price: "19.99", Discount: "4.99", Stock: 2}.Product("EUR")
+		require.NoError(t, err)
+
+		assert.Equal(t, "LAMP-1", product.SKU)
+		assert.Equal(t, "desk-lamp", product.Slug)
+		assert.Equal(t, "active", product.Status)
+		assert.Equal(t, "EUR", product.Currency)
+		assert.Equal(t, money.New(1999, "EUR"), product.Price)
+		assert.Equal(t, money.New(1500, "EUR"), product.FinalPrice)
+	})
+
+	t.Run("prices in the row's currency", func(t *testing.T) {
+		product, err := Row{SKU: "A", Name: "Abacus", Price: "1500", Currency: "jpy"}.Product("EUR")
+		require.NoError(t, err)
+		assert.Equal(t, money.New(1500, "JPY"), product.Price)
+	})
+
+	for name, row := range map[string]Row{
+		"no sku":            {Name: "Abacus", Price: "1"},
+		"short name":        {SKU: "A", Name: "Ab", Price: "1"},
+		"no price":          {SKU: "A", Name: "Abacus"},
+		"zero price":        {SKU: "A", Name: "Abacus", Price: "0"},
+		"too many decimals": {SKU: "A", Name: "Abacus", Price: "1.999"},
+		"big discount":      {SKU: "A", Name: "Abacus", Price: "1", Discount: "2"},
+		"negative stock":    {SKU: "A", Name: "Abacus", Price: "1", Stock: -1},
+		"bad currency":      {SKU: "A", Name: "Abacus", Price: "1", Currency: "EURO"},
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			_, err := row.Product("EUR")
+			assert.Error(t, err)
+		})
+	}
+}
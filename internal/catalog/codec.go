@@ -0,0 +1,317 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Columns are the CSV columns in the order they are exported. Lists, i.e.
+// images, tags and categories, are separated by ListSeparator within a cell.
+var Columns = []string{
+	"sku", "name", "slug", "description", "price", "discount", "currency", "stock", "status", "brand",
+	"image", "images", "tags", "categories", "weight", "dimensions", "meta_title", "meta_description",
+}
+
+// requiredColumns must be in the header of a CSV import
+var requiredColumns = []string{"sku", "name", "price"}
+
+// ListSeparator separates the values of a list in a CSV cell
+const ListSeparator = "|"
+
+// maxLineBytes is the longest NDJSON line read
+const maxLineBytes = 1 << 20
+
+// RowError reports a row that could not be decoded. Reading may go on after it.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Reader streams rows from a file
+type Reader interface {
+	// Read returns the next row and the line it starts on, or io.EOF after the
+	// last row. A *RowError reports a row that could not be decoded; any other
+	// error ends the file.
+	Read() (Row, int, error)
+}
+
+// NewReader returns a reader of rows in the format. A CSV file starts with a
+// header naming its columns, which it checks.
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r)
+	case NDJSON:
+		return &ndjsonReader{lines: bufio.NewReader(r)}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type csvReader struct {
+	csv     *csv.Reader
+	columns []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the file is empty; it needs a header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		// Spreadsheets may start the file with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(Columns, name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if slices.Contains(columns[:i], name) {
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		columns[i] = name
+	}
+	for _, name := range requiredColumns {
+		if !slices.Contains(columns, name) {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	return &csvReader{csv: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Read() (Row, int, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Row{}, parseErr.StartLine, &RowError{Line: parseErr.StartLine, Err: parseErr.Err}
+		}
+		return Row{}, 0, err
+	}
+
+	line, _ := r.csv.FieldPos(0)
+	if len(record) != len(r.columns) {
+		return Row{}, line, &RowError{Line: line, Err: fmt.Errorf("has %d fields, the header %d", len(record), len(r.columns))}
+	}
+
+	var row Row
+	for i, value := range record {
+		if err := setColumn(&row, r.columns[i], value); err != nil {
+			return Row{}, line, &RowError{Line: line, Err: err}
+		}
+	}
+	return row, line, nil
+}
+
+// setColumn sets the field of a row held in a CSV column
+func setColumn(row *Row, column, value string) error {
+	value = strings.TrimSpace(value)
+	switch column {
+	case "sku":
+		row.SKU = value
+	case "name":
+		row.Name = value
+	case "slug":
+		row.Slug = value
+	case "description":
+		row.Description = value
+	case "price":
+		row.Price = Amount(value)
+	case "discount":
+		row.Discount = Amount(value)
+	case "currency":
+		row.Currency = value
+	case "stock":
+		if value == "" {
+			return nil
+		}
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("stock %q is not a whole number", value)
+		}
+		row.Stock = stock
+	case "status":
+		row.Status = value
+	case "brand":
+		row.Brand = value
+	case "image":
+		row.Image = value
+	case "images":
+		row.Images = splitList(value)
+	case "tags":
+		row.Tags = splitList(value)
+	case "categories":
+		row.Categories = splitList(value)
+	case "weight":
+		if value == "" {
+			return nil
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("weight %q is not a number", value)
+		}
+		row.Weight = weight
+	case "dimensions":
+		row.Dimensions = value
+	case "meta_title":
+		row.MetaTitle = value
+	case "meta_description":
+		row.MetaDescription = value
+	}
+	return nil
+}
+
+// splitList reads the values of a list cell, skipping empty ones
+func splitList(cell string) []string {
+	var values []string
+	for _, value := range strings.Split(cell, ListSeparator) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+type ndjsonReader struct {
+	lines *bufio.Reader
+	line  int
+}
+
+func (r *ndjsonReader) Read() (Row, int, error) {
+	for {
+		data, err := r.readLine()
+		if err != nil {
+			return Row{}, r.line, err
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		var row Row
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			return Row{}, r.line, &RowError{Line: r.line, Err: err}
+		}
+		return row, r.line, nil
+	}
+}
+
+// readLine reads the next line, failing the row of a line too long to hold
+func (r *ndjsonReader) readLine() ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := r.lines.ReadSlice('\n')
+		if len(line)+len(chunk) > maxLineBytes {
+			tooLong = true
+			line = line[:0]
+		} else {
+			line = append(line, chunk...)
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil && !(errors.Is(err, io.EOF) && (len(line) > 0 || tooLong)) {
+			return nil, err
+		}
+		r.line++
+		if tooLong {
+			return nil, &RowError{Line: r.line, Err: fmt.Errorf("line is longer than %d bytes", maxLineBytes)}
+		}
+		return line, nil
+	}
+}
+
+// Writer streams rows to a file
+type Writer interface {
+	Write(row Row) error
+	// Flush writes any buffered rows; a CSV file gets its header even without rows
+	Flush() error
+}
+
+// NewWriter returns a writer of rows in the format
+func NewWriter(format Format, w io.Writer) Writer {
+	if format == NDJSON {
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}
+	}
+	return &csvWriter{csv: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	csv         *csv.Writer
+	wroteHeader bool
+}
+
+func (w *csvWriter) Write(row Row) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.csv.Write([]string{
+		row.SKU, row.Name, row.Slug, row.Description, string(row.Price), string(row.Discount), row.Currency,
+		strconv.Itoa(row.Stock), row.Status, row.Brand, row.Image,
+		strings.Join(row.Images, ListSeparator), strings.Join(row.Tags, ListSeparator), strings.Join(row.Categories, ListSeparator),
+		formatWeight(row.Weight), row.Dimensions, row.MetaTitle, row.MetaDescription,
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.csv.Write(Columns)
+}
+
+// formatWeight leaves a zero weight empty
+func formatWeight(weight float64) string {
+	if weight == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(weight, 'f', -1, 64)
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(row Row) error {
+	return w.encoder.Encode(row)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return nil
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // GetEnvString retrieves a string environment variable or returns a default value
@@ -32,3 +34,29 @@ func GetEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// GetEnvDuration retrieves a duration environment variable (e.g. "15m") or returns a default value
+func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// GetEnvList retrieves a list environment variable split on sep, or returns a default value
+func GetEnvList(key, sep string, defaultValue []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -23,6 +23,31 @@ const (
 	DEFAULT_GZIP_LEVEL               int    = 5
 	DEFAULT_RATE_LIMIT               int    = 20
 	DEFAULT_RATE_BURST               int    = 50
+	DEFAULT_COMMENT_MAX_DEPTH        int    = 5
+	DEFAULT_COMMENT_EDIT_WINDOW      int    = 900 // seconds
+	DEFAULT_COMMENT_RATE_LIMIT       int    = 5   // comments per minute per user
+	DEFAULT_COMMENT_REPORT_THRESHOLD int    = 3
+	DEFAULT_PAYMENT_PROVIDER         string = "fake"
+	DEFAULT_REFUND_RETRY_INTERVAL    int    = 60 // seconds
+	DEFAULT_CURRENCY                 string = "USD"
+	DEFAULT_TAX_RATE                 string = "0"     // percent
+	DEFAULT_STOCK_RESERVATION_TTL    int    = 900     // seconds
+	DEFAULT_RESERVATION_SWEEP        int    = 60      // seconds
+	DEFAULT_GUEST_BASKET_TTL         int    = 2592000 // seconds (30 days)
+	DEFAULT_GUEST_BASKET_SWEEP       int    = 3600    // seconds
+	DEFAULT_REVIEWS_VERIFIED_ONLY    bool   = false
+	DEFAULT_PRODUCT_IMPORT_MAX_SIZE  int    = 52428800 // bytes (50 MiB)
+	DEFAULT_STORAGE_DRIVER           string = "local"
+	DEFAULT_STORAGE_LOCAL_DIR        string = "./uploads"
+	DEFAULT_MEDIA_MAX_SIZE           int    = 10485760 // bytes (10 MiB)
+	DEFAULT_MEDIA_URL_TTL            int    = 900      // seconds
+	DEFAULT_IMAGE_SIZES              string = "thumbnail:150,medium:600,large:1200"
+	DEFAULT_IMAGE_FORMAT             string = "auto"
+	DEFAULT_VIEW_WINDOW              int    = 1800   // seconds
+	DEFAULT_VIEW_FLUSH               int    = 30     // seconds
+	DEFAULT_TRENDING_HALF_LIFE       int    = 172800 // seconds (2 days)
+	DEFAULT_TRENDING_SALE_WEIGHT     int    = 10     // views a sale counts as
+	DEFAULT_PRICE_SCHEDULE_INTERVAL  int    = 60     // seconds
 
 	// features
 	FEATURE_SERVICE    string = "service"
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
@@ -25,7 +26,8 @@ func Connect(dbUrl string) (*gorm.DB, error) {
 	maxRetries := 5
 	for i := 0; i < maxRetries; i++ {
 		db, err = gorm.Open(dialector, &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
+			Logger:         logger.Default.LogMode(logger.Info),
+			TranslateError: true,
 		})
 		if err == nil {
 			break
@@ -59,16 +61,51 @@ func Connect(dbUrl string) (*gorm.DB, error) {
 // Migrate performs auto-migration of database schemas
 func Migrate(db *gorm.DB) error {
 	log.Println("Running database migrations...")
+	if err := migrateMoneyColumns(db); err != nil {
+		return fmt.Errorf("database migration failed: %w", err)
+	}
+	if err := dropReplacedIndexes(db); err != nil {
+		return fmt.Errorf("database migration failed: %w", err)
+	}
+	if err := createSearchFunctions(db); err != nil {
+		return fmt.Errorf("database migration failed: %w", err)
+	}
+
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.Event{},
 		&models.Attendee{},
 		&models.Category{},
 		&models.Comment{},
+		&models.CommentRevision{},
+		&models.CommentReport{},
+		&models.CommentMention{},
+		&models.Notification{},
+		&models.NotificationMute{},
 		&models.Profile{},
 		&models.Product{},
+		&models.ProductOption{},
+		&models.ProductVariant{},
+		&models.ProductView{},
+		&models.ProductPriceChange{},
+		&models.ProductPriceSchedule{},
+		&models.InventoryAdjustment{},
+		&models.Coupon{},
 		&models.BasketItem{},
 		&models.Basket{},
+		&models.StockReservation{},
+		&models.Wishlist{},
+		&models.WishlistItem{},
+		&models.Review{},
+		&models.ProductImport{},
+		&models.Media{},
+		&models.MediaVariant{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderStatusChange{},
+		&models.Payment{},
+		&models.PaymentEvent{},
+		&models.CouponRedemption{},
 	)
 	if err != nil {
 		return fmt.Errorf("database migration failed: %w", err)
@@ -76,3 +113,100 @@ func Migrate(db *gorm.DB) error {
 	log.Println("Database migration completed successfully")
 	return nil
 }
+
+// moneyColumns lists the price columns that used to hold float amounts in major units
+var moneyColumns = []struct {
+	Model  any
+	Table  string
+	Column string
+}{
+	{&models.Product{}, "products", "price"},
+	{&models.Product{}, "products", "discount"},
+	{&models.Product{}, "products", "final_price"},
+	{&models.BasketItem{}, "basket_items", "unit_price"},
+	{&models.Order{}, "orders", "total"},
+	{&models.OrderItem{}, "order_items", "unit_price"},
+	{&models.OrderItem{}, "order_items", "subtotal"},
+	{&models.Payment{}, "payments", "amount"},
+}
+
+// migrateMoneyColumns converts float price columns to bigint minor units.
+// Existing amounts are taken to be in a currency with two decimals, which the
+// currency columns added by AutoMigrate default to.
+func migrateMoneyColumns(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	for _, mc := range moneyColumns {
+		if !migrator.HasTable(mc.Table) || !migrator.HasColumn(mc.Model, mc.Column) {
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(mc.Model)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", mc.Table, err)
+		}
+		for _, columnType := range columnTypes {
+			if columnType.Name() != mc.Column {
+				continue
+			}
+			switch strings.ToLower(columnType.DatabaseTypeName()) {
+			case "float4", "float8", "real", "double precision", "numeric":
+				log.Printf("Converting %s.%s to minor units...", mc.Table, mc.Column)
+				sql := fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE bigint USING ROUND(%q * 100)::bigint`, mc.Table, mc.Column, mc.Column)
+				if err := db.Exec(sql).Error; err != nil {
+					return fmt.Errorf("failed to convert %s.%s: %w", mc.Table, mc.Column, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// replacedIndexes lists indexes superseded by an index under another name,
+// which AutoMigrate creates but does not drop the old one for
+var replacedIndexes = []struct {
+	Model any
+	Table string
+	Index string
+}{
+	// Reservations became unique per variant rather than per product
+	{&models.StockReservation{}, "stock_reservations", "idx_reservation_basket_product"},
+	// Category names and slugs became unique among the categories not deleted
+	{&models.Category{}, "categories", "idx_categories_name"},
+	{&models.Category{}, "categories", "idx_categories_slug"},
+}
+
+// dropReplacedIndexes drops the indexes in replacedIndexes that still exist
+func dropReplacedIndexes(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	for _, ri := range replacedIndexes {
+		if !migrator.HasTable(ri.Table) || !migrator.HasIndex(ri.Model, ri.Index) {
+			continue
+		}
+		log.Printf("Dropping replaced index %s on %s...", ri.Index, ri.Table)
+		if err := migrator.DropIndex(ri.Model, ri.Index); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", ri.Index, err)
+		}
+	}
+	return nil
+}
+
+// searchFunctions are the functions generated search columns use. Generated
+// columns only take immutable expressions, and array_to_string is merely
+// stable as it handles arrays of any type; for text arrays it is immutable.
+var searchFunctions = []string{
+	`CREATE OR REPLACE FUNCTION immutable_array_to_string(elements text[], separator text) RETURNS text
+		LANGUAGE sql IMMUTABLE PARALLEL SAFE
+		AS 'SELECT array_to_string(elements, separator)'`,
+}
+
+// createSearchFunctions creates or replaces the functions in searchFunctions
+func createSearchFunctions(db *gorm.DB) error {
+	for _, sql := range searchFunctions {
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create search function: %w", err)
+		}
+	}
+	return nil
+}
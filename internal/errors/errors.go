@@ -16,6 +16,7 @@ var (
 	ErrInternalServer    = errors.New("internal server error")
 	ErrDatabaseOperation = errors.New("database operation failed")
 	ErrValidation        = errors.New("validation failed")
+	ErrConflict          = errors.New("conflict")
 )
 
 // AppError represents a custom application error
@@ -65,7 +66,7 @@ func getStatusCode(errType error) int {
 	switch errType {
 	case ErrNotFound:
 		return http.StatusNotFound
-	case ErrAlreadyExists:
+	case ErrAlreadyExists, ErrConflict:
 		return http.StatusConflict
 	case ErrInvalidInput, ErrValidation:
 		return http.StatusBadRequest
@@ -0,0 +1,117 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// jpegOrientation reads the EXIF orientation of a JPEG image, 1 to 8, from
+// its APP1 segment. Images without one are upright, i.e. 1.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xff {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xd8 || marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			pos += 2
+			continue
+		}
+		// Metadata segments come before the image data
+		if marker == 0xda || marker == 0xd9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xe1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation reads the orientation tag of the first IFD of TIFF data
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		// The orientation is a SHORT held in the entry itself
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orient turns an image with an EXIF orientation upright
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	// Orientations 5 to 8 swap the width and height
+	outWidth, outHeight := width, height
+	if orientation >= 5 {
+		outWidth, outHeight = height, width
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var ox, oy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				ox, oy = width-1-x, y
+			case 3: // rotated 180°
+				ox, oy = width-1-x, height-1-y
+			case 4: // mirrored vertically
+				ox, oy = x, height-1-y
+			case 5: // mirrored horizontally and rotated 270° clockwise
+				ox, oy = y, x
+			case 6: // rotated 90° clockwise
+				ox, oy = height-1-y, x
+			case 7: // mirrored horizontally and rotated 90° clockwise
+				ox, oy = height-1-y, width-1-x
+			case 8: // rotated 270° clockwise
+				ox, oy = y, width-1-x
+			}
+			src := img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+			dst := out.PixOffset(ox, oy)
+			copy(out.Pix[dst:dst+4], img.Pix[src:src+4])
+		}
+	}
+	return out
+}
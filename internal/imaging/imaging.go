@@ -0,0 +1,175 @@
+// Package imaging makes resized variants of uploaded images, e.g. thumbnails.
+// Variants are re-encoded from the decoded pixels, so they carry no EXIF or
+// other metadata of the original.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // decodes GIF uploads
+	"image/jpeg"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decodes WebP uploads
+)
+
+// Output formats of variants
+const (
+	// FormatAuto encodes opaque images as JPEG and the others as PNG
+	FormatAuto = "auto"
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// MaxPixels is the largest image, in pixels, that is decoded. It keeps
+// small files of huge dimensions from exhausting memory.
+const MaxPixels = 50_000_000
+
+// jpegQuality is the quality JPEG variants are encoded with
+const jpegQuality = 85
+
+// ErrTooLarge is returned for images of more than MaxPixels pixels
+var ErrTooLarge = errors.New("image is too large")
+
+var sizeNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// Size is a configured variant size. Variants fit in a square of Max pixels,
+// keeping the aspect ratio; smaller images are not enlarged.
+type Size struct {
+	Name string
+	Max  int
+}
+
+// ParseSizes parses sizes given as "name:max" pairs separated by commas,
+// e.g. "thumbnail:150,medium:600,large:1200"
+func ParseSizes(s string) ([]Size, error) {
+	var sizes []Size
+	seen := map[string]bool{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("image size %q must be given as name:max", pair)
+		}
+		name = strings.TrimSpace(name)
+		if !sizeNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid image size name %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("image size %q is given twice", name)
+		}
+		bound, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || bound <= 0 || bound > maxWebPDimension {
+			return nil, fmt.Errorf("invalid maximum %q of image size %q", value, name)
+		}
+		seen[name] = true
+		sizes = append(sizes, Size{Name: name, Max: bound})
+	}
+	return sizes, nil
+}
+
+// CheckFormat rejects unknown output formats
+func CheckFormat(format string) error {
+	switch format {
+	case FormatAuto, FormatJPEG, FormatPNG, FormatWebP:
+		return nil
+	}
+	return fmt.Errorf("unknown image format %q", format)
+}
+
+// ContentType returns the content type of an output format
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Extension returns the file extension of an output format
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// Source is a decoded image variants are made of
+type Source struct {
+	img image.Image
+	// orientation is the EXIF orientation of a JPEG image, 1 when upright
+	orientation int
+}
+
+// Decode decodes a JPEG, PNG, GIF or WebP image. Only the first frame of
+// animated images is kept.
+func Decode(data []byte) (*Source, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	source := &Source{img: img, orientation: 1}
+	if format == "jpeg" {
+		source.orientation = jpegOrientation(data)
+	}
+	return source, nil
+}
+
+// Resize returns the image fit in a square of bound pixels and turned upright
+func (s *Source) Resize(bound int) image.Image {
+	bounds := s.img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if longest := max(width, height); longest > bound {
+		width = scaleDimension(width, bound, longest)
+		height = scaleDimension(height, bound, longest)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), s.img, bounds, draw.Src, nil)
+	return orient(dst, s.orientation)
+}
+
+// scaleDimension scales a dimension by bound/longest, rounding to at least a pixel
+func scaleDimension(dimension, bound, longest int) int {
+	return max(1, (dimension*bound+longest/2)/longest)
+}
+
+// OutputFormat resolves the configured output format for an image
+func OutputFormat(format string, img image.Image) string {
+	if format != FormatAuto {
+		return format
+	}
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return FormatJPEG
+	}
+	return FormatPNG
+}
+
+// Encode encodes an image in an output format other than FormatAuto
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatPNG:
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		return encoder.Encode(w, img)
+	case FormatWebP:
+		return encodeWebP(w, img)
+	}
+	return fmt.Errorf("unknown image format %q", format)
+}
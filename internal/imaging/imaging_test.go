@@ -0,0 +1,185 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := ParseSizes("thumbnail:150, medium:600,large:1200")
+	require.NoError(t, err)
+	assert.Equal(t, []Size{{"thumbnail", 150}, {"medium", 600}, {"large", 1200}}, sizes)
+
+	for _, s := range []string{"thumbnail", "thumbnail:0", "thumbnail:x", "Thumb:10", "a:10,a:20", "huge:20000"} {
+		_, err := ParseSizes(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestResize(t *testing.T) {
+	source := &Source{img: image.NewRGBA(image.Rect(0, 0, 800, 400)), orientation: 1}
+	assert.Equal(t, image.Rect(0, 0, 150, 75), source.Resize(150).Bounds())
+	// Smaller images are not enlarged
+	assert.Equal(t, image.Rect(0, 0, 800, 400), source.Resize(1200).Bounds())
+
+	// Sideways photos are turned upright
+	source.orientation = 6
+	assert.Equal(t, image.Rect(0, 0, 75, 150), source.Resize(150).Bounds())
+}
+
+func TestDecodeStripsAndAppliesOrientation(t *testing.T) {
+	// A 4x2 image, red on the left half and blue on the right
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 100}))
+	data := withOrientation(encoded.Bytes(), 6)
+	assert.Equal(t, 6, jpegOrientation(data))
+
+	source, err := Decode(data)
+	require.NoError(t, err)
+	resized := source.Resize(100)
+	assert.Equal(t, image.Rect(0, 0, 2, 4), resized.Bounds())
+	// Turned 90° clockwise, the left half is on top
+	r, _, b, _ := resized.At(0, 0).RGBA()
+	assert.Greater(t, r, b)
+	r, _, b, _ = resized.At(0, 3).RGBA()
+	assert.Greater(t, b, r)
+
+	var out bytes.Buffer
+	require.NoError(t, Encode(&out, resized, FormatJPEG))
+	assert.NotContains(t, out.String(), "Exif")
+	assert.Equal(t, 1, jpegOrientation(out.Bytes()))
+}
+
+func TestDecodeRejectsHugeImages(t *testing.T) {
+	// Only the header of the PNG is read before the image is rejected
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 1, 1))))
+	data := encoded.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := Decode(data)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestOutputFormat(t *testing.T) {
+	opaque := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	opaque.Set(0, 0, color.RGBA{R: 10, A: 255})
+	transparent := image.NewRGBA(image.Rect(0, 0, 1, 1))
+
+	assert.Equal(t, FormatJPEG, OutputFormat(FormatAuto, opaque))
+	assert.Equal(t, FormatPNG, OutputFormat(FormatAuto, transparent))
+	assert.Equal(t, FormatWebP, OutputFormat(FormatWebP, opaque))
+	assert.Equal(t, ".jpg", Extension(FormatJPEG))
+	assert.Equal(t, "image/webp", ContentType(FormatWebP))
+}
+
+func TestEncodeWebP(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+	random.Read(noise.Pix)
+	gradient := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			gradient.Set(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: 128, A: 255})
+		}
+	}
+	flat := image.NewNRGBA(image.Rect(0, 0, 5, 3))
+	for i := range flat.Pix {
+		flat.Pix[i] = 200
+	}
+
+	for name, img := range map[string]*image.NRGBA{"noise": noise, "gradient": gradient, "flat": flat} {
+		t.Run(name, func(t *testing.T) {
+			var encoded bytes.Buffer
+			require.NoError(t, Encode(&encoded, img, FormatWebP))
+
+			decoded, err := webp.Decode(bytes.NewReader(encoded.Bytes()))
+			require.NoError(t, err)
+			require.Equal(t, img.Bounds(), decoded.Bounds())
+			for y := 0; y < img.Rect.Dy(); y++ {
+				for x := 0; x < img.Rect.Dx(); x++ {
+					want := img.NRGBAAt(x, y)
+					got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					if want.A == 0 {
+						// Fully transparent pixels have no color
+						got.R, got.G, got.B, want.R, want.G, want.B = 0, 0, 0, 0, 0, 0
+					}
+					require.Equal(t, want, got, "pixel %d,%d", x, y)
+				}
+			}
+		})
+	}
+
+	// Pixels of few colors take few bits
+	background := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	for i := range background.Pix {
+		background.Pix[i] = uint8(i % 4 * 60)
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, Encode(&encoded, background, FormatWebP))
+	assert.Less(t, encoded.Len(), 100)
+}
+
+func TestHuffmanLengthsAreLimited(t *testing.T) {
+	// Fibonacci frequencies make the deepest unlimited Huffman trees
+	freqs := make([]int, 30)
+	a, b := 1, 1
+	for i := range freqs {
+		freqs[i] = a
+		a, b = b, a+b
+	}
+	lengths := huffmanLengths(freqs, maxCodeLength)
+
+	kraft := 0.0
+	for _, length := range lengths {
+		require.LessOrEqual(t, length, maxCodeLength)
+		require.Positive(t, length)
+		kraft += 1 / float64(uint(1)<<length)
+	}
+	assert.InDelta(t, 1.0, kraft, 1e-9)
+}
+
+// withOrientation inserts an EXIF segment with an orientation after the
+// start of a JPEG image
+func withOrientation(data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(42))
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(8))
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(1))
+	_ = binary.Write(&tiff, binary.BigEndian, [4]uint16{0x0112, 3, 0, 1})
+	_ = binary.Write(&tiff, binary.BigEndian, [2]uint16{orientation, 0})
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xff, 0xe1})
+	_ = binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(data[2:])
+	return out.Bytes()
+}
@@ -0,0 +1,325 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+// The standard library and golang.org/x/image only decode WebP, so variants
+// are encoded here in the simplest form of the lossless format (VP8L): the
+// subtract-green transform and a Huffman code per channel, without
+// backward references or color caches. It trades some size for not needing
+// cgo and libwebp.
+
+// maxWebPDimension is the largest width or height of a lossless WebP image
+const maxWebPDimension = 1 << 14
+
+const (
+	vp8lSignature = 0x2f
+	// subtractGreenTransform is the VP8L transform type subtracting the green
+	// channel from the red and blue ones
+	subtractGreenTransform = 2
+	// greenAlphabetSize counts the literal green values and the 24 length
+	// prefix codes of the green channel
+	greenAlphabetSize = 256 + 24
+	// maxCodeLength is the longest Huffman code VP8L allows
+	maxCodeLength = 15
+	// maxCodeLengthCodeLength is the longest code of a code length
+	maxCodeLengthCodeLength = 7
+)
+
+// codeLengthCodeOrder is the order code lengths of code lengths are written in
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP encodes an image as a lossless WebP file
+func encodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxWebPDimension || height > maxWebPDimension {
+		return fmt.Errorf("cannot encode a %dx%d image as WebP", width, height)
+	}
+
+	// Pixels as green, red - green, blue - green and alpha symbols
+	pixels := make([][4]uint8, 0, width*height)
+	var histograms [4][]int
+	histograms[0] = make([]int, greenAlphabetSize)
+	for i := 1; i < 4; i++ {
+		histograms[i] = make([]int, 256)
+	}
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pixel := [4]uint8{c.G, c.R - c.G, c.B - c.G, c.A}
+			for i, symbol := range pixel {
+				histograms[i][symbol]++
+			}
+			opaque = opaque && c.A == 0xff
+			pixels = append(pixels, pixel)
+		}
+	}
+
+	var bw bitWriter
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if opaque {
+		bw.write(0, 1)
+	} else {
+		bw.write(1, 1)
+	}
+	bw.write(0, 3) // version
+
+	bw.write(1, 1) // a transform follows
+	bw.write(subtractGreenTransform, 2)
+	bw.write(0, 1) // no more transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // a single set of prefix codes
+
+	var codes [4]prefixCode
+	for i, histogram := range histograms {
+		codes[i] = newPrefixCode(histogram, maxCodeLength)
+		codes[i].writeTo(&bw)
+	}
+	// No backward references, so the distance code is a single unused symbol
+	bw.write(1, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+
+	for _, pixel := range pixels {
+		for i, symbol := range pixel {
+			codes[i].writeSymbol(&bw, int(symbol))
+		}
+	}
+	data := bw.flush()
+
+	var header bytes.Buffer
+	chunkSize := len(data)
+	padding := chunkSize % 2
+	header.WriteString("RIFF")
+	_ = binary.Write(&header, binary.LittleEndian, uint32(4+8+chunkSize+padding))
+	header.WriteString("WEBPVP8L")
+	_ = binary.Write(&header, binary.LittleEndian, uint32(chunkSize))
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// bitWriter packs bits least significant first, as VP8L reads them
+type bitWriter struct {
+	buf   []byte
+	bits  uint64
+	nBits uint
+}
+
+func (b *bitWriter) write(value uint32, n uint) {
+	b.bits |= uint64(value) << b.nBits
+	b.nBits += n
+	for b.nBits >= 8 {
+		b.buf = append(b.buf, byte(b.bits))
+		b.bits >>= 8
+		b.nBits -= 8
+	}
+}
+
+func (b *bitWriter) flush() []byte {
+	if b.nBits > 0 {
+		b.buf = append(b.buf, byte(b.bits))
+		b.bits, b.nBits = 0, 0
+	}
+	return b.buf
+}
+
+// prefixCode is a canonical Huffman code of an alphabet
+type prefixCode struct {
+	lengths []int
+	// codes hold the bits of each symbol's code reversed, as they are written
+	codes []uint32
+	// single is the only symbol of codes with one symbol, which take no bits
+	single int
+}
+
+// newPrefixCode builds a Huffman code for symbol frequencies, with codes of
+// at most limit bits
+func newPrefixCode(histogram []int, limit int) prefixCode {
+	code := prefixCode{lengths: huffmanLengths(histogram, limit), single: -1}
+	used := 0
+	for symbol, length := range code.lengths {
+		if length > 0 {
+			used++
+			code.single = symbol
+		}
+	}
+	if used > 1 {
+		code.single = -1
+	}
+	code.codes = canonicalCodes(code.lengths)
+	return code
+}
+
+// writeTo writes the code lengths of the code
+func (p prefixCode) writeTo(bw *bitWriter) {
+	if p.single >= 0 || p.isEmpty() {
+		symbol := max(p.single, 0)
+		bw.write(1, 1) // a simple code
+		bw.write(0, 1) // of one symbol
+		if symbol < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(symbol), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(symbol), 8)
+		}
+		return
+	}
+
+	histogram := make([]int, len(codeLengthCodeOrder))
+	for _, length := range p.lengths {
+		histogram[length]++
+	}
+	lengthCode := newPrefixCode(histogram, maxCodeLengthCodeLength)
+
+	count := len(codeLengthCodeOrder)
+	for count > 4 && lengthCode.lengths[codeLengthCodeOrder[count-1]] == 0 {
+		count--
+	}
+	bw.write(0, 1) // a normal code
+	bw.write(uint32(count-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:count] {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bw.write(0, 1) // every code length is written
+	for _, length := range p.lengths {
+		lengthCode.writeSymbol(bw, length)
+	}
+}
+
+func (p prefixCode) writeSymbol(bw *bitWriter, symbol int) {
+	if p.single >= 0 {
+		return
+	}
+	bw.write(p.codes[symbol], uint(p.lengths[symbol]))
+}
+
+func (p prefixCode) isEmpty() bool {
+	for _, length := range p.lengths {
+		if length > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// huffmanLengths returns the code lengths of a Huffman code for symbol
+// frequencies. Frequencies are flattened until no code is longer than limit.
+func huffmanLengths(histogram []int, limit int) []int {
+	freqs := append([]int(nil), histogram...)
+	for {
+		lengths, longest := huffmanTree(freqs)
+		if longest <= limit {
+			return lengths
+		}
+		for i, freq := range freqs {
+			if freq > 0 {
+				freqs[i] = (freq + 1) / 2
+			}
+		}
+	}
+}
+
+// huffmanTree returns the code lengths of an optimal prefix code, and the
+// longest of them. A lone symbol gets a code of length 1.
+func huffmanTree(freqs []int) ([]int, int) {
+	type node struct {
+		freq        int
+		symbol      int
+		left, right int
+	}
+	var nodes []node
+	for symbol, freq := range freqs {
+		if freq > 0 {
+			nodes = append(nodes, node{freq: freq, symbol: symbol, left: -1, right: -1})
+		}
+	}
+	lengths := make([]int, len(freqs))
+	switch len(nodes) {
+	case 0:
+		return lengths, 0
+	case 1:
+		lengths[nodes[0].symbol] = 1
+		return lengths, 1
+	}
+
+	// Leaves sorted by frequency, and the merged nodes, which are made in
+	// order of frequency, form the two queues of the linear-time algorithm
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].freq < nodes[j].freq })
+	leaves := len(nodes)
+	nextLeaf, nextMerged := 0, leaves
+	take := func() int {
+		if nextLeaf < leaves && (nextMerged >= len(nodes) || nodes[nextLeaf].freq <= nodes[nextMerged].freq) {
+			nextLeaf++
+			return nextLeaf - 1
+		}
+		nextMerged++
+		return nextMerged - 1
+	}
+	for merged := 0; merged < leaves-1; merged++ {
+		left, right := take(), take()
+		nodes = append(nodes, node{freq: nodes[left].freq + nodes[right].freq, symbol: -1, left: left, right: right})
+	}
+
+	longest := 0
+	depths := make([]int, len(nodes))
+	for i := len(nodes) - 1; i >= leaves; i-- {
+		depths[nodes[i].left] = depths[i] + 1
+		depths[nodes[i].right] = depths[i] + 1
+	}
+	for i := 0; i < leaves; i++ {
+		lengths[nodes[i].symbol] = depths[i]
+		longest = max(longest, depths[i])
+	}
+	return lengths, longest
+}
+
+// canonicalCodes assigns canonical codes to code lengths, with their bits
+// reversed as VP8L reads codes from their most significant bit
+func canonicalCodes(lengths []int) []uint32 {
+	var counts [maxCodeLength + 1]uint32
+	for _, length := range lengths {
+		counts[length]++
+	}
+	counts[0] = 0
+	var next [maxCodeLength + 2]uint32
+	for length := 1; length <= maxCodeLength; length++ {
+		next[length+1] = (next[length] + counts[length]) << 1
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		code := next[length]
+		next[length]++
+		var reversed uint32
+		for i := 0; i < length; i++ {
+			reversed = reversed<<1 | (code>>i)&1
+		}
+		codes[symbol] = reversed
+	}
+	return codes
+}
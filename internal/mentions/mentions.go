@@ -0,0 +1,129 @@
+package mentions
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+// handlePattern matches an @email or an @name
+var handlePattern = regexp.MustCompile(`@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}|[\p{L}\p{N}_.\-]+)`)
+
+// Candidate is an unresolved @mention found in content
+type Candidate struct {
+	Handle  string
+	IsEmail bool
+	// Start and End are character offsets of the mention, including the @
+	Start int
+	End   int
+}
+
+// Parse finds @name and @email mentions in content. A mention must start the
+// content or follow a character that is not part of a word, so e-mail addresses
+// written without a leading @ are not treated as mentions.
+func Parse(content string) []Candidate {
+	var candidates []Candidate
+
+	for _, loc := range handlePattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(content[:start])
+			if unicode.IsLetter(prev) || unicode.IsDigit(prev) || prev == '_' || prev == '.' {
+				continue
+			}
+		}
+
+		handle := content[loc[2]:loc[3]]
+		isEmail := strings.Contains(handle, "@")
+		if !isEmail {
+			// Trailing punctuation ends a sentence rather than a name
+			trimmed := strings.TrimRight(handle, ".-")
+			end -= len(handle) - len(trimmed)
+			handle = trimmed
+		}
+		if handle == "" {
+			continue
+		}
+
+		candidates = append(candidates, Candidate{
+			Handle:  handle,
+			IsEmail: isEmail,
+			Start:   utf8.RuneCountInString(content[:start]),
+			End:     utf8.RuneCountInString(content[:end]),
+		})
+	}
+
+	return candidates
+}
+
+// Handles splits the distinct candidate handles into lower-cased names and emails
+func Handles(candidates []Candidate) (names []string, emails []string) {
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		handle := strings.ToLower(c.Handle)
+		if seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		if c.IsEmail {
+			emails = append(emails, handle)
+		} else {
+			names = append(names, handle)
+		}
+	}
+	return names, emails
+}
+
+// Resolve turns candidates into mention spans for the given users. Emails match
+// exactly; names match case-insensitively and are skipped when several users share them.
+func Resolve(candidates []Candidate, users []*models.User) []models.CommentMention {
+	byEmail := make(map[string]*models.User)
+	byName := make(map[string]*models.User)
+	ambiguous := make(map[string]bool)
+	for _, user := range users {
+		byEmail[strings.ToLower(user.Email)] = user
+		name := strings.ToLower(user.Name)
+		if _, exists := byName[name]; exists {
+			ambiguous[name] = true
+		}
+		byName[name] = user
+	}
+
+	var spans []models.CommentMention
+	for _, c := range candidates {
+		handle := strings.ToLower(c.Handle)
+		user := byEmail[handle]
+		if !c.IsEmail {
+			user = nil
+			if !ambiguous[handle] {
+				user = byName[handle]
+			}
+		}
+		if user == nil {
+			continue
+		}
+		spans = append(spans, models.CommentMention{
+			UserID: user.ID,
+			Start:  c.Start,
+			End:    c.End,
+			Text:   "@" + c.Handle,
+		})
+	}
+	return spans
+}
+
+// UserIDs returns the distinct mentioned user IDs in order of first mention
+func UserIDs(spans []models.CommentMention) []int {
+	seen := make(map[int]bool)
+	var ids []int
+	for _, span := range spans {
+		if !seen[span.UserID] {
+			seen[span.UserID] = true
+			ids = append(ids, span.UserID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,51 @@
+package mentions
+
+import (
+	"testing"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	candidates := Parse("Thanks @alice and @Bob.Smith@example.com, ping bob@example.com or @carol.")
+
+	if assert.Len(t, candidates, 3) {
+		assert.Equal(t, Candidate{Handle: "alice", Start: 7, End: 13}, candidates[0])
+		assert.Equal(t, Candidate{Handle: "Bob.Smith@example.com", IsEmail: true, Start: 18, End: 40}, candidates[1])
+		assert.Equal(t, "carol", candidates[2].Handle)
+	}
+}
+
+func TestParseUsesCharacterOffsets(t *testing.T) {
+	candidates := Parse("héllo @zoë!")
+
+	if assert.Len(t, candidates, 1) {
+		assert.Equal(t, "zoë", candidates[0].Handle)
+		assert.Equal(t, 6, candidates[0].Start)
+		assert.Equal(t, 10, candidates[0].End)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	users := []*models.User{
+		{ID: 1, Name: "Alice", Email: "alice@example.com"},
+		{ID: 2, Name: "Sam", Email: "sam.one@example.com"},
+		{ID: 3, Name: "Sam", Email: "sam.two@example.com"},
+	}
+	candidates := Parse("@alice @sam @SAM.TWO@example.com @nobody @Alice")
+
+	names, emails := Handles(candidates)
+	assert.ElementsMatch(t, []string{"alice", "sam", "nobody"}, names)
+	assert.Equal(t, []string{"sam.two@example.com"}, emails)
+
+	spans := Resolve(candidates, users)
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, 1, spans[0].UserID)
+		assert.Equal(t, "@alice", spans[0].Text)
+		// The ambiguous @sam is skipped, the email resolves
+		assert.Equal(t, 3, spans[1].UserID)
+		assert.Equal(t, 1, spans[2].UserID)
+	}
+	assert.Equal(t, []int{1, 3}, UserIDs(spans))
+}
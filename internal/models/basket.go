@@ -3,27 +3,73 @@ package models
 import (
 	"time"
 
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
 	"gorm.io/gorm"
 )
 
+// Basket statuses
+const (
+	BasketStatusActive    = "active"
+	BasketStatusCompleted = "completed"
+	// BasketStatusMerged marks a guest basket moved into a user's basket
+	BasketStatusMerged = "merged"
+)
+
 type Basket struct {
 	ID        int            `json:"id" gorm:"primaryKey"`
 	UserID    *int           `json:"userId" gorm:"index"`
 	User      *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Status    string         `json:"status" gorm:"default:'active'"` // active, completed
+	Status    string         `json:"status" gorm:"default:'active'"` // active, completed, merged
 	Items     []BasketItem   `json:"items" gorm:"foreignKey:BasketID"`
+	CouponID  *int           `json:"couponId"`
+	Coupon    *Coupon        `json:"coupon,omitempty" gorm:"foreignKey:CouponID;constraint:OnDelete:SET NULL"`
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty" swaggerignore:"true"`
 }
 
 type BasketItem struct {
-	ID        int       `json:"id" gorm:"primaryKey"`
-	BasketID  int       `json:"basketId" gorm:"not null;index"`
-	ProductID int       `json:"productId" gorm:"not null"`
-	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
-	Quantity  int       `json:"quantity" gorm:"not null;check:quantity > 0"`
-	UnitPrice float64   `json:"unitPrice" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        int     `json:"id" gorm:"primaryKey"`
+	BasketID  int     `json:"basketId" gorm:"not null;index"`
+	ProductID int     `json:"productId" gorm:"not null"`
+	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
+	// VariantID is set for products sold in variants. Removing the variant
+	// removes the item.
+	VariantID *int            `json:"variantId" gorm:"index"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID;constraint:OnDelete:CASCADE"`
+	Quantity  int             `json:"quantity" gorm:"not null;check:quantity > 0"`
+	UnitPrice money.Money     `json:"unitPrice" gorm:"not null"`
+	// UnitDiscount is the product discount per unit when the item was added
+	UnitDiscount money.Money `json:"unitDiscount" gorm:"not null;default:0"`
+	Currency     string      `json:"currency" gorm:"size:3;not null;default:'USD'"`
+	// PriceChanged is set when the item was re-priced after its product's price
+	// changed, until its quantity is next changed
+	PriceChanged bool      `json:"priceChanged" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// AfterFind restores the currency of the item's price
+func (i *BasketItem) AfterFind(tx *gorm.DB) error {
+	i.UnitPrice.Currency = i.Currency
+	i.UnitDiscount.Currency = i.Currency
+	if i.Variant != nil && i.Variant.Price != nil {
+		i.Variant.Price.Currency = i.Currency
+	}
+	return nil
+}
+
+// Reprice takes the current price and discount of the item's product, or of its
+// variant when it has one, when they changed since the item was added, flagging
+// the item. It reports whether the item changed.
+func (i *BasketItem) Reprice(product *Product, variant *ProductVariant) bool {
+	price := product.VariantPrice(variant)
+	if i.UnitPrice.Amount == price.Amount && i.UnitDiscount.Amount == product.Discount.Amount && i.Currency == product.Currency {
+		return false
+	}
+	i.UnitPrice = price
+	i.UnitDiscount = money.New(product.Discount.Amount, product.Currency)
+	i.Currency = product.Currency
+	i.PriceChanged = true
+	return true
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasketItemReprice(t *testing.T) {
+	product := &Product{Price: money.New(1200, "EUR"), Discount: money.New(200, "EUR"), Currency: "EUR"}
+
+	t.Run("unchanged prices are kept", func(t *testing.T) {
+		item := BasketItem{UnitPrice: money.New(1200, "EUR"), UnitDiscount: money.New(200, "EUR"), Currency: "EUR"}
+		assert.False(t, item.Reprice(product, nil))
+		assert.False(t, item.PriceChanged)
+	})
+
+	t.Run("changed prices are taken and flagged", func(t *testing.T) {
+		item := BasketItem{UnitPrice: money.New(1000, "EUR"), Currency: "EUR"}
+		assert.True(t, item.Reprice(product, nil))
+		assert.True(t, item.PriceChanged)
+		assert.Equal(t, money.New(1200, "EUR"), item.UnitPrice)
+		assert.Equal(t, money.New(200, "EUR"), item.UnitDiscount)
+	})
+
+	t.Run("variants are priced at their own price", func(t *testing.T) {
+		price := money.New(1500, "EUR")
+		variant := &ProductVariant{ID: 3, Price: &price}
+		item := BasketItem{UnitPrice: money.New(1200, "EUR"), UnitDiscount: money.New(200, "EUR"), Currency: "EUR"}
+		assert.True(t, item.Reprice(product, variant))
+		assert.Equal(t, money.New(1500, "EUR"), item.UnitPrice)
+		assert.Equal(t, money.New(200, "EUR"), item.UnitDiscount)
+
+		assert.False(t, item.Reprice(product, variant))
+		assert.False(t, item.Reprice(product, &ProductVariant{ID: 4, Price: &price}))
+		assert.True(t, item.Reprice(product, &ProductVariant{ID: 5}))
+		assert.Equal(t, money.New(1200, "EUR"), item.UnitPrice)
+	})
+}
@@ -1,15 +1,28 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Ways of deleting a category that has subcategories or products
+const (
+	// CategoryDeleteReassign moves the subcategories and products of the
+	// category to another category, by default its parent
+	CategoryDeleteReassign = "reassign"
+	// CategoryDeleteCascade deletes the subcategories too and takes the
+	// products out of them all
+	CategoryDeleteCascade = "cascade"
+)
+
+// Category groups products. Names and slugs are unique among the categories
+// not deleted, so those of deleted categories can be taken again.
 type Category struct {
 	ID          int    `json:"id" gorm:"primaryKey"`
-	Name        string `json:"name" binding:"required,min=3" gorm:"uniqueIndex;not null"`
-	Slug        string `json:"slug" gorm:"uniqueIndex"`
+	Name        string `json:"name" binding:"required,min=3" gorm:"uniqueIndex:idx_categories_live_name,where:deleted_at IS NULL;not null"`
+	Slug        string `json:"slug" gorm:"uniqueIndex:idx_categories_live_slug,where:deleted_at IS NULL"`
 	Description string `json:"description" gorm:"type:text"`
 	Image       string `json:"image"`
 	Status      string `json:"status" gorm:"default:'active'"` // active, inactive
@@ -25,3 +38,54 @@ type Category struct {
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty" swaggerignore:"true"`
 }
+
+// CategoryTree nests categories under their parents and returns the roots,
+// each level sorted by name. Categories whose parent is not among them, such
+// as the children of deleted ones, are left out.
+func CategoryTree(categories []Category) []Category {
+	children := make(map[int][]Category)
+	var roots []Category
+	for _, category := range categories {
+		category.Parent, category.Children = nil, nil
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var nest func(level []Category) []Category
+	nest = func(level []Category) []Category {
+		nested := make([]Category, 0, len(level))
+		for _, category := range level {
+			category.Children = nest(children[category.ID])
+			nested = append(nested, category)
+		}
+		sort.SliceStable(nested, func(i, j int) bool { return nested[i].Name < nested[j].Name })
+		return nested
+	}
+	return nest(roots)
+}
+
+// CategorySubtree returns the IDs of a category and all of its descendants
+// among categories, the category's own first
+func CategorySubtree(categories []Category, id int) []int {
+	children := make(map[int][]int)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	subtree := []int{id}
+	seen := map[int]bool{id: true}
+	for i := 0; i < len(subtree); i++ {
+		for _, child := range children[subtree[i]] {
+			if !seen[child] {
+				seen[child] = true
+				subtree = append(subtree, child)
+			}
+		}
+	}
+	return subtree
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryTree(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	categories := []Category{
+		{ID: 4, Name: "Laptops", ParentID: parent(2)},
+		{ID: 1, Name: "Music"},
+		{ID: 2, Name: "Electronics"},
+		{ID: 3, Name: "Phones", ParentID: parent(2)},
+		{ID: 5, Name: "Gaming laptops", ParentID: parent(4)},
+		// The parent of an orphan was deleted
+		{ID: 6, Name: "Orphan", ParentID: parent(99)},
+	}
+
+	tree := CategoryTree(categories)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Electronics", tree[0].Name)
+	assert.Equal(t, "Music", tree[1].Name)
+	assert.Empty(t, tree[1].Children)
+
+	require.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "Laptops", tree[0].Children[0].Name)
+	assert.Equal(t, "Phones", tree[0].Children[1].Name)
+	require.Len(t, tree[0].Children[0].Children, 1)
+	assert.Equal(t, 5, tree[0].Children[0].Children[0].ID)
+
+	assert.Empty(t, CategoryTree(nil))
+}
+
+func TestCategorySubtree(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	categories := []Category{
+		{ID: 1, Name: "Electronics"},
+		{ID: 2, Name: "Phones", ParentID: parent(1)},
+		{ID: 3, Name: "Laptops", ParentID: parent(1)},
+		{ID: 4, Name: "Gaming laptops", ParentID: parent(3)},
+		{ID: 5, Name: "Music"},
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 4}, CategorySubtree(categories, 1))
+	assert.Equal(t, []int{3, 4}, CategorySubtree(categories, 3))
+	assert.Equal(t, []int{5}, CategorySubtree(categories, 5))
+
+	// Moving a category under itself or a descendant would make a cycle
+	assert.Contains(t, CategorySubtree(categories, 1), 4)
+	assert.NotContains(t, CategorySubtree(categories, 3), 2)
+
+	// A cycle already in the data does not loop forever
+	cyclic := []Category{{ID: 1, ParentID: parent(2)}, {ID: 2, ParentID: parent(1)}}
+	assert.Equal(t, []int{1, 2}, CategorySubtree(cyclic, 1))
+}
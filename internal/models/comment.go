@@ -4,15 +4,59 @@ import (
 	"time"
 )
 
+// DeletedCommentContent replaces the content of a deleted comment that still has replies
+const DeletedCommentContent = "[deleted]"
+
+// Comment moderation statuses
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusRejected = "rejected"
+	CommentStatusHidden   = "hidden"
+	// CommentStatusReported is no status comments have: the moderation queue
+	// lists the comments with open reports under it
+	CommentStatusReported = "reported"
+)
+
 // Comment represents a comment on an event
 type Comment struct {
-	ID        int       `json:"id" gorm:"primaryKey"`
-	UserID    int       `json:"userId" gorm:"not null"`
-	EventID   int       `json:"eventId" gorm:"not null"`
-	Content   string    `json:"content" binding:"required,min=1" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"userId" gorm:"not null"`
+	EventID   int        `json:"eventId" gorm:"not null;index"`
+	ParentID  *int       `json:"parentId" gorm:"index"`
+	Depth     int        `json:"depth" gorm:"not null;default:0"`
+	Content   string     `json:"content" binding:"required,min=1" gorm:"not null"`
+	Status    string     `json:"status" gorm:"size:20;not null;default:'approved';index"`
+	IsDeleted bool       `json:"isDeleted" gorm:"not null;default:false"`
+	IsEdited  bool       `json:"isEdited" gorm:"not null;default:false"`
+	EditedAt  *time.Time `json:"editedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+
+	// ReplyCount is the number of direct replies, populated by list queries
+	ReplyCount int `json:"replyCount" gorm:"->;-:migration" binding:"-"`
+
+	// ReportCount is the number of open reports, populated by moderation queries
+	ReportCount int `json:"reportCount,omitempty" gorm:"->;-:migration" binding:"-"`
+
+	// Mentions are the users mentioned in the content
+	Mentions []CommentMention `json:"mentions,omitempty" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE" binding:"-"`
+
+	// Replies holds nested replies when a thread is retrieved
+	Replies []*Comment `json:"replies,omitempty" gorm:"-" binding:"-"`
 
 	// Associations (optional, for preloading if needed)
-	User  User  `json:"user,omitempty" gorm:"foreignKey:UserID" binding:"-"`
-	Event Event `json:"-" gorm:"foreignKey:EventID" binding:"-"`
+	User   User     `json:"user,omitempty" gorm:"foreignKey:UserID" binding:"-"`
+	Event  Event    `json:"-" gorm:"foreignKey:EventID" binding:"-"`
+	Parent *Comment `json:"-" gorm:"foreignKey:ParentID" binding:"-"`
+}
+
+// IsVisible reports whether the comment is shown publicly
+func (c *Comment) IsVisible() bool {
+	return c.Status == "" || c.Status == CommentStatusApproved
+}
+
+// IsReply reports whether the comment is a reply to another comment
+func (c *Comment) IsReply() bool {
+	return c.ParentID != nil
 }
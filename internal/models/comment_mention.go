@@ -0,0 +1,13 @@
+package models
+
+// CommentMention is a resolved @mention of a user within a comment.
+// Start and End are character offsets into the comment content.
+type CommentMention struct {
+	ID        int    `json:"-" gorm:"primaryKey"`
+	CommentID int    `json:"-" gorm:"not null;index"`
+	UserID    int    `json:"userId" gorm:"not null;index"`
+	User      User   `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Start     int    `json:"start" gorm:"not null"`
+	End       int    `json:"end" gorm:"not null"`
+	Text      string `json:"text" gorm:"size:255;not null"`
+}
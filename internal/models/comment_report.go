@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Comment report statuses
+const (
+	ReportStatusOpen     = "open"
+	ReportStatusResolved = "resolved"
+)
+
+// CommentReport is a user's report of an inappropriate comment
+type CommentReport struct {
+	ID         int        `json:"id" gorm:"primaryKey"`
+	CommentID  int        `json:"commentId" gorm:"not null;uniqueIndex:idx_comment_reporter"`
+	Comment    Comment    `json:"-" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+	ReporterID int        `json:"reporterId" gorm:"not null;uniqueIndex:idx_comment_reporter"`
+	Reporter   User       `json:"-" gorm:"foreignKey:ReporterID"`
+	Reason     string     `json:"reason" gorm:"size:500"`
+	Status     string     `json:"status" gorm:"size:20;not null;default:'open';index"`
+	ResolvedBy *int       `json:"resolvedBy"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
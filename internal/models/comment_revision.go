@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CommentRevision stores a previous version of an edited comment
+type CommentRevision struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	CommentID int       `json:"commentId" gorm:"not null;index"`
+	Comment   Comment   `json:"-" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+	Content   string    `json:"content" gorm:"not null"`
+	EditedBy  int       `json:"editedBy" gorm:"not null"`
+	Editor    User      `json:"editor,omitempty" gorm:"foreignKey:EditedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"gorm.io/gorm"
+)
+
+// Coupon types
+const (
+	CouponTypePercentage = "percentage"
+	CouponTypeFixed      = "fixed"
+)
+
+// Coupon is a promotion code that discounts a basket. Coupons restricted to
+// products or categories only discount matching items.
+type Coupon struct {
+	ID   int    `json:"id" gorm:"primaryKey"`
+	Code string `json:"code" gorm:"size:50;not null;uniqueIndex"`
+	Type string `json:"type" gorm:"size:20;not null"`
+	// PercentOff is the whole-percent discount of percentage coupons
+	PercentOff int `json:"percentOff" gorm:"not null;default:0"`
+	// AmountOff and MinSpend are in Currency; percentage coupons without a
+	// minimum spend have no currency and apply to any basket
+	AmountOff money.Money `json:"amountOff" gorm:"not null;default:0"`
+	MinSpend  money.Money `json:"minSpend" gorm:"not null;default:0"`
+	Currency  string      `json:"currency" gorm:"size:3"`
+	// MaxRedemptions and MaxPerUser of zero mean unlimited
+	MaxRedemptions int        `json:"maxRedemptions" gorm:"not null;default:0"`
+	MaxPerUser     int        `json:"maxPerUser" gorm:"not null;default:0"`
+	Redemptions    int        `json:"redemptions" gorm:"not null;default:0"`
+	StartsAt       *time.Time `json:"startsAt"`
+	EndsAt         *time.Time `json:"endsAt"`
+	Active         bool       `json:"active" gorm:"not null;default:true"`
+	Products       []Product  `json:"products,omitempty" gorm:"many2many:coupon_products;"`
+	Categories     []Category `json:"categories,omitempty" gorm:"many2many:coupon_categories;"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// IsActiveAt reports whether the coupon can be used at the given time
+func (c *Coupon) IsActiveAt(t time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.StartsAt != nil && t.Before(*c.StartsAt) {
+		return false
+	}
+	if c.EndsAt != nil && !t.Before(*c.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// IsExhausted reports whether the coupon reached its global usage limit
+func (c *Coupon) IsExhausted() bool {
+	return c.MaxRedemptions > 0 && c.Redemptions >= c.MaxRedemptions
+}
+
+// CheckUsable returns why a user with the given number of past redemptions
+// cannot use the coupon at time t, or nil when they can
+func (c *Coupon) CheckUsable(t time.Time, userRedemptions int64) error {
+	if !c.IsActiveAt(t) {
+		return fmt.Errorf("coupon %s is not active", c.Code)
+	}
+	if c.IsExhausted() {
+		return fmt.Errorf("coupon %s has been fully redeemed", c.Code)
+	}
+	if c.MaxPerUser > 0 && userRedemptions >= int64(c.MaxPerUser) {
+		return fmt.Errorf("you have already used coupon %s", c.Code)
+	}
+	return nil
+}
+
+// IsRestricted reports whether the coupon only applies to some products or categories
+func (c *Coupon) IsRestricted() bool {
+	return len(c.Products) > 0 || len(c.Categories) > 0
+}
+
+// AfterFind restores the currency of the coupon amounts
+func (c *Coupon) AfterFind(tx *gorm.DB) error {
+	c.AmountOff.Currency = c.Currency
+	c.MinSpend.Currency = c.Currency
+	return nil
+}
+
+// CouponRedemption records a coupon used by an order
+type CouponRedemption struct {
+	ID        int         `json:"id" gorm:"primaryKey"`
+	CouponID  int         `json:"couponId" gorm:"not null;index:idx_redemption_coupon_user"`
+	UserID    int         `json:"userId" gorm:"not null;index:idx_redemption_coupon_user"`
+	OrderID   int         `json:"orderId" gorm:"not null;uniqueIndex"`
+	Amount    money.Money `json:"amount" gorm:"not null"`
+	Currency  string      `json:"currency" gorm:"size:3;not null"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// AfterFind restores the currency of the redeemed amount
+func (r *CouponRedemption) AfterFind(tx *gorm.DB) error {
+	r.Amount.Currency = r.Currency
+	return nil
+}
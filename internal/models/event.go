@@ -1,5 +1,10 @@
 package models
 
+import (
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"gorm.io/gorm"
+)
+
 // Event represents an event in the system
 type Event struct {
 	ID          int    `json:"id" gorm:"primaryKey"`
@@ -9,4 +14,19 @@ type Event struct {
 	Description string `json:"description" binding:"required,min=10" gorm:"not null"`
 	Date        string `json:"date" binding:"required,datetime=2006-01-02" gorm:"not null"`
 	Location    string `json:"location" binding:"required,min=3" gorm:"not null"`
+
+	// SearchVector is generated from the name, location and description for
+	// full-text search
+	SearchVector string `json:"-" gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(name, '')), 'A') || setweight(to_tsvector('english', coalesce(location, '')), 'B') || setweight(to_tsvector('english', coalesce(description, '')), 'C')) STORED;index:idx_events_search_vector,type:gin"`
+	// SearchRank and SearchSnippet are set on the results of full-text searches.
+	// They are not columns, so queries joining other tables select the table's
+	// columns explicitly rather than every field's.
+	SearchRank    float64 `json:"searchRank,omitempty" gorm:"->;-:migration"`
+	SearchSnippet string  `json:"searchSnippet,omitempty" gorm:"->;-:migration"`
+}
+
+// AfterFind highlights the search snippet
+func (e *Event) AfterFind(tx *gorm.DB) error {
+	e.SearchSnippet = query.HighlightSnippet(e.SearchSnippet)
+	return nil
 }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Reasons of inventory adjustments
+const (
+	InventoryReasonInitial    = "initial"
+	InventoryReasonRestock    = "restock"
+	InventoryReasonSale       = "sale"
+	InventoryReasonReturn     = "return"
+	InventoryReasonCorrection = "correction"
+	InventoryReasonImport     = "import"
+)
+
+// InventoryAdjustment is an entry of the inventory ledger, recording a change
+// of a product's stock and why it was made. A product's stock is the sum of
+// its adjustments' deltas, and a variant's the sum of the deltas of the
+// adjustments with its VariantID. A nil ActorID means the change was made by
+// the system.
+type InventoryAdjustment struct {
+	ID        int      `json:"id" gorm:"primaryKey"`
+	ProductID int      `json:"productId" gorm:"not null;index"`
+	Product   *Product `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	// VariantID outlives the variant, so the ledger keeps adding up
+	VariantID *int   `json:"variantId,omitempty" gorm:"index"`
+	Delta     int    `json:"delta" gorm:"not null"`
+	Reason    string `json:"reason" gorm:"size:20;not null;index"`
+	// StockAfter is the variant's stock after the adjustment, or the product's
+	// for adjustments of no variant
+	StockAfter int    `json:"stockAfter" gorm:"not null"`
+	OrderID    *int   `json:"orderId,omitempty" gorm:"index"`
+	ActorID    *int   `json:"actorId"`
+	Note       string `json:"note,omitempty" gorm:"size:500"`
+	// LowStock is set when the adjustment took the product's stock to or below
+	// its low-stock threshold
+	LowStock  bool      `json:"lowStock" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CrossedLowStock reports whether the product's stock fell from above its
+// low-stock threshold to or below it. A threshold of zero turns alerts off.
+func (p *Product) CrossedLowStock(previousStock int) bool {
+	return p.LowStockThreshold > 0 && previousStock > p.LowStockThreshold && p.Stock <= p.LowStockThreshold
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductCrossedLowStock(t *testing.T) {
+	for name, tc := range map[string]struct {
+		threshold, previous, stock int
+		crossed                    bool
+	}{
+		"falls to the threshold":    {threshold: 5, previous: 8, stock: 5, crossed: true},
+		"falls below the threshold": {threshold: 5, previous: 6, stock: 0, crossed: true},
+		"stays above the threshold": {threshold: 5, previous: 9, stock: 6},
+		"was already low":           {threshold: 5, previous: 5, stock: 3},
+		"rises to the threshold":    {threshold: 5, previous: 2, stock: 5},
+		"alerts are off":            {threshold: 0, previous: 3, stock: 0},
+	} {
+		product := &Product{Stock: tc.stock, LowStockThreshold: tc.threshold}
+		assert.Equal(t, tc.crossed, product.CrossedLowStock(tc.previous), name)
+	}
+}
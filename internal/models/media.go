@@ -0,0 +1,115 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MediaPath is where stored files are linked from. Links are stable and
+// redirect to a short-lived signed URL of the file.
+const MediaPath = "/api/v1/media/"
+
+// MediaFilesPath is where this application serves the signed URLs of files
+// kept by the local storage
+const MediaFilesPath = "/api/v1/media/files"
+
+// Media is an uploaded file kept in storage. Files are stored once by the
+// SHA-256 of their content, so uploading the same file again returns it.
+type Media struct {
+	ID     int `json:"id" gorm:"primaryKey"`
+	UserID int `json:"userId" gorm:"not null;index"`
+	// Key is where the file is kept in storage
+	Key         string `json:"-" gorm:"size:255;not null;uniqueIndex"`
+	Hash        string `json:"hash" gorm:"size:64;not null;uniqueIndex"`
+	ContentType string `json:"contentType" gorm:"size:100;not null"`
+	Size        int64  `json:"size" gorm:"not null"`
+	Filename    string `json:"filename" gorm:"size:255"`
+	// URL is the stable link to the file, for product images, avatars and the like
+	URL string `json:"url" gorm:"-"`
+	// Srcset links to the resized variants of the image, by size name
+	Srcset    map[string]string `json:"srcset,omitempty" gorm:"-"`
+	CreatedAt time.Time         `json:"createdAt"`
+
+	Variants []MediaVariant `json:"-" gorm:"foreignKey:MediaID;constraint:OnDelete:CASCADE"`
+}
+
+// AfterFind sets the links to the file
+func (m *Media) AfterFind(tx *gorm.DB) error {
+	m.URL = MediaURL(m.ID)
+	m.Srcset = Srcset(m.URL)
+	return nil
+}
+
+// AfterCreate sets the links to the file
+func (m *Media) AfterCreate(tx *gorm.DB) error {
+	m.URL = MediaURL(m.ID)
+	m.Srcset = Srcset(m.URL)
+	return nil
+}
+
+// MediaVariant is a resized copy of a stored image, e.g. its thumbnail
+type MediaVariant struct {
+	ID      int `json:"id" gorm:"primaryKey"`
+	MediaID int `json:"mediaId" gorm:"not null;uniqueIndex:idx_media_variant"`
+	// Name is the name of the configured size the variant was made for
+	Name string `json:"name" gorm:"size:50;not null;uniqueIndex:idx_media_variant"`
+	// MaxSize is the bound of the size when the variant was made; variants
+	// are made again when the size is configured differently
+	MaxSize     int       `json:"maxSize" gorm:"not null"`
+	Key         string    `json:"-" gorm:"size:255;not null"`
+	ContentType string    `json:"contentType" gorm:"size:100;not null"`
+	Width       int       `json:"width" gorm:"not null"`
+	Height      int       `json:"height" gorm:"not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// imageVariants are the names of the configured image sizes, set once at startup
+var imageVariants []string
+
+// SetImageVariants sets the names of the image sizes srcsets link to
+func SetImageVariants(names []string) {
+	imageVariants = names
+}
+
+// Srcset returns links to the variants of a stored image by size name, or
+// nil when the link does not point to a stored file
+func Srcset(link string) map[string]string {
+	id, ok := MediaIDFromURL(link)
+	if !ok || len(imageVariants) == 0 {
+		return nil
+	}
+	srcset := make(map[string]string, len(imageVariants))
+	for _, name := range imageVariants {
+		srcset[name] = MediaVariantURL(id, name)
+	}
+	return srcset
+}
+
+// MediaURL returns the stable link to a stored file
+func MediaURL(id int) string {
+	return MediaPath + strconv.Itoa(id)
+}
+
+// MediaVariantURL returns the stable link to a variant of a stored image
+func MediaVariantURL(id int, name string) string {
+	return MediaURL(id) + "/" + name
+}
+
+// MediaIDFromURL returns the ID of the stored file a link points to, if it
+// points to one
+func MediaIDFromURL(link string) (int, bool) {
+	rest, ok := strings.CutPrefix(link, MediaPath)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(rest)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
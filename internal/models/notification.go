@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Notification types
+const (
+	NotificationTypeMention     = "mention"
+	NotificationTypePriceDrop   = "price_drop"
+	NotificationTypeBackInStock = "back_in_stock"
+	NotificationTypeLowStock    = "low_stock"
+)
+
+// Notification is an in-app notification for a user
+type Notification struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"userId" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Type      string     `json:"type" gorm:"size:30;not null"`
+	ActorID   *int       `json:"actorId"`
+	Actor     *User      `json:"actor,omitempty" gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL"`
+	EventID   *int       `json:"eventId"`
+	CommentID *int       `json:"commentId"`
+	ProductID *int       `json:"productId"`
+	Message   string     `json:"message" gorm:"size:500;not null"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// IsRead reports whether the notification has been read
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+// NotificationMute silences mention notifications from an event for a user
+type NotificationMute struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"userId" gorm:"not null;uniqueIndex:idx_mute_user_event"`
+	User      User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	EventID   int       `json:"eventId" gorm:"not null;uniqueIndex:idx_mute_user_event"`
+	Event     Event     `json:"-" gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"createdAt"`
+}
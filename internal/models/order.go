@@ -0,0 +1,158 @@
+package models
+
+import (
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"gorm.io/gorm"
+)
+
+// Order statuses
+const (
+	OrderStatusPending    = "pending"
+	OrderStatusPaid       = "paid"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+	OrderStatusRefunded   = "refunded"
+)
+
+// orderTransitions lists the statuses each order status may move to
+var orderTransitions = map[string][]string{
+	OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:       {OrderStatusProcessing, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:    {OrderStatusDelivered},
+	OrderStatusDelivered:  {OrderStatusRefunded},
+}
+
+// CanTransitionOrder reports whether an order may move from one status to another
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoresStock reports whether moving an order into the status returns its items to stock
+func RestoresStock(status string) bool {
+	return status == OrderStatusCancelled || status == OrderStatusRefunded
+}
+
+// PurchasedStatuses lists the statuses of orders that were paid for and not refunded
+var PurchasedStatuses = []string{OrderStatusPaid, OrderStatusProcessing, OrderStatusShipped, OrderStatusDelivered}
+
+// Order is a completed checkout of a user's basket
+type Order struct {
+	ID         int                 `json:"id" gorm:"primaryKey"`
+	UserID     int                 `json:"userId" gorm:"not null;index"`
+	User       *User               `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	BasketID   *int                `json:"basketId" gorm:"uniqueIndex"`
+	Status     string              `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	Subtotal   money.Money         `json:"subtotal" gorm:"not null;default:0"`
+	Discount   money.Money         `json:"discount" gorm:"not null;default:0"`
+	Tax        money.Money         `json:"tax" gorm:"not null;default:0"`
+	CouponID   *int                `json:"couponId"`
+	CouponCode string              `json:"couponCode,omitempty" gorm:"size:50"`
+	TaxRate    string              `json:"taxRate" gorm:"size:10"`
+	Total      money.Money         `json:"total" gorm:"not null"`
+	Currency   string              `json:"currency" gorm:"size:3;not null;default:'USD'"`
+	Items      []OrderItem         `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	History    []OrderStatusChange `json:"history,omitempty" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	Payments   []Payment           `json:"payments,omitempty" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	UpdatedAt  time.Time           `json:"updatedAt"`
+}
+
+// HasSeller reports whether the order contains products sold by the user
+func (o *Order) HasSeller(userID int) bool {
+	for _, item := range o.Items {
+		if item.SellerID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSoleSeller reports whether all of the order's products are sold by the user
+func (o *Order) IsSoleSeller(userID int) bool {
+	for _, item := range o.Items {
+		if item.SellerID != userID {
+			return false
+		}
+	}
+	return len(o.Items) > 0
+}
+
+// ForSeller returns the order as one of its sellers sees it: only the seller's
+// items, a subtotal and total of those items, and none of the order-wide
+// discount, tax, coupon or payments, which cover other sellers' items too
+func (o *Order) ForSeller(sellerID int) *Order {
+	scoped := *o
+	scoped.Items = make([]OrderItem, 0, len(o.Items))
+	var subtotal int64
+	for _, item := range o.Items {
+		if item.SellerID == sellerID {
+			scoped.Items = append(scoped.Items, item)
+			subtotal += item.Subtotal.Amount
+		}
+	}
+	scoped.Subtotal = money.New(subtotal, o.Currency)
+	scoped.Total = scoped.Subtotal
+	scoped.Discount = money.Zero(o.Currency)
+	scoped.Tax = money.Zero(o.Currency)
+	scoped.TaxRate = ""
+	scoped.CouponID = nil
+	scoped.CouponCode = ""
+	scoped.Payments = nil
+	return &scoped
+}
+
+// AfterFind restores the currency of the order's and its loaded items' amounts
+func (o *Order) AfterFind(tx *gorm.DB) error {
+	for _, amount := range []*money.Money{&o.Subtotal, &o.Discount, &o.Tax, &o.Total} {
+		amount.Currency = o.Currency
+	}
+	for i := range o.Items {
+		o.Items[i].UnitPrice.Currency = o.Currency
+		o.Items[i].UnitDiscount.Currency = o.Currency
+		o.Items[i].Subtotal.Currency = o.Currency
+	}
+	return nil
+}
+
+// OrderItem is a product line of an order. Name, SKU, seller and price are
+// snapshotted at checkout so later product changes do not alter the order.
+// Amounts are in the order's currency.
+type OrderItem struct {
+	ID          int    `json:"id" gorm:"primaryKey"`
+	OrderID     int    `json:"orderId" gorm:"not null;index"`
+	ProductID   int    `json:"productId" gorm:"not null;index"`
+	SellerID    int    `json:"sellerId" gorm:"not null;default:0;index"`
+	ProductName string `json:"productName" gorm:"not null"`
+	SKU         string `json:"sku"`
+	// VariantID and VariantOptions record the variant bought, if any
+	VariantID      *int              `json:"variantId,omitempty"`
+	VariantOptions map[string]string `json:"variantOptions,omitempty" gorm:"type:jsonb;serializer:json"`
+	Quantity       int               `json:"quantity" gorm:"not null;check:quantity > 0"`
+	UnitPrice      money.Money       `json:"unitPrice" gorm:"not null"`
+	UnitDiscount   money.Money       `json:"unitDiscount" gorm:"not null;default:0"`
+	// Subtotal is the quantity times the unit price less the unit discount
+	Subtotal  money.Money `json:"subtotal" gorm:"not null"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// OrderStatusChange records an order status transition and who made it.
+// A nil ActorID means the change was made by the system.
+type OrderStatusChange struct {
+	ID         int       `json:"id" gorm:"primaryKey"`
+	OrderID    int       `json:"orderId" gorm:"not null;index"`
+	FromStatus string    `json:"fromStatus" gorm:"size:20"`
+	ToStatus   string    `json:"toStatus" gorm:"size:20;not null"`
+	ActorID    *int      `json:"actorId"`
+	Note       string    `json:"note" gorm:"size:500"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{OrderStatusPending, OrderStatusPaid, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPending, OrderStatusShipped, false},
+		{OrderStatusPaid, OrderStatusProcessing, true},
+		{OrderStatusProcessing, OrderStatusShipped, true},
+		{OrderStatusShipped, OrderStatusCancelled, false},
+		{OrderStatusShipped, OrderStatusDelivered, true},
+		{OrderStatusDelivered, OrderStatusRefunded, true},
+		{OrderStatusCancelled, OrderStatusPaid, false},
+		{OrderStatusRefunded, OrderStatusRefunded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, CanTransitionOrder(tt.from, tt.to))
+		})
+	}
+}
+
+func TestOrderForSeller(t *testing.T) {
+	couponID := 3
+	order := &Order{
+		ID: 1, UserID: 9, Currency: "USD",
+		Subtotal: money.New(3500, "USD"), Discount: money.New(500, "USD"), Tax: money.New(300, "USD"), Total: money.New(3300, "USD"),
+		CouponID: &couponID, CouponCode: "SAVE5", TaxRate: "0.1",
+		Items: []OrderItem{
+			{ID: 1, SellerID: 1, Quantity: 2, Subtotal: money.New(2000, "USD")},
+			{ID: 2, SellerID: 2, Quantity: 1, Subtotal: money.New(1500, "USD")},
+		},
+		Payments: []Payment{{ID: 1, Amount: money.New(3300, "USD")}},
+	}
+
+	assert.False(t, order.IsSoleSeller(1))
+	assert.True(t, (&Order{Items: order.Items[:1]}).IsSoleSeller(1))
+	assert.False(t, (&Order{}).IsSoleSeller(1))
+
+	scoped := order.ForSeller(1)
+	if assert.Len(t, scoped.Items, 1) {
+		assert.Equal(t, 1, scoped.Items[0].ID)
+	}
+	assert.Equal(t, money.New(2000, "USD"), scoped.Subtotal)
+	assert.Equal(t, money.New(2000, "USD"), scoped.Total)
+	assert.True(t, scoped.Discount.IsZero())
+	assert.True(t, scoped.Tax.IsZero())
+	assert.Nil(t, scoped.CouponID)
+	assert.Empty(t, scoped.CouponCode)
+	assert.Empty(t, scoped.Payments)
+	assert.Len(t, order.Items, 2, "the order itself is left as it is")
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"gorm.io/gorm"
+)
+
+// Payment statuses
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusRefunded  = "refunded"
+	PaymentStatusCancelled = "cancelled"
+	// PaymentStatusRefundPending marks a payment owed back to the buyer until
+	// the provider confirms its refund
+	PaymentStatusRefundPending = "refund_pending"
+)
+
+// Payment records one attempt to pay for an order through a payment provider
+type Payment struct {
+	ID       int         `json:"id" gorm:"primaryKey"`
+	OrderID  int         `json:"orderId" gorm:"not null;index"`
+	Provider string      `json:"provider" gorm:"size:50;not null"`
+	IntentID string      `json:"intentId" gorm:"size:255;index"`
+	Amount   money.Money `json:"amount" gorm:"not null"`
+	Currency string      `json:"currency" gorm:"size:3;not null"`
+	Status   string      `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	Error    string      `json:"error,omitempty"`
+	// ClientSecret lets the buyer confirm the intent with the provider; it is
+	// only returned when the attempt is created and never stored
+	ClientSecret string    `json:"clientSecret,omitempty" gorm:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// AfterFind restores the currency of the payment amount
+func (p *Payment) AfterFind(tx *gorm.DB) error {
+	p.Amount.Currency = p.Currency
+	return nil
+}
+
+// PaymentEvent records a processed provider webhook so redelivered events are ignored
+type PaymentEvent struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	Provider  string    `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_payment_event"`
+	EventID   string    `json:"eventId" gorm:"size:255;not null;uniqueIndex:idx_payment_event"`
+	Type      string    `json:"type" gorm:"size:50;not null"`
+	PaymentID *int      `json:"paymentId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -1,18 +1,30 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+// ProductStatusActive is the status of products on sale
+const ProductStatusActive = "active"
+
 type Product struct {
-	ID          int     `json:"id" gorm:"primaryKey"`
-	Name        string  `json:"name" binding:"required,min=3" gorm:"not null"`
-	Description string  `json:"description" gorm:"type:text"`
-	Price       float64 `json:"price" binding:"required,gt=0" gorm:"not null"`
-	Stock       int     `json:"stock" binding:"required,gte=0" gorm:"not null"`
+	ID          int         `json:"id" gorm:"primaryKey"`
+	Name        string      `json:"name" binding:"required,min=3" gorm:"not null"`
+	Description string      `json:"description" gorm:"type:text"`
+	Price       money.Money `json:"price" gorm:"not null"`
+	Currency    string      `json:"currency" gorm:"size:3;not null;default:'USD'"`
+	Stock       int         `json:"stock" binding:"required,gte=0" gorm:"not null"`
+	// Available is the stock not held by basket reservations
+	Available int `json:"available" gorm:"-"`
+	// LowStockThreshold is the stock at or below which the seller is notified;
+	// zero turns the notifications off
+	LowStockThreshold int `json:"lowStockThreshold" binding:"gte=0" gorm:"not null;default:0"`
 
 	// Advanced Product Details
 	SKU    string         `json:"sku" gorm:"unique;not null"`
@@ -21,26 +33,105 @@ type Product struct {
 	Image  string         `json:"image"`
 	Images pq.StringArray `json:"images" gorm:"type:text[]" swaggertype:"array,string" example:"[\"url1\",\"url2\"]"`
 	Tags   pq.StringArray `json:"tags" gorm:"type:text[]" swaggertype:"array,string" example:"[\"tag1\",\"tag2\"]"`
+	// ImageSrcset links to the resized variants of the image, by size name
+	ImageSrcset map[string]string `json:"imageSrcset,omitempty" gorm:"-"`
 
 	MetaTitle       string `json:"metaTitle"`
 	MetaDescription string `json:"metaDescription"`
 
-	Discount   float64 `json:"discount" gorm:"default:0"`
-	FinalPrice float64 `json:"finalPrice"`
-	Brand      string  `json:"brand"`
-	Weight     float64 `json:"weight"`
-	Dimensions string  `json:"dimensions"`
+	Discount   money.Money `json:"discount" gorm:"not null;default:0"`
+	FinalPrice money.Money `json:"finalPrice" gorm:"not null;default:0"`
+	Brand      string      `json:"brand"`
+	Weight     float64     `json:"weight"`
+	Dimensions string      `json:"dimensions"`
 
 	Rating       float64 `json:"rating" gorm:"default:0"`
 	ReviewsCount int     `json:"reviewsCount" gorm:"default:0"`
 	Views        int     `json:"views" gorm:"default:0"`
 
+	// SearchVector is generated from the name, brand, tags and description for
+	// full-text search. It needs the immutable_array_to_string function the
+	// migrations create.
+	SearchVector string `json:"-" gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(name, '')), 'A') || setweight(to_tsvector('english', coalesce(brand, '') || ' ' || coalesce(immutable_array_to_string(tags, ' '), '')), 'B') || setweight(to_tsvector('english', coalesce(description, '')), 'C')) STORED;index:idx_products_search_vector,type:gin"`
+	// SearchRank and SearchSnippet are set on the results of full-text searches.
+	// They are not columns, so queries joining other tables select the table's
+	// columns explicitly rather than every field's.
+	SearchRank    float64 `json:"searchRank,omitempty" gorm:"->;-:migration"`
+	SearchSnippet string  `json:"searchSnippet,omitempty" gorm:"->;-:migration"`
+
 	UserID int  `json:"userId" gorm:"not null"`
 	User   User `json:"user" gorm:"foreignKey:UserID"`
 
 	Categories []Category `json:"categories" gorm:"many2many:product_categories;"`
 
+	// Options and Variants are set for products sold in several variants, whose
+	// stock then adds up to the product's
+	Options  []ProductOption  `json:"options" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Variants []ProductVariant `json:"variants" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty" swaggerignore:"true"`
 }
+
+// SetAvailable derives the available stock from the quantity held by reservations
+func (p *Product) SetAvailable(reserved int) {
+	p.Available = p.Stock - reserved
+	if p.Available < 0 {
+		p.Available = 0
+	}
+}
+
+// ApplyCurrency settles the product's currency from its prices, its Currency
+// field or the fallback, in that order, and checks every price uses it
+func (p *Product) ApplyCurrency(fallback string) error {
+	currency := p.Price.Currency
+	if currency == "" {
+		currency = p.Currency
+	}
+	if currency == "" {
+		currency = fallback
+	}
+	if err := money.ValidateCurrency(currency); err != nil {
+		return err
+	}
+
+	prices := []*money.Money{&p.Price, &p.Discount, &p.FinalPrice}
+	for _, variant := range p.Variants {
+		if variant.Price != nil {
+			prices = append(prices, variant.Price)
+		}
+	}
+	for _, price := range prices {
+		if price.Currency == "" {
+			price.Currency = currency
+		}
+		if price.Currency != currency {
+			return fmt.Errorf("%w: product is priced in %s but a price is in %s", money.ErrCurrencyMismatch, currency, price.Currency)
+		}
+	}
+	p.Currency = currency
+	return nil
+}
+
+// AfterFind restores the currency of the product's prices, its variants' included,
+// and highlights the search snippet
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	p.Price.Currency = p.Currency
+	p.Discount.Currency = p.Currency
+	p.FinalPrice.Currency = p.Currency
+	for _, variant := range p.Variants {
+		if variant.Price != nil {
+			variant.Price.Currency = p.Currency
+		}
+	}
+	p.ImageSrcset = Srcset(p.Image)
+	p.SearchSnippet = query.HighlightSnippet(p.SearchSnippet)
+	return nil
+}
+
+// AfterSave links to the resized variants of the image
+func (p *Product) AfterSave(tx *gorm.DB) error {
+	p.ImageSrcset = Srcset(p.Image)
+	return nil
+}
@@ -0,0 +1,43 @@
+package models
+
+// PriceFacetBounds are the lower bounds of the price buckets products are
+// counted in, in minor units. The last bucket has no upper bound.
+var PriceFacetBounds = []int64{0, 1000, 2500, 5000, 10000, 25000, 50000, 100000}
+
+// ProductFacets counts the products matching a search by the values they can
+// be narrowed down by, e.g. for the filters of a storefront sidebar. Values no
+// product has are left out.
+type ProductFacets struct {
+	Categories []FacetCount  `json:"categories"`
+	Brands     []FacetCount  `json:"brands"`
+	Tags       []FacetCount  `json:"tags"`
+	Prices     []PriceBucket `json:"prices"`
+	Statuses   []FacetCount  `json:"statuses"`
+}
+
+// FacetCount counts the products having a value, e.g. a brand. Categories are
+// given by ID, labelled with their name.
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket counts the products priced from Min up to, but not including,
+// Max, in minor units
+type PriceBucket struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// NewPriceBucket returns the empty price bucket of an index into
+// PriceFacetBounds
+func NewPriceBucket(index int) PriceBucket {
+	bucket := PriceBucket{Min: PriceFacetBounds[index]}
+	if index+1 < len(PriceFacetBounds) {
+		upper := PriceFacetBounds[index+1]
+		bucket.Max = &upper
+	}
+	return bucket
+}
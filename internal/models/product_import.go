@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Product import statuses
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// MaxImportRowErrors is the most row errors an import reports; rows failing
+// beyond it are only counted
+const MaxImportRowErrors = 1000
+
+// ProductImport is a background job creating and updating products from a
+// CSV or NDJSON file. Rows that fail are reported in Errors and the others are
+// imported regardless; the job fails only when the file cannot be read on.
+type ProductImport struct {
+	ID     int    `json:"id" gorm:"primaryKey"`
+	UserID int    `json:"userId" gorm:"not null;index"`
+	Format string `json:"format" gorm:"size:10;not null"`
+	Status string `json:"status" gorm:"size:20;not null;default:'pending'"`
+	// Rows counts the rows read so far, each either created, updated or failed
+	Rows    int              `json:"rows" gorm:"not null;default:0"`
+	Created int              `json:"created" gorm:"not null;default:0"`
+	Updated int              `json:"updated" gorm:"not null;default:0"`
+	Failed  int              `json:"failed" gorm:"not null;default:0"`
+	Errors  []ImportRowError `json:"errors" gorm:"type:jsonb;serializer:json"`
+	// Error is why a failed job stopped
+	Error      string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ImportRowError reports a row of an import that was not imported
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// RowFailed records a row that was not imported
+func (i *ProductImport) RowFailed(line int, sku string, err error) {
+	i.Rows++
+	i.Failed++
+	if len(i.Errors) < MaxImportRowErrors {
+		i.Errors = append(i.Errors, ImportRowError{Line: line, SKU: sku, Error: err.Error()})
+	}
+}
+
+// RowImported records a row that created or updated a product
+func (i *ProductImport) RowImported(created bool) {
+	i.Rows++
+	if created {
+		i.Created++
+	} else {
+		i.Updated++
+	}
+}
+
+// Finish ends the job, failed with the reason when err is not nil
+func (i *ProductImport) Finish(err error, at time.Time) {
+	i.Status = ImportStatusCompleted
+	if err != nil {
+		i.Status = ImportStatusFailed
+		i.Error = err.Error()
+	}
+	i.FinishedAt = &at
+}
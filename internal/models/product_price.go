@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"gorm.io/gorm"
+)
+
+// Reasons a product's price changed
+const (
+	PriceChangeCreated       = "created"
+	PriceChangeUpdated       = "updated"
+	PriceChangeImported      = "imported"
+	PriceChangeScheduleStart = "schedule_started"
+	PriceChangeScheduleEnd   = "schedule_ended"
+)
+
+// ProductPriceChange records the price and discount a product had from
+// CreatedAt on. Changes are only ever appended. A nil ActorID means the change
+// was made by the system, e.g. by a price schedule.
+type ProductPriceChange struct {
+	ID         int         `json:"id" gorm:"primaryKey"`
+	ProductID  int         `json:"productId" gorm:"not null;index"`
+	Product    *Product    `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Price      money.Money `json:"price" gorm:"not null"`
+	Discount   money.Money `json:"discount" gorm:"not null;default:0"`
+	FinalPrice money.Money `json:"finalPrice" gorm:"not null"`
+	Currency   string      `json:"currency" gorm:"size:3;not null"`
+	Reason     string      `json:"reason" gorm:"size:20;not null"`
+	ScheduleID *int        `json:"scheduleId,omitempty"`
+	ActorID    *int        `json:"actorId"`
+	CreatedAt  time.Time   `json:"createdAt"`
+}
+
+// NewProductPriceChange records the current price of a product
+func NewProductPriceChange(product *Product, reason string, scheduleID, actorID *int) *ProductPriceChange {
+	return &ProductPriceChange{
+		ProductID:  product.ID,
+		Price:      product.Price,
+		Discount:   product.Discount,
+		FinalPrice: product.FinalPrice,
+		Currency:   product.Currency,
+		Reason:     reason,
+		ScheduleID: scheduleID,
+		ActorID:    actorID,
+	}
+}
+
+// AfterFind restores the currency of the change's amounts
+func (c *ProductPriceChange) AfterFind(tx *gorm.DB) error {
+	for _, amount := range []*money.Money{&c.Price, &c.Discount, &c.FinalPrice} {
+		amount.Currency = c.Currency
+	}
+	return nil
+}
+
+// ProductRepricing is a price change a price schedule made, with the product
+// as repriced and its final price from before
+type ProductRepricing struct {
+	Product            Product
+	PreviousFinalPrice money.Money
+}
+
+// Price schedule statuses
+const (
+	PriceScheduleScheduled = "scheduled"
+	PriceScheduleActive    = "active"
+	PriceScheduleCompleted = "completed"
+	PriceScheduleCancelled = "cancelled"
+)
+
+// ProductPriceSchedule changes a product's price and discount at StartsAt.
+// Schedules with an end are sales: at EndsAt the product gets back the price
+// and discount it had when the sale started, unless they were changed since.
+// Schedules without an end complete as soon as they start.
+type ProductPriceSchedule struct {
+	ID        int         `json:"id" gorm:"primaryKey"`
+	ProductID int         `json:"productId" gorm:"not null;index"`
+	Product   *Product    `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Price     money.Money `json:"price" gorm:"not null"`
+	Discount  money.Money `json:"discount" gorm:"not null;default:0"`
+	Currency  string      `json:"currency" gorm:"size:3;not null"`
+	StartsAt  time.Time   `json:"startsAt" gorm:"not null;index"`
+	EndsAt    *time.Time  `json:"endsAt" gorm:"index"`
+	Status    string      `json:"status" gorm:"size:20;not null;default:'scheduled';index"`
+	// PreviousPrice and PreviousDiscount are the product's when a sale started
+	PreviousPrice    *money.Money `json:"previousPrice,omitempty"`
+	PreviousDiscount *money.Money `json:"previousDiscount,omitempty"`
+	CreatedByID      int          `json:"createdById" gorm:"not null"`
+	CreatedAt        time.Time    `json:"createdAt"`
+	UpdatedAt        time.Time    `json:"updatedAt"`
+}
+
+// IsPending reports whether the schedule has yet to start or end
+func (s *ProductPriceSchedule) IsPending() bool {
+	return s.Status == PriceScheduleScheduled || s.Status == PriceScheduleActive
+}
+
+// AfterFind restores the currency of the schedule's amounts
+func (s *ProductPriceSchedule) AfterFind(tx *gorm.DB) error {
+	for _, amount := range []*money.Money{&s.Price, &s.Discount, s.PreviousPrice, s.PreviousDiscount} {
+		if amount != nil {
+			amount.Currency = s.Currency
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/lib/pq"
+)
+
+// ProductOption is an option a product comes in, e.g. a size, with the values
+// its variants may take
+type ProductOption struct {
+	ID        int            `json:"id" gorm:"primaryKey"`
+	ProductID int            `json:"productId" gorm:"not null;uniqueIndex:idx_product_option_name"`
+	Name      string         `json:"name" gorm:"size:50;not null;uniqueIndex:idx_product_option_name"`
+	Values    pq.StringArray `json:"values" gorm:"type:text[];not null" swaggertype:"array,string" example:"[\"S\",\"M\",\"L\"]"`
+	Position  int            `json:"position" gorm:"not null;default:0"`
+}
+
+// ProductVariant is a sellable combination of a product's option values with
+// its own SKU, stock and images. A nil Price sells the variant at the product's
+// price; the product's discount applies either way.
+type ProductVariant struct {
+	ID        int               `json:"id" gorm:"primaryKey"`
+	ProductID int               `json:"productId" gorm:"not null;index"`
+	SKU       string            `json:"sku" gorm:"uniqueIndex;not null"`
+	Options   map[string]string `json:"options" gorm:"type:jsonb;serializer:json;not null" swaggertype:"object,string" example:"size:M"`
+	Price     *money.Money      `json:"price,omitempty"`
+	Stock     int               `json:"stock" gorm:"not null;default:0"`
+	// Available is the stock not held by basket reservations
+	Available int            `json:"available" gorm:"-"`
+	Images    pq.StringArray `json:"images" gorm:"type:text[]" swaggertype:"array,string" example:"[\"url1\",\"url2\"]"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// SetAvailable derives the available stock from the quantity held by reservations
+func (v *ProductVariant) SetAvailable(reserved int) {
+	v.Available = max(v.Stock-reserved, 0)
+}
+
+// Matches reports whether the variant has all the given option values
+func (v *ProductVariant) Matches(options map[string]string) bool {
+	for name, value := range options {
+		if v.Options[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// HasVariants reports whether the product is sold as variants
+func (p *Product) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// Variant returns the product's variant with the given ID, or nil
+func (p *Product) Variant(id int) *ProductVariant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+// VariantPrice returns the unit price of a variant of the product, or of the
+// product itself when variant is nil
+func (p *Product) VariantPrice(variant *ProductVariant) money.Money {
+	if variant != nil && variant.Price != nil {
+		return money.New(variant.Price.Amount, p.Currency)
+	}
+	return money.New(p.Price.Amount, p.Currency)
+}
+
+// VariantsMatching returns the product's variants having all the given option values
+func (p *Product) VariantsMatching(options map[string]string) []ProductVariant {
+	if len(options) == 0 {
+		return p.Variants
+	}
+	matching := make([]ProductVariant, 0, len(p.Variants))
+	for _, variant := range p.Variants {
+		if variant.Matches(options) {
+			matching = append(matching, variant)
+		}
+	}
+	return matching
+}
+
+// ValidateVariants checks the product's options and variants: options need a
+// name and distinct values, and every variant a SKU, non-negative stock, a
+// value for each option and no other, and a combination of values no other
+// variant has. Prices are checked against the discount by the caller. The
+// product's stock becomes the sum of its variants' stock.
+func (p *Product) ValidateVariants() error {
+	if len(p.Variants) > 0 && len(p.Options) == 0 {
+		return errors.New("variants need the product's options to be defined")
+	}
+
+	allowed := make(map[string][]string, len(p.Options))
+	for i := range p.Options {
+		option := &p.Options[i]
+		option.Name = strings.TrimSpace(option.Name)
+		if option.Name == "" {
+			return errors.New("options need a name")
+		}
+		if _, ok := allowed[option.Name]; ok {
+			return fmt.Errorf("option %q is defined twice", option.Name)
+		}
+		if len(option.Values) == 0 {
+			return fmt.Errorf("option %q needs at least one value", option.Name)
+		}
+		for j, value := range option.Values {
+			if value == "" || slices.Contains(option.Values[:j], value) {
+				return fmt.Errorf("option %q has an empty or repeated value", option.Name)
+			}
+		}
+		option.Position = i
+		allowed[option.Name] = option.Values
+	}
+
+	skus := make(map[string]bool, len(p.Variants))
+	combinations := make(map[string]bool, len(p.Variants))
+	stock := 0
+	for _, variant := range p.Variants {
+		if variant.SKU == "" {
+			return errors.New("variants need a SKU")
+		}
+		if skus[variant.SKU] {
+			return fmt.Errorf("SKU %q is used by two variants", variant.SKU)
+		}
+		skus[variant.SKU] = true
+		if variant.Stock < 0 {
+			return fmt.Errorf("variant %s cannot have negative stock", variant.SKU)
+		}
+
+		if len(variant.Options) != len(allowed) {
+			return fmt.Errorf("variant %s needs exactly one value for each option", variant.SKU)
+		}
+		for name, value := range variant.Options {
+			values, ok := allowed[name]
+			if !ok {
+				return fmt.Errorf("variant %s has unknown option %q", variant.SKU, name)
+			}
+			if !slices.Contains(values, value) {
+				return fmt.Errorf("variant %s has value %q not offered for option %q", variant.SKU, value, name)
+			}
+		}
+
+		combination := variantCombination(variant.Options)
+		if combinations[combination] {
+			return fmt.Errorf("two variants have the options %s", combination)
+		}
+		combinations[combination] = true
+		stock += variant.Stock
+	}
+
+	if len(p.Variants) > 0 {
+		p.Stock = stock
+	}
+	return nil
+}
+
+// variantCombination renders option values in a stable order, e.g. "color=red, size=M"
+func variantCombination(options map[string]string) string {
+	names := slices.Collect(maps.Keys(options))
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + options[name]
+	}
+	return strings.Join(parts, ", ")
+}
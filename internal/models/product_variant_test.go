@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductValidateVariants(t *testing.T) {
+	newProduct := func() *Product {
+		return &Product{
+			Stock: 1,
+			Options: []ProductOption{
+				{Name: "size", Values: []string{"S", "M"}},
+				{Name: " color ", Values: []string{"red"}},
+			},
+			Variants: []ProductVariant{
+				{SKU: "TEE-S-RED", Options: map[string]string{"size": "S", "color": "red"}, Stock: 3},
+				{SKU: "TEE-M-RED", Options: map[string]string{"size": "M", "color": "red"}, Stock: 4},
+			},
+		}
+	}
+
+	t.Run("valid variants set the product's stock", func(t *testing.T) {
+		product := newProduct()
+		require.NoError(t, product.ValidateVariants())
+		assert.Equal(t, 7, product.Stock)
+		assert.Equal(t, "color", product.Options[1].Name)
+		assert.Equal(t, 1, product.Options[1].Position)
+	})
+
+	t.Run("products without variants keep their stock", func(t *testing.T) {
+		product := &Product{Stock: 5}
+		require.NoError(t, product.ValidateVariants())
+		assert.Equal(t, 5, product.Stock)
+	})
+
+	invalid := map[string]func(p *Product){
+		"variants without options": func(p *Product) { p.Options = nil },
+		"unnamed option":           func(p *Product) { p.Options[0].Name = "" },
+		"repeated option":          func(p *Product) { p.Options[1].Name = "size" },
+		"option without values":    func(p *Product) { p.Options[1].Values = nil },
+		"repeated option value":    func(p *Product) { p.Options[0].Values = []string{"S", "S"} },
+		"variant without SKU":      func(p *Product) { p.Variants[0].SKU = "" },
+		"repeated SKU":             func(p *Product) { p.Variants[1].SKU = "TEE-S-RED" },
+		"negative stock":           func(p *Product) { p.Variants[0].Stock = -1 },
+		"missing option value":     func(p *Product) { delete(p.Variants[0].Options, "color") },
+		"unknown option":           func(p *Product) { p.Variants[0].Options["fit"] = "slim" },
+		"value not offered":        func(p *Product) { p.Variants[0].Options["size"] = "XL" },
+		"repeated combination":     func(p *Product) { p.Variants[1].Options["size"] = "S" },
+	}
+	for name, change := range invalid {
+		t.Run(name, func(t *testing.T) {
+			product := newProduct()
+			change(product)
+			assert.Error(t, product.ValidateVariants())
+		})
+	}
+}
+
+func TestProductVariantsMatching(t *testing.T) {
+	product := &Product{Variants: []ProductVariant{
+		{ID: 1, Options: map[string]string{"size": "S", "color": "red"}},
+		{ID: 2, Options: map[string]string{"size": "M", "color": "red"}},
+		{ID: 3, Options: map[string]string{"size": "M", "color": "blue"}},
+	}}
+
+	assert.Len(t, product.VariantsMatching(nil), 3)
+	assert.Len(t, product.VariantsMatching(map[string]string{"color": "red"}), 2)
+
+	matching := product.VariantsMatching(map[string]string{"size": "M", "color": "blue"})
+	require.Len(t, matching, 1)
+	assert.Equal(t, 3, matching[0].ID)
+
+	assert.Empty(t, product.VariantsMatching(map[string]string{"size": "XL"}))
+	assert.Equal(t, 2, product.Variant(2).ID)
+	assert.Nil(t, product.Variant(9))
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ProductView counts the views of a product in an hour, from which trending
+// products are ranked. The total is kept in Product.Views.
+type ProductView struct {
+	ProductID int       `json:"productId" gorm:"primaryKey;autoIncrement:false"`
+	Product   Product   `json:"-" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Hour      time.Time `json:"hour" gorm:"primaryKey;index"`
+	Views     int       `json:"views" gorm:"not null"`
+}
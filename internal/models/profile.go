@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Profile struct {
 	ID        int    `json:"id" gorm:"primaryKey"`
@@ -8,7 +12,9 @@ type Profile struct {
 	User      User   `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
 	Bio       string `json:"bio" gorm:"type:text"`
 	AvatarURL string `json:"avatarUrl" gorm:"size:500"`
-	Phone     string `json:"phone" gorm:"size:25"`
+	// AvatarSrcset links to the resized variants of the avatar, by size name
+	AvatarSrcset map[string]string `json:"avatarSrcset,omitempty" gorm:"-"`
+	Phone        string            `json:"phone" gorm:"size:25"`
 
 	DateOfBirth *time.Time `json:"dateOfBirth"`
 	Country     string     `json:"country" gorm:"size:100"`
@@ -28,3 +34,15 @@ type Profile struct {
 	CreatedAt          time.Time `json:"createdAt"`
 	UpdatedAt          time.Time `json:"updatedAt"`
 }
+
+// AfterFind links to the resized variants of the avatar
+func (p *Profile) AfterFind(tx *gorm.DB) error {
+	p.AvatarSrcset = Srcset(p.AvatarURL)
+	return nil
+}
+
+// AfterSave links to the resized variants of the avatar
+func (p *Profile) AfterSave(tx *gorm.DB) error {
+	p.AvatarSrcset = Srcset(p.AvatarURL)
+	return nil
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Review is a user's rating of a product from 1 to 5 with an optional title and
+// body. A user reviews a product at most once.
+type Review struct {
+	ID        int    `json:"id" gorm:"primaryKey"`
+	ProductID int    `json:"productId" gorm:"not null;uniqueIndex:idx_review_product_user"`
+	UserID    int    `json:"userId" gorm:"not null;uniqueIndex:idx_review_product_user;index"`
+	User      *User  `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Rating    int    `json:"rating" gorm:"not null;check:rating BETWEEN 1 AND 5"`
+	Title     string `json:"title" gorm:"size:200"`
+	Body      string `json:"body" gorm:"type:text"`
+	// VerifiedPurchase is set when the reviewer bought the product
+	VerifiedPurchase bool      `json:"verifiedPurchase" gorm:"not null;default:false"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
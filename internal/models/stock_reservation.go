@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// StockReservation holds stock for a basket item until it expires. A basket has
+// at most one reservation per product variant, covering the item's whole
+// quantity. VariantID is zero for products sold without variants.
+type StockReservation struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	BasketID  int       `json:"basketId" gorm:"not null;uniqueIndex:idx_reservation_basket_item"`
+	ProductID int       `json:"productId" gorm:"not null;uniqueIndex:idx_reservation_basket_item;index"`
+	VariantID int       `json:"variantId" gorm:"not null;default:0;uniqueIndex:idx_reservation_basket_item;index"`
+	Quantity  int       `json:"quantity" gorm:"not null;check:quantity > 0"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null;index"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
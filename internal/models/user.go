@@ -4,11 +4,23 @@ import (
 	"time"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User represents a user in the system
 type User struct {
 	ID        int        `json:"id" gorm:"primaryKey"`
 	Email     string     `json:"email" gorm:"uniqueIndex;not null"`
 	Name      string     `json:"name" gorm:"not null"`
 	Password  string     `json:"-" gorm:"not null"` // Never expose password in JSON
+	Role      string     `json:"role" gorm:"size:20;not null;default:'user'"`
 	LastLogin *time.Time `json:"lastLogin"`
 }
+
+// IsAdmin reports whether the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// SaveForLaterWishlist is the wishlist basket items are saved to for later
+const SaveForLaterWishlist = "Saved for later"
+
+// Wishlist is a named list of products a user keeps without reserving them.
+// Anyone with its share token can view it.
+type Wishlist struct {
+	ID         int            `json:"id" gorm:"primaryKey"`
+	UserID     int            `json:"userId" gorm:"not null;uniqueIndex:idx_wishlist_user_name"`
+	User       User           `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Name       string         `json:"name" gorm:"size:100;not null;uniqueIndex:idx_wishlist_user_name"`
+	ShareToken *string        `json:"shareToken,omitempty" gorm:"size:64;uniqueIndex"`
+	Items      []WishlistItem `json:"items" gorm:"foreignKey:WishlistID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	UpdatedAt  time.Time      `json:"updatedAt"`
+}
+
+// WishlistItem is a product kept on a wishlist
+type WishlistItem struct {
+	ID         int       `json:"id" gorm:"primaryKey"`
+	WishlistID int       `json:"wishlistId" gorm:"not null;uniqueIndex:idx_wishlist_product"`
+	ProductID  int       `json:"productId" gorm:"not null;uniqueIndex:idx_wishlist_product;index"`
+	Product    Product   `json:"product" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// HasProduct reports whether the product is on the wishlist
+func (w *Wishlist) HasProduct(productID int) bool {
+	for _, item := range w.Items {
+		if item.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
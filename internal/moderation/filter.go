@@ -0,0 +1,64 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter holds comments whose content matches a blocked word or pattern for review
+type Filter struct {
+	rules []rule
+}
+
+type rule struct {
+	term string
+	re   *regexp.Regexp
+}
+
+// NewFilter compiles a filter from blocked words and regular expressions.
+// Words match case-insensitively on word boundaries; patterns are used as given.
+func NewFilter(words []string, patterns []string) (*Filter, error) {
+	f := &Filter{}
+
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(word) + `\b`)
+		f.rules = append(f.rules, rule{term: word, re: re})
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid comment filter pattern %q: %w", pattern, err)
+		}
+		f.rules = append(f.rules, rule{term: pattern, re: re})
+	}
+
+	return f, nil
+}
+
+// Match reports whether the content matches any rule and returns the first matching term
+func (f *Filter) Match(content string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	for _, r := range f.rules {
+		if r.re.MatchString(content) {
+			return r.term, true
+		}
+	}
+	return "", false
+}
+
+// Empty reports whether the filter has no rules
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.rules) == 0
+}
@@ -0,0 +1,45 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMatch(t *testing.T) {
+	f, err := NewFilter([]string{"spam", " "}, []string{`buy\s+now`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		content string
+		term    string
+		matched bool
+	}{
+		{name: "word matches case-insensitively", content: "This is SPAM!", term: "spam", matched: true},
+		{name: "word respects boundaries", content: "spammer here", matched: false},
+		{name: "pattern matches", content: "buy   now please", term: `buy\s+now`, matched: true},
+		{name: "clean content", content: "See you at the event", matched: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term, matched := f.Match(tt.content)
+			assert.Equal(t, tt.matched, matched)
+			assert.Equal(t, tt.term, term)
+		})
+	}
+}
+
+func TestFilterInvalidPattern(t *testing.T) {
+	_, err := NewFilter(nil, []string{"("})
+	assert.Error(t, err)
+}
+
+func TestNilFilter(t *testing.T) {
+	var f *Filter
+	_, matched := f.Match("anything")
+	assert.False(t, matched)
+	assert.True(t, f.Empty())
+}
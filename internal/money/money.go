@@ -0,0 +1,355 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// currencyPattern matches an ISO 4217 currency code
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// exponents lists currencies whose minor unit is not 1/100 of the major unit
+var exponents = map[string]int{
+	"BHD": 3, "CLP": 0, "ISK": 0, "JOD": 3, "JPY": 0,
+	"KRW": 0, "KWD": 3, "OMR": 3, "TND": 3, "VND": 0,
+}
+
+// symbols lists currency symbols used when formatting
+var symbols = map[string]string{
+	"EUR": "€", "GBP": "£", "JPY": "¥", "USD": "$",
+}
+
+// Money is an exact amount in the minor unit of an ISO 4217 currency, e.g.
+// cents for USD. In the database only the amount is stored, as a bigint; the
+// model owning the column keeps the currency in its own column.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New creates an amount in minor units of a currency
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns a zero amount in a currency
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Parse reads a decimal amount in major units, e.g. "19.99", exactly. It fails
+// if the amount has more decimals than the currency's minor unit.
+func Parse(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if err := ValidateCurrency(currency); err != nil {
+		return Money{}, err
+	}
+
+	amount = strings.TrimSpace(amount)
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(strings.TrimPrefix(amount, "-"), "+")
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	exp := Exponent(currency)
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimals for %s", amount, exp, currency)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	digits := whole + frac
+	if strings.Trim(digits, "0123456789") != "" {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// ValidateCurrency checks that a code looks like an ISO 4217 currency
+func ValidateCurrency(currency string) error {
+	if !currencyPattern.MatchString(currency) {
+		return fmt.Errorf("invalid currency %q", currency)
+	}
+	return nil
+}
+
+// Exponent returns the number of decimals of a currency's minor unit
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// SameCurrency reports whether both amounts are in the same currency
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency
+}
+
+// Add returns m + other. A zero amount without a currency adopts the other's
+// currency, so sums can start from Money{}.
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.combine(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+// Sub returns m - other
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.combine(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, nil
+}
+
+// Cmp compares two amounts, returning -1, 0 or 1
+func (m Money) Cmp(other Money) (int, error) {
+	if _, err := m.combine(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Mul returns the amount multiplied by a quantity
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// MulRatio returns the amount multiplied by num/den, rounding half away from zero
+func (m Money) MulRatio(num, den int64) Money {
+	return Money{Amount: divRound(m.Amount*num, den), Currency: m.Currency}
+}
+
+// Percent returns a percentage of the amount given in basis points
+// (1/100 of a percent), rounding half away from zero
+func (m Money) Percent(basisPoints int64) Money {
+	return m.MulRatio(basisPoints, 10000)
+}
+
+// Allocate splits the amount by ratios without losing minor units; the
+// remainder goes one unit at a time to the first parts
+func (m Money) Allocate(ratios ...int64) []Money {
+	var total int64
+	for _, ratio := range ratios {
+		total += ratio
+	}
+
+	parts := make([]Money, len(ratios))
+	if total == 0 {
+		for i := range parts {
+			parts[i] = Money{Currency: m.Currency}
+		}
+		return parts
+	}
+
+	remainder := m.Amount
+	for i, ratio := range ratios {
+		share := m.Amount * ratio / total
+		parts[i] = Money{Amount: share, Currency: m.Currency}
+		remainder -= share
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(parts) {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].Amount += step
+		remainder -= step
+	}
+	return parts
+}
+
+// Decimal returns the amount in major units, e.g. "19.99"
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Format returns the amount for display, e.g. "$19.99" or "CHF 19.99"
+func (m Money) Format() string {
+	decimal := m.Decimal()
+	sign := ""
+	if strings.HasPrefix(decimal, "-") {
+		sign = "-"
+		decimal = decimal[1:]
+	}
+
+	if symbol, ok := symbols[m.Currency]; ok {
+		return sign + symbol + decimal
+	}
+	if m.Currency == "" {
+		return sign + decimal
+	}
+	return sign + m.Currency + " " + decimal
+}
+
+// String implements fmt.Stringer
+func (m Money) String() string {
+	return m.Format()
+}
+
+// jsonMoney is the JSON form of Money
+type jsonMoney struct {
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Formatted string `json:"formatted,omitempty"`
+}
+
+// MarshalJSON renders the amount in minor units, the currency and a formatted string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.Amount, Currency: m.Currency, Formatted: m.Format()})
+}
+
+// UnmarshalJSON reads {"amount": <minor units>, "currency": "USD"}. The currency
+// may be omitted when the owning model supplies it.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*m = Money{}
+		return nil
+	}
+
+	var v jsonMoney
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.New("money must be an object with an integer amount in minor units and a currency")
+	}
+
+	currency := strings.ToUpper(v.Currency)
+	if currency != "" {
+		if err := ValidateCurrency(currency); err != nil {
+			return err
+		}
+	}
+
+	*m = Money{Amount: v.Amount, Currency: currency}
+	return nil
+}
+
+// GormDataType stores money as a bigint of minor units
+func (Money) GormDataType() string {
+	return "bigint"
+}
+
+// Value implements driver.Valuer, storing the amount in minor units
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan implements sql.Scanner, reading an amount in minor units. The currency
+// is left for the owning model to set.
+func (m *Money) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		m.Amount = 0
+	case int64:
+		m.Amount = v
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money", value)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into money: %w", s, err)
+	}
+	m.Amount = amount
+	return nil
+}
+
+// combine returns the currency of an operation on m and other
+func (m Money) combine(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency, nil
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+}
+
+// divRound divides n by d, rounding half away from zero
+func divRound(n, d int64) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	q, r := n/d, n%d
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= d {
+		if n < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
@@ -0,0 +1,117 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{"19.99", "USD", 1999, false},
+		{"19.9", "usd", 1990, false},
+		{"19", "USD", 1900, false},
+		{".5", "USD", 50, false},
+		{"-0.01", "EUR", -1, false},
+		{"1500", "JPY", 1500, false},
+		{"1.234", "KWD", 1234, false},
+		{"0.1 + 0.2", "USD", 0, true},
+		{"19.999", "USD", 0, true},
+		{"1.5", "JPY", 0, true},
+		{"", "USD", 0, true},
+		{"10", "US", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.amount, tt.currency)
+		if tt.wantErr {
+			assert.Error(t, err, tt.amount)
+			continue
+		}
+		require.NoError(t, err, tt.amount)
+		assert.Equal(t, tt.want, got.Amount, tt.amount)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 in float64 is 0.30000000000000004
+	sum, err := New(10, "USD").Add(New(20, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, New(30, "USD"), sum)
+
+	total := Money{}
+	for i := 0; i < 3; i++ {
+		total, err = total.Add(New(333, "USD"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, New(999, "USD"), total)
+
+	_, err = New(100, "USD").Add(New(100, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = New(100, "USD").Cmp(New(100, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	diff, err := New(1000, "USD").Sub(New(1999, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(-999), diff.Amount)
+
+	assert.Equal(t, int64(2997), New(999, "USD").Mul(3).Amount)
+}
+
+func TestRounding(t *testing.T) {
+	// 15% of 9.99 is 1.4985, rounded half away from zero
+	assert.Equal(t, int64(150), New(999, "USD").Percent(1500).Amount)
+	assert.Equal(t, int64(-150), New(-999, "USD").Percent(1500).Amount)
+	assert.Equal(t, int64(1), New(1, "USD").MulRatio(1, 2).Amount)
+	assert.Equal(t, int64(0), New(1, "USD").MulRatio(1, 3).Amount)
+}
+
+func TestAllocate(t *testing.T) {
+	parts := New(100, "USD").Allocate(1, 1, 1)
+	assert.Equal(t, []int64{34, 33, 33}, []int64{parts[0].Amount, parts[1].Amount, parts[2].Amount})
+
+	parts = New(-5, "USD").Allocate(1, 0, 1)
+	assert.Equal(t, []int64{-3, 0, -2}, []int64{parts[0].Amount, parts[1].Amount, parts[2].Amount})
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "$19.99", New(1999, "USD").Format())
+	assert.Equal(t, "-$0.05", New(-5, "USD").Format())
+	assert.Equal(t, "¥1500", New(1500, "JPY").Format())
+	assert.Equal(t, "CHF 1.00", New(100, "CHF").Format())
+	assert.Equal(t, "1.234", New(1234, "KWD").Decimal())
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(1999, "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1999,"currency":"USD","formatted":"$19.99"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":250,"currency":"eur"}`), &m))
+	assert.Equal(t, New(250, "EUR"), m)
+
+	assert.Error(t, json.Unmarshal([]byte(`19.99`), &m))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":1.5,"currency":"USD"}`), &m))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":1,"currency":"dollars"}`), &m))
+}
+
+func TestScanValue(t *testing.T) {
+	value, err := New(1999, "USD").Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1999), value)
+
+	var m Money
+	require.NoError(t, m.Scan(int64(42)))
+	assert.Equal(t, int64(42), m.Amount)
+	require.NoError(t, m.Scan([]byte("7")))
+	assert.Equal(t, int64(7), m.Amount)
+	assert.Error(t, m.Scan(1.5))
+}
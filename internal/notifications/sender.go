@@ -0,0 +1,49 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+// Channel is an out-of-app delivery channel for notifications
+type Channel string
+
+// Delivery channels
+const (
+	ChannelEmail Channel = "email"
+	ChannelPush  Channel = "push"
+)
+
+// Sender delivers a stored notification over an out-of-app channel
+type Sender interface {
+	Send(ctx context.Context, channel Channel, notification *models.Notification) error
+}
+
+// LogSender records deliveries in the application log. It is the default
+// sender until an email or push provider is configured.
+type LogSender struct{}
+
+// Send logs the delivery
+func (LogSender) Send(ctx context.Context, channel Channel, notification *models.Notification) error {
+	logging.Info(ctx, "notification delivered", "channel", string(channel), "notification_id", notification.ID, "user_id", notification.UserID)
+	return nil
+}
+
+// Channels returns the out-of-app channels enabled by a user's profile.
+// Users without a profile get the profile defaults, which enable both.
+func Channels(profile *models.Profile) []Channel {
+	if profile == nil {
+		return []Channel{ChannelEmail, ChannelPush}
+	}
+
+	var channels []Channel
+	if profile.EmailNotifications {
+		channels = append(channels, ChannelEmail)
+	}
+	if profile.PushNotifications {
+		channels = append(channels, ChannelPush)
+	}
+	return channels
+}
@@ -0,0 +1,167 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+)
+
+// FakeProviderName is the name recorded for payments made through FakeProvider
+const FakeProviderName = "fake"
+
+// FakeProvider is a deterministic in-memory provider for local development and
+// tests. Intent IDs are derived from the order and a per-provider sequence, and
+// webhooks are signed with HMAC-SHA256 of the payload using the webhook secret.
+type FakeProvider struct {
+	secret string
+
+	mu      sync.Mutex
+	seq     int
+	intents map[string]*Intent
+}
+
+// fakeWebhook is the JSON body of a fake provider webhook
+type fakeWebhook struct {
+	ID       string      `json:"id"`
+	Type     string      `json:"type"`
+	IntentID string      `json:"intentId"`
+	Amount   money.Money `json:"amount"`
+	Reason   string      `json:"reason,omitempty"`
+}
+
+// NewFakeProvider creates a fake provider that signs webhooks with secret
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{secret: secret, intents: make(map[string]*Intent)}
+}
+
+// Name returns the provider name
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+// CreateIntent creates an intent that waits for confirmation
+func (p *FakeProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	id := fmt.Sprintf("pi_fake_%d_%d", req.OrderID, p.seq)
+	intent := &Intent{
+		ID:           id,
+		ClientSecret: id + "_secret",
+		Status:       IntentStatusRequiresConfirmation,
+		Amount:       req.Amount,
+	}
+	p.intents[id] = intent
+
+	copied := *intent
+	return &copied, nil
+}
+
+// Capture marks an intent as succeeded
+func (p *FakeProvider) Capture(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		// Intents created before a restart are unknown; treat them as capturable
+		intent = &Intent{ID: intentID}
+		p.intents[intentID] = intent
+	}
+	if intent.Status == IntentStatusFailed || intent.Status == IntentStatusCanceled {
+		return nil, fmt.Errorf("intent %s has %s", intentID, intent.Status)
+	}
+	intent.Status = IntentStatusSucceeded
+
+	copied := *intent
+	return &copied, nil
+}
+
+// Cancel marks an intent that was not captured as canceled
+func (p *FakeProvider) Cancel(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		intent = &Intent{ID: intentID}
+		p.intents[intentID] = intent
+	}
+	if intent.Status == IntentStatusSucceeded {
+		return nil, fmt.Errorf("intent %s was already captured", intentID)
+	}
+	intent.Status = IntentStatusCanceled
+
+	copied := *intent
+	return &copied, nil
+}
+
+// Refund refunds an amount of an intent
+func (p *FakeProvider) Refund(ctx context.Context, intentID string, amount money.Money) (*Refund, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("refund amount must be positive")
+	}
+	return &Refund{ID: "re_" + intentID, IntentID: intentID, Amount: amount}, nil
+}
+
+// VerifyWebhook checks the hex HMAC-SHA256 signature of the payload
+func (p *FakeProvider) VerifyWebhook(payload []byte, signature string) error {
+	if p.secret == "" {
+		return errors.New("webhook secret not configured")
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.mac(payload)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseWebhook decodes a fake webhook payload
+func (p *FakeProvider) ParseWebhook(payload []byte) (*WebhookEvent, error) {
+	var body fakeWebhook
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if body.ID == "" || body.Type == "" || body.IntentID == "" {
+		return nil, errors.New("invalid webhook payload: id, type and intentId are required")
+	}
+
+	return &WebhookEvent{
+		ID:       body.ID,
+		Type:     body.Type,
+		IntentID: body.IntentID,
+		Amount:   body.Amount,
+		Reason:   body.Reason,
+	}, nil
+}
+
+// Webhook builds a signed webhook payload for an event, as the provider would send it
+func (p *FakeProvider) Webhook(event WebhookEvent) (payload []byte, signature string) {
+	payload, _ = json.Marshal(fakeWebhook{
+		ID:       event.ID,
+		Type:     event.Type,
+		IntentID: event.IntentID,
+		Amount:   event.Amount,
+		Reason:   event.Reason,
+	})
+	return payload, hex.EncodeToString(p.mac(payload))
+}
+
+func (p *FakeProvider) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,57 @@
+package payments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProviderIntents(t *testing.T) {
+	p := NewFakeProvider("secret")
+	ctx := context.Background()
+
+	first, err := p.CreateIntent(ctx, IntentRequest{OrderID: 7, Amount: money.New(1250, "USD")})
+	require.NoError(t, err)
+	assert.Equal(t, "pi_fake_7_1", first.ID)
+	assert.Equal(t, IntentStatusRequiresConfirmation, first.Status)
+
+	second, err := p.CreateIntent(ctx, IntentRequest{OrderID: 7, Amount: money.New(1250, "USD")})
+	require.NoError(t, err)
+	assert.Equal(t, "pi_fake_7_2", second.ID)
+
+	captured, err := p.Capture(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, IntentStatusSucceeded, captured.Status)
+
+	canceled, err := p.Cancel(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, IntentStatusCanceled, canceled.Status)
+	_, err = p.Capture(ctx, second.ID)
+	assert.Error(t, err, "canceled intents cannot be captured")
+	_, err = p.Cancel(ctx, first.ID)
+	assert.Error(t, err, "captured intents cannot be canceled")
+
+	_, err = p.CreateIntent(ctx, IntentRequest{OrderID: 8})
+	assert.Error(t, err)
+}
+
+func TestFakeProviderWebhooks(t *testing.T) {
+	p := NewFakeProvider("secret")
+
+	payload, signature := p.Webhook(WebhookEvent{ID: "evt_1", Type: EventPaymentSucceeded, IntentID: "pi_fake_7_1", Amount: money.New(1250, "USD")})
+	require.NoError(t, p.VerifyWebhook(payload, signature))
+
+	event, err := p.ParseWebhook(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, EventPaymentSucceeded, event.Type)
+	assert.Equal(t, "pi_fake_7_1", event.IntentID)
+	assert.Equal(t, money.New(1250, "USD"), event.Amount)
+
+	assert.ErrorIs(t, p.VerifyWebhook(payload, "00"+signature[2:]), ErrInvalidSignature)
+	assert.ErrorIs(t, p.VerifyWebhook(append(payload, ' '), signature), ErrInvalidSignature)
+	assert.Error(t, NewFakeProvider("").VerifyWebhook(payload, signature))
+}
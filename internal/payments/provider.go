@@ -0,0 +1,87 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+)
+
+// Intent statuses
+const (
+	IntentStatusRequiresConfirmation = "requires_confirmation"
+	IntentStatusRequiresCapture      = "requires_capture"
+	IntentStatusSucceeded            = "succeeded"
+	IntentStatusFailed               = "failed"
+	IntentStatusCanceled             = "canceled"
+)
+
+// Webhook event types
+const (
+	EventPaymentAuthorized = "payment.authorized"
+	EventPaymentSucceeded  = "payment.succeeded"
+	EventPaymentFailed     = "payment.failed"
+	EventRefundSucceeded   = "refund.succeeded"
+)
+
+// ErrInvalidSignature is returned when a webhook signature does not match its payload
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// IntentRequest describes the payment to collect for an order
+type IntentRequest struct {
+	OrderID int
+	Amount  money.Money
+}
+
+// Intent is a provider-side payment for an order
+type Intent struct {
+	ID           string
+	ClientSecret string
+	Status       string
+	Amount       money.Money
+}
+
+// Refund is a provider-side refund of a captured payment
+type Refund struct {
+	ID       string
+	IntentID string
+	Amount   money.Money
+}
+
+// WebhookEvent is a parsed provider notification
+type WebhookEvent struct {
+	ID       string
+	Type     string
+	IntentID string
+	Amount   money.Money
+	Reason   string
+}
+
+// PaymentProvider collects and refunds payments through an external provider
+type PaymentProvider interface {
+	// Name identifies the provider in stored payment records
+	Name() string
+	// CreateIntent starts collecting a payment
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+	// Capture collects an authorized payment
+	Capture(ctx context.Context, intentID string) (*Intent, error)
+	// Cancel releases an intent that was not captured, e.g. an authorization
+	Cancel(ctx context.Context, intentID string) (*Intent, error)
+	// Refund returns an amount of a captured payment to the buyer
+	Refund(ctx context.Context, intentID string, amount money.Money) (*Refund, error)
+	// VerifyWebhook checks that a webhook payload was sent by the provider
+	VerifyWebhook(payload []byte, signature string) error
+	// ParseWebhook decodes a verified webhook payload
+	ParseWebhook(payload []byte) (*WebhookEvent, error)
+}
+
+// NewProvider creates the named payment provider
+func NewProvider(name, webhookSecret string) (PaymentProvider, error) {
+	switch name {
+	case FakeProviderName:
+		return NewFakeProvider(webhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
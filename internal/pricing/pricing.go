@@ -0,0 +1,285 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+)
+
+// Line is a priced line of a basket or order
+type Line struct {
+	ProductID int
+	Quantity  int
+	// UnitPrice is the list price and UnitDiscount the product discount per unit
+	UnitPrice    money.Money
+	UnitDiscount money.Money
+	// CategoryIDs are the product's categories, used by restricted coupons
+	CategoryIDs []int
+}
+
+// ErrCouponNotApplicable is returned when a coupon cannot discount the lines
+var ErrCouponNotApplicable = errors.New("coupon not applicable")
+
+// Totals is the price breakdown of a basket or order. Discount includes the
+// coupon discount and tax is charged on the subtotal after discounts.
+type Totals struct {
+	Subtotal       money.Money `json:"subtotal"`
+	Discount       money.Money `json:"discount"`
+	CouponDiscount money.Money `json:"couponDiscount"`
+	CouponCode     string      `json:"couponCode,omitempty"`
+	Tax            money.Money `json:"tax"`
+	Total          money.Money `json:"total"`
+	// TaxRate is the applied rate in percent, e.g. "19.00"
+	TaxRate string `json:"taxRate"`
+	Country string `json:"country,omitempty"`
+}
+
+// QuoteFunc prices a set of lines with an optional coupon
+type QuoteFunc func(lines []Line, coupon *models.Coupon) (*Totals, error)
+
+// Engine prices baskets and orders using tax rates by country. The zero value
+// charges no tax.
+type Engine struct {
+	// rates are basis points (1/100 of a percent) by upper-cased country
+	rates       map[string]int64
+	defaultRate int64
+}
+
+// NewEngine creates an engine from "COUNTRY:PERCENT" tax rules, e.g. "DE:19",
+// and the rate for countries without a rule
+func NewEngine(taxRules []string, defaultRate string) (*Engine, error) {
+	e := &Engine{rates: make(map[string]int64)}
+
+	if strings.TrimSpace(defaultRate) != "" {
+		rate, err := parseRate(defaultRate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default tax rate: %w", err)
+		}
+		e.defaultRate = rate
+	}
+
+	for _, rule := range taxRules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		country, percent, ok := strings.Cut(rule, ":")
+		country = normalizeCountry(country)
+		if !ok || country == "" {
+			return nil, fmt.Errorf("invalid tax rule %q: expected COUNTRY:PERCENT", rule)
+		}
+		rate, err := parseRate(percent)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tax rule %q: %w", rule, err)
+		}
+		e.rates[country] = rate
+	}
+
+	return e, nil
+}
+
+// TaxRate returns the tax rate for a country in basis points
+func (e *Engine) TaxRate(country string) int64 {
+	if e == nil {
+		return 0
+	}
+	if rate, ok := e.rates[normalizeCountry(country)]; ok {
+		return rate
+	}
+	return e.defaultRate
+}
+
+// Quote prices lines for a buyer in a country with an optional coupon. All
+// lines must share a currency. Coupon validity and usage limits are the
+// caller's concern; Quote only checks the coupon applies to the lines.
+func (e *Engine) Quote(lines []Line, country string, coupon *models.Coupon) (*Totals, error) {
+	var subtotal, discount money.Money
+	for _, line := range lines {
+		if line.UnitDiscount.Amount > line.UnitPrice.Amount {
+			return nil, fmt.Errorf("discount of product %d exceeds its price", line.ProductID)
+		}
+
+		var err error
+		if subtotal, err = subtotal.Add(line.UnitPrice.Mul(int64(line.Quantity))); err != nil {
+			return nil, err
+		}
+		if discount, err = discount.Add(line.UnitDiscount.Mul(int64(line.Quantity))); err != nil {
+			return nil, err
+		}
+	}
+
+	// Discounts carry the subtotal's currency even when there are none
+	discount.Currency = subtotal.Currency
+
+	taxable, err := subtotal.Sub(discount)
+	if err != nil {
+		return nil, err
+	}
+
+	couponDiscount := money.Zero(subtotal.Currency)
+	couponCode := ""
+	if coupon != nil {
+		if couponDiscount, err = CouponDiscount(lines, taxable, coupon); err != nil {
+			return nil, err
+		}
+		couponCode = coupon.Code
+		if discount, err = discount.Add(couponDiscount); err != nil {
+			return nil, err
+		}
+		if taxable, err = taxable.Sub(couponDiscount); err != nil {
+			return nil, err
+		}
+	}
+
+	rate := e.TaxRate(country)
+	tax := taxable.Percent(rate)
+	total, err := taxable.Add(tax)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Totals{
+		Subtotal:       subtotal,
+		Discount:       discount,
+		CouponDiscount: couponDiscount,
+		CouponCode:     couponCode,
+		Tax:            tax,
+		Total:          total,
+		TaxRate:        formatRate(rate),
+		Country:        strings.TrimSpace(country),
+	}, nil
+}
+
+// CouponDiscount returns what a coupon takes off lines whose subtotal after
+// product discounts is net. Restricted coupons only discount matching lines and
+// fixed discounts never exceed the amount they apply to.
+func CouponDiscount(lines []Line, net money.Money, coupon *models.Coupon) (money.Money, error) {
+	if coupon.Currency != "" && len(lines) > 0 && coupon.Currency != net.Currency {
+		return money.Money{}, fmt.Errorf("%w: %s is only valid for %s baskets", ErrCouponNotApplicable, coupon.Code, coupon.Currency)
+	}
+	if cmp, err := net.Cmp(coupon.MinSpend); err != nil {
+		return money.Money{}, err
+	} else if cmp < 0 {
+		return money.Money{}, fmt.Errorf("%w: %s requires a minimum spend of %s", ErrCouponNotApplicable, coupon.Code, coupon.MinSpend.Format())
+	}
+
+	eligible := money.Zero(net.Currency)
+	for _, line := range lines {
+		if !couponCovers(coupon, line) {
+			continue
+		}
+		lineNet, err := line.UnitPrice.Sub(line.UnitDiscount)
+		if err != nil {
+			return money.Money{}, err
+		}
+		if eligible, err = eligible.Add(lineNet.Mul(int64(line.Quantity))); err != nil {
+			return money.Money{}, err
+		}
+	}
+	if !eligible.IsPositive() {
+		return money.Money{}, fmt.Errorf("%w: %s does not apply to any item in the basket", ErrCouponNotApplicable, coupon.Code)
+	}
+
+	switch coupon.Type {
+	case models.CouponTypePercentage:
+		return eligible.Percent(int64(coupon.PercentOff) * 100), nil
+	case models.CouponTypeFixed:
+		cmp, err := coupon.AmountOff.Cmp(eligible)
+		if err != nil {
+			return money.Money{}, err
+		}
+		if cmp > 0 {
+			return eligible, nil
+		}
+		return coupon.AmountOff, nil
+	}
+	return money.Money{}, fmt.Errorf("%w: unknown coupon type %q", ErrCouponNotApplicable, coupon.Type)
+}
+
+// couponCovers reports whether a coupon applies to a line
+func couponCovers(coupon *models.Coupon, line Line) bool {
+	if !coupon.IsRestricted() {
+		return true
+	}
+	for _, product := range coupon.Products {
+		if product.ID == line.ProductID {
+			return true
+		}
+	}
+	for _, category := range coupon.Categories {
+		for _, id := range line.CategoryIDs {
+			if category.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// QuoteFor returns a QuoteFunc pricing lines for a buyer in a country
+func (e *Engine) QuoteFor(country string) QuoteFunc {
+	return func(lines []Line, coupon *models.Coupon) (*Totals, error) {
+		return e.Quote(lines, country, coupon)
+	}
+}
+
+// BasketLines returns the priced lines of a basket at its snapshot prices,
+// with the categories of any loaded products
+func BasketLines(basket *models.Basket) []Line {
+	lines := make([]Line, 0, len(basket.Items))
+	for _, item := range basket.Items {
+		var categoryIDs []int
+		for _, category := range item.Product.Categories {
+			categoryIDs = append(categoryIDs, category.ID)
+		}
+		lines = append(lines, Line{
+			ProductID:    item.ProductID,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+			UnitDiscount: item.UnitDiscount,
+			CategoryIDs:  categoryIDs,
+		})
+	}
+	return lines
+}
+
+// FinalPrice derives a product's final price from its price and discount
+func FinalPrice(price, discount money.Money) (money.Money, error) {
+	final, err := price.Sub(discount)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if final.IsNegative() {
+		return money.Money{}, fmt.Errorf("discount %s exceeds price %s", discount, price)
+	}
+	return final, nil
+}
+
+func normalizeCountry(country string) string {
+	return strings.ToUpper(strings.TrimSpace(country))
+}
+
+// parseRate reads a percentage with up to two decimals into basis points
+func parseRate(percent string) (int64, error) {
+	percent = strings.TrimSpace(percent)
+	whole, frac, _ := strings.Cut(percent, ".")
+	if whole == "" || len(frac) > 2 {
+		return 0, fmt.Errorf("rate %q must be a percentage with at most two decimals", percent)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	rate, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || rate < 0 || rate > 10000 {
+		return 0, fmt.Errorf("rate %q must be a percentage between 0 and 100", percent)
+	}
+	return rate, nil
+}
+
+// formatRate renders basis points as a percentage, e.g. 1950 as "19.50"
+func formatRate(rate int64) string {
+	return fmt.Sprintf("%d.%02d", rate/100, rate%100)
+}
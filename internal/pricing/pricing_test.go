@@ -0,0 +1,121 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEngine(t *testing.T) {
+	e, err := NewEngine([]string{"DE:19", " fr : 20 ", "HU:27.5", ""}, "7.25")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1900), e.TaxRate("de"))
+	assert.Equal(t, int64(2000), e.TaxRate("FR"))
+	assert.Equal(t, int64(2750), e.TaxRate("HU"))
+	assert.Equal(t, int64(725), e.TaxRate("US"))
+	assert.Equal(t, int64(725), e.TaxRate(""))
+
+	for _, rules := range [][]string{{"DE"}, {":19"}, {"DE:abc"}, {"DE:19.125"}, {"DE:101"}, {"DE:-1"}} {
+		_, err := NewEngine(rules, "")
+		assert.Error(t, err, rules)
+	}
+	_, err = NewEngine(nil, "x")
+	assert.Error(t, err)
+}
+
+func TestQuote(t *testing.T) {
+	e, err := NewEngine([]string{"DE:19"}, "")
+	require.NoError(t, err)
+
+	lines := []Line{
+		{ProductID: 1, Quantity: 3, UnitPrice: money.New(999, "EUR"), UnitDiscount: money.New(100, "EUR")},
+		{ProductID: 2, Quantity: 1, UnitPrice: money.New(450, "EUR")},
+	}
+
+	totals, err := e.Quote(lines, "DE", nil)
+	require.NoError(t, err)
+	assert.Equal(t, money.New(3447, "EUR"), totals.Subtotal)
+	assert.Equal(t, money.New(300, "EUR"), totals.Discount)
+	// 19% of 31.47 is 5.9793
+	assert.Equal(t, money.New(598, "EUR"), totals.Tax)
+	assert.Equal(t, money.New(3745, "EUR"), totals.Total)
+	assert.Equal(t, "19.00", totals.TaxRate)
+
+	untaxed, err := e.Quote(lines, "US", nil)
+	require.NoError(t, err)
+	assert.True(t, untaxed.Tax.IsZero())
+	assert.Equal(t, money.New(3147, "EUR"), untaxed.Total)
+
+	empty, err := e.Quote(nil, "DE", nil)
+	require.NoError(t, err)
+	assert.True(t, empty.Total.IsZero())
+
+	_, err = e.Quote(append(lines, Line{ProductID: 3, Quantity: 1, UnitPrice: money.New(100, "USD")}), "DE", nil)
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	_, err = e.Quote([]Line{{ProductID: 4, Quantity: 1, UnitPrice: money.New(100, "EUR"), UnitDiscount: money.New(200, "EUR")}}, "DE", nil)
+	assert.Error(t, err)
+
+	var zero *Engine
+	assert.Equal(t, int64(0), zero.TaxRate("DE"))
+}
+
+func TestFinalPrice(t *testing.T) {
+	final, err := FinalPrice(money.New(1999, "USD"), money.New(500, "USD"))
+	require.NoError(t, err)
+	assert.Equal(t, money.New(1499, "USD"), final)
+
+	_, err = FinalPrice(money.New(100, "USD"), money.New(101, "USD"))
+	assert.Error(t, err)
+	_, err = FinalPrice(money.New(100, "USD"), money.New(10, "EUR"))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestQuoteWithCoupon(t *testing.T) {
+	e, err := NewEngine([]string{"DE:19"}, "")
+	require.NoError(t, err)
+
+	lines := []Line{
+		{ProductID: 1, Quantity: 2, UnitPrice: money.New(1000, "EUR"), UnitDiscount: money.New(200, "EUR"), CategoryIDs: []int{7}},
+		{ProductID: 2, Quantity: 1, UnitPrice: money.New(500, "EUR")},
+	}
+
+	percent := &models.Coupon{Code: "TENOFF", Type: models.CouponTypePercentage, PercentOff: 10}
+	totals, err := e.Quote(lines, "DE", percent)
+	require.NoError(t, err)
+	// 10% of the 21.00 net subtotal
+	assert.Equal(t, money.New(210, "EUR"), totals.CouponDiscount)
+	assert.Equal(t, money.New(610, "EUR"), totals.Discount)
+	assert.Equal(t, "TENOFF", totals.CouponCode)
+	// 19% of 18.90 is 3.591
+	assert.Equal(t, money.New(359, "EUR"), totals.Tax)
+	assert.Equal(t, money.New(2249, "EUR"), totals.Total)
+
+	// Restricted coupons only discount matching lines
+	byCategory := &models.Coupon{Code: "CAT", Type: models.CouponTypePercentage, PercentOff: 50, Categories: []models.Category{{ID: 7}}}
+	totals, err = e.Quote(lines, "", byCategory)
+	require.NoError(t, err)
+	assert.Equal(t, money.New(800, "EUR"), totals.CouponDiscount)
+
+	byProduct := &models.Coupon{Code: "PROD", Type: models.CouponTypeFixed, AmountOff: money.New(1000, "EUR"), Currency: "EUR", Products: []models.Product{{ID: 2}}}
+	totals, err = e.Quote(lines, "", byProduct)
+	require.NoError(t, err)
+	// Fixed discounts are capped at the eligible amount
+	assert.Equal(t, money.New(500, "EUR"), totals.CouponDiscount)
+	assert.Equal(t, money.New(1600, "EUR"), totals.Total)
+
+	noMatch := &models.Coupon{Code: "NONE", Type: models.CouponTypePercentage, PercentOff: 10, Products: []models.Product{{ID: 99}}}
+	_, err = e.Quote(lines, "", noMatch)
+	assert.ErrorIs(t, err, ErrCouponNotApplicable)
+
+	minSpend := &models.Coupon{Code: "BIG", Type: models.CouponTypeFixed, AmountOff: money.New(500, "EUR"), MinSpend: money.New(5000, "EUR"), Currency: "EUR"}
+	_, err = e.Quote(lines, "", minSpend)
+	assert.ErrorIs(t, err, ErrCouponNotApplicable)
+
+	otherCurrency := &models.Coupon{Code: "USD5", Type: models.CouponTypeFixed, AmountOff: money.New(500, "USD"), Currency: "USD"}
+	_, err = e.Quote(lines, "", otherCurrency)
+	assert.ErrorIs(t, err, ErrCouponNotApplicable)
+}
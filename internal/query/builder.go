@@ -1,7 +1,9 @@
 package query
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"gorm.io/gorm"
@@ -17,9 +19,13 @@ type QueryBuilder struct {
 	request        *QueryParams
 	db             *gorm.DB
 	allowedFilters map[string]bool
+	filterFuncs    map[string]FilterFunc
 	allowedSorts   map[string]bool
 	defaultSort    []SortField
 	searchColumns  []string
+	// fullTextColumn is the tsvector column searched in full-text mode
+	fullTextColumn  string
+	headlineColumns []string
 }
 
 // NewQueryBuilder creates a new query builder
@@ -27,6 +33,7 @@ func NewQueryBuilder(db *gorm.DB) *QueryBuilder {
 	return &QueryBuilder{
 		db:             db,
 		allowedFilters: make(map[string]bool),
+		filterFuncs:    make(map[string]FilterFunc),
 		allowedSorts:   make(map[string]bool),
 		defaultSort:    []SortField{{Field: "created_at", Direction: SortDesc}},
 	}
@@ -55,6 +62,14 @@ func (qb *QueryBuilder) AllowFilters(fields ...string) *QueryBuilder {
 	return qb
 }
 
+// FilterWith applies the filters on a field with a function rather than as a
+// condition on a column, e.g. for fields held in another table. The field is
+// allowed as a filter.
+func (qb *QueryBuilder) FilterWith(field string, apply FilterFunc) *QueryBuilder {
+	qb.filterFuncs[field] = apply
+	return qb
+}
+
 // AllowSorts sets allowed sort fields (for security)
 func (qb *QueryBuilder) AllowSorts(fields ...string) *QueryBuilder {
 	for _, f := range fields {
@@ -75,10 +90,36 @@ func (qb *QueryBuilder) SearchColumns(columns ...string) *QueryBuilder {
 	return qb
 }
 
+// FullTextSearch switches the search to full-text mode: the tsvector column is
+// matched with websearch_to_tsquery instead of LIKE on the search columns, and
+// request search fields are ignored. Matches are ordered by rank unless a sort
+// is requested, and come with a snippet of the headline columns.
+func (qb *QueryBuilder) FullTextSearch(vectorColumn string, headlineColumns ...string) *QueryBuilder {
+	qb.fullTextColumn = vectorColumn
+	qb.headlineColumns = headlineColumns
+	return qb
+}
+
+// ErrRankedCursor is returned by Validate for cursor pagination of a full-text
+// search. Cursors hold the ID of the last result, which matches ranked by
+// relevance are not in the order of.
+var ErrRankedCursor = errors.New("cursor pagination is not supported with a full-text search; use page pagination")
+
 // ===========================================
 // BUILD METHODS
 // ===========================================
 
+// Validate reports whether the query params can be built, to be checked
+// before Build
+func (qb *QueryBuilder) Validate() error {
+	qb.ensureRequest()
+
+	if qb.request.Type == CursorPagination && qb.isFullText() {
+		return ErrRankedCursor
+	}
+	return nil
+}
+
 // Build applies pagination, filtering, and sorting to the query
 func (qb *QueryBuilder) Build() *gorm.DB {
 	qb.ensureRequest()
@@ -86,12 +127,26 @@ func (qb *QueryBuilder) Build() *gorm.DB {
 	query := qb.db
 	query = qb.applyFilters(query)
 	query = qb.applySearch(query)
+	query = qb.applyRanking(query)
 	query = qb.applySorting(query)
 	query = qb.applyPagination(query)
 
 	return query
 }
 
+// BuildFilters applies filtering and search, leaving out the filters on the
+// except fields, without sorting or pagination. It starts a new query on every
+// call, e.g. for counts of facets, each without its own filters.
+func (qb *QueryBuilder) BuildFilters(except ...string) *gorm.DB {
+	qb.ensureRequest()
+
+	query := qb.db.Session(&gorm.Session{})
+	query = qb.applyFilters(query, except...)
+	query = qb.applySearch(query)
+
+	return query
+}
+
 // BuildWithCount applies pagination and returns both query and total count
 func (qb *QueryBuilder) BuildWithCount(model interface{}) (*gorm.DB, int64) {
 	qb.ensureRequest()
@@ -120,9 +175,14 @@ func (qb *QueryBuilder) ensureRequest() {
 // FILTER APPLICATION
 // ===========================================
 
-func (qb *QueryBuilder) applyFilters(query *gorm.DB) *gorm.DB {
+func (qb *QueryBuilder) applyFilters(query *gorm.DB, except ...string) *gorm.DB {
 	for _, filter := range qb.request.Filters {
-		if qb.isFilterAllowed(filter.Field) {
+		if slices.Contains(except, filter.Field) {
+			continue
+		}
+		if apply, ok := qb.filterFuncs[filter.Field]; ok {
+			query = apply(query, filter)
+		} else if qb.isFilterAllowed(filter.Field) {
 			query = ApplyFilter(query, filter)
 		}
 	}
@@ -145,6 +205,9 @@ func (qb *QueryBuilder) applySearch(query *gorm.DB) *gorm.DB {
 	if qb.request.Search == "" {
 		return query
 	}
+	if qb.isFullText() {
+		return FullTextSearch(qb.request.Search, qb.fullTextColumn)(query)
+	}
 
 	searchFields := qb.getSearchFields()
 	if len(searchFields) == 0 {
@@ -162,6 +225,30 @@ func (qb *QueryBuilder) getSearchFields() []string {
 	return qb.searchColumns
 }
 
+func (qb *QueryBuilder) isFullText() bool {
+	return qb.fullTextColumn != "" && strings.TrimSpace(qb.request.Search) != ""
+}
+
+// applyRanking selects the rank and snippet of full-text matches as
+// search_rank and search_snippet. Snippets are unescaped, with matches between
+// highlight markers, until HighlightSnippet is applied.
+func (qb *QueryBuilder) applyRanking(query *gorm.DB) *gorm.DB {
+	if !qb.isFullText() {
+		return query
+	}
+
+	tsquery := fmt.Sprintf("websearch_to_tsquery('%s', ?)", TextSearchConfig)
+	columns := fmt.Sprintf("*, ts_rank_cd(%s, %s) AS search_rank", qb.fullTextColumn, tsquery)
+	args := []interface{}{qb.request.Search}
+	if len(qb.headlineColumns) > 0 {
+		// Markers already in the text are dropped so that only matches get tags
+		columns += fmt.Sprintf(", ts_headline('%s', translate(concat_ws(' ', %s), '%s', ''), %s, '%s') AS search_snippet",
+			TextSearchConfig, strings.Join(qb.headlineColumns, ", "), highlightStart+highlightStop, tsquery, headlineOptions)
+		args = append(args, qb.request.Search)
+	}
+	return query.Select(columns, args...)
+}
+
 // ===========================================
 // SORT APPLICATION
 // ===========================================
@@ -169,6 +256,10 @@ func (qb *QueryBuilder) getSearchFields() []string {
 func (qb *QueryBuilder) applySorting(query *gorm.DB) *gorm.DB {
 	sorts := qb.request.Sort
 	if len(sorts) == 0 {
+		// Best matches first, ties in the default order
+		if qb.isFullText() {
+			query = query.Order("search_rank DESC")
+		}
 		sorts = qb.defaultSort
 	}
 
@@ -0,0 +1,123 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type searchResult struct {
+	ID            int
+	SearchRank    float64
+	SearchSnippet string
+}
+
+// dryRun returns the SQL and vars a built query would run, without a database
+func dryRun(t *testing.T, build func(db *gorm.DB) *QueryBuilder) (string, []interface{}) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+
+	var results []searchResult
+	stmt := build(db.Table("products")).Build().Find(&results).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestFullTextSearch(t *testing.T) {
+	t.Run("ranks matches and selects snippets", func(t *testing.T) {
+		sql, vars := dryRun(t, func(db *gorm.DB) *QueryBuilder {
+			return NewQueryBuilder(db).
+				WithRequest(&QueryParams{Search: `"red shoes" -leather`, SearchFields: []string{"sku"}}).
+				FullTextSearch("search_vector", "name", "description")
+		})
+
+		assert.Contains(t, sql, `SELECT *, ts_rank_cd(search_vector, websearch_to_tsquery('english', $1)) AS search_rank, `+
+			`ts_headline('english', translate(concat_ws(' ', name, description), '`+highlightStart+highlightStop+`', ''), `+
+			`websearch_to_tsquery('english', $2), '`+headlineOptions+`') AS search_snippet`)
+		assert.Contains(t, sql, `WHERE search_vector @@ websearch_to_tsquery('english', $3)`)
+		assert.Contains(t, sql, `ORDER BY search_rank DESC,created_at DESC`)
+		assert.NotContains(t, sql, "LIKE")
+		assert.Equal(t, `"red shoes" -leather`, vars[0])
+	})
+
+	t.Run("keeps a requested sort", func(t *testing.T) {
+		sql, _ := dryRun(t, func(db *gorm.DB) *QueryBuilder {
+			return NewQueryBuilder(db).
+				WithRequest(&QueryParams{Search: "shoes", Sort: []SortField{{Field: "price", Direction: SortAsc}}}).
+				FullTextSearch("search_vector")
+		})
+
+		assert.Contains(t, sql, "ts_rank_cd")
+		assert.NotContains(t, sql, "ts_headline")
+		assert.Contains(t, sql, "ORDER BY price ASC")
+		assert.NotContains(t, sql, "ORDER BY search_rank")
+	})
+
+	t.Run("does nothing without a search", func(t *testing.T) {
+		sql, _ := dryRun(t, func(db *gorm.DB) *QueryBuilder {
+			return NewQueryBuilder(db).WithRequest(&QueryParams{Search: "  "}).FullTextSearch("search_vector", "name")
+		})
+
+		assert.NotContains(t, sql, "search_")
+		assert.Contains(t, sql, "ORDER BY created_at DESC")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	fullText := func(req *QueryParams) *QueryBuilder {
+		return NewQueryBuilder(nil).WithRequest(req).FullTextSearch("search_vector")
+	}
+
+	assert.ErrorIs(t, fullText(&QueryParams{Type: CursorPagination, Search: "shoes"}).Validate(), ErrRankedCursor)
+	assert.NoError(t, fullText(&QueryParams{Type: OffsetPagination, Search: "shoes"}).Validate())
+	assert.NoError(t, fullText(&QueryParams{Type: CursorPagination, Search: "  "}).Validate())
+	assert.NoError(t, NewQueryBuilder(nil).WithRequest(&QueryParams{Type: CursorPagination, Search: "shoes"}).SearchColumns("name").Validate())
+}
+
+func TestLikeSearch(t *testing.T) {
+	sql, vars := dryRun(t, func(db *gorm.DB) *QueryBuilder {
+		return NewQueryBuilder(db).WithRequest(&QueryParams{Search: "Shoes"}).SearchColumns("name", "slug")
+	})
+
+	assert.Contains(t, sql, "LOWER(name) LIKE $1 OR LOWER(slug) LIKE $2")
+	assert.Equal(t, "%shoes%", vars[0])
+}
+
+func TestBuildFilters(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+
+	tagged := func(query *gorm.DB, filter Filter) *gorm.DB {
+		return query.Where("EXISTS (SELECT 1 FROM product_tags WHERE product_id = products.id AND tag IN ?)", filter.StringValues())
+	}
+	builder := NewQueryBuilder(db.Table("products")).
+		WithRequest(&QueryParams{Filters: []Filter{
+			{Field: "brand", Operator: OpEqual, Value: "Acme"},
+			{Field: "tags", Operator: OpIn, Values: []interface{}{"sale", "new"}},
+			{Field: "secret", Operator: OpEqual, Value: "x"},
+		}}).
+		AllowFilters("brand").
+		FilterWith("tags", tagged)
+
+	var results []searchResult
+	all := builder.BuildFilters().Find(&results).Statement
+	assert.Contains(t, all.SQL.String(), "WHERE brand = $1 AND (EXISTS (SELECT 1 FROM product_tags WHERE product_id = products.id AND tag IN ($2,$3)))")
+	assert.NotContains(t, all.SQL.String(), "secret")
+	assert.NotContains(t, all.SQL.String(), "ORDER BY")
+	assert.Equal(t, []interface{}{"Acme", "sale", "new"}, all.Vars)
+
+	// Every call starts a new query
+	withoutBrand := builder.BuildFilters("brand").Find(&results).Statement
+	assert.Contains(t, withoutBrand.SQL.String(), "WHERE EXISTS (SELECT 1 FROM product_tags WHERE product_id = products.id AND tag IN ($1,$2))")
+	assert.NotContains(t, withoutBrand.SQL.String(), "brand")
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	snippet := `<script>alert("x")</script> ` + highlightStart + "red" + highlightStop + " & " + highlightStart + "shoes" + highlightStop
+
+	assert.Equal(t, `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>red</mark> &amp; <mark>shoes</mark>`, HighlightSnippet(snippet))
+	assert.Empty(t, HighlightSnippet(""))
+}
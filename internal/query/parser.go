@@ -240,6 +240,22 @@ func parseFilterParam(key string, values []string) *Filter {
 	return filter
 }
 
+// KeyedFilters returns the values of the filters on field by key, for fields
+// whose brackets hold a key rather than an operator.
+// Example: option[size]=M&option[color]=red gives {"size": "M", "color": "red"}
+func KeyedFilters(filters []Filter, field string) map[string]string {
+	keyed := make(map[string]string)
+	for _, filter := range filters {
+		if filter.Field != field || filter.Operator == "" {
+			continue
+		}
+		if value, ok := filter.Value.(string); ok && value != "" {
+			keyed[string(filter.Operator)] = value
+		}
+	}
+	return keyed
+}
+
 func stringsToInterfaces(strs []string) []interface{} {
 	result := make([]interface{}, len(strs))
 	for i, s := range strs {
@@ -53,6 +53,18 @@ func Search(term string, columns ...string) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// FullTextSearch is a GORM scope matching a tsvector column against a search
+// in web search syntax, e.g. `"running shoes" -trail or sandals`
+// Usage: db.Scopes(query.FullTextSearch("running shoes", "search_vector")).Find(&products)
+func FullTextSearch(term, vectorColumn string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if strings.TrimSpace(term) == "" {
+			return db
+		}
+		return db.Where(fmt.Sprintf("%s @@ websearch_to_tsquery('%s', ?)", vectorColumn, TextSearchConfig), term)
+	}
+}
+
 // SortBy is a GORM scope for sorting by a single field
 // Usage: db.Scopes(query.SortBy("created_at", query.SortDesc)).Find(&users)
 func SortBy(field string, direction SortDirection) func(db *gorm.DB) *gorm.DB {
@@ -1,6 +1,13 @@
 package query
 
-import "time"
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // ===========================================
 // PAGINATION TYPES & CONSTANTS
@@ -24,6 +31,29 @@ const (
 	SortDesc SortDirection = "desc"
 )
 
+const (
+	// TextSearchConfig is the text search configuration full-text searches
+	// parse queries with. The tsvector columns of models use the same one.
+	TextSearchConfig = "english"
+	// highlightStart and highlightStop are the private-use characters
+	// search snippets mark matched words with until HighlightSnippet turns
+	// them into tags, as the snippet text has to be escaped first
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+	// headlineOptions highlight matched words of search snippets between
+	// highlightStart and highlightStop
+	headlineOptions = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=30, MinWords=10, MaxFragments=2`
+)
+
+// snippetReplacer turns the highlight markers of escaped snippets into tags
+var snippetReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// HighlightSnippet HTML-escapes a search snippet and wraps its matched words
+// in <mark> tags
+func HighlightSnippet(snippet string) string {
+	return snippetReplacer.Replace(html.EscapeString(snippet))
+}
+
 // FilterOperator defines the filter operation
 type FilterOperator string
 
@@ -53,6 +83,9 @@ type SortField struct {
 	Direction SortDirection `json:"direction"`
 }
 
+// FilterFunc applies a filter to a GORM query
+type FilterFunc func(query *gorm.DB, filter Filter) *gorm.DB
+
 // Filter represents a single filter condition
 type Filter struct {
 	Field    string         `json:"field"`
@@ -61,6 +94,25 @@ type Filter struct {
 	Values   []interface{}  `json:"values,omitempty"` // For IN, NOT IN, BETWEEN operators
 }
 
+// StringValues returns the non-empty values of an eq or in filter as strings
+func (f Filter) StringValues() []string {
+	var values []interface{}
+	switch f.Operator {
+	case OpEqual:
+		values = []interface{}{f.Value}
+	case OpIn:
+		values = f.Values
+	}
+
+	var result []string
+	for _, value := range values {
+		if s := fmt.Sprint(value); value != nil && s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
 // CursorData holds the cursor information for cursor-based pagination
 type CursorData struct {
 	ID        int       `json:"id"`
@@ -70,7 +70,7 @@ func (r *AttendeeRepository) GetAttendeesByEvent(ctx context.Context, eventID in
 func (r *AttendeeRepository) GetEventsByAttendee(ctx context.Context, userID int) ([]*models.Event, error) {
 	var events []*models.Event
 	// Using a JOIN query to fetch events directly
-	err := r.DB.WithContext(ctx).Table("events").
+	err := r.DB.WithContext(ctx).Table("events").Select("events.*").
 		Joins("JOIN attendees ON attendees.event_id = events.id").
 		Where("attendees.user_id = ?", userID).
 		Find(&events).Error
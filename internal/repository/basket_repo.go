@@ -3,19 +3,22 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
 	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BasketRepository struct {
-	DB *gorm.DB
+	DB        *gorm.DB
+	TxManager *TxManager
 }
 
-func NewBasketRepository(db *gorm.DB) *BasketRepository {
-	return &BasketRepository{DB: db}
+func NewBasketRepository(db *gorm.DB, txManager *TxManager) *BasketRepository {
+	return &BasketRepository{DB: db, TxManager: txManager}
 }
 
 // GetActiveBasket retrieves the active basket for a user
@@ -23,7 +26,8 @@ func (r *BasketRepository) GetActiveBasket(ctx context.Context, userID int) (*mo
 	logging.Debug(ctx, "retrieving active basket", "user_id", userID)
 
 	var basket models.Basket
-	err := r.DB.WithContext(ctx).Preload("Items.Product").Where("user_id = ? AND status = ?", userID, "active").First(&basket).Error
+	err := r.DB.WithContext(ctx).Scopes(preloadBasket).
+		Where("user_id = ? AND status = ?", userID, models.BasketStatusActive).First(&basket).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logging.Debug(ctx, "no active basket found", "user_id", userID)
@@ -33,10 +37,73 @@ func (r *BasketRepository) GetActiveBasket(ctx context.Context, userID int) (*mo
 		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve active basket")
 	}
 
+	if err := r.repriceBasket(ctx, &basket); err != nil {
+		logging.Error(ctx, "failed to re-price basket", err, "basket_id", basket.ID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve active basket")
+	}
+
 	logging.Debug(ctx, "active basket retrieved successfully", "user_id", userID, "basket_id", basket.ID)
 	return &basket, nil
 }
 
+// GetGuestBasket retrieves an active basket that belongs to no user
+func (r *BasketRepository) GetGuestBasket(ctx context.Context, basketID int) (*models.Basket, error) {
+	logging.Debug(ctx, "retrieving guest basket", "basket_id", basketID)
+
+	var basket models.Basket
+	err := r.DB.WithContext(ctx).Scopes(preloadBasket).
+		Where("id = ? AND user_id IS NULL AND status = ?", basketID, models.BasketStatusActive).First(&basket).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.Debug(ctx, "no guest basket found", "basket_id", basketID)
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "guest basket with ID %d not found", basketID)
+		}
+		logging.Error(ctx, "failed to retrieve guest basket", err, "basket_id", basketID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve basket")
+	}
+
+	if err := r.repriceBasket(ctx, &basket); err != nil {
+		logging.Error(ctx, "failed to re-price basket", err, "basket_id", basket.ID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve basket")
+	}
+
+	return &basket, nil
+}
+
+// preloadBasket loads what pricing a basket needs: its items' products with
+// their categories and variants and its coupon with its restrictions
+func preloadBasket(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Items.Product.Categories").
+		Preload("Items.Variant").
+		Preload("Coupon.Products").
+		Preload("Coupon.Categories")
+}
+
+// repriceBasket brings the prices of the basket's items in line with their
+// products, saving and flagging the items that changed
+func (r *BasketRepository) repriceBasket(ctx context.Context, basket *models.Basket) error {
+	for i := range basket.Items {
+		item := &basket.Items[i]
+		// Items of deleted products are left for checkout to reject
+		if item.Product.ID == 0 || !item.Reprice(&item.Product, item.Variant) {
+			continue
+		}
+
+		err := r.DB.WithContext(ctx).Model(item).Updates(map[string]any{
+			"unit_price":    item.UnitPrice,
+			"unit_discount": item.UnitDiscount,
+			"currency":      item.Currency,
+			"price_changed": true,
+		}).Error
+		if err != nil {
+			return err
+		}
+		logging.Info(ctx, "basket item re-priced", "basket_id", basket.ID, "item_id", item.ID, "product_id", item.ProductID, "variant_id", item.VariantID)
+	}
+	return nil
+}
+
 // CreateBasket creates a new basket
 func (r *BasketRepository) CreateBasket(ctx context.Context, basket *models.Basket) error {
 	logging.Debug(ctx, "creating new basket", "user_id", basket.UserID)
@@ -50,94 +117,426 @@ func (r *BasketRepository) CreateBasket(ctx context.Context, basket *models.Bask
 	return nil
 }
 
-// AddItem adds an item to the basket or updates quantity if it exists
-func (r *BasketRepository) AddItem(ctx context.Context, basketID int, item *models.BasketItem) error {
-	logging.Debug(ctx, "adding item to basket", "basket_id", basketID, "product_id", item.ProductID, "quantity", item.Quantity)
+// AddItem adds an item to the basket or updates quantity if it exists. Items
+// of the same product are told apart by variant. The product is locked while
+// the item's whole quantity is reserved until reserveUntil, so concurrent
+// baskets cannot reserve more than is in stock.
+func (r *BasketRepository) AddItem(ctx context.Context, basketID int, item *models.BasketItem, reserveUntil time.Time) error {
+	logging.Debug(ctx, "adding item to basket", "basket_id", basketID, "product_id", item.ProductID, "variant_id", item.VariantID, "quantity", item.Quantity)
+
+	updated := false
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		// Locking the basket keeps concurrent adds from mixing currencies
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&models.Basket{}, basketID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "basket with ID %d not found", basketID)
+			}
+			return err
+		}
+
+		// A basket is priced in a single currency
+		var otherCurrency int64
+		err = tx.Model(&models.BasketItem{}).
+			Where("basket_id = ? AND currency <> ?", basketID, item.Currency).
+			Count(&otherCurrency).Error
+		if err != nil {
+			return err
+		}
+		if otherCurrency > 0 {
+			return appErrors.Newf(appErrors.ErrConflict, "cannot add a product priced in %s to a basket in another currency", item.Currency).
+				WithDetail("currency", item.Currency)
+		}
 
-	var existingItem models.BasketItem
-	err := r.DB.WithContext(ctx).Where("basket_id = ? AND product_id = ?", basketID, item.ProductID).First(&existingItem).Error
+		product, err := lockProduct(tx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		variant, err := findVariant(tx, product, item.VariantID)
+		if err != nil {
+			return err
+		}
+
+		var existingItem models.BasketItem
+		result := tx.Where("basket_id = ? AND product_id = ?", basketID, item.ProductID).
+			Scopes(sameVariant(item.VariantID)).Limit(1).Find(&existingItem)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if err == nil {
-		// Item exists, update quantity
-		existingItem.Quantity += item.Quantity
-		if err := r.DB.WithContext(ctx).Save(&existingItem).Error; err != nil {
-			logging.Error(ctx, "failed to update basket item quantity", err, "basket_id", basketID, "product_id", item.ProductID)
-			return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update basket item")
+		quantity := item.Quantity
+		if result.RowsAffected > 0 {
+			quantity += existingItem.Quantity
 		}
-		logging.Info(ctx, "basket item quantity updated", "basket_id", basketID, "product_id", item.ProductID, "new_quantity", existingItem.Quantity)
-		return nil
-	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := reserveStock(tx, product, variant, basketID, quantity, reserveUntil); err != nil {
+			return err
+		}
+
+		if result.RowsAffected > 0 {
+			// Item exists, update quantity
+			updated = true
+			return tx.Model(&existingItem).Updates(map[string]any{"quantity": quantity, "price_changed": false}).Error
+		}
+
 		// Item does not exist, create new
 		item.BasketID = basketID
-		if err := r.DB.WithContext(ctx).Create(item).Error; err != nil {
-			logging.Error(ctx, "failed to create basket item", err, "basket_id", basketID, "product_id", item.ProductID)
-			return appErrors.New(appErrors.ErrDatabaseOperation, "failed to add item to basket")
+		return tx.Create(item).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
 		}
-		logging.Info(ctx, "item added to basket", "basket_id", basketID, "product_id", item.ProductID, "quantity", item.Quantity)
-		return nil
+		logging.Error(ctx, "failed to add item to basket", err, "basket_id", basketID, "product_id", item.ProductID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to add item to basket")
 	}
 
-	logging.Error(ctx, "failed to check existing basket item", err, "basket_id", basketID, "product_id", item.ProductID)
-	return appErrors.New(appErrors.ErrDatabaseOperation, "failed to process basket item")
+	if updated {
+		logging.Info(ctx, "basket item quantity updated", "basket_id", basketID, "product_id", item.ProductID)
+	} else {
+		logging.Info(ctx, "item added to basket", "basket_id", basketID, "product_id", item.ProductID, "quantity", item.Quantity)
+	}
+	return nil
 }
 
-// UpdateItemQuantity updates the quantity of an item in the basket
-func (r *BasketRepository) UpdateItemQuantity(ctx context.Context, itemID int, quantity int) error {
-	logging.Debug(ctx, "updating basket item quantity", "item_id", itemID, "quantity", quantity)
+// UpdateItemQuantity updates the quantity of an item in the basket and its
+// reservation. A quantity of zero or less removes the item. Items of other
+// baskets are not found.
+func (r *BasketRepository) UpdateItemQuantity(ctx context.Context, basketID, itemID int, quantity int, reserveUntil time.Time) error {
+	logging.Debug(ctx, "updating basket item quantity", "basket_id", basketID, "item_id", itemID, "quantity", quantity)
 
-	if quantity <= 0 {
-		if err := r.DB.WithContext(ctx).Delete(&models.BasketItem{}, itemID).Error; err != nil {
-			logging.Error(ctx, "failed to delete basket item", err, "item_id", itemID)
-			return appErrors.New(appErrors.ErrDatabaseOperation, "failed to remove basket item")
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var item models.BasketItem
+		if err := tx.Where("basket_id = ?", basketID).First(&item, itemID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID)
+			}
+			return err
 		}
-		logging.Info(ctx, "basket item removed", "item_id", itemID)
-		return nil
-	}
 
-	result := r.DB.WithContext(ctx).Model(&models.BasketItem{}).Where("id = ?", itemID).Update("quantity", quantity)
-	if result.Error != nil {
-		logging.Error(ctx, "failed to update basket item quantity", result.Error, "item_id", itemID)
+		if quantity <= 0 {
+			if err := tx.Delete(&item).Error; err != nil {
+				return err
+			}
+			return releaseItemStock(tx, &item)
+		}
+
+		product, err := lockProduct(tx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		variant, err := findVariant(tx, product, item.VariantID)
+		if err != nil {
+			return err
+		}
+		if err := reserveStock(tx, product, variant, item.BasketID, quantity, reserveUntil); err != nil {
+			return err
+		}
+		return tx.Model(&item).Updates(map[string]any{"quantity": quantity, "price_changed": false}).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		logging.Error(ctx, "failed to update basket item quantity", err, "basket_id", basketID, "item_id", itemID)
 		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update basket item quantity")
 	}
 
-	if result.RowsAffected == 0 {
-		logging.Debug(ctx, "no basket item found to update", "item_id", itemID)
-		return appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID)
+	logging.Info(ctx, "basket item quantity updated", "basket_id", basketID, "item_id", itemID, "quantity", quantity)
+	return nil
+}
+
+// RemoveItem removes an item from the basket and releases its reservation.
+// Items of other baskets are not found.
+func (r *BasketRepository) RemoveItem(ctx context.Context, basketID, itemID int) error {
+	logging.Debug(ctx, "removing item from basket", "basket_id", basketID, "item_id", itemID)
+
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var item models.BasketItem
+		if err := tx.Where("basket_id = ?", basketID).First(&item, itemID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID)
+			}
+			return err
+		}
+
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		return releaseItemStock(tx, &item)
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			logging.Debug(ctx, "no basket item found to remove", "basket_id", basketID, "item_id", itemID)
+			return appErr
+		}
+		logging.Error(ctx, "failed to remove basket item", err, "basket_id", basketID, "item_id", itemID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to remove basket item")
 	}
 
-	logging.Info(ctx, "basket item quantity updated", "item_id", itemID, "quantity", quantity)
+	logging.Info(ctx, "basket item removed successfully", "basket_id", basketID, "item_id", itemID)
 	return nil
 }
 
-// RemoveItem removes an item from the basket
-func (r *BasketRepository) RemoveItem(ctx context.Context, itemID int) error {
-	logging.Debug(ctx, "removing item from basket", "item_id", itemID)
+// ClearBasket removes all items from the basket and releases their reservations
+func (r *BasketRepository) ClearBasket(ctx context.Context, basketID int) error {
+	logging.Debug(ctx, "clearing basket", "basket_id", basketID)
 
-	result := r.DB.WithContext(ctx).Delete(&models.BasketItem{}, itemID)
-	if result.Error != nil {
-		logging.Error(ctx, "failed to remove basket item", result.Error, "item_id", itemID)
-		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to remove basket item")
+	var removed int64
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		result := tx.Where("basket_id = ?", basketID).Delete(&models.BasketItem{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+		return releaseStock(tx, basketID)
+	})
+	if err != nil {
+		logging.Error(ctx, "failed to clear basket", err, "basket_id", basketID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to clear basket")
 	}
 
-	if result.RowsAffected == 0 {
-		logging.Debug(ctx, "no basket item found to remove", "item_id", itemID)
-		return appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID)
+	logging.Info(ctx, "basket cleared successfully", "basket_id", basketID, "items_removed", removed)
+	return nil
+}
+
+// MergeGuestBasket moves the items of a guest basket into the user's active
+// basket, creating it when needed. Quantities of product variants in both
+// baskets are summed and capped at the stock available to the user, items priced in another
+// currency than the user's basket are dropped and the guest's coupon is kept when
+// the user's basket has none. The guest basket is then marked merged.
+func (r *BasketRepository) MergeGuestBasket(ctx context.Context, guestBasketID, userID int, reserveUntil time.Time) error {
+	logging.Debug(ctx, "merging guest basket", "basket_id", guestBasketID, "user_id", userID)
+
+	var basketID int
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var guest models.Basket
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id IS NULL AND status = ?", guestBasketID, models.BasketStatusActive).
+			First(&guest).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "guest basket with ID %d not found", guestBasketID)
+			}
+			return err
+		}
+		if err := tx.Where("basket_id = ?", guest.ID).Order("product_id ASC, variant_id ASC").Find(&guest.Items).Error; err != nil {
+			return err
+		}
+
+		var basket models.Basket
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND status = ?", userID, models.BasketStatusActive).
+			Limit(1).Find(&basket)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			basket = models.Basket{UserID: &userID, Status: models.BasketStatusActive}
+			if err := tx.Create(&basket).Error; err != nil {
+				return err
+			}
+		}
+		basketID = basket.ID
+
+		if err := tx.Where("basket_id = ?", basket.ID).Find(&basket.Items).Error; err != nil {
+			return err
+		}
+		existing := make(map[basketLine]*models.BasketItem, len(basket.Items))
+		currency := ""
+		for i := range basket.Items {
+			existing[lineOf(&basket.Items[i])] = &basket.Items[i]
+			currency = basket.Items[i].Currency
+		}
+
+		// The guest's stock becomes the user's to claim
+		if err := releaseStock(tx, guest.ID); err != nil {
+			return err
+		}
+
+		for _, guestItem := range guest.Items {
+			if currency != "" && guestItem.Currency != currency {
+				logging.Info(ctx, "dropping guest basket item in another currency", "basket_id", guest.ID, "product_id", guestItem.ProductID)
+				continue
+			}
+
+			product, err := lockProduct(tx, guestItem.ProductID)
+			if err != nil {
+				if appErrors.IsType(err, appErrors.ErrNotFound) {
+					continue
+				}
+				return err
+			}
+			variant, err := findVariant(tx, product, guestItem.VariantID)
+			if err != nil {
+				if appErrors.IsType(err, appErrors.ErrNotFound) {
+					continue
+				}
+				return err
+			}
+			available, err := availableStock(tx, product, variant, basket.ID)
+			if err != nil {
+				return err
+			}
+
+			line := lineOf(&guestItem)
+			current := 0
+			if item, ok := existing[line]; ok {
+				current = item.Quantity
+			}
+			quantity := min(current+guestItem.Quantity, available)
+			if quantity <= current {
+				logging.Info(ctx, "guest basket item not merged, no stock available", "basket_id", guest.ID, "product_id", product.ID)
+				continue
+			}
+
+			if err := reserveStock(tx, product, variant, basket.ID, quantity, reserveUntil); err != nil {
+				return err
+			}
+			if item, ok := existing[line]; ok {
+				err = tx.Model(item).Update("quantity", quantity).Error
+			} else {
+				err = tx.Create(&models.BasketItem{
+					BasketID:     basket.ID,
+					ProductID:    product.ID,
+					VariantID:    guestItem.VariantID,
+					Quantity:     quantity,
+					UnitPrice:    guestItem.UnitPrice,
+					UnitDiscount: guestItem.UnitDiscount,
+					Currency:     guestItem.Currency,
+				}).Error
+			}
+			if err != nil {
+				return err
+			}
+			currency = guestItem.Currency
+		}
+
+		if basket.CouponID == nil && guest.CouponID != nil {
+			if err := tx.Model(&basket).Update("coupon_id", guest.CouponID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&guest).Updates(map[string]any{"status": models.BasketStatusMerged, "coupon_id": nil}).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		logging.Error(ctx, "failed to merge guest basket", err, "basket_id", guestBasketID, "user_id", userID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to merge basket")
 	}
 
-	logging.Info(ctx, "basket item removed successfully", "item_id", itemID)
+	logging.Info(ctx, "guest basket merged", "guest_basket_id", guestBasketID, "basket_id", basketID, "user_id", userID)
 	return nil
 }
 
-// ClearBasket removes all items from the basket
-func (r *BasketRepository) ClearBasket(ctx context.Context, basketID int) error {
-	logging.Debug(ctx, "clearing basket", "basket_id", basketID)
+// DeleteStaleGuestBaskets deletes guest baskets, with their items and
+// reservations, that have not changed since before
+func (r *BasketRepository) DeleteStaleGuestBaskets(ctx context.Context, before time.Time) (int64, error) {
+	logging.Debug(ctx, "deleting stale guest baskets", "before", before)
+
+	var deleted int64
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var ids []int
+		err := tx.Unscoped().Model(&models.Basket{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("user_id IS NULL AND updated_at < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM basket_items WHERE basket_items.basket_id = baskets.id AND basket_items.updated_at >= ?)", before).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
 
-	result := r.DB.WithContext(ctx).Where("basket_id = ?", basketID).Delete(&models.BasketItem{})
+		if err := tx.Where("basket_id IN ?", ids).Delete(&models.StockReservation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("basket_id IN ?", ids).Delete(&models.BasketItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Delete(&models.Basket{}, ids)
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		logging.Error(ctx, "failed to delete stale guest baskets", err)
+		return 0, appErrors.New(appErrors.ErrDatabaseOperation, "failed to delete stale guest baskets")
+	}
+
+	if deleted > 0 {
+		logging.Info(ctx, "stale guest baskets deleted", "count", deleted)
+	}
+	return deleted, nil
+}
+
+// lockProduct locks a product row for the rest of the transaction
+func lockProduct(tx *gorm.DB, productID int) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "product with ID %d not found", productID)
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// findVariant returns the variant of a locked product with the given ID, or nil
+// when variantID is nil. The product's lock covers its variants' stock.
+func findVariant(tx *gorm.DB, product *models.Product, variantID *int) (*models.ProductVariant, error) {
+	if variantID == nil {
+		return nil, nil
+	}
+
+	var variant models.ProductVariant
+	if err := tx.Where("product_id = ?", product.ID).First(&variant, *variantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "variant with ID %d of %s not found", *variantID, product.Name)
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// sameVariant scopes basket items to those of the given variant, or without
+// one when variantID is nil
+func sameVariant(variantID *int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if variantID == nil {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", *variantID)
+	}
+}
+
+// basketLine identifies what a basket item holds: a product, or one of its variants
+type basketLine struct {
+	ProductID int
+	VariantID int
+}
+
+// lineOf returns the line of a basket item
+func lineOf(item *models.BasketItem) basketLine {
+	return basketLine{ProductID: item.ProductID, VariantID: variantKey(item.VariantID)}
+}
+
+// SetCoupon applies a coupon to the basket, or removes it when couponID is nil
+func (r *BasketRepository) SetCoupon(ctx context.Context, basketID int, couponID *int) error {
+	logging.Debug(ctx, "setting basket coupon", "basket_id", basketID, "coupon_id", couponID)
+
+	result := r.DB.WithContext(ctx).Model(&models.Basket{}).Where("id = ?", basketID).Update("coupon_id", couponID)
 	if result.Error != nil {
-		logging.Error(ctx, "failed to clear basket", result.Error, "basket_id", basketID)
-		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to clear basket")
+		logging.Error(ctx, "failed to set basket coupon", result.Error, "basket_id", basketID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update basket coupon")
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.Newf(appErrors.ErrNotFound, "basket with ID %d not found", basketID)
 	}
 
-	logging.Info(ctx, "basket cleared successfully", "basket_id", basketID, "items_removed", result.RowsAffected)
+	logging.Info(ctx, "basket coupon updated", "basket_id", basketID, "coupon_id", couponID)
 	return nil
 }
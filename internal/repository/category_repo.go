@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"slices"
 
 	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
 	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
@@ -25,9 +26,27 @@ func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
 func (r *CategoryRepository) Insert(ctx context.Context, category *models.Category) (*models.Category, error) {
 	logging.Debug(ctx, "creating new category", "name", category.Name)
 
-	result := r.DB.WithContext(ctx).Create(category)
-	if result.Error != nil {
-		logging.Error(ctx, "failed to create category", result.Error, "name", category.Name)
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The parent cannot be deleted while the category is created under it
+		if category.ParentID != nil {
+			if err := lockCategories(tx); err != nil {
+				return err
+			}
+			if _, err := findCategory(tx, *category.ParentID); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Parent", "Children").Create(category).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, appErrors.New(appErrors.ErrConflict, "a category with this name or slug already exists")
+		}
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to create category", err, "name", category.Name)
 		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create category")
 	}
 
@@ -88,6 +107,154 @@ func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*model
 	return &category, nil
 }
 
+// Update saves a category's own fields, leaving its parent and children as they are
+func (r *CategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	logging.Debug(ctx, "updating category", "category_id", category.ID)
+
+	if err := r.DB.WithContext(ctx).Omit("Parent", "Children").Save(category).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return appErrors.New(appErrors.ErrConflict, "a category with this name or slug already exists")
+		}
+		logging.Error(ctx, "failed to update category", err, "category_id", category.ID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update category")
+	}
+
+	logging.Info(ctx, "category updated successfully", "category_id", category.ID)
+	return nil
+}
+
+// Tree retrieves all categories nested under their parents, with the top-level
+// categories as the roots
+func (r *CategoryRepository) Tree(ctx context.Context) ([]models.Category, error) {
+	logging.Debug(ctx, "retrieving category tree")
+
+	var categories []models.Category
+	if err := r.DB.WithContext(ctx).Find(&categories).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve category tree", err)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve category tree")
+	}
+
+	return models.CategoryTree(categories), nil
+}
+
+// Move makes a category a subcategory of another, or a top-level category for
+// a nil parentID. A category cannot move under itself or its subcategories.
+func (r *CategoryRepository) Move(ctx context.Context, id int, parentID *int) (*models.Category, error) {
+	logging.Debug(ctx, "moving category", "category_id", id, "parent_id", parentID)
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCategories(tx); err != nil {
+			return err
+		}
+		if _, err := findCategory(tx, id); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			subtree, err := categorySubtree(tx, id)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(subtree, *parentID) {
+				return appErrors.New(appErrors.ErrInvalidInput, "a category cannot move under itself or its subcategories")
+			}
+			if _, err := findCategory(tx, *parentID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Category{ID: id}).Update("parent_id", parentID).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to move category", err, "category_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to move category")
+	}
+
+	logging.Info(ctx, "category moved", "category_id", id, "parent_id", parentID)
+	return r.Get(ctx, id)
+}
+
+// Delete soft deletes a category. With CategoryDeleteReassign its subcategories
+// and products move to the target category, or to the category's parent for a
+// nil targetID, and with CategoryDeleteCascade its subcategories are deleted
+// too and the products taken out of them all. It returns how many products
+// were left without any category, as happens to the products of a cascade or
+// of a top-level category.
+func (r *CategoryRepository) Delete(ctx context.Context, id int, strategy string, targetID *int) (int, error) {
+	logging.Debug(ctx, "deleting category", "category_id", id, "strategy", strategy, "target_id", targetID)
+
+	uncategorized := 0
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCategories(tx); err != nil {
+			return err
+		}
+		category, err := findCategory(tx, id)
+		if err != nil {
+			return err
+		}
+		subtree, err := categorySubtree(tx, id)
+		if err != nil {
+			return err
+		}
+
+		switch strategy {
+		case models.CategoryDeleteReassign:
+			target := category.ParentID
+			if targetID != nil {
+				if slices.Contains(subtree, *targetID) {
+					return appErrors.New(appErrors.ErrInvalidInput, "a category cannot be reassigned to itself or its subcategories")
+				}
+				if _, err := findCategory(tx, *targetID); err != nil {
+					return err
+				}
+				target = targetID
+			}
+
+			if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Update("parent_id", target).Error; err != nil {
+				return err
+			}
+			if target != nil {
+				err := tx.Exec(`INSERT INTO product_categories (product_id, category_id)
+					SELECT product_id, ? FROM product_categories WHERE category_id = ?
+					ON CONFLICT DO NOTHING`, *target, id).Error
+				if err != nil {
+					return err
+				}
+			}
+			subtree = []int{id}
+		case models.CategoryDeleteCascade:
+		default:
+			return appErrors.Newf(appErrors.ErrInvalidInput, "unknown delete strategy %q", strategy)
+		}
+
+		if uncategorized, err = uncategorizedProducts(tx, subtree); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM product_categories WHERE category_id IN ?", subtree).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", subtree).Delete(&models.Category{}).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return 0, appErr
+		}
+		logging.Error(ctx, "failed to delete category", err, "category_id", id)
+		return 0, appErrors.New(appErrors.ErrDatabaseOperation, "failed to delete category")
+	}
+
+	if uncategorized > 0 {
+		logging.Warn(ctx, "category deletion left products without a category", "category_id", id, "strategy", strategy, "count", uncategorized)
+	}
+	logging.Info(ctx, "category deleted", "category_id", id, "strategy", strategy, "target_id", targetID)
+	return uncategorized, nil
+}
+
 // ListWithPagination retrieves categories with pagination
 func (r *CategoryRepository) ListWithPagination(ctx context.Context, req *query.PaginationRequest) ([]*models.Category, *query.PaginationResponse, error) {
 	logging.Debug(ctx, "retrieving categories with pagination", "page", req.Page, "page_size", req.PageSize)
@@ -125,3 +292,44 @@ func (r *CategoryRepository) ListWithPagination(ctx context.Context, req *query.
 	logging.Info(ctx, "categories retrieved successfully", "count", len(categories), "total", total, "page", req.Page)
 	return categories, paginationResp, nil
 }
+
+// lockCategories keeps the category hierarchy from changing until the
+// transaction ends, so concurrent moves cannot form a cycle and new categories
+// cannot be added under deleted ones
+func lockCategories(tx *gorm.DB) error {
+	return tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE").Error
+}
+
+// findCategory retrieves a category, which lockCategories keeps from changing
+func findCategory(tx *gorm.DB, id int) (*models.Category, error) {
+	var category models.Category
+	if err := tx.Take(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "category with ID %d not found", id)
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+// categorySubtree returns the IDs of a category and all of its descendants
+func categorySubtree(tx *gorm.DB, id int) ([]int, error) {
+	var categories []models.Category
+	if err := tx.Select("id", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return models.CategorySubtree(categories, id), nil
+}
+
+// uncategorizedProducts counts the products all of whose categories are among
+// ids
+func uncategorizedProducts(tx *gorm.DB, ids []int) (int, error) {
+	var count int64
+	err := tx.Table("product_categories pc").
+		Joins("JOIN products p ON p.id = pc.product_id AND p.deleted_at IS NULL").
+		Where("pc.category_id IN ?", ids).
+		Where("NOT EXISTS (SELECT 1 FROM product_categories other WHERE other.product_id = pc.product_id AND other.category_id NOT IN ?)", ids).
+		Distinct("pc.product_id").
+		Count(&count).Error
+	return int(count), err
+}
@@ -2,13 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
 	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// threadIDsQuery selects the IDs of a visible comment and all of its visible descendants
+const threadIDsQuery = `WITH RECURSIVE thread AS (
+	SELECT id FROM comments WHERE id = ? AND status = 'approved'
+	UNION ALL
+	SELECT c.id FROM comments c JOIN thread t ON c.parent_id = t.id WHERE c.status = 'approved'
+) SELECT id FROM thread`
+
 // CommentRepository handles comment database operations
 type CommentRepository struct {
 	DB *gorm.DB
@@ -19,9 +30,16 @@ func NewCommentRepository(db *gorm.DB) *CommentRepository {
 	return &CommentRepository{DB: db}
 }
 
+// withReplyCount selects comment columns along with the number of visible direct replies
+func (r *CommentRepository) withReplyCount(db *gorm.DB) *gorm.DB {
+	replies := r.DB.Table("comments AS replies").Select("COUNT(*)").
+		Where("replies.parent_id = comments.id AND replies.status = ?", models.CommentStatusApproved)
+	return db.Select("comments.*, (?) AS reply_count", replies)
+}
+
 // Insert creates a new comment
 func (r *CommentRepository) Insert(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
-	logging.Debug(ctx, "creating new comment", "event_id", comment.EventID, "user_id", comment.UserID)
+	logging.Debug(ctx, "creating new comment", "event_id", comment.EventID, "user_id", comment.UserID, "parent_id", comment.ParentID)
 
 	result := r.DB.WithContext(ctx).Create(comment)
 	if result.Error != nil {
@@ -30,7 +48,7 @@ func (r *CommentRepository) Insert(ctx context.Context, comment *models.Comment)
 	}
 
 	// Preload user info to return complete object
-	if err := r.DB.WithContext(ctx).Preload("User").First(comment, comment.ID).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Preload("User").Preload("Mentions").First(comment, comment.ID).Error; err != nil {
 		logging.Error(ctx, "failed to preload user data for comment", err, "comment_id", comment.ID)
 		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve complete comment")
 	}
@@ -49,16 +67,305 @@ func (r *CommentRepository) GetByEvent(ctx context.Context, eventID int) ([]*mod
 	return comments, nil
 }
 
-// Delete removes a comment
+// ListTopLevelByEvent retrieves cursor-paginated top-level comments for an event, including reply counts
+func (r *CommentRepository) ListTopLevelByEvent(ctx context.Context, eventID int, params *query.QueryParams) ([]*models.Comment, *query.PaginatedList, error) {
+	logging.Debug(ctx, "retrieving top-level comments", "event_id", eventID, "cursor", params.Cursor, "page_size", params.PageSize)
+
+	var comments []*models.Comment
+
+	base := r.DB.WithContext(ctx).Model(&models.Comment{}).
+		Where("event_id = ? AND parent_id IS NULL AND status = ?", eventID, models.CommentStatusApproved)
+
+	builder := query.NewQueryBuilder(r.withReplyCount(base)).
+		WithRequest(params).
+		AllowFilters("user_id", "created_at").
+		AllowSorts("id").
+		DefaultSort("id", query.SortAsc)
+
+	if err := builder.Build().Preload("User").Preload("Mentions").Find(&comments).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve top-level comments", err, "event_id", eventID)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve comments")
+	}
+
+	var firstID, lastID int
+	if len(comments) > 0 {
+		firstID = comments[0].ID
+		lastID = comments[len(comments)-1].ID
+	}
+
+	result := query.BuildResponse(comments, params, 0, len(comments), firstID, lastID)
+
+	logging.Debug(ctx, "top-level comments retrieved successfully", "event_id", eventID, "count", len(comments))
+	return comments, result, nil
+}
+
+// GetThread retrieves a comment together with all of its nested replies
+func (r *CommentRepository) GetThread(ctx context.Context, id int) (*models.Comment, error) {
+	logging.Debug(ctx, "retrieving comment thread", "comment_id", id)
+
+	var comments []*models.Comment
+	err := r.withReplyCount(r.DB.WithContext(ctx).Model(&models.Comment{})).
+		Where("comments.id IN (?)", r.DB.Raw(threadIDsQuery, id)).
+		Preload("User").
+		Preload("Mentions").
+		Order("depth ASC, id ASC").
+		Find(&comments).Error
+	if err != nil {
+		logging.Error(ctx, "failed to retrieve comment thread", err, "comment_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve comment thread")
+	}
+
+	// Rows are ordered by depth, so every parent is indexed before its replies
+	byID := make(map[int]*models.Comment, len(comments))
+	var root *models.Comment
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+		if comment.ID == id {
+			root = comment
+			continue
+		}
+		if comment.ParentID != nil {
+			if parent, ok := byID[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, comment)
+			}
+		}
+	}
+
+	if root == nil {
+		logging.Debug(ctx, "comment thread not found", "comment_id", id)
+		return nil, appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", id)
+	}
+
+	logging.Debug(ctx, "comment thread retrieved successfully", "comment_id", id, "size", len(comments))
+	return root, nil
+}
+
+// ListForModeration retrieves an event's comments in a moderation status, or those with
+// open reports when status is models.CommentStatusReported, including report counts
+func (r *CommentRepository) ListForModeration(ctx context.Context, eventID int, status string, params *query.QueryParams) ([]*models.Comment, *query.PaginatedList, error) {
+	logging.Debug(ctx, "retrieving comments for moderation", "event_id", eventID, "status", status)
+
+	var comments []*models.Comment
+	var total int64
+
+	reports := r.DB.Table("comment_reports").Select("COUNT(*)").
+		Where("comment_reports.comment_id = comments.id AND comment_reports.status = ?", models.ReportStatusOpen)
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("comments.event_id = ?", eventID)
+		if status == models.CommentStatusReported {
+			return db.Where("EXISTS (?)", reports)
+		}
+		return db.Where("comments.status = ?", status)
+	}
+
+	if params.IncludeTotal {
+		if err := r.DB.WithContext(ctx).Model(&models.Comment{}).Scopes(scope).Count(&total).Error; err != nil {
+			logging.Error(ctx, "failed to count comments for moderation", err, "event_id", eventID)
+			return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count comments")
+		}
+	}
+
+	base := r.DB.WithContext(ctx).Model(&models.Comment{}).Scopes(scope).
+		Select("comments.*, (?) AS report_count", reports)
+
+	builder := query.NewQueryBuilder(base).
+		WithRequest(params).
+		AllowFilters("user_id", "created_at").
+		AllowSorts("id", "created_at").
+		DefaultSort("created_at", query.SortAsc)
+
+	if err := builder.Build().Preload("User").Preload("Mentions").Find(&comments).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve comments for moderation", err, "event_id", eventID)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve comments")
+	}
+
+	var firstID, lastID int
+	if len(comments) > 0 {
+		firstID = comments[0].ID
+		lastID = comments[len(comments)-1].ID
+	}
+
+	result := query.BuildResponse(comments, params, total, len(comments), firstID, lastID)
+
+	logging.Debug(ctx, "comments for moderation retrieved successfully", "event_id", eventID, "count", len(comments))
+	return comments, result, nil
+}
+
+// SetStatus changes the moderation status of a comment
+func (r *CommentRepository) SetStatus(ctx context.Context, id int, status string) error {
+	logging.Debug(ctx, "setting comment status", "comment_id", id, "status", status)
+
+	result := r.DB.WithContext(ctx).Model(&models.Comment{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		logging.Error(ctx, "failed to set comment status", result.Error, "comment_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update comment status")
+	}
+
+	if result.RowsAffected == 0 {
+		return appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", id)
+	}
+
+	logging.Info(ctx, "comment status updated", "comment_id", id, "status", status)
+	return nil
+}
+
+// Update replaces the content, status and mentions of a comment, storing the previous version as a revision
+func (r *CommentRepository) Update(ctx context.Context, id int, content string, status string, mentions []models.CommentMention, editorID int) (*models.Comment, error) {
+	logging.Debug(ctx, "updating comment", "comment_id", id, "editor_id", editorID)
+
+	var comment models.Comment
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", id)
+			}
+			return err
+		}
+
+		revision := &models.CommentRevision{
+			CommentID: comment.ID,
+			Content:   comment.Content,
+			EditedBy:  editorID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		for i := range mentions {
+			mentions[i].ID = 0
+			mentions[i].CommentID = comment.ID
+		}
+		if len(mentions) > 0 {
+			if err := tx.Create(&mentions).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		return tx.Model(&comment).Updates(map[string]interface{}{
+			"content":   content,
+			"status":    status,
+			"is_edited": true,
+			"edited_at": now,
+		}).Error
+	})
+	if err != nil {
+		if appErrors.IsType(err, appErrors.ErrNotFound) {
+			return nil, err
+		}
+		logging.Error(ctx, "failed to update comment", err, "comment_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to update comment")
+	}
+
+	if err := r.DB.WithContext(ctx).Preload("User").Preload("Mentions").First(&comment, id).Error; err != nil {
+		logging.Error(ctx, "failed to reload updated comment", err, "comment_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve updated comment")
+	}
+
+	logging.Info(ctx, "comment updated successfully", "comment_id", id, "editor_id", editorID)
+	return &comment, nil
+}
+
+// GetRevisions retrieves the previous versions of a comment, newest first
+func (r *CommentRepository) GetRevisions(ctx context.Context, commentID int) ([]*models.CommentRevision, error) {
+	logging.Debug(ctx, "retrieving comment revisions", "comment_id", commentID)
+
+	var revisions []*models.CommentRevision
+	if err := r.DB.WithContext(ctx).Preload("Editor").Where("comment_id = ?", commentID).Order("id DESC").Find(&revisions).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve comment revisions", err, "comment_id", commentID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve comment revisions")
+	}
+
+	logging.Debug(ctx, "comment revisions retrieved successfully", "comment_id", commentID, "count", len(revisions))
+	return revisions, nil
+}
+
+// Delete removes a comment. A comment that still has replies is replaced by a
+// tombstone so the replies stay attached; deleting the last reply of a tombstone
+// removes the tombstone as well.
 func (r *CommentRepository) Delete(ctx context.Context, id int) error {
-	result := r.DB.WithContext(ctx).Delete(&models.Comment{}, id)
-	return result.Error
+	logging.Debug(ctx, "deleting comment", "comment_id", id)
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var comment models.Comment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "comment with ID %d not found", id)
+			}
+			return err
+		}
+
+		hasReplies, err := r.hasReplies(tx, comment.ID)
+		if err != nil {
+			return err
+		}
+		if hasReplies {
+			if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&comment).Updates(map[string]interface{}{
+				"content":    models.DeletedCommentContent,
+				"is_deleted": true,
+			}).Error
+		}
+
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+
+		// Prune tombstoned ancestors that no longer have any replies
+		parentID := comment.ParentID
+		for parentID != nil {
+			var parent models.Comment
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&parent, *parentID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			if !parent.IsDeleted {
+				return nil
+			}
+			hasReplies, err := r.hasReplies(tx, parent.ID)
+			if err != nil || hasReplies {
+				return err
+			}
+			if err := tx.Delete(&parent).Error; err != nil {
+				return err
+			}
+			parentID = parent.ParentID
+		}
+		return nil
+	})
+	if err != nil {
+		if appErrors.IsType(err, appErrors.ErrNotFound) {
+			return err
+		}
+		logging.Error(ctx, "failed to delete comment", err, "comment_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to delete comment")
+	}
+
+	logging.Info(ctx, "comment deleted successfully", "comment_id", id)
+	return nil
+}
+
+// hasReplies reports whether a comment has at least one direct reply
+func (r *CommentRepository) hasReplies(tx *gorm.DB, id int) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.Comment{}).Where("parent_id = ?", id).Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 // Get retrieves a comment by ID
 func (r *CommentRepository) Get(ctx context.Context, id int) (*models.Comment, error) {
 	var comment models.Comment
-	result := r.DB.WithContext(ctx).First(&comment, id)
+	result := r.DB.WithContext(ctx).Preload("Mentions").First(&comment, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, nil
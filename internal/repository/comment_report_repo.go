@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"gorm.io/gorm"
+)
+
+// CommentReportRepository handles comment report database operations
+type CommentReportRepository struct {
+	DB *gorm.DB
+}
+
+// NewCommentReportRepository creates a new CommentReportRepository
+func NewCommentReportRepository(db *gorm.DB) *CommentReportRepository {
+	return &CommentReportRepository{DB: db}
+}
+
+// Insert records a report; a user can report a comment only once
+func (r *CommentReportRepository) Insert(ctx context.Context, report *models.CommentReport) (*models.CommentReport, error) {
+	logging.Debug(ctx, "creating comment report", "comment_id", report.CommentID, "reporter_id", report.ReporterID)
+
+	if err := r.DB.WithContext(ctx).Create(report).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, appErrors.New(appErrors.ErrAlreadyExists, "You have already reported this comment")
+		}
+		logging.Error(ctx, "failed to create comment report", err, "comment_id", report.CommentID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to report comment")
+	}
+
+	logging.Info(ctx, "comment reported", "report_id", report.ID, "comment_id", report.CommentID)
+	return report, nil
+}
+
+// CountOpenByComment counts unresolved reports for a comment
+func (r *CommentReportRepository) CountOpenByComment(ctx context.Context, commentID int) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.CommentReport{}).
+		Where("comment_id = ? AND status = ?", commentID, models.ReportStatusOpen).
+		Count(&count).Error
+	if err != nil {
+		logging.Error(ctx, "failed to count comment reports", err, "comment_id", commentID)
+		return 0, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count comment reports")
+	}
+	return count, nil
+}
+
+// ResolveByComment marks all open reports for a comment as resolved
+func (r *CommentReportRepository) ResolveByComment(ctx context.Context, commentID, resolverID int) error {
+	logging.Debug(ctx, "resolving comment reports", "comment_id", commentID, "resolver_id", resolverID)
+
+	now := time.Now()
+	result := r.DB.WithContext(ctx).Model(&models.CommentReport{}).
+		Where("comment_id = ? AND status = ?", commentID, models.ReportStatusOpen).
+		Updates(map[string]interface{}{
+			"status":      models.ReportStatusResolved,
+			"resolved_by": resolverID,
+			"resolved_at": now,
+		})
+	if result.Error != nil {
+		logging.Error(ctx, "failed to resolve comment reports", result.Error, "comment_id", commentID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to resolve comment reports")
+	}
+
+	logging.Info(ctx, "comment reports resolved", "comment_id", commentID, "count", result.RowsAffected)
+	return nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"gorm.io/gorm"
+)
+
+// CouponRepository handles coupon database operations
+type CouponRepository struct {
+	DB *gorm.DB
+}
+
+// NewCouponRepository creates a new CouponRepository
+func NewCouponRepository(db *gorm.DB) *CouponRepository {
+	return &CouponRepository{DB: db}
+}
+
+// Insert creates a coupon restricted to the given products and categories. Codes
+// are stored upper-cased and must be unique.
+func (r *CouponRepository) Insert(ctx context.Context, coupon *models.Coupon, productIDs, categoryIDs []int) (*models.Coupon, error) {
+	coupon.Code = strings.ToUpper(strings.TrimSpace(coupon.Code))
+	logging.Debug(ctx, "creating coupon", "code", coupon.Code)
+
+	if len(productIDs) > 0 {
+		if err := r.DB.WithContext(ctx).Where("id IN ?", productIDs).Find(&coupon.Products).Error; err != nil {
+			logging.Error(ctx, "failed to load coupon products", err, "code", coupon.Code)
+			return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create coupon")
+		}
+		if len(coupon.Products) != len(uniqueIDs(productIDs)) {
+			return nil, appErrors.New(appErrors.ErrInvalidInput, "coupon references products that do not exist")
+		}
+	}
+	if len(categoryIDs) > 0 {
+		if err := r.DB.WithContext(ctx).Where("id IN ?", categoryIDs).Find(&coupon.Categories).Error; err != nil {
+			logging.Error(ctx, "failed to load coupon categories", err, "code", coupon.Code)
+			return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create coupon")
+		}
+		if len(coupon.Categories) != len(uniqueIDs(categoryIDs)) {
+			return nil, appErrors.New(appErrors.ErrInvalidInput, "coupon references categories that do not exist")
+		}
+	}
+
+	// Link the existing products and categories without re-saving them
+	if err := r.DB.WithContext(ctx).Omit("Products.*", "Categories.*").Create(coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, appErrors.Newf(appErrors.ErrAlreadyExists, "coupon %s already exists", coupon.Code)
+		}
+		logging.Error(ctx, "failed to create coupon", err, "code", coupon.Code)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create coupon")
+	}
+
+	logging.Info(ctx, "coupon created successfully", "coupon_id", coupon.ID, "code", coupon.Code)
+	return coupon, nil
+}
+
+// Get retrieves a coupon by ID with its restrictions
+func (r *CouponRepository) Get(ctx context.Context, id int) (*models.Coupon, error) {
+	logging.Debug(ctx, "retrieving coupon", "coupon_id", id)
+
+	var coupon models.Coupon
+	err := r.DB.WithContext(ctx).Preload("Products").Preload("Categories").First(&coupon, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "coupon with ID %d not found", id)
+		}
+		logging.Error(ctx, "failed to retrieve coupon", err, "coupon_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve coupon")
+	}
+
+	return &coupon, nil
+}
+
+// GetByCode retrieves a coupon by its case-insensitive code with its restrictions
+func (r *CouponRepository) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	logging.Debug(ctx, "retrieving coupon by code", "code", code)
+
+	var coupon models.Coupon
+	err := r.DB.WithContext(ctx).Preload("Products").Preload("Categories").Where("code = ?", code).First(&coupon).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "coupon %s not found", code)
+		}
+		logging.Error(ctx, "failed to retrieve coupon", err, "code", code)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve coupon")
+	}
+
+	return &coupon, nil
+}
+
+// List retrieves coupons, newest first
+func (r *CouponRepository) List(ctx context.Context, params *query.QueryParams) ([]*models.Coupon, *query.PaginatedList, error) {
+	logging.Debug(ctx, "retrieving coupons")
+
+	var coupons []*models.Coupon
+	var total int64
+
+	if params.IncludeTotal {
+		if err := r.DB.WithContext(ctx).Model(&models.Coupon{}).Count(&total).Error; err != nil {
+			logging.Error(ctx, "failed to count coupons", err)
+			return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count coupons")
+		}
+	}
+
+	builder := query.NewQueryBuilder(r.DB.WithContext(ctx).Model(&models.Coupon{})).
+		WithRequest(params).
+		AllowFilters("type", "active", "code").
+		AllowSorts("id", "created_at", "redemptions").
+		DefaultSort("created_at", query.SortDesc)
+
+	if err := builder.Build().Preload("Products").Preload("Categories").Find(&coupons).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve coupons", err)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve coupons")
+	}
+
+	var firstID, lastID int
+	if len(coupons) > 0 {
+		firstID = coupons[0].ID
+		lastID = coupons[len(coupons)-1].ID
+	}
+
+	result := query.BuildResponse(coupons, params, total, len(coupons), firstID, lastID)
+	return coupons, result, nil
+}
+
+// Deactivate stops a coupon from being applied or redeemed
+func (r *CouponRepository) Deactivate(ctx context.Context, id int) error {
+	logging.Debug(ctx, "deactivating coupon", "coupon_id", id)
+
+	result := r.DB.WithContext(ctx).Model(&models.Coupon{}).Where("id = ?", id).Update("active", false)
+	if result.Error != nil {
+		logging.Error(ctx, "failed to deactivate coupon", result.Error, "coupon_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to deactivate coupon")
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.Newf(appErrors.ErrNotFound, "coupon with ID %d not found", id)
+	}
+
+	logging.Info(ctx, "coupon deactivated", "coupon_id", id)
+	return nil
+}
+
+// CountUserRedemptions counts how many orders of a user redeemed a coupon
+func (r *CouponRepository) CountUserRedemptions(ctx context.Context, couponID, userID int) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ?", couponID, userID).
+		Count(&count).Error
+	if err != nil {
+		logging.Error(ctx, "failed to count coupon redemptions", err, "coupon_id", couponID, "user_id", userID)
+		return 0, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count coupon redemptions")
+	}
+	return count, nil
+}
+
+// uniqueIDs returns ids without duplicates
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
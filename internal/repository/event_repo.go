@@ -157,8 +157,11 @@ func (r *EventRepository) ListWithAdvancedPagination(ctx context.Context, req *q
 		WithRequest(req).
 		AllowFilters("name", "location", "owner_id", "start_date", "end_date", "created_at", "status").
 		AllowSorts("name", "start_date", "end_date", "created_at", "updated_at").
-		SearchColumns("name", "description", "location").
+		FullTextSearch("search_vector", "name", "description", "location").
 		DefaultSort("created_at", query.SortDesc)
+	if err := builder.Validate(); err != nil {
+		return nil, nil, appErrors.New(appErrors.ErrInvalidInput, err.Error())
+	}
 
 	// Get count if needed
 	if req.IncludeTotal {
@@ -168,7 +171,7 @@ func (r *EventRepository) ListWithAdvancedPagination(ctx context.Context, req *q
 			countQuery = query.FilterBy(filter)(countQuery)
 		}
 		if req.Search != "" {
-			countQuery = query.Search(req.Search, "name", "description", "location")(countQuery)
+			countQuery = query.FullTextSearch(req.Search, "search_vector")(countQuery)
 		}
 		countQuery.Count(&total)
 	}
@@ -2,15 +2,20 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
 )
 
 type BasketRepositoryInterface interface {
 	GetActiveBasket(ctx context.Context, userID int) (*models.Basket, error)
+	GetGuestBasket(ctx context.Context, basketID int) (*models.Basket, error)
 	CreateBasket(ctx context.Context, basket *models.Basket) error
-	AddItem(ctx context.Context, basketID int, item *models.BasketItem) error
-	UpdateItemQuantity(ctx context.Context, itemID int, quantity int) error
-	RemoveItem(ctx context.Context, itemID int) error
+	AddItem(ctx context.Context, basketID int, item *models.BasketItem, reserveUntil time.Time) error
+	UpdateItemQuantity(ctx context.Context, basketID, itemID int, quantity int, reserveUntil time.Time) error
+	RemoveItem(ctx context.Context, basketID, itemID int) error
 	ClearBasket(ctx context.Context, basketID int) error
+	SetCoupon(ctx context.Context, basketID int, couponID *int) error
+	MergeGuestBasket(ctx context.Context, guestBasketID, userID int, reserveUntil time.Time) error
+	DeleteStaleGuestBaskets(ctx context.Context, before time.Time) (int64, error)
 }
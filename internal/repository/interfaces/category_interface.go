@@ -11,4 +11,8 @@ type CategoryRepositoryInterface interface {
 	GetAll(ctx context.Context) ([]*models.Category, error)
 	Get(ctx context.Context, id int) (*models.Category, error)
 	GetBySlug(ctx context.Context, slug string) (*models.Category, error)
+	Update(ctx context.Context, category *models.Category) error
+	Tree(ctx context.Context) ([]models.Category, error)
+	Move(ctx context.Context, id int, parentID *int) (*models.Category, error)
+	Delete(ctx context.Context, id int, strategy string, targetID *int) (int, error)
 }
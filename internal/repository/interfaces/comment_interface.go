@@ -4,11 +4,18 @@ import (
 	"context"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
 )
 
 type CommentRepositoryInterface interface {
 	Insert(ctx context.Context, comment *models.Comment) (*models.Comment, error)
 	GetByEvent(ctx context.Context, eventID int) ([]*models.Comment, error)
+	ListTopLevelByEvent(ctx context.Context, eventID int, params *query.QueryParams) ([]*models.Comment, *query.PaginatedList, error)
+	GetThread(ctx context.Context, id int) (*models.Comment, error)
+	ListForModeration(ctx context.Context, eventID int, status string, params *query.QueryParams) ([]*models.Comment, *query.PaginatedList, error)
+	SetStatus(ctx context.Context, id int, status string) error
+	Update(ctx context.Context, id int, content string, status string, mentions []models.CommentMention, editorID int) (*models.Comment, error)
+	GetRevisions(ctx context.Context, commentID int) ([]*models.CommentRevision, error)
 	Delete(ctx context.Context, id int) error
 	Get(ctx context.Context, id int) (*models.Comment, error)
 }
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+type CommentReportRepositoryInterface interface {
+	Insert(ctx context.Context, report *models.CommentReport) (*models.CommentReport, error)
+	CountOpenByComment(ctx context.Context, commentID int) (int64, error)
+	ResolveByComment(ctx context.Context, commentID, resolverID int) error
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+)
+
+type CouponRepositoryInterface interface {
+	Insert(ctx context.Context, coupon *models.Coupon, productIDs, categoryIDs []int) (*models.Coupon, error)
+	Get(ctx context.Context, id int) (*models.Coupon, error)
+	GetByCode(ctx context.Context, code string) (*models.Coupon, error)
+	List(ctx context.Context, params *query.QueryParams) ([]*models.Coupon, *query.PaginatedList, error)
+	Deactivate(ctx context.Context, id int) error
+	CountUserRedemptions(ctx context.Context, couponID, userID int) (int64, error)
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+)
+
+type InventoryRepositoryInterface interface {
+	Adjust(ctx context.Context, adjustment *models.InventoryAdjustment) error
+	Reconcile(ctx context.Context, productID int, actorID *int) ([]models.InventoryAdjustment, error)
+	List(ctx context.Context, productID int, params *query.QueryParams) ([]*models.InventoryAdjustment, *query.PaginatedList, error)
+	ListByOrder(ctx context.Context, orderID int) ([]models.InventoryAdjustment, error)
+}
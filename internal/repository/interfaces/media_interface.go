@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+type MediaRepositoryInterface interface {
+	Insert(ctx context.Context, media *models.Media) (*models.Media, error)
+	Get(ctx context.Context, id int) (*models.Media, error)
+	GetByHash(ctx context.Context, hash string) (*models.Media, error)
+	GetVariant(ctx context.Context, mediaID int, name string) (*models.MediaVariant, error)
+	SaveVariant(ctx context.Context, variant *models.MediaVariant) error
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+)
+
+type NotificationRepositoryInterface interface {
+	Insert(ctx context.Context, notification *models.Notification) (*models.Notification, error)
+	ListByUser(ctx context.Context, userID int, unreadOnly bool, params *query.QueryParams) ([]*models.Notification, *query.PaginatedList, error)
+	MarkRead(ctx context.Context, userID, id int) error
+	MarkAllRead(ctx context.Context, userID int) (int64, error)
+	Mute(ctx context.Context, userID, eventID int) error
+	Unmute(ctx context.Context, userID, eventID int) error
+	MutedUserIDs(ctx context.Context, eventID int, userIDs []int) ([]int, error)
+	MentionedUserIDs(ctx context.Context, commentID int) ([]int, error)
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+)
+
+type OrderRepositoryInterface interface {
+	Checkout(ctx context.Context, userID int, quote pricing.QuoteFunc) (*models.Order, error)
+	Get(ctx context.Context, id int) (*models.Order, error)
+	ListByUser(ctx context.Context, userID int, params *query.QueryParams) ([]*models.Order, *query.PaginatedList, error)
+	ListBySeller(ctx context.Context, sellerID int, params *query.QueryParams) ([]*models.Order, *query.PaginatedList, error)
+	Transition(ctx context.Context, id int, status string, actorID *int, note string) (*models.Order, error)
+	HasPurchased(ctx context.Context, userID, productID int) (bool, error)
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+type PaymentRepositoryInterface interface {
+	Insert(ctx context.Context, payment *models.Payment) (*models.Payment, error)
+	GetByIntent(ctx context.Context, provider, intentID string) (*models.Payment, error)
+	ListByOrder(ctx context.Context, orderID int) ([]*models.Payment, error)
+	ListPendingRefunds(ctx context.Context, before time.Time) ([]*models.Payment, error)
+	UpdateStatus(ctx context.Context, id int, status, errMsg string) error
+	HasEvent(ctx context.Context, provider, eventID string) (bool, error)
+	RecordEvent(ctx context.Context, event *models.PaymentEvent) error
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+type ProductImportRepositoryInterface interface {
+	Insert(ctx context.Context, job *models.ProductImport) (*models.ProductImport, error)
+	Get(ctx context.Context, id int) (*models.ProductImport, error)
+	Update(ctx context.Context, job *models.ProductImport) error
+}
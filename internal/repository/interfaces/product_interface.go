@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
 	"github.com/alireza-akbarzadeh/ginflow/internal/query"
@@ -11,10 +12,20 @@ type ProductRepositoryInterface interface {
 	Insert(ctx context.Context, product *models.Product) (*models.Product, error)
 	GetAll(ctx context.Context, page, limit int, search string, categoryID int) ([]models.Product, int64, error)
 	ListWithAdvancedPagination(ctx context.Context, req *query.QueryParams) ([]models.Product, *query.PaginatedList, error)
+	Facets(ctx context.Context, req *query.QueryParams) (*models.ProductFacets, error)
 	Get(ctx context.Context, id int) (*models.Product, error)
 	GetBySlug(ctx context.Context, slug string) (*models.Product, error)
 	Update(ctx context.Context, product *models.Product) error
+	UpsertBySKU(ctx context.Context, product *models.Product, categorySlugs []string) (*models.Product, error)
 	Delete(ctx context.Context, id int) error
 	GetByUser(ctx context.Context, userID int) ([]models.Product, error)
 	GetByCategory(ctx context.Context, categoryID int) ([]models.Product, error)
+	AddViews(ctx context.Context, views []models.ProductView) error
+	Trending(ctx context.Context, now time.Time, halfLife time.Duration, saleWeight, limit int) ([]models.Product, error)
+	Related(ctx context.Context, product *models.Product, limit int) ([]models.Product, error)
+	PriceHistory(ctx context.Context, productID int) ([]models.ProductPriceChange, error)
+	PriceSchedules(ctx context.Context, productID int) ([]models.ProductPriceSchedule, error)
+	CreatePriceSchedule(ctx context.Context, schedule *models.ProductPriceSchedule) error
+	CancelPriceSchedule(ctx context.Context, productID, scheduleID int, actorID *int) (*models.ProductPriceSchedule, error)
+	ApplyPriceSchedules(ctx context.Context, now time.Time) ([]models.ProductRepricing, error)
 }
@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+type ReservationRepositoryInterface interface {
+	ReleaseExpired(ctx context.Context, now time.Time) (int64, error)
+}
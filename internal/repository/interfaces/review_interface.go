@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+)
+
+type ReviewRepositoryInterface interface {
+	Insert(ctx context.Context, review *models.Review) (*models.Review, error)
+	Get(ctx context.Context, id int) (*models.Review, error)
+	ListByProduct(ctx context.Context, productID int, params *query.QueryParams) ([]*models.Review, *query.PaginatedList, error)
+	Update(ctx context.Context, review *models.Review) error
+	Delete(ctx context.Context, id int) error
+}
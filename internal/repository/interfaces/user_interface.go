@@ -11,6 +11,7 @@ type UserRepositoryInterface interface {
 	Insert(ctx context.Context, user *models.User) (*models.User, error)
 	Get(ctx context.Context, id int) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByMentions(ctx context.Context, names []string, emails []string) ([]*models.User, error)
 	UpdatePassword(ctx context.Context, userID int, hashedPassword string) error
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id int) error
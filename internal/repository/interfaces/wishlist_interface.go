@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+type WishlistRepositoryInterface interface {
+	Insert(ctx context.Context, wishlist *models.Wishlist) (*models.Wishlist, error)
+	Get(ctx context.Context, id int) (*models.Wishlist, error)
+	GetByShareToken(ctx context.Context, token string) (*models.Wishlist, error)
+	ListByUser(ctx context.Context, userID int) ([]*models.Wishlist, error)
+	Delete(ctx context.Context, id int) error
+	SetShareToken(ctx context.Context, id int, token *string) error
+	AddItem(ctx context.Context, wishlistID, productID int) error
+	RemoveItem(ctx context.Context, wishlistID, productID int) error
+	SaveForLater(ctx context.Context, userID, basketID, itemID int) (*models.Wishlist, error)
+	WatcherIDs(ctx context.Context, productID int) ([]int, error)
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InventoryRepository handles inventory ledger database operations
+type InventoryRepository struct {
+	DB *gorm.DB
+}
+
+// NewInventoryRepository creates a new InventoryRepository
+func NewInventoryRepository(db *gorm.DB) *InventoryRepository {
+	return &InventoryRepository{DB: db}
+}
+
+// Adjust changes the stock of a product, or of one of its variants, and adds
+// the adjustment to the ledger. Products sold in variants are adjusted per
+// variant, and stock cannot go below zero.
+func (r *InventoryRepository) Adjust(ctx context.Context, adjustment *models.InventoryAdjustment) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, adjustment.ProductID)
+		if err != nil {
+			return err
+		}
+
+		// The product's lock covers its variants' stock
+		var variants []models.ProductVariant
+		if err := tx.Select("id", "stock").Where("product_id = ?", product.ID).Find(&variants).Error; err != nil {
+			return err
+		}
+
+		stock := product.Stock
+		switch {
+		case adjustment.VariantID != nil:
+			i := slices.IndexFunc(variants, func(variant models.ProductVariant) bool { return variant.ID == *adjustment.VariantID })
+			if i < 0 {
+				return appErrors.Newf(appErrors.ErrNotFound, "variant with ID %d of the product not found", *adjustment.VariantID)
+			}
+			stock = variants[i].Stock
+		case len(variants) > 0:
+			return appErrors.New(appErrors.ErrInvalidInput, "the stock of products sold in variants is adjusted per variant")
+		}
+		if stock+adjustment.Delta < 0 {
+			return appErrors.Newf(appErrors.ErrConflict, "only %d in stock", stock).WithDetail("stock", stock)
+		}
+
+		return adjustInventory(tx, adjustment)
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		logging.Error(ctx, "failed to adjust inventory", err, "product_id", adjustment.ProductID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to adjust inventory")
+	}
+
+	logging.Info(ctx, "inventory adjusted", "product_id", adjustment.ProductID, "variant_id", adjustment.VariantID,
+		"delta", adjustment.Delta, "reason", adjustment.Reason, "actor_id", adjustment.ActorID)
+	return nil
+}
+
+// Reconcile adds corrections to the ledger of a product so it adds up to the
+// stock of the product and of its variants, e.g. for stock from before the
+// ledger, and returns them
+func (r *InventoryRepository) Reconcile(ctx context.Context, productID int, actorID *int) ([]models.InventoryAdjustment, error) {
+	var corrections []models.InventoryAdjustment
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Select("id", "stock").Where("product_id = ?", productID).Order("id ASC").Find(&product.Variants).Error; err != nil {
+			return err
+		}
+
+		var sums []struct {
+			VariantID *int
+			Total     int
+		}
+		err = tx.Model(&models.InventoryAdjustment{}).Select("variant_id, SUM(delta) AS total").
+			Where("product_id = ?", productID).Group("variant_id").Scan(&sums).Error
+		if err != nil {
+			return err
+		}
+		ledgerStock := 0
+		ledgerVariants := make(map[int]int)
+		for _, sum := range sums {
+			ledgerStock += sum.Total
+			if sum.VariantID != nil {
+				ledgerVariants[*sum.VariantID] = sum.Total
+			}
+		}
+
+		// The ledger's stock is taken to the actual one, without low-stock alerts
+		corrections = stockChanges(product, ledgerStock, ledgerVariants, models.InventoryReasonCorrection, actorID)
+		if len(corrections) == 0 {
+			return nil
+		}
+		for i := range corrections {
+			corrections[i].Note = "Reconciled with the stock"
+		}
+		return tx.Create(&corrections).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to reconcile inventory", err, "product_id", productID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to reconcile inventory")
+	}
+
+	if len(corrections) > 0 {
+		logging.Info(ctx, "inventory reconciled", "product_id", productID, "corrections", len(corrections), "actor_id", actorID)
+	}
+	return corrections, nil
+}
+
+// List retrieves the inventory ledger of a product, latest first
+func (r *InventoryRepository) List(ctx context.Context, productID int, params *query.QueryParams) ([]*models.InventoryAdjustment, *query.PaginatedList, error) {
+	var adjustments []*models.InventoryAdjustment
+	var total int64
+
+	base := r.DB.WithContext(ctx).Model(&models.InventoryAdjustment{}).Where("product_id = ?", productID)
+	builder := query.NewQueryBuilder(base).
+		WithRequest(params).
+		AllowFilters("reason", "variant_id", "order_id", "created_at").
+		AllowSorts("id", "created_at").
+		DefaultSort("created_at", query.SortDesc)
+
+	if params.IncludeTotal {
+		if err := builder.BuildFilters().Count(&total).Error; err != nil {
+			logging.Error(ctx, "failed to count inventory adjustments", err, "product_id", productID)
+			return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count inventory adjustments")
+		}
+	}
+
+	if err := builder.Build().Find(&adjustments).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve inventory adjustments", err, "product_id", productID)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve inventory adjustments")
+	}
+
+	var firstID, lastID int
+	if len(adjustments) > 0 {
+		firstID = adjustments[0].ID
+		lastID = adjustments[len(adjustments)-1].ID
+	}
+
+	return adjustments, query.BuildResponse(adjustments, params, total, len(adjustments), firstID, lastID), nil
+}
+
+// ListByOrder retrieves the adjustments made for an order, with their products
+func (r *InventoryRepository) ListByOrder(ctx context.Context, orderID int) ([]models.InventoryAdjustment, error) {
+	var adjustments []models.InventoryAdjustment
+	err := r.DB.WithContext(ctx).Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("order_id = ?", orderID).Order("id ASC").Find(&adjustments).Error
+	if err != nil {
+		logging.Error(ctx, "failed to retrieve order inventory adjustments", err, "order_id", orderID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve inventory adjustments")
+	}
+	return adjustments, nil
+}
+
+// adjustInventory changes the stock of the adjustment's product, and of its
+// variant when it has one, by the adjustment's delta and adds the adjustment
+// to the ledger
+func adjustInventory(tx *gorm.DB, adjustment *models.InventoryAdjustment) error {
+	var product models.Product
+	// Unscoped so soft-deleted products still get their stock back
+	err := tx.Unscoped().Model(&product).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "stock"}, {Name: "low_stock_threshold"}}}).
+		Where("id = ?", adjustment.ProductID).
+		Update("stock", gorm.Expr("stock + ?", adjustment.Delta)).Error
+	if err != nil {
+		return err
+	}
+	adjustment.StockAfter = product.Stock
+	adjustment.LowStock = product.CrossedLowStock(product.Stock - adjustment.Delta)
+
+	if adjustment.VariantID != nil {
+		var variant models.ProductVariant
+		err := tx.Model(&variant).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "stock"}}}).
+			Where("id = ?", *adjustment.VariantID).
+			Update("stock", gorm.Expr("stock + ?", adjustment.Delta)).Error
+		if err != nil {
+			return err
+		}
+		adjustment.StockAfter = variant.Stock
+	}
+
+	return tx.Create(adjustment).Error
+}
+
+// recordInventory adds adjustments of a product's stock, already changed from
+// previousStock, to the ledger. The last is marked when together they took the
+// product's stock to or below its low-stock threshold.
+func recordInventory(tx *gorm.DB, product *models.Product, previousStock int, adjustments []models.InventoryAdjustment) error {
+	if len(adjustments) == 0 {
+		return nil
+	}
+	adjustments[len(adjustments)-1].LowStock = product.CrossedLowStock(previousStock)
+	return tx.Create(&adjustments).Error
+}
+
+// stockChanges returns the adjustments taking the stock of a product and its
+// variants from the previous to the current, by variant ID. Previous variants
+// the product no longer has go out of stock, and the rest of the product's
+// change is an adjustment of no variant.
+func stockChanges(product *models.Product, previousStock int, previousVariants map[int]int, reason string, actorID *int) []models.InventoryAdjustment {
+	var adjustments []models.InventoryAdjustment
+	add := func(variantID *int, delta, stockAfter int) {
+		adjustments = append(adjustments, models.InventoryAdjustment{
+			ProductID:  product.ID,
+			VariantID:  variantID,
+			Delta:      delta,
+			Reason:     reason,
+			StockAfter: stockAfter,
+			ActorID:    actorID,
+		})
+	}
+
+	variantsDelta := 0
+	current := make(map[int]bool, len(product.Variants))
+	for _, variant := range product.Variants {
+		current[variant.ID] = true
+		if delta := variant.Stock - previousVariants[variant.ID]; delta != 0 {
+			add(&variant.ID, delta, variant.Stock)
+			variantsDelta += delta
+		}
+	}
+
+	removed := make([]int, 0, len(previousVariants))
+	for id, stock := range previousVariants {
+		if !current[id] && stock != 0 {
+			removed = append(removed, id)
+		}
+	}
+	slices.Sort(removed)
+	for _, id := range removed {
+		add(&id, -previousVariants[id], 0)
+		variantsDelta -= previousVariants[id]
+	}
+
+	if delta := product.Stock - previousStock - variantsDelta; delta != 0 {
+		add(nil, delta, product.Stock)
+	}
+	return adjustments
+}
+
+// variantStock returns the stored stock of a product's variants by variant ID
+func variantStock(tx *gorm.DB, productID int) (map[int]int, error) {
+	var variants []models.ProductVariant
+	if err := tx.Select("id", "stock").Where("product_id = ?", productID).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	stock := make(map[int]int, len(variants))
+	for _, variant := range variants {
+		stock[variant.ID] = variant.Stock
+	}
+	return stock, nil
+}
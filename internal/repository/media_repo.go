@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MediaRepository handles uploaded file database operations
+type MediaRepository struct {
+	DB *gorm.DB
+}
+
+// NewMediaRepository creates a new MediaRepository
+func NewMediaRepository(db *gorm.DB) *MediaRepository {
+	return &MediaRepository{DB: db}
+}
+
+// Insert records a stored file. When the same content was recorded meanwhile,
+// that record is returned instead.
+func (r *MediaRepository) Insert(ctx context.Context, media *models.Media) (*models.Media, error) {
+	err := r.DB.WithContext(ctx).Create(media).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		existing, err := r.GetByHash(ctx, media.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		logging.Error(ctx, "failed to create media", err, "hash", media.Hash)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create media")
+	}
+
+	logging.Info(ctx, "media created", "media_id", media.ID, "user_id", media.UserID, "size", media.Size)
+	return media, nil
+}
+
+// Get retrieves a stored file by ID
+func (r *MediaRepository) Get(ctx context.Context, id int) (*models.Media, error) {
+	var media models.Media
+	if err := r.DB.WithContext(ctx).First(&media, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "media with ID %d not found", id)
+		}
+		logging.Error(ctx, "failed to retrieve media", err, "media_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve media")
+	}
+	return &media, nil
+}
+
+// GetByHash retrieves the stored file with the given content hash, or nil if
+// there is none
+func (r *MediaRepository) GetByHash(ctx context.Context, hash string) (*models.Media, error) {
+	var media models.Media
+	if err := r.DB.WithContext(ctx).Where("hash = ?", hash).First(&media).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logging.Error(ctx, "failed to retrieve media by hash", err, "hash", hash)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve media")
+	}
+	return &media, nil
+}
+
+// GetVariant retrieves the variant of a stored image made for a size, or nil
+// if there is none
+func (r *MediaRepository) GetVariant(ctx context.Context, mediaID int, name string) (*models.MediaVariant, error) {
+	var variant models.MediaVariant
+	err := r.DB.WithContext(ctx).Where("media_id = ? AND name = ?", mediaID, name).First(&variant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logging.Error(ctx, "failed to retrieve media variant", err, "media_id", mediaID, "name", name)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve media variant")
+	}
+	return &variant, nil
+}
+
+// SaveVariant records a variant of a stored image, replacing the one made for
+// the same size before
+func (r *MediaRepository) SaveVariant(ctx context.Context, variant *models.MediaVariant) error {
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "media_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_size", "key", "content_type", "width", "height", "size", "updated_at"}),
+	}).Create(variant).Error
+	if err != nil {
+		logging.Error(ctx, "failed to save media variant", err, "media_id", variant.MediaID, "name", variant.Name)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to save media variant")
+	}
+
+	logging.Debug(ctx, "media variant saved", "media_id", variant.MediaID, "name", variant.Name, "size", variant.Size)
+	return nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository handles notification database operations
+type NotificationRepository struct {
+	DB *gorm.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{DB: db}
+}
+
+// Insert creates a new notification
+func (r *NotificationRepository) Insert(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
+	logging.Debug(ctx, "creating notification", "user_id", notification.UserID, "type", notification.Type)
+
+	if err := r.DB.WithContext(ctx).Create(notification).Error; err != nil {
+		logging.Error(ctx, "failed to create notification", err, "user_id", notification.UserID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create notification")
+	}
+
+	logging.Info(ctx, "notification created", "notification_id", notification.ID, "user_id", notification.UserID)
+	return notification, nil
+}
+
+// ListByUser retrieves a user's notifications, newest first
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID int, unreadOnly bool, params *query.QueryParams) ([]*models.Notification, *query.PaginatedList, error) {
+	logging.Debug(ctx, "retrieving notifications", "user_id", userID, "unread_only", unreadOnly)
+
+	var notifications []*models.Notification
+	var total int64
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		if unreadOnly {
+			db = db.Where("read_at IS NULL")
+		}
+		return db
+	}
+
+	if params.IncludeTotal {
+		if err := r.DB.WithContext(ctx).Model(&models.Notification{}).Scopes(scope).Count(&total).Error; err != nil {
+			logging.Error(ctx, "failed to count notifications", err, "user_id", userID)
+			return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count notifications")
+		}
+	}
+
+	builder := query.NewQueryBuilder(r.DB.WithContext(ctx).Model(&models.Notification{}).Scopes(scope)).
+		WithRequest(params).
+		AllowFilters("type", "event_id").
+		AllowSorts("id", "created_at").
+		DefaultSort("created_at", query.SortDesc)
+
+	if err := builder.Build().Preload("Actor").Find(&notifications).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve notifications", err, "user_id", userID)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve notifications")
+	}
+
+	var firstID, lastID int
+	if len(notifications) > 0 {
+		firstID = notifications[0].ID
+		lastID = notifications[len(notifications)-1].ID
+	}
+
+	result := query.BuildResponse(notifications, params, total, len(notifications), firstID, lastID)
+
+	logging.Debug(ctx, "notifications retrieved successfully", "user_id", userID, "count", len(notifications))
+	return notifications, result, nil
+}
+
+// MarkRead marks one of a user's notifications as read
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id int) error {
+	logging.Debug(ctx, "marking notification read", "notification_id", id, "user_id", userID)
+
+	var notification models.Notification
+	result := r.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Limit(1).Find(&notification)
+	if result.Error != nil {
+		logging.Error(ctx, "failed to retrieve notification", result.Error, "notification_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update notification")
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.Newf(appErrors.ErrNotFound, "notification with ID %d not found", id)
+	}
+	if notification.IsRead() {
+		return nil
+	}
+
+	if err := r.DB.WithContext(ctx).Model(&notification).Update("read_at", time.Now()).Error; err != nil {
+		logging.Error(ctx, "failed to mark notification read", err, "notification_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update notification")
+	}
+
+	return nil
+}
+
+// MarkAllRead marks all of a user's unread notifications as read and returns how many changed
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	logging.Debug(ctx, "marking all notifications read", "user_id", userID)
+
+	result := r.DB.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		logging.Error(ctx, "failed to mark notifications read", result.Error, "user_id", userID)
+		return 0, appErrors.New(appErrors.ErrDatabaseOperation, "failed to update notifications")
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Mute silences mention notifications from an event for a user
+func (r *NotificationRepository) Mute(ctx context.Context, userID, eventID int) error {
+	logging.Debug(ctx, "muting event mentions", "user_id", userID, "event_id", eventID)
+
+	mute := &models.NotificationMute{UserID: userID, EventID: eventID}
+	if err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(mute).Error; err != nil {
+		logging.Error(ctx, "failed to mute event mentions", err, "user_id", userID, "event_id", eventID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to mute event")
+	}
+
+	logging.Info(ctx, "event mentions muted", "user_id", userID, "event_id", eventID)
+	return nil
+}
+
+// Unmute restores mention notifications from an event for a user
+func (r *NotificationRepository) Unmute(ctx context.Context, userID, eventID int) error {
+	logging.Debug(ctx, "unmuting event mentions", "user_id", userID, "event_id", eventID)
+
+	if err := r.DB.WithContext(ctx).Where("user_id = ? AND event_id = ?", userID, eventID).Delete(&models.NotificationMute{}).Error; err != nil {
+		logging.Error(ctx, "failed to unmute event mentions", err, "user_id", userID, "event_id", eventID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to unmute event")
+	}
+
+	logging.Info(ctx, "event mentions unmuted", "user_id", userID, "event_id", eventID)
+	return nil
+}
+
+// MentionedUserIDs returns the users already notified of a mention in a comment
+func (r *NotificationRepository) MentionedUserIDs(ctx context.Context, commentID int) ([]int, error) {
+	var userIDs []int
+	err := r.DB.WithContext(ctx).Model(&models.Notification{}).
+		Where("comment_id = ? AND type = ?", commentID, models.NotificationTypeMention).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		logging.Error(ctx, "failed to retrieve mention notifications", err, "comment_id", commentID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve mention notifications")
+	}
+
+	return userIDs, nil
+}
+
+// MutedUserIDs returns which of the given users have muted mentions from an event
+func (r *NotificationRepository) MutedUserIDs(ctx context.Context, eventID int, userIDs []int) ([]int, error) {
+	var muted []int
+	if len(userIDs) == 0 {
+		return muted, nil
+	}
+
+	err := r.DB.WithContext(ctx).Model(&models.NotificationMute{}).
+		Where("event_id = ? AND user_id IN ?", eventID, userIDs).
+		Pluck("user_id", &muted).Error
+	if err != nil {
+		logging.Error(ctx, "failed to retrieve event mutes", err, "event_id", eventID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve event mutes")
+	}
+
+	return muted, nil
+}
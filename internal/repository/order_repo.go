@@ -0,0 +1,527 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderRepository handles order database operations
+type OrderRepository struct {
+	DB        *gorm.DB
+	TxManager *TxManager
+}
+
+// NewOrderRepository creates a new OrderRepository
+func NewOrderRepository(db *gorm.DB, txManager *TxManager) *OrderRepository {
+	return &OrderRepository{DB: db, TxManager: txManager}
+}
+
+// Checkout turns the user's active basket into an order. Within one transaction it
+// locks the basket and its products, checks stock of the products, or of their
+// variants, not reserved by other baskets and decrements it, releases the basket's reservations, re-validates and
+// redeems the basket's coupon, prices the basket with quote, snapshots the basket
+// prices into order items and marks the basket completed. Baskets holding
+// prices that no longer match their products are rejected so the buyer can
+// review them first.
+func (r *OrderRepository) Checkout(ctx context.Context, userID int, quote pricing.QuoteFunc) (*models.Order, error) {
+	logging.Debug(ctx, "checking out basket", "user_id", userID)
+
+	var order *models.Order
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var basket models.Basket
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND status = ?", userID, models.BasketStatusActive).
+			First(&basket).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.New(appErrors.ErrInvalidInput, "Your basket is empty")
+			}
+			return err
+		}
+
+		if err := tx.Preload("Product.Categories").Where("basket_id = ?", basket.ID).Order("id ASC").Find(&basket.Items).Error; err != nil {
+			return err
+		}
+		if len(basket.Items) == 0 {
+			return appErrors.New(appErrors.ErrInvalidInput, "Your basket is empty")
+		}
+
+		products, err := r.lockProducts(tx, basket.Items)
+		if err != nil {
+			return err
+		}
+
+		// Other baskets' reservations hold stock this basket cannot take
+		productIDs := make([]int, 0, len(products))
+		for id := range products {
+			productIDs = append(productIDs, id)
+		}
+		reserved, err := reservedStock(tx, productIDs, basket.ID)
+		if err != nil {
+			return err
+		}
+		variants, err := r.findVariants(tx, basket.Items)
+		if err != nil {
+			return err
+		}
+		variantIDs := make([]int, 0, len(variants))
+		for id := range variants {
+			variantIDs = append(variantIDs, id)
+		}
+		reservedVariants, err := reservedVariantStock(tx, variantIDs, basket.ID)
+		if err != nil {
+			return err
+		}
+
+		coupon, err := r.lockCoupon(tx, &basket)
+		if err != nil {
+			return err
+		}
+
+		order = &models.Order{
+			UserID:   userID,
+			BasketID: &basket.ID,
+			Status:   models.OrderStatusPending,
+			History: []models.OrderStatusChange{
+				{ToStatus: models.OrderStatusPending, ActorID: &userID, Note: "Order placed"},
+			},
+		}
+		var repriced []int
+		for i := range basket.Items {
+			item := &basket.Items[i]
+			product, ok := products[item.ProductID]
+			if !ok {
+				return appErrors.Newf(appErrors.ErrConflict, "product with ID %d is no longer available", item.ProductID).
+					WithDetail("productId", item.ProductID)
+			}
+			if available := product.Stock - reserved[product.ID]; available < item.Quantity {
+				return appErrors.Newf(appErrors.ErrConflict, "insufficient stock for %s", product.Name).
+					WithDetail("productId", product.ID).
+					WithDetail("available", max(available, 0)).
+					WithDetail("requested", item.Quantity)
+			}
+
+			orderItem := models.OrderItem{
+				ProductID:    product.ID,
+				SellerID:     product.UserID,
+				ProductName:  product.Name,
+				SKU:          product.SKU,
+				Quantity:     item.Quantity,
+				UnitPrice:    item.UnitPrice,
+				UnitDiscount: item.UnitDiscount,
+				Subtotal:     money.New(item.UnitPrice.Amount-item.UnitDiscount.Amount, item.Currency).Mul(int64(item.Quantity)),
+			}
+
+			var variant *models.ProductVariant
+			if item.VariantID != nil {
+				variant, ok = variants[*item.VariantID]
+				if !ok {
+					return appErrors.Newf(appErrors.ErrConflict, "a variant of %s is no longer available", product.Name).
+						WithDetail("productId", product.ID).
+						WithDetail("variantId", *item.VariantID)
+				}
+				if available := variant.Stock - reservedVariants[variant.ID]; available < item.Quantity {
+					return appErrors.Newf(appErrors.ErrConflict, "insufficient stock for %s (%s)", product.Name, variant.SKU).
+						WithDetail("productId", product.ID).
+						WithDetail("variantId", variant.ID).
+						WithDetail("available", max(available, 0)).
+						WithDetail("requested", item.Quantity)
+				}
+				orderItem.VariantID = &variant.ID
+				orderItem.VariantOptions = variant.Options
+				orderItem.SKU = variant.SKU
+			}
+
+			if item.Reprice(product, variant) {
+				repriced = append(repriced, item.ProductID)
+				continue
+			}
+			order.Items = append(order.Items, orderItem)
+		}
+
+		if len(repriced) > 0 {
+			return appErrors.New(appErrors.ErrConflict, "Prices in your basket changed, please review it before checking out").
+				WithDetail("productIds", repriced)
+		}
+
+		totals, err := quote(pricing.BasketLines(&basket), coupon)
+		if err != nil {
+			if errors.Is(err, pricing.ErrCouponNotApplicable) {
+				return appErrors.New(appErrors.ErrConflict, err.Error()).WithDetail("coupon", coupon.Code)
+			}
+			return appErrors.Newf(appErrors.ErrConflict, "Your basket cannot be priced: %s", err.Error())
+		}
+		order.Subtotal = totals.Subtotal
+		order.Discount = totals.Discount
+		order.Tax = totals.Tax
+		order.TaxRate = totals.TaxRate
+		order.Total = totals.Total
+		order.Currency = order.Total.Currency
+		if coupon != nil {
+			order.CouponID = &coupon.ID
+			order.CouponCode = coupon.Code
+		}
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		for _, item := range order.Items {
+			if err := adjustStock(tx, item, -item.Quantity, models.InventoryReasonSale, &userID); err != nil {
+				return err
+			}
+		}
+
+		if coupon != nil {
+			if err := r.redeemCoupon(tx, coupon, order, totals.CouponDiscount); err != nil {
+				return err
+			}
+		}
+
+		// The stock is now taken by the order
+		if err := releaseStock(tx, basket.ID); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Basket{}).Where("id = ?", basket.ID).Update("status", models.BasketStatusCompleted).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			logging.Debug(ctx, "checkout rejected", "user_id", userID, "reason", appErr.Message)
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to check out basket", err, "user_id", userID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to check out basket")
+	}
+
+	logging.Info(ctx, "order placed successfully", "order_id", order.ID, "user_id", userID, "total", order.Total.String())
+	return order, nil
+}
+
+// lockProducts locks the products of the basket items in ID order, so concurrent
+// checkouts of overlapping baskets cannot deadlock, and returns them by ID
+func (r *OrderRepository) lockProducts(tx *gorm.DB, items []models.BasketItem) (map[int]*models.Product, error) {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	sort.Ints(ids)
+
+	var products []*models.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	return byID, nil
+}
+
+// findVariants returns the variants of the basket items by ID. The products'
+// locks cover their variants' stock.
+func (r *OrderRepository) findVariants(tx *gorm.DB, items []models.BasketItem) (map[int]*models.ProductVariant, error) {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		if item.VariantID != nil {
+			ids = append(ids, *item.VariantID)
+		}
+	}
+	byID := make(map[int]*models.ProductVariant, len(ids))
+	if len(ids) == 0 {
+		return byID, nil
+	}
+
+	var variants []*models.ProductVariant
+	if err := tx.Where("id IN ?", ids).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		byID[variant.ID] = variant
+	}
+	return byID, nil
+}
+
+// adjustStock changes the stock of an order item's product, and of its variant
+// when it has one, by delta and records it in the inventory ledger
+func adjustStock(tx *gorm.DB, item models.OrderItem, delta int, reason string, actorID *int) error {
+	return adjustInventory(tx, &models.InventoryAdjustment{
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Delta:     delta,
+		Reason:    reason,
+		OrderID:   &item.OrderID,
+		ActorID:   actorID,
+	})
+}
+
+// lockCoupon locks the basket's coupon, so concurrent checkouts redeem it one at
+// a time, and checks it is still usable by the basket's owner
+func (r *OrderRepository) lockCoupon(tx *gorm.DB, basket *models.Basket) (*models.Coupon, error) {
+	if basket.CouponID == nil {
+		return nil, nil
+	}
+
+	var coupon models.Coupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, *basket.CouponID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.New(appErrors.ErrConflict, "The coupon on your basket no longer exists")
+		}
+		return nil, err
+	}
+	if err := tx.Model(&coupon).Association("Products").Find(&coupon.Products); err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&coupon).Association("Categories").Find(&coupon.Categories); err != nil {
+		return nil, err
+	}
+
+	var userRedemptions int64
+	err = tx.Model(&models.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ?", coupon.ID, basket.UserID).
+		Count(&userRedemptions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := coupon.CheckUsable(time.Now(), userRedemptions); err != nil {
+		return nil, appErrors.New(appErrors.ErrConflict, err.Error()).WithDetail("coupon", coupon.Code)
+	}
+	return &coupon, nil
+}
+
+// redeemCoupon records the coupon discount of an order and counts the redemption
+func (r *OrderRepository) redeemCoupon(tx *gorm.DB, coupon *models.Coupon, order *models.Order, amount money.Money) error {
+	err := tx.Create(&models.CouponRedemption{
+		CouponID: coupon.ID,
+		UserID:   order.UserID,
+		OrderID:  order.ID,
+		Amount:   amount,
+		Currency: order.Currency,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Coupon{}).Where("id = ?", coupon.ID).
+		Update("redemptions", gorm.Expr("redemptions + 1")).Error
+}
+
+// releaseCoupon gives back the coupon redemption of an order that will not be fulfilled
+func (r *OrderRepository) releaseCoupon(tx *gorm.DB, orderID int) error {
+	var redemption models.CouponRedemption
+	result := tx.Where("order_id = ?", orderID).Limit(1).Find(&redemption)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	if err := tx.Delete(&redemption).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Coupon{}).Where("id = ? AND redemptions > 0", redemption.CouponID).
+		Update("redemptions", gorm.Expr("redemptions - 1")).Error
+}
+
+// Transition moves an order to a new status and records the change. Cancelling or
+// refunding returns the order's items to stock and gives back its coupon
+// redemption in the same transaction.
+func (r *OrderRepository) Transition(ctx context.Context, id int, status string, actorID *int, note string) (*models.Order, error) {
+	logging.Debug(ctx, "transitioning order", "order_id", id, "status", status)
+
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "order with ID %d not found", id)
+			}
+			return err
+		}
+
+		if !models.CanTransitionOrder(order.Status, status) {
+			return appErrors.Newf(appErrors.ErrConflict, "order cannot move from %s to %s", order.Status, status).
+				WithDetail("status", order.Status)
+		}
+
+		if models.RestoresStock(status) {
+			if err := r.restoreStock(tx, order.ID, actorID); err != nil {
+				return err
+			}
+			if err := r.releaseCoupon(tx, order.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&order).Update("status", status).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.OrderStatusChange{
+			OrderID:    order.ID,
+			FromStatus: order.Status,
+			ToStatus:   status,
+			ActorID:    actorID,
+			Note:       note,
+		}).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to transition order", err, "order_id", id, "status", status)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to update order status")
+	}
+
+	logging.Info(ctx, "order status changed", "order_id", id, "status", status, "actor_id", actorID)
+	return r.Get(ctx, id)
+}
+
+// restoreStock returns an order's items to stock, locking products in ID order
+func (r *OrderRepository) restoreStock(tx *gorm.DB, orderID int, actorID *int) error {
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ?", orderID).Order("product_id ASC").Find(&items).Error; err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := adjustStock(tx, item, item.Quantity, models.InventoryReasonReturn, actorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get retrieves an order with its items, status history and payment attempts
+func (r *OrderRepository) Get(ctx context.Context, id int) (*models.Order, error) {
+	logging.Debug(ctx, "retrieving order", "order_id", id)
+
+	var order models.Order
+	err := r.DB.WithContext(ctx).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Payments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&order, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logging.Debug(ctx, "order not found", "order_id", id)
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "order with ID %d not found", id)
+		}
+		logging.Error(ctx, "failed to retrieve order", err, "order_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve order")
+	}
+
+	return &order, nil
+}
+
+// HasPurchased reports whether the user has a paid, unrefunded order of the product
+func (r *OrderRepository) HasPurchased(ctx context.Context, userID, productID int) (bool, error) {
+	var purchased bool
+	err := r.DB.WithContext(ctx).Raw(
+		"SELECT EXISTS (SELECT 1 FROM order_items JOIN orders ON orders.id = order_items.order_id WHERE orders.user_id = ? AND order_items.product_id = ? AND orders.status IN ?)",
+		userID, productID, models.PurchasedStatuses,
+	).Scan(&purchased).Error
+	if err != nil {
+		logging.Error(ctx, "failed to check purchase", err, "user_id", userID, "product_id", productID)
+		return false, appErrors.New(appErrors.ErrDatabaseOperation, "failed to check purchase")
+	}
+	return purchased, nil
+}
+
+// ListByUser retrieves a user's orders, newest first
+func (r *OrderRepository) ListByUser(ctx context.Context, userID int, params *query.QueryParams) ([]*models.Order, *query.PaginatedList, error) {
+	logging.Debug(ctx, "retrieving orders", "user_id", userID)
+
+	var orders []*models.Order
+	var total int64
+
+	if params.IncludeTotal {
+		if err := r.DB.WithContext(ctx).Model(&models.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+			logging.Error(ctx, "failed to count orders", err, "user_id", userID)
+			return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count orders")
+		}
+	}
+
+	builder := query.NewQueryBuilder(r.DB.WithContext(ctx).Model(&models.Order{}).Where("user_id = ?", userID)).
+		WithRequest(params).
+		AllowFilters("status", "created_at").
+		AllowSorts("id", "created_at", "total").
+		DefaultSort("created_at", query.SortDesc)
+
+	if err := builder.Build().Preload("Items").Find(&orders).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve orders", err, "user_id", userID)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve orders")
+	}
+
+	var firstID, lastID int
+	if len(orders) > 0 {
+		firstID = orders[0].ID
+		lastID = orders[len(orders)-1].ID
+	}
+
+	result := query.BuildResponse(orders, params, total, len(orders), firstID, lastID)
+
+	logging.Debug(ctx, "orders retrieved successfully", "user_id", userID, "count", len(orders))
+	return orders, result, nil
+}
+
+// ListBySeller retrieves orders containing products sold by the user, newest first
+func (r *OrderRepository) ListBySeller(ctx context.Context, sellerID int, params *query.QueryParams) ([]*models.Order, *query.PaginatedList, error) {
+	logging.Debug(ctx, "retrieving seller orders", "seller_id", sellerID)
+
+	var orders []*models.Order
+	var total int64
+
+	sellerItems := r.DB.Model(&models.OrderItem{}).Select("order_id").Where("seller_id = ?", sellerID)
+
+	if params.IncludeTotal {
+		if err := r.DB.WithContext(ctx).Model(&models.Order{}).Where("id IN (?)", sellerItems).Count(&total).Error; err != nil {
+			logging.Error(ctx, "failed to count seller orders", err, "seller_id", sellerID)
+			return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count orders")
+		}
+	}
+
+	builder := query.NewQueryBuilder(r.DB.WithContext(ctx).Model(&models.Order{}).Where("id IN (?)", sellerItems)).
+		WithRequest(params).
+		AllowFilters("status", "created_at").
+		AllowSorts("id", "created_at", "total").
+		DefaultSort("created_at", query.SortDesc)
+
+	if err := builder.Build().Preload("Items").Find(&orders).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve seller orders", err, "seller_id", sellerID)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve orders")
+	}
+	for i, order := range orders {
+		orders[i] = order.ForSeller(sellerID)
+	}
+
+	var firstID, lastID int
+	if len(orders) > 0 {
+		firstID = orders[0].ID
+		lastID = orders[len(orders)-1].ID
+	}
+
+	result := query.BuildResponse(orders, params, total, len(orders), firstID, lastID)
+
+	logging.Debug(ctx, "seller orders retrieved successfully", "seller_id", sellerID, "count", len(orders))
+	return orders, result, nil
+}
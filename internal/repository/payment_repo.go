@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PaymentRepository handles payment database operations
+type PaymentRepository struct {
+	DB *gorm.DB
+}
+
+// NewPaymentRepository creates a new PaymentRepository
+func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
+	return &PaymentRepository{DB: db}
+}
+
+// Insert records a payment attempt
+func (r *PaymentRepository) Insert(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	logging.Debug(ctx, "recording payment attempt", "order_id", payment.OrderID, "provider", payment.Provider)
+
+	if err := r.DB.WithContext(ctx).Create(payment).Error; err != nil {
+		logging.Error(ctx, "failed to record payment attempt", err, "order_id", payment.OrderID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to record payment")
+	}
+
+	logging.Info(ctx, "payment attempt recorded", "payment_id", payment.ID, "order_id", payment.OrderID, "status", payment.Status)
+	return payment, nil
+}
+
+// GetByIntent retrieves the payment for a provider intent
+func (r *PaymentRepository) GetByIntent(ctx context.Context, provider, intentID string) (*models.Payment, error) {
+	logging.Debug(ctx, "retrieving payment by intent", "provider", provider, "intent_id", intentID)
+
+	var payment models.Payment
+	err := r.DB.WithContext(ctx).Where("provider = ? AND intent_id = ?", provider, intentID).First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "payment for intent %s not found", intentID)
+		}
+		logging.Error(ctx, "failed to retrieve payment", err, "intent_id", intentID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve payment")
+	}
+
+	return &payment, nil
+}
+
+// ListByOrder retrieves an order's payment attempts, oldest first
+func (r *PaymentRepository) ListByOrder(ctx context.Context, orderID int) ([]*models.Payment, error) {
+	logging.Debug(ctx, "retrieving order payments", "order_id", orderID)
+
+	var payments []*models.Payment
+	if err := r.DB.WithContext(ctx).Where("order_id = ?", orderID).Order("id ASC").Find(&payments).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve order payments", err, "order_id", orderID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve payments")
+	}
+
+	return payments, nil
+}
+
+// ListPendingRefunds retrieves the payments whose refund has been pending since
+// before the given time, oldest first
+func (r *PaymentRepository) ListPendingRefunds(ctx context.Context, before time.Time) ([]*models.Payment, error) {
+	var payments []*models.Payment
+	err := r.DB.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", models.PaymentStatusRefundPending, before).
+		Order("id ASC").Find(&payments).Error
+	if err != nil {
+		logging.Error(ctx, "failed to retrieve pending refunds", err)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve pending refunds")
+	}
+
+	return payments, nil
+}
+
+// UpdateStatus sets a payment's status and failure message
+func (r *PaymentRepository) UpdateStatus(ctx context.Context, id int, status, errMsg string) error {
+	logging.Debug(ctx, "updating payment status", "payment_id", id, "status", status)
+
+	result := r.DB.WithContext(ctx).Model(&models.Payment{}).Where("id = ?", id).
+		Updates(map[string]any{"status": status, "error": errMsg})
+	if result.Error != nil {
+		logging.Error(ctx, "failed to update payment status", result.Error, "payment_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update payment")
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.Newf(appErrors.ErrNotFound, "payment with ID %d not found", id)
+	}
+
+	logging.Info(ctx, "payment status updated", "payment_id", id, "status", status)
+	return nil
+}
+
+// HasEvent reports whether a provider webhook event has already been processed
+func (r *PaymentRepository) HasEvent(ctx context.Context, provider, eventID string) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.PaymentEvent{}).
+		Where("provider = ? AND event_id = ?", provider, eventID).
+		Count(&count).Error
+	if err != nil {
+		logging.Error(ctx, "failed to check payment event", err, "event_id", eventID)
+		return false, appErrors.New(appErrors.ErrDatabaseOperation, "failed to check payment event")
+	}
+	return count > 0, nil
+}
+
+// RecordEvent marks a provider webhook event as processed. Recording an event
+// twice is not an error.
+func (r *PaymentRepository) RecordEvent(ctx context.Context, event *models.PaymentEvent) error {
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(event).Error
+	if err != nil {
+		logging.Error(ctx, "failed to record payment event", err, "event_id", event.EventID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to record payment event")
+	}
+
+	logging.Debug(ctx, "payment event recorded", "event_id", event.EventID, "type", event.Type)
+	return nil
+}
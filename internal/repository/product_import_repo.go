@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"gorm.io/gorm"
+)
+
+// ProductImportRepository handles product import job database operations
+type ProductImportRepository struct {
+	DB *gorm.DB
+}
+
+// NewProductImportRepository creates a new ProductImportRepository
+func NewProductImportRepository(db *gorm.DB) *ProductImportRepository {
+	return &ProductImportRepository{DB: db}
+}
+
+// Insert creates an import job
+func (r *ProductImportRepository) Insert(ctx context.Context, job *models.ProductImport) (*models.ProductImport, error) {
+	if err := r.DB.WithContext(ctx).Create(job).Error; err != nil {
+		logging.Error(ctx, "failed to create product import", err, "user_id", job.UserID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create product import")
+	}
+
+	logging.Info(ctx, "product import created", "import_id", job.ID, "user_id", job.UserID, "format", job.Format)
+	return job, nil
+}
+
+// Get retrieves an import job by ID
+func (r *ProductImportRepository) Get(ctx context.Context, id int) (*models.ProductImport, error) {
+	var job models.ProductImport
+	if err := r.DB.WithContext(ctx).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "product import with ID %d not found", id)
+		}
+		logging.Error(ctx, "failed to retrieve product import", err, "import_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve product import")
+	}
+
+	return &job, nil
+}
+
+// Update saves the progress and outcome of an import job
+func (r *ProductImportRepository) Update(ctx context.Context, job *models.ProductImport) error {
+	err := r.DB.WithContext(ctx).Model(job).
+		Select("status", "rows", "created", "updated", "failed", "errors", "error", "finished_at", "updated_at").
+		Updates(job).Error
+	if err != nil {
+		logging.Error(ctx, "failed to update product import", err, "import_id", job.ID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update product import")
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PriceHistory retrieves the price changes of a product, latest first
+func (r *ProductRepository) PriceHistory(ctx context.Context, productID int) ([]models.ProductPriceChange, error) {
+	var changes []models.ProductPriceChange
+	err := r.DB.WithContext(ctx).Where("product_id = ?", productID).
+		Order("created_at DESC, id DESC").Find(&changes).Error
+	if err != nil {
+		logging.Error(ctx, "failed to get price history", err, "product_id", productID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to get price history")
+	}
+	return changes, nil
+}
+
+// PriceSchedules retrieves the price schedules of a product in the order they start
+func (r *ProductRepository) PriceSchedules(ctx context.Context, productID int) ([]models.ProductPriceSchedule, error) {
+	var schedules []models.ProductPriceSchedule
+	err := r.DB.WithContext(ctx).Where("product_id = ?", productID).
+		Order("starts_at ASC, id ASC").Find(&schedules).Error
+	if err != nil {
+		logging.Error(ctx, "failed to get price schedules", err, "product_id", productID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to get price schedules")
+	}
+	return schedules, nil
+}
+
+// CreatePriceSchedule schedules a price change of a product. Pending schedules
+// of a product cannot overlap: a sale covers its start up to its end and other
+// schedules their start only.
+func (r *ProductRepository) CreatePriceSchedule(ctx context.Context, schedule *models.ProductPriceSchedule) error {
+	end, bounds := schedule.StartsAt, "[]"
+	if schedule.EndsAt != nil {
+		end, bounds = *schedule.EndsAt, "[)"
+	}
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the product keeps concurrent schedules from overlapping
+		if _, err := lockProduct(tx, schedule.ProductID); err != nil {
+			return err
+		}
+
+		var overlapping int64
+		err := tx.Model(&models.ProductPriceSchedule{}).
+			Where("product_id = ? AND status IN ?", schedule.ProductID, []string{models.PriceScheduleScheduled, models.PriceScheduleActive}).
+			Where("tstzrange(starts_at, coalesce(ends_at, starts_at), CASE WHEN ends_at IS NULL THEN '[]' ELSE '[)' END) && tstzrange(?, ?, ?)", schedule.StartsAt, end, bounds).
+			Count(&overlapping).Error
+		if err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return appErrors.New(appErrors.ErrConflict, "the schedule overlaps another pending schedule of the product")
+		}
+
+		schedule.Status = models.PriceScheduleScheduled
+		return tx.Create(schedule).Error
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		logging.Error(ctx, "failed to create price schedule", err, "product_id", schedule.ProductID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to create price schedule")
+	}
+
+	logging.Info(ctx, "price schedule created", "schedule_id", schedule.ID, "product_id", schedule.ProductID, "starts_at", schedule.StartsAt)
+	return nil
+}
+
+// CancelPriceSchedule cancels a pending price schedule of a product. Sales
+// already started end at once, restoring the product's price.
+func (r *ProductRepository) CancelPriceSchedule(ctx context.Context, productID, scheduleID int, actorID *int) (*models.ProductPriceSchedule, error) {
+	var schedule models.ProductPriceSchedule
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ?", productID).Take(&schedule, scheduleID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.Newf(appErrors.ErrNotFound, "price schedule with ID %d not found", scheduleID)
+		}
+		if err != nil {
+			return err
+		}
+		if !schedule.IsPending() {
+			return appErrors.Newf(appErrors.ErrConflict, "price schedule is already %s", schedule.Status).
+				WithDetail("status", schedule.Status)
+		}
+		_, err = endPriceSchedule(tx, &schedule, models.PriceScheduleCancelled, actorID)
+		return err
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to cancel price schedule", err, "schedule_id", scheduleID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to cancel price schedule")
+	}
+
+	logging.Info(ctx, "price schedule cancelled", "schedule_id", scheduleID, "product_id", productID, "actor_id", actorID)
+	return &schedule, nil
+}
+
+// ApplyPriceSchedules starts the price schedules due by now and ends the sales
+// over by then, each in its own transaction, and returns the price changes
+// they made. Sales end before schedules starting at the same time start.
+func (r *ProductRepository) ApplyPriceSchedules(ctx context.Context, now time.Time) ([]models.ProductRepricing, error) {
+	var due []models.ProductPriceSchedule
+	err := r.DB.WithContext(ctx).Select("id").
+		Where("(status = ? AND starts_at <= ?) OR (status = ? AND ends_at <= ?)",
+			models.PriceScheduleScheduled, now, models.PriceScheduleActive, now).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN status = ? THEN ends_at ELSE starts_at END ASC, status = ? DESC, id ASC",
+			Vars: []interface{}{models.PriceScheduleActive, models.PriceScheduleActive},
+		}}).
+		Find(&due).Error
+	if err != nil {
+		logging.Error(ctx, "failed to find due price schedules", err)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to apply price schedules")
+	}
+
+	var repricings []models.ProductRepricing
+	applied, failed := 0, 0
+	for _, schedule := range due {
+		var repricing *models.ProductRepricing
+		err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			repricing, err = applyPriceSchedule(ctx, tx, schedule.ID, now)
+			return err
+		})
+		if err != nil {
+			logging.Error(ctx, "failed to apply price schedule", err, "schedule_id", schedule.ID)
+			failed++
+			continue
+		}
+		if repricing != nil {
+			repricings = append(repricings, *repricing)
+		}
+		applied++
+	}
+
+	if applied > 0 {
+		logging.Info(ctx, "price schedules applied", "count", applied)
+	}
+	if failed > 0 {
+		return repricings, appErrors.Newf(appErrors.ErrDatabaseOperation, "failed to apply %d price schedules", failed)
+	}
+	return repricings, nil
+}
+
+// applyPriceSchedule starts or ends a schedule if it is still due, returning
+// the price change it made if any
+func applyPriceSchedule(ctx context.Context, tx *gorm.DB, id int, now time.Time) (*models.ProductRepricing, error) {
+	var schedule models.ProductPriceSchedule
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+
+	switch {
+	case schedule.Status == models.PriceScheduleActive && schedule.EndsAt != nil && !schedule.EndsAt.After(now):
+		return endPriceSchedule(tx, &schedule, models.PriceScheduleCompleted, nil)
+	case schedule.Status == models.PriceScheduleScheduled && !schedule.StartsAt.After(now):
+		return startPriceSchedule(ctx, tx, &schedule, now)
+	}
+	return nil, nil
+}
+
+// startPriceSchedule gives the product the schedule's price. Sales missed
+// altogether complete without changing the price, and schedules the product
+// can no longer take, being deleted, repriced in another currency or having a
+// variant cheaper than the discount, are cancelled.
+func startPriceSchedule(ctx context.Context, tx *gorm.DB, schedule *models.ProductPriceSchedule, now time.Time) (*models.ProductRepricing, error) {
+	if schedule.EndsAt != nil && !schedule.EndsAt.After(now) {
+		return nil, setScheduleStatus(tx, schedule, models.PriceScheduleCompleted)
+	}
+
+	product, err := lockProduct(tx, schedule.ProductID)
+	if err != nil && !appErrors.IsType(err, appErrors.ErrNotFound) {
+		return nil, err
+	}
+	finalPrice, err := pricing.FinalPrice(schedule.Price, schedule.Discount)
+	if product == nil || err != nil || product.Currency != schedule.Currency {
+		logging.Warn(ctx, "price schedule no longer applies to its product", "schedule_id", schedule.ID, "product_id", schedule.ProductID)
+		return nil, setScheduleStatus(tx, schedule, models.PriceScheduleCancelled)
+	}
+	var cheaperVariants int64
+	err = tx.Model(&models.ProductVariant{}).
+		Where("product_id = ? AND price IS NOT NULL AND price < ?", product.ID, schedule.Discount).
+		Count(&cheaperVariants).Error
+	if err != nil {
+		return nil, err
+	}
+	if cheaperVariants > 0 {
+		logging.Warn(ctx, "price schedule discount exceeds a variant's price", "schedule_id", schedule.ID, "product_id", schedule.ProductID)
+		return nil, setScheduleStatus(tx, schedule, models.PriceScheduleCancelled)
+	}
+
+	previous := *product
+	if err := setPrice(tx, product, schedule.Price, schedule.Discount, finalPrice); err != nil {
+		return nil, err
+	}
+	if err := recordPriceChange(tx, &previous, product, models.PriceChangeScheduleStart, &schedule.ID, nil); err != nil {
+		return nil, err
+	}
+	repricing := &models.ProductRepricing{Product: *product, PreviousFinalPrice: previous.FinalPrice}
+
+	if schedule.EndsAt == nil {
+		return repricing, setScheduleStatus(tx, schedule, models.PriceScheduleCompleted)
+	}
+	schedule.PreviousPrice = &previous.Price
+	schedule.PreviousDiscount = &previous.Discount
+	schedule.Status = models.PriceScheduleActive
+	return repricing, tx.Model(schedule).Select("status", "previous_price", "previous_discount", "updated_at").Updates(schedule).Error
+}
+
+// endPriceSchedule ends a schedule with the given status. A sale under way
+// gives the product back its price from before, unless the price was changed
+// during the sale, and the price change is returned.
+func endPriceSchedule(tx *gorm.DB, schedule *models.ProductPriceSchedule, status string, actorID *int) (*models.ProductRepricing, error) {
+	var repricing *models.ProductRepricing
+	if schedule.Status == models.PriceScheduleActive && schedule.PreviousPrice != nil && schedule.PreviousDiscount != nil {
+		product, err := lockProduct(tx, schedule.ProductID)
+		if err != nil && !appErrors.IsType(err, appErrors.ErrNotFound) {
+			return nil, err
+		}
+		if product != nil && product.Currency == schedule.Currency &&
+			product.Price.Amount == schedule.Price.Amount && product.Discount.Amount == schedule.Discount.Amount {
+			finalPrice, err := pricing.FinalPrice(*schedule.PreviousPrice, *schedule.PreviousDiscount)
+			if err != nil {
+				return nil, err
+			}
+			previous := *product
+			if err := setPrice(tx, product, *schedule.PreviousPrice, *schedule.PreviousDiscount, finalPrice); err != nil {
+				return nil, err
+			}
+			if err := recordPriceChange(tx, &previous, product, models.PriceChangeScheduleEnd, &schedule.ID, actorID); err != nil {
+				return nil, err
+			}
+			repricing = &models.ProductRepricing{Product: *product, PreviousFinalPrice: previous.FinalPrice}
+		}
+	}
+	return repricing, setScheduleStatus(tx, schedule, status)
+}
+
+func setScheduleStatus(tx *gorm.DB, schedule *models.ProductPriceSchedule, status string) error {
+	schedule.Status = status
+	return tx.Model(schedule).Update("status", status).Error
+}
+
+// setPrice updates a product's price, discount and final price
+func setPrice(tx *gorm.DB, product *models.Product, price, discount, finalPrice money.Money) error {
+	product.Price, product.Discount, product.FinalPrice = price, discount, finalPrice
+	return tx.Model(product).Select("price", "discount", "final_price", "updated_at").Updates(product).Error
+}
+
+// recordPriceChange adds the product's price to its price history, unless it
+// is the same as the previous one
+func recordPriceChange(tx *gorm.DB, previous, product *models.Product, reason string, scheduleID, actorID *int) error {
+	if previous != nil && previous.Currency == product.Currency &&
+		previous.Price.Amount == product.Price.Amount && previous.Discount.Amount == product.Discount.Amount {
+		return nil
+	}
+	return tx.Create(models.NewProductPriceChange(product, reason, scheduleID, actorID)).Error
+}
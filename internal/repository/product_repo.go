@@ -1,12 +1,32 @@
 package repository
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
+	"slices"
+	"strconv"
+	"time"
 
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
 	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VariantOptionFilter is the query filter field selecting products by the
+// options of their variants, e.g. option[size]=M
+const VariantOptionFilter = "option"
+
+// Query filter fields selecting products by category ID and by tag, e.g.
+// category[in]=1&category[in]=2 or tags[eq]=sale
+const (
+	CategoryFilter = "category"
+	TagFilter      = "tags"
 )
 
 type ProductRepository struct {
@@ -18,9 +38,24 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 }
 
 func (r *ProductRepository) Insert(ctx context.Context, product *models.Product) (*models.Product, error) {
-	result := r.DB.WithContext(ctx).Create(product)
-	if result.Error != nil {
-		return nil, result.Error
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := recordPriceChange(tx, nil, product, models.PriceChangeCreated, nil, &product.UserID); err != nil {
+			return err
+		}
+		return recordInventory(tx, product, 0, stockChanges(product, 0, nil, models.InventoryReasonInitial, &product.UserID))
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errDuplicateProduct
+		}
+		return nil, err
+	}
+	product.SetAvailable(0)
+	for i := range product.Variants {
+		product.Variants[i].SetAvailable(0)
 	}
 	return product, nil
 }
@@ -49,7 +84,7 @@ func (r *ProductRepository) GetAll(ctx context.Context, page, limit int, search
 	}
 
 	// Fetch products with relationships
-	result := query.Preload("User").Preload("Categories").
+	result := query.Select("products.*").Preload("User").Preload("Categories").Scopes(preloadVariants).
 		Offset(offset).Limit(limit).
 		Order("created_at desc").
 		Find(&products)
@@ -57,40 +92,49 @@ func (r *ProductRepository) GetAll(ctx context.Context, page, limit int, search
 	if result.Error != nil {
 		return nil, 0, result.Error
 	}
+	if err := r.setAvailable(ctx, productRefs(products)...); err != nil {
+		return nil, 0, err
+	}
 
 	return products, total, nil
 }
 
-// ListWithAdvancedPagination retrieves products with advanced pagination, filtering, sorting, and search
+// ListWithAdvancedPagination retrieves products with advanced pagination, filtering, sorting, and search.
+// Option filters, e.g. option[size]=M, keep the products with a variant having
+// those option values and only those of their variants. Products are filtered
+// by category and tag with category[in]=1&category[in]=2 and tags[in]=sale.
 func (r *ProductRepository) ListWithAdvancedPagination(ctx context.Context, req *query.QueryParams) ([]models.Product, *query.PaginatedList, error) {
 	var products []models.Product
 	var total int64
 
+	options := query.KeyedFilters(req.Filters, VariantOptionFilter)
+	hasVariantOptions, err := variantOptionsScope(options)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Build pagination query
-	builder := query.NewQueryBuilder(r.DB.WithContext(ctx).Model(&models.Product{})).
-		WithRequest(req).
-		AllowFilters("name", "slug", "user_id", "price", "status", "created_at").
-		AllowSorts("name", "price", "created_at", "updated_at").
-		SearchColumns("name", "slug", "description").
-		DefaultSort("created_at", query.SortDesc)
+	builder := r.searchBuilder(ctx, req, hasVariantOptions)
+	if err := builder.Validate(); err != nil {
+		return nil, nil, appErrors.New(appErrors.ErrInvalidInput, err.Error())
+	}
 
 	// Get count if needed
 	if req.IncludeTotal {
-		countQuery := r.DB.WithContext(ctx).Model(&models.Product{})
-		for _, filter := range req.Filters {
-			countQuery = query.FilterBy(filter)(countQuery)
-		}
-		if req.Search != "" {
-			countQuery = query.Search(req.Search, "name", "slug", "description")(countQuery)
-		}
-		countQuery.Count(&total)
+		builder.BuildFilters().Count(&total)
 	}
 
 	// Execute main query
 	dbQuery := builder.Build()
-	if err := dbQuery.Preload("User").Preload("Categories").Find(&products).Error; err != nil {
+	if err := dbQuery.Preload("User").Preload("Categories").Scopes(preloadVariants).Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := r.setAvailable(ctx, productRefs(products)...); err != nil {
 		return nil, nil, err
 	}
+	for i := range products {
+		products[i].Variants = products[i].VariantsMatching(options)
+	}
 
 	// Get first and last IDs for cursor pagination
 	var firstID, lastID int
@@ -105,36 +149,392 @@ func (r *ProductRepository) ListWithAdvancedPagination(ctx context.Context, req
 	return products, result, nil
 }
 
+// facetLimit is the most values of a brand or tag facet counted, those of the
+// most products
+const facetLimit = 50
+
+// Facets counts the products matching a search and its filters by category,
+// brand, tag, price bucket and status. Each facet is counted with every filter
+// applied but its own, so the other values stay listed once one is chosen.
+// The facets are counted in a single query.
+func (r *ProductRepository) Facets(ctx context.Context, req *query.QueryParams) (*models.ProductFacets, error) {
+	hasVariantOptions, err := variantOptionsScope(query.KeyedFilters(req.Filters, VariantOptionFilter))
+	if err != nil {
+		return nil, err
+	}
+	builder := r.searchBuilder(ctx, req, hasVariantOptions)
+
+	categories := r.DB.Table("product_categories").
+		Select("'category' AS facet, categories.id::text AS value, categories.name AS label, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = product_categories.category_id AND categories.deleted_at IS NULL").
+		Where("product_categories.product_id IN (?)", builder.BuildFilters(CategoryFilter).Select("products.id")).
+		Group("categories.id, categories.name")
+	brands := builder.BuildFilters("brand").
+		Select("'brand' AS facet, brand AS value, '' AS label, COUNT(*) AS count").
+		Where("brand <> ''").
+		Group("brand").Order("count DESC, value").Limit(facetLimit)
+	tags := builder.BuildFilters(TagFilter).
+		Select("'tag' AS facet, tag AS value, '' AS label, COUNT(*) AS count").
+		Joins("CROSS JOIN LATERAL unnest(products.tags) AS tag").
+		Where("tag <> ''").
+		Group("tag").Order("count DESC, value").Limit(facetLimit)
+	prices := builder.BuildFilters("price").
+		Select("'price' AS facet, width_bucket(price, ?::bigint[])::text AS value, '' AS label, COUNT(*) AS count", pq.Array(models.PriceFacetBounds)).
+		Group("value")
+	statuses := builder.BuildFilters("status").
+		Select("'status' AS facet, status AS value, '' AS label, COUNT(*) AS count").
+		Group("status")
+
+	var rows []struct {
+		Facet string
+		models.FacetCount
+	}
+	err = r.DB.WithContext(ctx).
+		Raw("(?) UNION ALL (?) UNION ALL (?) UNION ALL (?) UNION ALL (?)", categories, brands, tags, prices, statuses).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count product facets")
+	}
+
+	facets := &models.ProductFacets{
+		Categories: []models.FacetCount{},
+		Brands:     []models.FacetCount{},
+		Tags:       []models.FacetCount{},
+		Prices:     []models.PriceBucket{},
+		Statuses:   []models.FacetCount{},
+	}
+	for _, row := range rows {
+		switch row.Facet {
+		case "category":
+			facets.Categories = append(facets.Categories, row.FacetCount)
+		case "brand":
+			facets.Brands = append(facets.Brands, row.FacetCount)
+		case "tag":
+			facets.Tags = append(facets.Tags, row.FacetCount)
+		case "status":
+			facets.Statuses = append(facets.Statuses, row.FacetCount)
+		case "price":
+			// width_bucket counts buckets from 1
+			index, err := strconv.Atoi(row.Value)
+			if err != nil || index < 1 || index > len(models.PriceFacetBounds) {
+				continue
+			}
+			bucket := models.NewPriceBucket(index - 1)
+			bucket.Count = row.Count
+			facets.Prices = append(facets.Prices, bucket)
+		}
+	}
+	sortFacetCounts(facets.Categories)
+	sortFacetCounts(facets.Statuses)
+	slices.SortFunc(facets.Prices, func(a, b models.PriceBucket) int { return cmp.Compare(a.Min, b.Min) })
+
+	return facets, nil
+}
+
+// searchBuilder configures the query builder of product searches
+func (r *ProductRepository) searchBuilder(ctx context.Context, req *query.QueryParams, hasVariantOptions func(db *gorm.DB) *gorm.DB) *query.QueryBuilder {
+	return query.NewQueryBuilder(r.DB.WithContext(ctx).Model(&models.Product{}).Scopes(hasVariantOptions)).
+		WithRequest(req).
+		AllowFilters("name", "slug", "user_id", "price", "status", "brand", "created_at").
+		FilterWith(CategoryFilter, categoryFilter).
+		FilterWith(TagFilter, tagFilter).
+		AllowSorts("name", "price", "created_at", "updated_at").
+		FullTextSearch("search_vector", "name", "description").
+		DefaultSort("created_at", query.SortDesc)
+}
+
+// categoryFilter keeps the products in any of the categories of a category[eq]
+// or category[in] filter
+func categoryFilter(db *gorm.DB, filter query.Filter) *gorm.DB {
+	var ids []int
+	for _, value := range filter.StringValues() {
+		if id, err := strconv.Atoi(value); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return db
+	}
+	return db.Where("EXISTS (SELECT 1 FROM product_categories WHERE product_categories.product_id = products.id AND product_categories.category_id IN ?)", ids)
+}
+
+// tagFilter keeps the products with any of the tags of a tags[eq] or tags[in]
+// filter
+func tagFilter(db *gorm.DB, filter query.Filter) *gorm.DB {
+	tags := filter.StringValues()
+	if len(tags) == 0 {
+		return db
+	}
+	return db.Where("products.tags && ?", pq.StringArray(tags))
+}
+
+// sortFacetCounts orders facet values by count, the most common first
+func sortFacetCounts(counts []models.FacetCount) {
+	slices.SortFunc(counts, func(a, b models.FacetCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+}
+
 // Get retrieves a product by ID
 func (r *ProductRepository) Get(ctx context.Context, id int) (*models.Product, error) {
 	var product models.Product
-	result := r.DB.WithContext(ctx).Preload("User").Preload("Categories").First(&product, id)
+	result := r.DB.WithContext(ctx).Preload("User").Preload("Categories").Scopes(preloadVariants).First(&product, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
 		return nil, result.Error
 	}
+	if err := r.setAvailable(ctx, &product); err != nil {
+		return nil, err
+	}
 	return &product, nil
 }
 
 // GetBySlug retrieves a product by its slug
 func (r *ProductRepository) GetBySlug(ctx context.Context, slug string) (*models.Product, error) {
 	var product models.Product
-	result := r.DB.WithContext(ctx).Preload("User").Preload("Categories").Where("slug = ?", slug).First(&product)
+	result := r.DB.WithContext(ctx).Preload("User").Preload("Categories").Scopes(preloadVariants).Where("slug = ?", slug).First(&product)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
 		return nil, result.Error
 	}
+	if err := r.setAvailable(ctx, &product); err != nil {
+		return nil, err
+	}
 	return &product, nil
 }
 
-// Update updates an existing product
+// Update updates an existing product with its options and variants. The
+// product's variants are matched by ID: those missing are deleted, with the
+// basket items and reservations holding them, and those without an ID created.
+// A changed price is added to the product's price history and changed stock
+// to the inventory ledger as corrections.
 func (r *ProductRepository) Update(ctx context.Context, product *models.Product) error {
-	result := r.DB.WithContext(ctx).Save(product)
-	return result.Error
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		previous, err := lockProduct(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		previousVariants, err := variantStock(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		// Only the fields owners edit are written: the rating and review count
+		// are kept by refreshRating and the views by AddViews, and the product
+		// may be stale by now
+		product.Rating, product.ReviewsCount, product.Views = previous.Rating, previous.ReviewsCount, previous.Views
+		err = tx.Model(product).Select(
+			"name", "description", "price", "currency", "stock", "low_stock_threshold", "sku", "status", "image", "images", "tags",
+			"meta_title", "meta_description", "discount", "final_price", "brand", "weight", "dimensions", "updated_at",
+		).Updates(product).Error
+		if err != nil {
+			return err
+		}
+		// Products are only updated by their owners
+		if err := recordPriceChange(tx, previous, product, models.PriceChangeUpdated, nil, &product.UserID); err != nil {
+			return err
+		}
+		if err := replaceOptions(tx, product); err != nil {
+			return err
+		}
+		if err := syncVariants(tx, product); err != nil {
+			return err
+		}
+		adjustments := stockChanges(product, previous.Stock, previousVariants, models.InventoryReasonCorrection, &product.UserID)
+		return recordInventory(tx, product, previous.Stock, adjustments)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errDuplicateProduct
+		}
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		return err
+	}
+	return r.setAvailable(ctx, product)
+}
+
+// UpsertBySKU creates the product, or updates the product with its SKU,
+// restoring it if deleted, and returns the product as it was before with its
+// availability, or nil when it was created. Categories given by slug replace
+// the product's; without any they are kept. Products sold in variants keep
+// their stock, the sum of their variants', and products of other users are not
+// updated. Changed prices are added to the price history
+// and changed stock to the inventory ledger.
+func (r *ProductRepository) UpsertBySKU(ctx context.Context, product *models.Product, categorySlugs []string) (*models.Product, error) {
+	var previous *models.Product
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		categories, err := categoriesBySlug(tx, categorySlugs)
+		if err != nil {
+			return err
+		}
+
+		var existing models.Product
+		err = tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("sku = ?", product.SKU).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			product.Categories = categories
+			if err := tx.Omit("Categories.*").Create(product).Error; err != nil {
+				return err
+			}
+			if err := recordPriceChange(tx, nil, product, models.PriceChangeImported, nil, &product.UserID); err != nil {
+				return err
+			}
+			return recordInventory(tx, product, 0, stockChanges(product, 0, nil, models.InventoryReasonImport, &product.UserID))
+		}
+		if err != nil {
+			return err
+		}
+		if existing.UserID != product.UserID {
+			return appErrors.Newf(appErrors.ErrForbidden, "product %s belongs to another user", product.SKU)
+		}
+		previous = &existing
+
+		var variants int64
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", existing.ID).Count(&variants).Error; err != nil {
+			return err
+		}
+		if variants > 0 {
+			product.Stock = existing.Stock
+		}
+
+		product.ID = existing.ID
+		err = tx.Unscoped().Model(product).Select(
+			"name", "description", "price", "currency", "stock", "status", "slug", "image", "images", "tags",
+			"meta_title", "meta_description", "discount", "final_price", "brand", "weight", "dimensions",
+			"deleted_at", "updated_at",
+		).Updates(product).Error
+		if err != nil {
+			return err
+		}
+		if err := recordPriceChange(tx, &existing, product, models.PriceChangeImported, nil, &product.UserID); err != nil {
+			return err
+		}
+		product.LowStockThreshold = existing.LowStockThreshold
+		adjustments := stockChanges(product, existing.Stock, nil, models.InventoryReasonImport, &product.UserID)
+		if err := recordInventory(tx, product, existing.Stock, adjustments); err != nil {
+			return err
+		}
+		if len(categories) == 0 {
+			return nil
+		}
+		return tx.Model(product).Association("Categories").Replace(categories)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errDuplicateProduct
+		}
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		return nil, err
+	}
+	if previous == nil {
+		return nil, nil
+	}
+
+	// The stock before counts against the reservations held now
+	if err := r.setAvailable(ctx, previous, product); err != nil {
+		logging.Error(ctx, "failed to compute availability of imported product", err, "product_id", product.ID)
+	}
+	return previous, nil
+}
+
+// categoriesBySlug loads the categories with the given slugs, all of which must exist
+func categoriesBySlug(tx *gorm.DB, slugs []string) ([]models.Category, error) {
+	if len(slugs) == 0 {
+		return nil, nil
+	}
+	var categories []models.Category
+	if err := tx.Where("slug IN ?", slugs).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, slug := range slugs {
+		if !slices.ContainsFunc(categories, func(category models.Category) bool { return category.Slug == slug }) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "category %q not found", slug)
+		}
+	}
+	return categories, nil
+}
+
+// errDuplicateProduct reports a product or variant SKU, or a product slug, in use
+var errDuplicateProduct = appErrors.New(appErrors.ErrAlreadyExists, "a product or variant with this SKU or slug already exists")
+
+// replaceOptions replaces the stored options of a product with its current ones
+func replaceOptions(tx *gorm.DB, product *models.Product) error {
+	if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductOption{}).Error; err != nil {
+		return err
+	}
+	if len(product.Options) == 0 {
+		return nil
+	}
+	for i := range product.Options {
+		product.Options[i].ID = 0
+		product.Options[i].ProductID = product.ID
+	}
+	return tx.Create(&product.Options).Error
+}
+
+// syncVariants brings the stored variants of a product in line with its
+// current ones. Removed variants go first so their SKUs can be reused.
+func syncVariants(tx *gorm.DB, product *models.Product) error {
+	var storedIDs []int
+	if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Pluck("id", &storedIDs).Error; err != nil {
+		return err
+	}
+
+	kept := make(map[int]bool, len(product.Variants))
+	for _, variant := range product.Variants {
+		if variant.ID == 0 {
+			continue
+		}
+		if !slices.Contains(storedIDs, variant.ID) {
+			return appErrors.Newf(appErrors.ErrNotFound, "variant with ID %d of %s not found", variant.ID, product.Name)
+		}
+		kept[variant.ID] = true
+	}
+
+	var removed []int
+	for _, id := range storedIDs {
+		if !kept[id] {
+			removed = append(removed, id)
+		}
+	}
+	if len(removed) > 0 {
+		if err := tx.Where("variant_id IN ?", removed).Delete(&models.StockReservation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("variant_id IN ?", removed).Delete(&models.BasketItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ProductVariant{}, removed).Error; err != nil {
+			return err
+		}
+	}
+
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		variant.ProductID = product.ID
+		if variant.ID == 0 {
+			if err := tx.Create(variant).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		err := tx.Model(variant).Select("sku", "options", "price", "stock", "images", "updated_at").Updates(variant).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Delete removes a product by ID
@@ -146,21 +546,201 @@ func (r *ProductRepository) Delete(ctx context.Context, id int) error {
 // GetByUser retrieves all products created by a specific user
 func (r *ProductRepository) GetByUser(ctx context.Context, userID int) ([]models.Product, error) {
 	var products []models.Product
-	result := r.DB.WithContext(ctx).Where("user_id = ?", userID).Preload("Categories").Find(&products)
+	result := r.DB.WithContext(ctx).Where("user_id = ?", userID).Preload("Categories").Scopes(preloadVariants).Find(&products)
 	if result.Error != nil {
 		return nil, result.Error
 	}
+	if err := r.setAvailable(ctx, productRefs(products)...); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
 // GetByCategory retrieves all products in a specific category
 func (r *ProductRepository) GetByCategory(ctx context.Context, categoryID int) ([]models.Product, error) {
 	var products []models.Product
-	result := r.DB.WithContext(ctx).Joins("JOIN product_categories ON products.id = product_categories.product_id").
+	result := r.DB.WithContext(ctx).Select("products.*").Joins("JOIN product_categories ON products.id = product_categories.product_id").
 		Where("product_categories.category_id = ?", categoryID).
-		Preload("User").Preload("Categories").Find(&products)
+		Preload("User").Preload("Categories").Scopes(preloadVariants).Find(&products)
 	if result.Error != nil {
 		return nil, result.Error
 	}
+	if err := r.setAvailable(ctx, productRefs(products)...); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// AddViews adds counted views to the hourly view counts and view totals of
+// products, in one transaction
+func (r *ProductRepository) AddViews(ctx context.Context, views []models.ProductView) error {
+	if len(views) == 0 {
+		return nil
+	}
+
+	totals := make(map[int]int)
+	for _, view := range views {
+		totals[view.ProductID] += view.Views
+	}
+	ids := make([]int64, 0, len(totals))
+	counts := make([]int64, 0, len(totals))
+	for id, n := range totals {
+		ids = append(ids, int64(id))
+		counts = append(counts, int64(n))
+	}
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "hour"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("product_views.views + excluded.views")}),
+		}).Create(&views).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE products SET views = products.views + counted.views
+			FROM (SELECT unnest(?::bigint[]) AS id, unnest(?::bigint[]) AS views) AS counted
+			WHERE products.id = counted.id`, pq.Array(ids), pq.Array(counts)).Error
+	})
+	if err != nil {
+		logging.Error(ctx, "failed to save product views", err, "products", len(ids))
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to save product views")
+	}
+	return nil
+}
+
+// trendingHorizon is how many half-lives back views and sales count towards
+// trending products, after which they weigh less than 1/32 of recent ones
+const trendingHorizon = 5
+
+// Trending retrieves the active products with the most views and sales,
+// weighed down by half every halfLife. A sale weighs as much as saleWeight views.
+func (r *ProductRepository) Trending(ctx context.Context, now time.Time, halfLife time.Duration, saleWeight, limit int) ([]models.Product, error) {
+	since := now.Add(-trendingHorizon * halfLife)
+	halfLifeSeconds := halfLife.Seconds()
+
+	views := r.DB.Table("product_views").
+		Select("product_id, views * power(0.5, EXTRACT(EPOCH FROM ?::timestamptz - hour) / ?) AS score", now, halfLifeSeconds).
+		Where("hour >= ?", since)
+	sales := r.DB.Table("order_items").
+		Select("order_items.product_id, order_items.quantity * ? * power(0.5, EXTRACT(EPOCH FROM ?::timestamptz - orders.created_at) / ?) AS score", saleWeight, now, halfLifeSeconds).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.created_at >= ? AND orders.status IN ?", since, models.PurchasedStatuses)
+	scores := r.DB.Raw("SELECT product_id, SUM(score) AS score FROM ((?) UNION ALL (?)) AS scores GROUP BY product_id", views, sales)
+
+	var products []models.Product
+	err := r.DB.WithContext(ctx).Select("products.*").
+		Joins("JOIN (?) AS trending ON trending.product_id = products.id", scores).
+		Where("products.status = ?", models.ProductStatusActive).
+		Order("trending.score DESC, products.id").
+		Limit(limit).
+		Preload("Categories").Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.setAvailable(ctx, productRefs(products)...); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
+
+// Weights of what related products share
+const (
+	relatedCategoryWeight = 2
+	relatedTagWeight      = 1
+)
+
+// Related retrieves the active products sharing the most categories and tags
+// with a product, a category counting as much as two tags
+func (r *ProductRepository) Related(ctx context.Context, product *models.Product, limit int) ([]models.Product, error) {
+	categoryIDs := make([]int, len(product.Categories))
+	for i, category := range product.Categories {
+		categoryIDs[i] = category.ID
+	}
+	tags := pq.StringArray(product.Tags)
+	if tags == nil {
+		tags = pq.StringArray{}
+	}
+	if len(categoryIDs) == 0 && len(tags) == 0 {
+		return []models.Product{}, nil
+	}
+
+	inCategories := "FROM product_categories WHERE product_categories.product_id = products.id AND product_categories.category_id IN ?"
+	var products []models.Product
+	err := r.DB.WithContext(ctx).
+		Select("products.*, (SELECT COUNT(*) "+inCategories+") * ? + cardinality(ARRAY(SELECT unnest(products.tags) INTERSECT SELECT unnest(?::text[]))) * ? AS related_score",
+			categoryIDs, relatedCategoryWeight, tags, relatedTagWeight).
+		Where("products.id <> ? AND products.status = ?", product.ID, models.ProductStatusActive).
+		Where("EXISTS (SELECT 1 "+inCategories+") OR products.tags && ?::text[]", categoryIDs, tags).
+		Order("related_score DESC, products.rating DESC, products.id").
+		Limit(limit).
+		Preload("Categories").Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.setAvailable(ctx, productRefs(products)...); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// setAvailable fills in the available stock of products and their variants
+// from their unexpired reservations
+func (r *ProductRepository) setAvailable(ctx context.Context, products ...*models.Product) error {
+	ids := make([]int, 0, len(products))
+	var variantIDs []int
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		for _, variant := range product.Variants {
+			variantIDs = append(variantIDs, variant.ID)
+		}
+	}
+	reserved, err := reservedStock(r.DB.WithContext(ctx), ids)
+	if err != nil {
+		return err
+	}
+	reservedVariants, err := reservedVariantStock(r.DB.WithContext(ctx), variantIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		product.SetAvailable(reserved[product.ID])
+		for i := range product.Variants {
+			product.Variants[i].SetAvailable(reservedVariants[product.Variants[i].ID])
+		}
+	}
+	return nil
+}
+
+// preloadVariants loads a product's options in order and its variants
+func preloadVariants(db *gorm.DB) *gorm.DB {
+	return db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_options.position ASC")
+	}).Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_variants.id ASC")
+	})
+}
+
+// variantOptionsScope keeps the products with a variant having all the given
+// option values
+func variantOptionsScope(options map[string]string) (func(db *gorm.DB) *gorm.DB, error) {
+	if len(options) == 0 {
+		return func(db *gorm.DB) *gorm.DB { return db }, nil
+	}
+	contains, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id AND product_variants.options @> ?::jsonb)", string(contains))
+	}, nil
+}
+
+// productRefs returns pointers to the products of a slice
+func productRefs(products []models.Product) []*models.Product {
+	refs := make([]*models.Product, len(products))
+	for i := range products {
+		refs[i] = &products[i]
+	}
+	return refs
+}
@@ -7,28 +7,52 @@ import (
 
 // Models holds all repository models
 type Models struct {
-	Users      interfaces.UserRepositoryInterface
-	Events     interfaces.EventRepositoryInterface
-	Attendees  interfaces.AttendeeRepositoryInterface
-	Categories interfaces.CategoryRepositoryInterface
-	Comments   interfaces.CommentRepositoryInterface
-	Profiles   interfaces.ProfileRepositoryInterface
-	Products   interfaces.ProductRepositoryInterface
-	Baskets    interfaces.BasketRepositoryInterface
-	TxManager  *TxManager
+	Users          interfaces.UserRepositoryInterface
+	Events         interfaces.EventRepositoryInterface
+	Attendees      interfaces.AttendeeRepositoryInterface
+	Categories     interfaces.CategoryRepositoryInterface
+	Comments       interfaces.CommentRepositoryInterface
+	CommentReports interfaces.CommentReportRepositoryInterface
+	Notifications  interfaces.NotificationRepositoryInterface
+	Profiles       interfaces.ProfileRepositoryInterface
+	Products       interfaces.ProductRepositoryInterface
+	Baskets        interfaces.BasketRepositoryInterface
+	Orders         interfaces.OrderRepositoryInterface
+	Payments       interfaces.PaymentRepositoryInterface
+	Coupons        interfaces.CouponRepositoryInterface
+	Reservations   interfaces.ReservationRepositoryInterface
+	Wishlists      interfaces.WishlistRepositoryInterface
+	Reviews        interfaces.ReviewRepositoryInterface
+	ProductImports interfaces.ProductImportRepositoryInterface
+	Media          interfaces.MediaRepositoryInterface
+	Inventory      interfaces.InventoryRepositoryInterface
+	TxManager      *TxManager
 }
 
 // NewModels creates a new Models instance with all repositories
 func NewModels(db *gorm.DB) *Models {
+	txManager := NewTxManager(db)
+
 	return &Models{
-		Users:      NewUserRepository(db),
-		Events:     NewEventRepository(db),
-		Attendees:  NewAttendeeRepository(db),
-		Categories: NewCategoryRepository(db),
-		Comments:   NewCommentRepository(db),
-		Profiles:   NewProfileRepository(db),
-		Products:   NewProductRepository(db),
-		Baskets:    NewBasketRepository(db),
-		TxManager:  NewTxManager(db),
+		Users:          NewUserRepository(db),
+		Events:         NewEventRepository(db),
+		Attendees:      NewAttendeeRepository(db),
+		Categories:     NewCategoryRepository(db),
+		Comments:       NewCommentRepository(db),
+		CommentReports: NewCommentReportRepository(db),
+		Notifications:  NewNotificationRepository(db),
+		Profiles:       NewProfileRepository(db),
+		Products:       NewProductRepository(db),
+		Baskets:        NewBasketRepository(db, txManager),
+		Orders:         NewOrderRepository(db, txManager),
+		Payments:       NewPaymentRepository(db),
+		Coupons:        NewCouponRepository(db),
+		Reservations:   NewReservationRepository(db),
+		Wishlists:      NewWishlistRepository(db, txManager),
+		Reviews:        NewReviewRepository(db, txManager),
+		ProductImports: NewProductImportRepository(db),
+		Media:          NewMediaRepository(db),
+		Inventory:      NewInventoryRepository(db),
+		TxManager:      txManager,
 	}
 }
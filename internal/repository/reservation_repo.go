@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReservationRepository handles stock reservation database operations. Baskets
+// create and release their reservations through the BasketRepository.
+type ReservationRepository struct {
+	DB *gorm.DB
+}
+
+// NewReservationRepository creates a new ReservationRepository
+func NewReservationRepository(db *gorm.DB) *ReservationRepository {
+	return &ReservationRepository{DB: db}
+}
+
+// ReleaseExpired deletes reservations that expired before now. Expired
+// reservations no longer hold stock, so this only keeps the table small.
+func (r *ReservationRepository) ReleaseExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.StockReservation{})
+	if result.Error != nil {
+		logging.Error(ctx, "failed to release expired reservations", result.Error)
+		return 0, appErrors.New(appErrors.ErrDatabaseOperation, "failed to release expired reservations")
+	}
+
+	if result.RowsAffected > 0 {
+		logging.Info(ctx, "expired stock reservations released", "count", result.RowsAffected)
+	}
+	return result.RowsAffected, nil
+}
+
+// reservedStock sums the unexpired reservations of products by product ID,
+// leaving out those of the excluded baskets
+func reservedStock(db *gorm.DB, productIDs []int, excludeBasketIDs ...int) (map[int]int, error) {
+	return sumReservations(db, "product_id", productIDs, excludeBasketIDs)
+}
+
+// reservedVariantStock sums the unexpired reservations of product variants by
+// variant ID, leaving out those of the excluded baskets
+func reservedVariantStock(db *gorm.DB, variantIDs []int, excludeBasketIDs ...int) (map[int]int, error) {
+	return sumReservations(db, "variant_id", variantIDs, excludeBasketIDs)
+}
+
+// sumReservations sums unexpired reservations grouped by column, for the given
+// values of it
+func sumReservations(db *gorm.DB, column string, ids []int, excludeBasketIDs []int) (map[int]int, error) {
+	reserved := make(map[int]int, len(ids))
+	if len(ids) == 0 {
+		return reserved, nil
+	}
+
+	var rows []struct {
+		ID       int
+		Reserved int
+	}
+	q := db.Model(&models.StockReservation{}).
+		Select(column+" AS id, SUM(quantity) AS reserved").
+		Where(column+" IN ? AND expires_at > ?", ids, time.Now())
+	if len(excludeBasketIDs) > 0 {
+		q = q.Where("basket_id NOT IN ?", excludeBasketIDs)
+	}
+	if err := q.Group(column).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		reserved[row.ID] = row.Reserved
+	}
+	return reserved, nil
+}
+
+// reserveStock reserves quantity of a locked product, or of one of its variants
+// when variant is set, for a basket until the given time, replacing the
+// basket's previous reservation of it. It fails with a conflict when other
+// baskets hold too much of the stock.
+func reserveStock(tx *gorm.DB, product *models.Product, variant *models.ProductVariant, basketID, quantity int, until time.Time) error {
+	available, err := availableStock(tx, product, variant, basketID)
+	if err != nil {
+		return err
+	}
+
+	if quantity > available {
+		name := product.Name
+		if variant != nil {
+			name += " (" + variant.SKU + ")"
+		}
+		appErr := appErrors.Newf(appErrors.ErrConflict, "only %d of %s available", available, name).
+			WithDetail("productId", product.ID).
+			WithDetail("available", available).
+			WithDetail("requested", quantity)
+		if variant != nil {
+			appErr = appErr.WithDetail("variantId", variant.ID)
+		}
+		return appErr
+	}
+
+	reservation := &models.StockReservation{
+		BasketID:  basketID,
+		ProductID: product.ID,
+		Quantity:  quantity,
+		ExpiresAt: until,
+	}
+	if variant != nil {
+		reservation.VariantID = variant.ID
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "basket_id"}, {Name: "product_id"}, {Name: "variant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity", "expires_at", "updated_at"}),
+	}).Create(reservation).Error
+}
+
+// availableStock returns the stock of a locked product, or of one of its
+// variants when variant is set, not reserved by baskets other than the given ones
+func availableStock(tx *gorm.DB, product *models.Product, variant *models.ProductVariant, basketIDs ...int) (int, error) {
+	if variant != nil {
+		reserved, err := reservedVariantStock(tx, []int{variant.ID}, basketIDs...)
+		if err != nil {
+			return 0, err
+		}
+		return max(variant.Stock-reserved[variant.ID], 0), nil
+	}
+
+	reserved, err := reservedStock(tx, []int{product.ID}, basketIDs...)
+	if err != nil {
+		return 0, err
+	}
+	return max(product.Stock-reserved[product.ID], 0), nil
+}
+
+// releaseStock deletes all of a basket's reservations
+func releaseStock(tx *gorm.DB, basketID int) error {
+	return tx.Where("basket_id = ?", basketID).Delete(&models.StockReservation{}).Error
+}
+
+// releaseItemStock deletes the reservation of a basket item
+func releaseItemStock(tx *gorm.DB, item *models.BasketItem) error {
+	return tx.Where("basket_id = ? AND product_id = ? AND variant_id = ?", item.BasketID, item.ProductID, variantKey(item.VariantID)).
+		Delete(&models.StockReservation{}).Error
+}
+
+// variantKey returns the variant ID reservations are keyed on, zero for none
+func variantKey(variantID *int) int {
+	if variantID == nil {
+		return 0
+	}
+	return *variantID
+}
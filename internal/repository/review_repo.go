@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReviewRepository handles product review database operations. Every change
+// recomputes the product's rating and review count in the same transaction,
+// with the product locked so concurrent reviews are counted one at a time.
+type ReviewRepository struct {
+	DB        *gorm.DB
+	TxManager *TxManager
+}
+
+// NewReviewRepository creates a new ReviewRepository
+func NewReviewRepository(db *gorm.DB, txManager *TxManager) *ReviewRepository {
+	return &ReviewRepository{DB: db, TxManager: txManager}
+}
+
+// Insert creates a review and updates its product's rating. A user reviews a
+// product at most once.
+func (r *ReviewRepository) Insert(ctx context.Context, review *models.Review) (*models.Review, error) {
+	logging.Debug(ctx, "creating review", "product_id", review.ProductID, "user_id", review.UserID)
+
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := lockRatedProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+		if err := tx.Create(review).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return appErrors.New(appErrors.ErrAlreadyExists, "you have already reviewed this product").
+					WithDetail("productId", review.ProductID)
+			}
+			return err
+		}
+		return refreshRating(tx, review.ProductID)
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to create review", err, "product_id", review.ProductID, "user_id", review.UserID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create review")
+	}
+
+	logging.Info(ctx, "review created successfully", "review_id", review.ID, "product_id", review.ProductID)
+	return review, nil
+}
+
+// Get retrieves a review by ID with its author
+func (r *ReviewRepository) Get(ctx context.Context, id int) (*models.Review, error) {
+	logging.Debug(ctx, "retrieving review", "review_id", id)
+
+	var review models.Review
+	if err := r.DB.WithContext(ctx).Preload("User").First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "review with ID %d not found", id)
+		}
+		logging.Error(ctx, "failed to retrieve review", err, "review_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve review")
+	}
+
+	return &review, nil
+}
+
+// ListByProduct retrieves the reviews of a product with their authors, newest first by default
+func (r *ReviewRepository) ListByProduct(ctx context.Context, productID int, params *query.QueryParams) ([]*models.Review, *query.PaginatedList, error) {
+	logging.Debug(ctx, "retrieving reviews", "product_id", productID, "page", params.Page, "page_size", params.PageSize)
+
+	var reviews []*models.Review
+	var total int64
+
+	filters := []string{"rating", "verified_purchase", "user_id"}
+	if params.IncludeTotal {
+		countQuery := r.DB.WithContext(ctx).Model(&models.Review{}).Where("product_id = ?", productID)
+		for _, filter := range params.Filters {
+			if slices.Contains(filters, filter.Field) {
+				countQuery = query.ApplyFilter(countQuery, filter)
+			}
+		}
+		if err := countQuery.Count(&total).Error; err != nil {
+			logging.Error(ctx, "failed to count reviews", err, "product_id", productID)
+			return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count reviews")
+		}
+	}
+
+	builder := query.NewQueryBuilder(r.DB.WithContext(ctx).Model(&models.Review{}).Where("product_id = ?", productID)).
+		WithRequest(params).
+		AllowFilters(filters...).
+		AllowSorts("id", "rating", "created_at").
+		DefaultSort("id", query.SortDesc)
+
+	if err := builder.Build().Preload("User").Find(&reviews).Error; err != nil {
+		logging.Error(ctx, "failed to retrieve reviews", err, "product_id", productID)
+		return nil, nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve reviews")
+	}
+
+	var firstID, lastID int
+	if len(reviews) > 0 {
+		firstID = reviews[0].ID
+		lastID = reviews[len(reviews)-1].ID
+	}
+
+	result := query.BuildResponse(reviews, params, total, len(reviews), firstID, lastID)
+	return reviews, result, nil
+}
+
+// Update saves a review's rating, title and body and updates its product's rating
+func (r *ReviewRepository) Update(ctx context.Context, review *models.Review) error {
+	logging.Debug(ctx, "updating review", "review_id", review.ID)
+
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := lockRatedProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+		result := tx.Model(review).Select("rating", "title", "body", "updated_at").Updates(review)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return appErrors.Newf(appErrors.ErrNotFound, "review with ID %d not found", review.ID)
+		}
+		return refreshRating(tx, review.ProductID)
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		logging.Error(ctx, "failed to update review", err, "review_id", review.ID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update review")
+	}
+
+	logging.Info(ctx, "review updated successfully", "review_id", review.ID, "product_id", review.ProductID)
+	return nil
+}
+
+// Delete removes a review and updates its product's rating
+func (r *ReviewRepository) Delete(ctx context.Context, id int) error {
+	logging.Debug(ctx, "deleting review", "review_id", id)
+
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var review models.Review
+		if err := tx.First(&review, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "review with ID %d not found", id)
+			}
+			return err
+		}
+		if err := lockRatedProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		return refreshRating(tx, review.ProductID)
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		logging.Error(ctx, "failed to delete review", err, "review_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to delete review")
+	}
+
+	logging.Info(ctx, "review deleted successfully", "review_id", id)
+	return nil
+}
+
+// lockRatedProduct locks a product for the rest of the transaction so its
+// rating is recomputed by one review at a time. Deleted products are locked
+// too, so their reviews can still be removed.
+func lockRatedProduct(tx *gorm.DB, productID int) error {
+	var product models.Product
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, productID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.Newf(appErrors.ErrNotFound, "product with ID %d not found", productID)
+		}
+		return err
+	}
+	return nil
+}
+
+// refreshRating recomputes a locked product's average rating and review count
+// from its reviews
+func refreshRating(tx *gorm.DB, productID int) error {
+	return tx.Unscoped().Model(&models.Product{}).Where("id = ?", productID).UpdateColumns(map[string]any{
+		"rating":        gorm.Expr("COALESCE((SELECT ROUND(AVG(rating)::numeric, 2) FROM reviews WHERE product_id = ?), 0)", productID),
+		"reviews_count": gorm.Expr("(SELECT COUNT(*) FROM reviews WHERE product_id = ?)", productID),
+	}).Error
+}
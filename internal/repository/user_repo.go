@@ -74,6 +74,32 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return &user, nil
 }
 
+// FindByMentions retrieves the users whose name or email matches a mention handle, case-insensitively
+func (r *UserRepository) FindByMentions(ctx context.Context, names []string, emails []string) ([]*models.User, error) {
+	var users []*models.User
+	if len(names) == 0 && len(emails) == 0 {
+		return users, nil
+	}
+
+	logging.Debug(ctx, "resolving mentioned users", "names", len(names), "emails", len(emails))
+
+	db := r.DB.WithContext(ctx).Model(&models.User{})
+	if len(names) > 0 && len(emails) > 0 {
+		db = db.Where("LOWER(name) IN ? OR LOWER(email) IN ?", names, emails)
+	} else if len(names) > 0 {
+		db = db.Where("LOWER(name) IN ?", names)
+	} else {
+		db = db.Where("LOWER(email) IN ?", emails)
+	}
+
+	if err := db.Find(&users).Error; err != nil {
+		logging.Error(ctx, "failed to resolve mentioned users", err)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to resolve mentioned users")
+	}
+
+	return users, nil
+}
+
 // UpdatePassword updates the user's password
 func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, hashedPassword string) error {
 	logging.Debug(ctx, "updating user password", "user_id", userID)
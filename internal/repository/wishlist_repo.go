@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/logging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WishlistRepository handles wishlist database operations
+type WishlistRepository struct {
+	DB        *gorm.DB
+	TxManager *TxManager
+}
+
+// NewWishlistRepository creates a new WishlistRepository
+func NewWishlistRepository(db *gorm.DB, txManager *TxManager) *WishlistRepository {
+	return &WishlistRepository{DB: db, TxManager: txManager}
+}
+
+// Insert creates a wishlist. Names are unique per user.
+func (r *WishlistRepository) Insert(ctx context.Context, wishlist *models.Wishlist) (*models.Wishlist, error) {
+	logging.Debug(ctx, "creating wishlist", "user_id", wishlist.UserID, "name", wishlist.Name)
+
+	if err := r.DB.WithContext(ctx).Create(wishlist).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, appErrors.Newf(appErrors.ErrAlreadyExists, "wishlist %q already exists", wishlist.Name)
+		}
+		logging.Error(ctx, "failed to create wishlist", err, "user_id", wishlist.UserID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to create wishlist")
+	}
+
+	logging.Info(ctx, "wishlist created successfully", "wishlist_id", wishlist.ID, "user_id", wishlist.UserID)
+	return wishlist, nil
+}
+
+// Get retrieves a wishlist by ID with its products
+func (r *WishlistRepository) Get(ctx context.Context, id int) (*models.Wishlist, error) {
+	logging.Debug(ctx, "retrieving wishlist", "wishlist_id", id)
+
+	var wishlist models.Wishlist
+	err := r.DB.WithContext(ctx).Scopes(preloadWishlist).First(&wishlist, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.Newf(appErrors.ErrNotFound, "wishlist with ID %d not found", id)
+		}
+		logging.Error(ctx, "failed to retrieve wishlist", err, "wishlist_id", id)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve wishlist")
+	}
+
+	return &wishlist, nil
+}
+
+// GetByShareToken retrieves a shared wishlist with its products
+func (r *WishlistRepository) GetByShareToken(ctx context.Context, token string) (*models.Wishlist, error) {
+	logging.Debug(ctx, "retrieving shared wishlist")
+
+	var wishlist models.Wishlist
+	err := r.DB.WithContext(ctx).Scopes(preloadWishlist).Where("share_token = ?", token).First(&wishlist).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.New(appErrors.ErrNotFound, "wishlist not found")
+		}
+		logging.Error(ctx, "failed to retrieve shared wishlist", err)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve wishlist")
+	}
+
+	return &wishlist, nil
+}
+
+// ListByUser retrieves a user's wishlists with their products, by name
+func (r *WishlistRepository) ListByUser(ctx context.Context, userID int) ([]*models.Wishlist, error) {
+	logging.Debug(ctx, "retrieving wishlists", "user_id", userID)
+
+	var wishlists []*models.Wishlist
+	err := r.DB.WithContext(ctx).Scopes(preloadWishlist).Where("user_id = ?", userID).Order("name ASC").Find(&wishlists).Error
+	if err != nil {
+		logging.Error(ctx, "failed to retrieve wishlists", err, "user_id", userID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve wishlists")
+	}
+
+	return wishlists, nil
+}
+
+// preloadWishlist loads a wishlist's products, oldest first
+func preloadWishlist(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("wishlist_items.created_at ASC")
+	}).Preload("Items.Product")
+}
+
+// Delete removes a wishlist with its items
+func (r *WishlistRepository) Delete(ctx context.Context, id int) error {
+	logging.Debug(ctx, "deleting wishlist", "wishlist_id", id)
+
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Where("wishlist_id = ?", id).Delete(&models.WishlistItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Wishlist{}, id).Error
+	})
+	if err != nil {
+		logging.Error(ctx, "failed to delete wishlist", err, "wishlist_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to delete wishlist")
+	}
+
+	logging.Info(ctx, "wishlist deleted successfully", "wishlist_id", id)
+	return nil
+}
+
+// SetShareToken sets the token a wishlist is shared with, or stops sharing it
+// when token is nil
+func (r *WishlistRepository) SetShareToken(ctx context.Context, id int, token *string) error {
+	logging.Debug(ctx, "updating wishlist sharing", "wishlist_id", id, "shared", token != nil)
+
+	err := r.DB.WithContext(ctx).Model(&models.Wishlist{}).Where("id = ?", id).Update("share_token", token).Error
+	if err != nil {
+		logging.Error(ctx, "failed to update wishlist sharing", err, "wishlist_id", id)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to update wishlist")
+	}
+	return nil
+}
+
+// AddItem adds a product to a wishlist. Adding a product already on it does nothing.
+func (r *WishlistRepository) AddItem(ctx context.Context, wishlistID, productID int) error {
+	logging.Debug(ctx, "adding product to wishlist", "wishlist_id", wishlistID, "product_id", productID)
+
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.WishlistItem{WishlistID: wishlistID, ProductID: productID}).Error
+	if err != nil {
+		logging.Error(ctx, "failed to add product to wishlist", err, "wishlist_id", wishlistID, "product_id", productID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to add product to wishlist")
+	}
+
+	logging.Info(ctx, "product added to wishlist", "wishlist_id", wishlistID, "product_id", productID)
+	return nil
+}
+
+// RemoveItem removes a product from a wishlist
+func (r *WishlistRepository) RemoveItem(ctx context.Context, wishlistID, productID int) error {
+	logging.Debug(ctx, "removing product from wishlist", "wishlist_id", wishlistID, "product_id", productID)
+
+	result := r.DB.WithContext(ctx).Where("wishlist_id = ? AND product_id = ?", wishlistID, productID).Delete(&models.WishlistItem{})
+	if result.Error != nil {
+		logging.Error(ctx, "failed to remove product from wishlist", result.Error, "wishlist_id", wishlistID, "product_id", productID)
+		return appErrors.New(appErrors.ErrDatabaseOperation, "failed to remove product from wishlist")
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.Newf(appErrors.ErrNotFound, "product with ID %d is not on the wishlist", productID)
+	}
+
+	logging.Info(ctx, "product removed from wishlist", "wishlist_id", wishlistID, "product_id", productID)
+	return nil
+}
+
+// SaveForLater moves a basket item to the user's save-for-later wishlist,
+// creating it when needed, and releases the item's reserved stock. Items of
+// other baskets are not found.
+func (r *WishlistRepository) SaveForLater(ctx context.Context, userID, basketID, itemID int) (*models.Wishlist, error) {
+	logging.Debug(ctx, "saving basket item for later", "user_id", userID, "basket_id", basketID, "item_id", itemID)
+
+	var wishlistID int
+	err := r.TxManager.WithTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var item models.BasketItem
+		if err := tx.Where("basket_id = ?", basketID).First(&item, itemID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return appErrors.Newf(appErrors.ErrNotFound, "basket item with ID %d not found", itemID)
+			}
+			return err
+		}
+
+		wishlist := models.Wishlist{UserID: userID, Name: models.SaveForLaterWishlist}
+		err := tx.Where("user_id = ? AND name = ?", userID, wishlist.Name).FirstOrCreate(&wishlist).Error
+		if err != nil {
+			return err
+		}
+		wishlistID = wishlist.ID
+
+		err = tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.WishlistItem{WishlistID: wishlist.ID, ProductID: item.ProductID}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		return releaseItemStock(tx, &item)
+	})
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logging.Error(ctx, "failed to save basket item for later", err, "basket_id", basketID, "item_id", itemID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to save item for later")
+	}
+
+	logging.Info(ctx, "basket item saved for later", "basket_id", basketID, "item_id", itemID, "wishlist_id", wishlistID)
+	return r.Get(ctx, wishlistID)
+}
+
+// WatcherIDs returns the distinct users with the product on any of their wishlists
+func (r *WishlistRepository) WatcherIDs(ctx context.Context, productID int) ([]int, error) {
+	var userIDs []int
+	err := r.DB.WithContext(ctx).Model(&models.WishlistItem{}).
+		Joins("JOIN wishlists ON wishlists.id = wishlist_items.wishlist_id").
+		Where("wishlist_items.product_id = ?", productID).
+		Distinct().
+		Pluck("wishlists.user_id", &userIDs).Error
+	if err != nil {
+		logging.Error(ctx, "failed to retrieve wishlist watchers", err, "product_id", productID)
+		return nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to retrieve wishlist watchers")
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,121 @@
+// Package storage keeps uploaded files in a backend such as the local
+// filesystem or an S3-compatible bucket and hands out signed URLs to them.
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Storage drivers
+const (
+	DriverMemory = "memory"
+	DriverLocal  = "local"
+	DriverS3     = "s3"
+)
+
+var (
+	// ErrNotFound is returned when no file is stored under a key
+	ErrNotFound = errors.New("file not found")
+	// ErrInvalidSignature is returned when a signed URL is forged or expired
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// Storage stores files by key, e.g. "media/ab/ab12….png"
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any file there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open reads the file stored under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key; a missing file is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL the file can be downloaded from until ttl passes
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// Verifier is implemented by storages whose signed URLs are served by this
+// application rather than by the backend itself
+type Verifier interface {
+	// Verify checks the expires and signature query parameters of a signed URL
+	Verify(key, expires, signature string, now time.Time) error
+}
+
+// Config selects and configures a storage driver
+type Config struct {
+	Driver string
+	// SigningSecret signs the URLs of files served by this application
+	SigningSecret string
+	// BaseURL is where this application serves signed files, e.g. "/api/v1/media/files"
+	BaseURL string
+
+	// LocalDir is the directory of the local driver
+	LocalDir string
+
+	// S3 is the configuration of the s3 driver
+	S3 S3Config
+}
+
+// New creates the configured storage
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverMemory:
+		return NewMemory(cfg.BaseURL, cfg.SigningSecret), nil
+	case DriverLocal:
+		return NewLocal(cfg.LocalDir, cfg.BaseURL, cfg.SigningSecret)
+	case DriverS3:
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// CheckKey rejects keys that are empty, absolute or climb out of the storage
+func CheckKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
+
+// urlSigner signs URLs of files served by this application with an
+// HMAC-SHA256 of the key and expiry time
+type urlSigner struct {
+	baseURL string
+	secret  []byte
+}
+
+func (s urlSigner) SignedURL(key string, ttl time.Duration) (string, error) {
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return strings.TrimSuffix(s.baseURL, "/") + "/" + key + "?" + query.Encode(), nil
+}
+
+func (s urlSigner) Verify(key, expires, signature string, now time.Time) error {
+	at, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > at {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s urlSigner) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
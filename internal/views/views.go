@@ -0,0 +1,119 @@
+// Package views counts product views in memory and writes them in batches, so
+// that viewing a product does not write to the database on every request.
+package views
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+)
+
+// Store records counted views
+type Store interface {
+	AddViews(ctx context.Context, views []models.ProductView) error
+}
+
+// Counter counts product views until they are flushed. A viewer's views of a
+// product count once per window.
+type Counter struct {
+	window time.Duration
+
+	mu sync.Mutex
+	// seen holds when the window of a viewer's last counted view of a product ends
+	seen    map[viewer]time.Time
+	pending map[bucket]int
+}
+
+type viewer struct {
+	productID int
+	id        string
+}
+
+// bucket is an hour of a product's views
+type bucket struct {
+	productID int
+	hour      time.Time
+}
+
+// NewCounter returns a counter counting a viewer's views of a product once
+// per window
+func NewCounter(window time.Duration) *Counter {
+	return &Counter{
+		window:  window,
+		seen:    make(map[viewer]time.Time),
+		pending: make(map[bucket]int),
+	}
+}
+
+// Record counts a view of a product by a viewer, e.g. "user:1" or
+// "ip:203.0.113.7", unless the viewer's last counted view of the product is
+// within the window. It reports whether the view counted.
+func (c *Counter) Record(productID int, viewerID string, now time.Time) bool {
+	key := viewer{productID: productID, id: viewerID}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if until, ok := c.seen[key]; ok && now.Before(until) {
+		return false
+	}
+	c.seen[key] = now.Add(c.window)
+	c.pending[bucket{productID: productID, hour: now.UTC().Truncate(time.Hour)}]++
+	return true
+}
+
+// Pending returns the number of counted views not flushed yet
+func (c *Counter) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+	for _, n := range c.pending {
+		total += n
+	}
+	return total
+}
+
+// Flush writes the counted views to the store in one batch and forgets the
+// viewers whose window has passed. Views the store fails to write are kept
+// for the next flush.
+func (c *Counter) Flush(ctx context.Context, store Store, now time.Time) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[bucket]int)
+	for key, until := range c.seen {
+		if !now.Before(until) {
+			delete(c.seen, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	views := make([]models.ProductView, 0, len(pending))
+	for b, n := range pending {
+		views = append(views, models.ProductView{ProductID: b.productID, Hour: b.hour, Views: n})
+	}
+	// A stable order keeps concurrent batches from deadlocking on row locks
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].ProductID != views[j].ProductID {
+			return views[i].ProductID < views[j].ProductID
+		}
+		return views[i].Hour.Before(views[j].Hour)
+	})
+
+	if err := store.AddViews(ctx, views); err != nil {
+		c.mu.Lock()
+		for b, n := range pending {
+			c.pending[b] += n
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storeFunc func(ctx context.Context, views []models.ProductView) error
+
+func (f storeFunc) AddViews(ctx context.Context, views []models.ProductView) error {
+	return f(ctx, views)
+}
+
+func TestCounter(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 50, 0, 0, time.UTC)
+	counter := NewCounter(30 * time.Minute)
+
+	assert.True(t, counter.Record(1, "ip:203.0.113.7", start))
+	// The same viewer counts once per window
+	assert.False(t, counter.Record(1, "ip:203.0.113.7", start.Add(29*time.Minute)))
+	assert.True(t, counter.Record(1, "user:5", start))
+	assert.True(t, counter.Record(2, "ip:203.0.113.7", start))
+	assert.True(t, counter.Record(1, "ip:203.0.113.7", start.Add(30*time.Minute)))
+	assert.Equal(t, 4, counter.Pending())
+
+	var flushed []models.ProductView
+	err := counter.Flush(context.Background(), storeFunc(func(_ context.Context, views []models.ProductView) error {
+		flushed = views
+		return nil
+	}), start.Add(30*time.Minute))
+	require.NoError(t, err)
+
+	// Views are counted by the hour
+	assert.Equal(t, []models.ProductView{
+		{ProductID: 1, Hour: start.Truncate(time.Hour), Views: 2},
+		{ProductID: 1, Hour: start.Truncate(time.Hour).Add(time.Hour), Views: 1},
+		{ProductID: 2, Hour: start.Truncate(time.Hour), Views: 1},
+	}, flushed)
+	assert.Zero(t, counter.Pending())
+	// Only viewers still within their window are remembered
+	assert.Len(t, counter.seen, 1)
+}
+
+func TestCounterKeepsViewsTheStoreFailsToWrite(t *testing.T) {
+	now := time.Now()
+	counter := NewCounter(time.Minute)
+	counter.Record(1, "user:1", now)
+
+	failing := storeFunc(func(context.Context, []models.ProductView) error { return errors.New("database is down") })
+	assert.Error(t, counter.Flush(context.Background(), failing, now))
+	assert.Equal(t, 1, counter.Pending())
+
+	counter.Record(1, "user:2", now)
+	var flushed []models.ProductView
+	require.NoError(t, counter.Flush(context.Background(), storeFunc(func(_ context.Context, views []models.ProductView) error {
+		flushed = views
+		return nil
+	}), now))
+	assert.Equal(t, 2, flushed[0].Views)
+
+	// Nothing is written without views
+	require.NoError(t, counter.Flush(context.Background(), failing, now))
+}
@@ -0,0 +1,368 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	"github.com/alireza-akbarzadeh/ginflow/internal/baskettoken"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestBasketTotals tests the totals block on the basket response
+func TestBasketTotals(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	engine, err := pricing.NewEngine([]string{"DE:19"}, "")
+	require.NoError(t, err)
+	ts.Handler.Pricing = engine
+
+	germanID := 1
+	noProfileID := 2
+	germanToken, _ := ts.GenerateToken(germanID)
+	noProfileToken, _ := ts.GenerateToken(noProfileID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, germanID).Return(&models.User{ID: germanID, Email: "de@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, noProfileID).Return(&models.User{ID: noProfileID, Email: "none@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, germanID).Return(&models.Profile{UserID: germanID, Country: "de"}, nil)
+	mockProfileRepo.On("GetByUserID", mock.Anything, noProfileID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	basketFor := func(userID int) *models.Basket {
+		return &models.Basket{ID: userID, UserID: &userID, Status: models.BasketStatusActive, Items: []models.BasketItem{
+			{ID: 1, ProductID: 3, Quantity: 2, UnitPrice: money.New(1000, "EUR"), UnitDiscount: money.New(250, "EUR"), Currency: "EUR"},
+			{ID: 2, ProductID: 4, Quantity: 1, UnitPrice: money.New(500, "EUR"), Currency: "EUR"},
+		}}
+	}
+
+	mockBasketRepo := ts.Mocks.Baskets.(*mocks.BasketRepositoryMock)
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, germanID).Return(basketFor(germanID), nil)
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, noProfileID).Return(basketFor(noProfileID), nil)
+
+	t.Run("basket totals include country tax", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/basket", germanToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Items, 2)
+		require.NotNil(t, resp.Totals)
+		assert.Equal(t, money.New(2500, "EUR"), resp.Totals.Subtotal)
+		assert.Equal(t, money.New(500, "EUR"), resp.Totals.Discount)
+		assert.Equal(t, money.New(380, "EUR"), resp.Totals.Tax)
+		assert.Equal(t, money.New(2380, "EUR"), resp.Totals.Total)
+		assert.Equal(t, "19.00", resp.Totals.TaxRate)
+	})
+
+	t.Run("users without a profile pay the default rate", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/basket", noProfileToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Totals)
+		assert.True(t, resp.Totals.Tax.IsZero())
+		assert.Equal(t, money.New(2000, "EUR"), resp.Totals.Total)
+	})
+}
+
+// TestBasketStockReservations tests that adding items reserves their stock
+func TestBasketStockReservations(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+	ts.Handler.StockReservationTTL = 10 * time.Minute
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "buyer@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, userID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 3).Return(&models.Product{ID: 3, Name: "Lamp", Price: money.New(1500, "USD"), Currency: "USD", Stock: 5, Available: 2}, nil)
+
+	mockBasketRepo := ts.Mocks.Baskets.(*mocks.BasketRepositoryMock)
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, userID).Return(&models.Basket{ID: 10, UserID: &userID, Status: models.BasketStatusActive}, nil)
+
+	t.Run("items are reserved for the reservation TTL", func(t *testing.T) {
+		var reservedUntil time.Time
+		mockBasketRepo.On("AddItem", mock.Anything, 10, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				reservedUntil = args.Get(3).(time.Time)
+			}).
+			Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/items", token, handlers.AddItemRequest{ProductID: 3, Quantity: 2})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), reservedUntil, 5*time.Second)
+	})
+
+	t.Run("items beyond available stock are rejected", func(t *testing.T) {
+		mockBasketRepo.On("AddItem", mock.Anything, 10, mock.Anything, mock.Anything).
+			Return(appErrors.New(appErrors.ErrConflict, "only 2 of Lamp available")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/items", token, handlers.AddItemRequest{ProductID: 3, Quantity: 3})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "only 2 of Lamp available")
+	})
+
+	t.Run("products show their available stock", func(t *testing.T) {
+		w := ts.createRequest("GET", "/api/v1/products/3", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var product map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+		assert.EqualValues(t, 5, product["stock"])
+		assert.EqualValues(t, 2, product["available"])
+	})
+
+	mockBasketRepo.AssertExpectations(t)
+}
+
+// TestGuestBaskets tests baskets identified by a basket token
+func TestGuestBaskets(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	guestBasketID := 20
+	guestToken := baskettoken.Sign(ts.JWTSecret, guestBasketID)
+
+	guestRequest := func(method, path, basketToken string, body interface{}) *httptest.ResponseRecorder {
+		var reqBody bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&reqBody).Encode(body))
+		}
+		req := httptest.NewRequest(method, path, &reqBody)
+		req.Header.Set("Content-Type", "application/json")
+		if basketToken != "" {
+			req.Header.Set(handlers.BasketTokenHeader, basketToken)
+		}
+		w := httptest.NewRecorder()
+		ts.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 3).Return(&models.Product{ID: 3, Name: "Lamp", Price: money.New(1500, "USD"), Currency: "USD", Stock: 5, Available: 5}, nil)
+
+	mockBasketRepo := ts.Mocks.Baskets.(*mocks.BasketRepositoryMock)
+	guestBasket := &models.Basket{ID: guestBasketID, Status: models.BasketStatusActive, Items: []models.BasketItem{
+		{ID: 7, ProductID: 3, Quantity: 1, UnitPrice: money.New(1500, "USD"), Currency: "USD"},
+	}}
+	mockBasketRepo.On("GetGuestBasket", mock.Anything, guestBasketID).Return(guestBasket, nil)
+
+	t.Run("guests get a basket and its token when adding an item", func(t *testing.T) {
+		mockBasketRepo.On("CreateBasket", mock.Anything, mock.MatchedBy(func(b *models.Basket) bool {
+			return b.UserID == nil
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Basket).ID = guestBasketID
+		}).Return(nil).Once()
+		mockBasketRepo.On("AddItem", mock.Anything, guestBasketID, mock.Anything, mock.Anything).Return(nil).Once()
+
+		w := guestRequest("POST", "/api/v1/basket/items", "", handlers.AddItemRequest{ProductID: 3, Quantity: 1})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, guestToken, w.Header().Get(handlers.BasketTokenHeader))
+		assert.Contains(t, w.Header().Get("Set-Cookie"), "basket_token="+guestToken)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Items, 1)
+	})
+
+	t.Run("guests read their basket with the token", func(t *testing.T) {
+		w := guestRequest("GET", "/api/v1/basket", guestToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, guestBasketID, resp.ID)
+		assert.Equal(t, money.New(1500, "USD"), resp.Totals.Total)
+	})
+
+	t.Run("forged tokens are ignored", func(t *testing.T) {
+		forged := baskettoken.Sign("another-secret", guestBasketID)
+
+		w := guestRequest("GET", "/api/v1/basket", forged, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Zero(t, resp.ID)
+		assert.Empty(t, resp.Items)
+		assert.Empty(t, w.Header().Get(handlers.BasketTokenHeader))
+	})
+
+	t.Run("guests cannot remove items of other baskets", func(t *testing.T) {
+		mockBasketRepo.On("RemoveItem", mock.Anything, guestBasketID, 99).
+			Return(appErrors.New(appErrors.ErrNotFound, "basket item with ID 99 not found")).Once()
+
+		w := guestRequest("DELETE", "/api/v1/basket/items/99", guestToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("guests cannot check out", func(t *testing.T) {
+		w := guestRequest("POST", "/api/v1/basket/checkout", guestToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("signing in merges the guest basket", func(t *testing.T) {
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+		mockUserRepo.On("GetByEmail", mock.Anything, "guest@example.com").
+			Return(&models.User{ID: 5, Email: "guest@example.com", Password: string(hashedPassword)}, nil).Once()
+		mockUserRepo.On("UpdateLastLogin", mock.Anything, 5).Return(nil).Once()
+		mockBasketRepo.On("MergeGuestBasket", mock.Anything, guestBasketID, 5, mock.Anything).Return(nil).Once()
+
+		w := guestRequest("POST", "/api/v1/auth/login", guestToken, handlers.LoginRequest{Email: "guest@example.com", Password: "password123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Set-Cookie"), "basket_token=;")
+	})
+
+	mockBasketRepo.AssertExpectations(t)
+}
+
+// TestBasketItemUpdates tests changing and removing items of the caller's basket
+func TestBasketItemUpdates(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "buyer@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, userID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	mockBasketRepo := ts.Mocks.Baskets.(*mocks.BasketRepositoryMock)
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, userID).Return(&models.Basket{ID: 10, UserID: &userID, Status: models.BasketStatusActive, Items: []models.BasketItem{
+		{ID: 1, ProductID: 3, Quantity: 2, UnitPrice: money.New(1200, "USD"), Currency: "USD", PriceChanged: true},
+	}}, nil)
+
+	t.Run("quantities are updated within the caller's basket", func(t *testing.T) {
+		mockBasketRepo.On("UpdateItemQuantity", mock.Anything, 10, 1, 2, mock.Anything).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("PATCH", "/api/v1/basket/items/1", token, map[string]int{"quantity": 2})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.PriceChanged)
+		assert.True(t, resp.Items[0].PriceChanged)
+		assert.Equal(t, money.New(2400, "USD"), resp.Totals.Total)
+	})
+
+	t.Run("items of other baskets are not found", func(t *testing.T) {
+		mockBasketRepo.On("UpdateItemQuantity", mock.Anything, 10, 99, 1, mock.Anything).
+			Return(appErrors.New(appErrors.ErrNotFound, "basket item with ID 99 not found")).Once()
+		mockBasketRepo.On("RemoveItem", mock.Anything, 10, 99).
+			Return(appErrors.New(appErrors.ErrNotFound, "basket item with ID 99 not found")).Once()
+
+		w := ts.createAuthenticatedRequest("PATCH", "/api/v1/basket/items/99", token, map[string]int{"quantity": 1})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = ts.createAuthenticatedRequest("DELETE", "/api/v1/basket/items/99", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("a quantity of zero removes the item", func(t *testing.T) {
+		mockBasketRepo.On("UpdateItemQuantity", mock.Anything, 10, 1, 0, mock.Anything).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("PATCH", "/api/v1/basket/items/1", token, map[string]int{"quantity": 0})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid quantities are rejected", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("PATCH", "/api/v1/basket/items/1", token, map[string]int{"quantity": -1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = ts.createAuthenticatedRequest("PATCH", "/api/v1/basket/items/1", token, map[string]string{})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("clearing responds with the empty basket", func(t *testing.T) {
+		mockBasketRepo.On("ClearBasket", mock.Anything, 10).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/basket", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 10, resp.ID)
+		assert.Empty(t, resp.Items)
+		assert.True(t, resp.Totals.Total.IsZero())
+	})
+
+	mockBasketRepo.AssertExpectations(t)
+}
+
+// TestBasketVariants tests adding product variants to the basket
+func TestBasketVariants(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "buyer@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, userID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	largePrice := money.New(1800, "USD")
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 5).Return(&models.Product{
+		ID: 5, Name: "T-Shirt", Price: money.New(1500, "USD"), Discount: money.New(200, "USD"), Currency: "USD",
+		Variants: []models.ProductVariant{
+			{ID: 7, SKU: "TEE-S", Options: map[string]string{"size": "S"}, Stock: 3},
+			{ID: 8, SKU: "TEE-L", Options: map[string]string{"size": "L"}, Stock: 3, Price: &largePrice},
+		},
+	}, nil)
+
+	mockBasketRepo := ts.Mocks.Baskets.(*mocks.BasketRepositoryMock)
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, userID).Return(&models.Basket{ID: 10, UserID: &userID, Status: models.BasketStatusActive}, nil)
+
+	variantID := func(id int) *int { return &id }
+
+	t.Run("variants are added at their own price", func(t *testing.T) {
+		var added *models.BasketItem
+		mockBasketRepo.On("AddItem", mock.Anything, 10, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { added = args.Get(2).(*models.BasketItem) }).
+			Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/items", token, handlers.AddItemRequest{ProductID: 5, VariantID: variantID(8), Quantity: 1})
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, added)
+		assert.Equal(t, 8, *added.VariantID)
+		assert.Equal(t, money.New(1800, "USD"), added.UnitPrice)
+		assert.Equal(t, money.New(200, "USD"), added.UnitDiscount)
+	})
+
+	t.Run("products with variants need one chosen", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/items", token, handlers.AddItemRequest{ProductID: 5, Quantity: 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Choose a variant")
+	})
+
+	t.Run("variants of other products are not found", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/items", token, handlers.AddItemRequest{ProductID: 5, VariantID: variantID(99), Quantity: 1})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	mockBasketRepo.AssertExpectations(t)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"testing"
 
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
 	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
 	"github.com/stretchr/testify/assert"
@@ -51,6 +52,24 @@ func TestCategoryManagement(t *testing.T) {
 		assert.Equal(t, category.Description, createdCategory.Description)
 	})
 
+	t.Run("create category under a missing parent", func(t *testing.T) {
+		parentID := 99
+		mockCategoryRepo.On("Insert", mock.Anything, mock.MatchedBy(func(c *models.Category) bool {
+			return c.Name == "Gadgets" && *c.ParentID == parentID
+		})).Return(nil, appErrors.Newf(appErrors.ErrNotFound, "category with ID %d not found", parentID)).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/categories", token, map[string]any{"name": "Gadgets", "parentId": parentID})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("create category with a taken name", func(t *testing.T) {
+		mockCategoryRepo.On("Insert", mock.Anything, mock.MatchedBy(func(c *models.Category) bool { return c.Name == "Technology" })).
+			Return(nil, appErrors.New(appErrors.ErrConflict, "a category with this name or slug already exists")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/categories", token, map[string]any{"name": "Technology"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
 	t.Run("get all categories", func(t *testing.T) {
 		categories := []*models.Category{
 			{Name: "Sports", Description: "Sports events"},
@@ -184,3 +203,128 @@ func TestCategoryEdgeCases(t *testing.T) {
 		assert.Equal(t, longName, createdCategory.Name)
 	})
 }
+
+// TestCategoryHierarchy tests updating, moving and deleting categories and the category tree
+func TestCategoryHierarchy(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	adminID, userID := 1, 2
+	adminToken, _ := ts.GenerateToken(adminID)
+	userToken, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "user@example.com"}, nil)
+
+	mockCategoryRepo := ts.Mocks.Categories.(*mocks.CategoryRepositoryMock)
+	electronicsID, phonesID := 1, 2
+
+	t.Run("tree nests categories", func(t *testing.T) {
+		mockCategoryRepo.On("Tree", mock.Anything).Return([]models.Category{
+			{ID: electronicsID, Name: "Electronics", Children: []models.Category{
+				{ID: phonesID, Name: "Phones", ParentID: &electronicsID},
+			}},
+		}, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/categories/tree", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var tree []models.Category
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tree))
+		if assert.Len(t, tree, 1) && assert.Len(t, tree[0].Children, 1) {
+			assert.Equal(t, "Phones", tree[0].Children[0].Name)
+		}
+	})
+
+	t.Run("category is updated", func(t *testing.T) {
+		mockCategoryRepo.On("Get", mock.Anything, phonesID).
+			Return(&models.Category{ID: phonesID, Name: "Phones", Slug: "phones", ParentID: &electronicsID, Status: "active"}, nil).Once()
+		mockCategoryRepo.On("Update", mock.Anything, mock.MatchedBy(func(c *models.Category) bool {
+			return c.ID == phonesID && c.Name == "Smartphones" && c.Slug == "smartphones" &&
+				c.Status == "active" && *c.ParentID == electronicsID
+		})).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", "/api/v1/categories/2", adminToken, map[string]any{
+			"name": "Smartphones", "description": "Phones and accessories", "parentId": 7,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("only admins manage categories", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("PUT", "/api/v1/categories/2", userToken, map[string]any{"name": "Smartphones"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = ts.createAuthenticatedRequest("DELETE", "/api/v1/categories/2", userToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = ts.createAuthenticatedRequest("POST", "/api/v1/categories/2/move", userToken, map[string]any{"parentId": nil})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("category is moved", func(t *testing.T) {
+		mockCategoryRepo.On("Move", mock.Anything, phonesID, (*int)(nil)).
+			Return(&models.Category{ID: phonesID, Name: "Phones"}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/categories/2/move", adminToken, map[string]any{"parentId": nil})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("move into own subtree is rejected", func(t *testing.T) {
+		mockCategoryRepo.On("Move", mock.Anything, electronicsID, &phonesID).
+			Return(nil, appErrors.New(appErrors.ErrInvalidInput, "a category cannot move under itself or its subcategories")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/categories/1/move", adminToken, map[string]any{"parentId": phonesID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("delete reassigns to the parent by default", func(t *testing.T) {
+		mockCategoryRepo.On("Delete", mock.Anything, phonesID, models.CategoryDeleteReassign, (*int)(nil)).Return(0, nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/categories/2", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"uncategorizedProducts": 0}`, w.Body.String())
+	})
+
+	t.Run("deleting a top-level category reports uncategorized products", func(t *testing.T) {
+		mockCategoryRepo.On("Delete", mock.Anything, electronicsID, models.CategoryDeleteReassign, (*int)(nil)).Return(2, nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/categories/1", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"uncategorizedProducts": 2}`, w.Body.String())
+	})
+
+	t.Run("delete reassigns to a target", func(t *testing.T) {
+		targetID := 5
+		mockCategoryRepo.On("Delete", mock.Anything, phonesID, models.CategoryDeleteReassign, &targetID).Return(0, nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/categories/2?target_id=5", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("delete cascades and reports uncategorized products", func(t *testing.T) {
+		mockCategoryRepo.On("Delete", mock.Anything, electronicsID, models.CategoryDeleteCascade, (*int)(nil)).Return(3, nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/categories/1?strategy=cascade", adminToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"uncategorizedProducts": 3}`, w.Body.String())
+	})
+
+	t.Run("invalid delete options are rejected", func(t *testing.T) {
+		for _, path := range []string{
+			"/api/v1/categories/1?strategy=orphan",
+			"/api/v1/categories/1?strategy=cascade&target_id=5",
+			"/api/v1/categories/1?target_id=abc",
+		} {
+			w := ts.createAuthenticatedRequest("DELETE", path, adminToken, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		}
+	})
+
+	t.Run("deleting a missing category", func(t *testing.T) {
+		mockCategoryRepo.On("Delete", mock.Anything, 99, models.CategoryDeleteReassign, (*int)(nil)).
+			Return(0, appErrors.New(appErrors.ErrNotFound, "category with ID 99 not found")).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/categories/99", adminToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
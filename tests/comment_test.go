@@ -5,8 +5,11 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/moderation"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
 	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -63,17 +66,20 @@ func TestCommentManagement(t *testing.T) {
 			{ID: 2, Content: "Second comment", UserID: user2ID, EventID: eventID},
 		}
 
-		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
-		mockCommentRepo.On("GetByEvent", mock.Anything, eventID).Return(comments, nil).Once()
+		mockCommentRepo.On("ListTopLevelByEvent", mock.Anything, eventID, mock.MatchedBy(func(p *query.QueryParams) bool {
+			return p.Type == query.CursorPagination
+		})).Return(comments, &query.PaginatedList{Success: true, Data: comments}, nil).Once()
 
-		// Get all comments for the event
+		// Get top-level comments for the event
 		w := ts.createRequest("GET", "/api/v1/events/"+strconv.Itoa(eventID)+"/comments", nil)
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var respComments []models.Comment
-		err := json.Unmarshal(w.Body.Bytes(), &respComments)
+		var resp struct {
+			Data []models.Comment `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
 		assert.NoError(t, err)
-		assert.Equal(t, len(comments), len(respComments))
+		assert.Equal(t, len(comments), len(resp.Data))
 	})
 
 	t.Run("delete own comment", func(t *testing.T) {
@@ -149,3 +155,305 @@ func TestCommentAuthorization(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
+
+// TestCommentThreads tests threaded replies
+func TestCommentThreads(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "threaduser@example.com"}, nil)
+
+	eventID := 1
+	event := &models.Event{ID: eventID, Name: "Thread Event", Description: "Event with threads", Date: "2025-12-31", Location: "Thread Location"}
+
+	mockEventRepo := ts.Mocks.Events.(*mocks.EventRepositoryMock)
+	mockCommentRepo := ts.Mocks.Comments.(*mocks.CommentRepositoryMock)
+	commentsURL := "/api/v1/events/" + strconv.Itoa(eventID) + "/comments"
+
+	t.Run("reply gets parent depth plus one", func(t *testing.T) {
+		parentID := 10
+		parent := &models.Comment{ID: parentID, EventID: eventID, UserID: userID, Depth: 1, Content: "Parent"}
+
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("Get", mock.Anything, parentID).Return(parent, nil).Once()
+		mockCommentRepo.On("Insert", mock.Anything, mock.MatchedBy(func(c *models.Comment) bool {
+			return c.ParentID != nil && *c.ParentID == parentID && c.Depth == 2
+		})).Return(&models.Comment{ID: 11, ParentID: &parentID, Depth: 2, Content: "Reply", EventID: eventID, UserID: userID}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL, token, map[string]interface{}{"content": "Reply", "parentId": parentID})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("reply beyond max depth is rejected", func(t *testing.T) {
+		parentID := 20
+		parent := &models.Comment{ID: parentID, EventID: eventID, UserID: userID, Depth: ts.Handler.CommentMaxDepth, Content: "Deep"}
+
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("Get", mock.Anything, parentID).Return(parent, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL, token, map[string]interface{}{"content": "Too deep", "parentId": parentID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("reply to comment on another event is rejected", func(t *testing.T) {
+		parentID := 30
+		parent := &models.Comment{ID: parentID, EventID: eventID + 1, UserID: userID, Content: "Elsewhere"}
+
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("Get", mock.Anything, parentID).Return(parent, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL, token, map[string]interface{}{"content": "Wrong event", "parentId": parentID})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("get thread returns nested replies", func(t *testing.T) {
+		rootID := 40
+		replyParent := rootID
+		thread := &models.Comment{
+			ID: rootID, EventID: eventID, Content: models.DeletedCommentContent, IsDeleted: true, ReplyCount: 1,
+			Replies: []*models.Comment{{ID: 41, EventID: eventID, ParentID: &replyParent, Depth: 1, Content: "Reply"}},
+		}
+
+		mockCommentRepo.On("GetThread", mock.Anything, rootID).Return(thread, nil).Once()
+
+		w := ts.createRequest("GET", commentsURL+"/"+strconv.Itoa(rootID)+"/thread", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Comment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.IsDeleted)
+		assert.Equal(t, models.DeletedCommentContent, resp.Content)
+		assert.Len(t, resp.Replies, 1)
+		assert.Equal(t, 41, resp.Replies[0].ID)
+	})
+
+	t.Run("deleted comment cannot be deleted again", func(t *testing.T) {
+		commentID := 50
+		tombstone := &models.Comment{ID: commentID, EventID: eventID, UserID: userID, IsDeleted: true, Content: models.DeletedCommentContent}
+
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(tombstone, nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", commentsURL+"/"+strconv.Itoa(commentID), token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestCommentEditing tests comment edits and revision history
+func TestCommentEditing(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	authorID := 1
+	ownerID := 2
+	authorToken, _ := ts.GenerateToken(authorID)
+	ownerToken, _ := ts.GenerateToken(ownerID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, authorID).Return(&models.User{ID: authorID, Email: "author@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, ownerID).Return(&models.User{ID: ownerID, Email: "owner@example.com"}, nil)
+
+	eventID := 1
+	event := &models.Event{ID: eventID, OwnerID: ownerID, Name: "Edit Event", Description: "Event for editing", Date: "2025-12-31", Location: "Edit Location"}
+
+	mockEventRepo := ts.Mocks.Events.(*mocks.EventRepositoryMock)
+	mockCommentRepo := ts.Mocks.Comments.(*mocks.CommentRepositoryMock)
+	commentURL := func(id int) string {
+		return "/api/v1/events/" + strconv.Itoa(eventID) + "/comments/" + strconv.Itoa(id)
+	}
+
+	t.Run("author edits within window", func(t *testing.T) {
+		commentID := 1
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Original", CreatedAt: time.Now()}
+		editedAt := time.Now()
+
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+		mockCommentRepo.On("Update", mock.Anything, commentID, "Edited", models.CommentStatusApproved, mock.Anything, authorID).
+			Return(&models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Edited", IsEdited: true, EditedAt: &editedAt}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", commentURL(commentID), authorToken, map[string]string{"content": "Edited"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Comment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.IsEdited)
+		assert.Equal(t, "Edited", resp.Content)
+	})
+
+	t.Run("edit after window is forbidden", func(t *testing.T) {
+		commentID := 2
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Old", CreatedAt: time.Now().Add(-ts.Handler.CommentEditWindow - time.Minute)}
+
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", commentURL(commentID), authorToken, map[string]string{"content": "Too late"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("only author can edit", func(t *testing.T) {
+		commentID := 3
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Mine", CreatedAt: time.Now()}
+
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", commentURL(commentID), ownerToken, map[string]string{"content": "Hijacked"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("event owner lists revisions", func(t *testing.T) {
+		commentID := 4
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Current", IsEdited: true}
+		revisions := []*models.CommentRevision{{ID: 1, CommentID: commentID, Content: "First", EditedBy: authorID}}
+
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+		mockCommentRepo.On("GetRevisions", mock.Anything, commentID).Return(revisions, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", commentURL(commentID)+"/revisions", ownerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp []models.CommentRevision
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+		assert.Equal(t, "First", resp[0].Content)
+	})
+
+	t.Run("non-owner cannot list revisions", func(t *testing.T) {
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", commentURL(4)+"/revisions", authorToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+// TestCommentModeration tests word filters, reports and the moderation queue
+func TestCommentModeration(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	authorID := 1
+	ownerID := 2
+	reporterID := 3
+	adminID := 4
+	authorToken, _ := ts.GenerateToken(authorID)
+	ownerToken, _ := ts.GenerateToken(ownerID)
+	reporterToken, _ := ts.GenerateToken(reporterID)
+	adminToken, _ := ts.GenerateToken(adminID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, authorID).Return(&models.User{ID: authorID, Email: "author@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, ownerID).Return(&models.User{ID: ownerID, Email: "owner@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, reporterID).Return(&models.User{ID: reporterID, Email: "reporter@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+
+	eventID := 1
+	event := &models.Event{ID: eventID, OwnerID: ownerID, Name: "Moderated Event", Description: "Event with moderation", Date: "2025-12-31", Location: "Moderation Location"}
+
+	mockEventRepo := ts.Mocks.Events.(*mocks.EventRepositoryMock)
+	mockCommentRepo := ts.Mocks.Comments.(*mocks.CommentRepositoryMock)
+	mockReportRepo := ts.Mocks.CommentReports.(*mocks.CommentReportRepositoryMock)
+	commentsURL := "/api/v1/events/" + strconv.Itoa(eventID) + "/comments"
+
+	filter, err := moderation.NewFilter([]string{"spam"}, nil)
+	assert.NoError(t, err)
+	ts.Handler.CommentFilter = filter
+
+	t.Run("filtered comment is held for review", func(t *testing.T) {
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("Insert", mock.Anything, mock.MatchedBy(func(c *models.Comment) bool {
+			return c.Status == models.CommentStatusPending
+		})).Return(&models.Comment{ID: 1, EventID: eventID, UserID: authorID, Content: "Buy SPAM now", Status: models.CommentStatusPending}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL, authorToken, models.Comment{Content: "Buy SPAM now"})
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("comment rate limit", func(t *testing.T) {
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil)
+		mockCommentRepo.On("Insert", mock.Anything, mock.Anything).Return(&models.Comment{ID: 2, EventID: eventID, UserID: authorID, Content: "Hello", Status: models.CommentStatusApproved}, nil)
+
+		// The filtered comment above already used one of the author's slots
+		for i := 1; i < ts.Handler.CommentRateLimit; i++ {
+			w := ts.createAuthenticatedRequest("POST", commentsURL, authorToken, models.Comment{Content: "Hello"})
+			assert.Equal(t, http.StatusCreated, w.Code)
+		}
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL, authorToken, models.Comment{Content: "Hello"})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		// Other users have their own limit
+		w = ts.createAuthenticatedRequest("POST", commentsURL, reporterToken, models.Comment{Content: "Hello"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("report threshold holds comment for review", func(t *testing.T) {
+		commentID := 10
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Rude", Status: models.CommentStatusApproved}
+
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+		mockReportRepo.On("Insert", mock.Anything, mock.MatchedBy(func(r *models.CommentReport) bool {
+			return r.CommentID == commentID && r.ReporterID == reporterID
+		})).Return(&models.CommentReport{ID: 1, CommentID: commentID, ReporterID: reporterID, Reason: "Rude", Status: models.ReportStatusOpen}, nil).Once()
+		mockReportRepo.On("CountOpenByComment", mock.Anything, commentID).Return(int64(ts.Handler.CommentReportThreshold), nil).Once()
+		mockCommentRepo.On("SetStatus", mock.Anything, commentID, models.CommentStatusPending).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL+"/"+strconv.Itoa(commentID)+"/report", reporterToken, map[string]string{"reason": "Rude"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockCommentRepo.AssertCalled(t, "SetStatus", mock.Anything, commentID, models.CommentStatusPending)
+	})
+
+	t.Run("cannot report own comment", func(t *testing.T) {
+		commentID := 11
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: reporterID, Content: "Mine", Status: models.CommentStatusApproved}
+
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL+"/"+strconv.Itoa(commentID)+"/report", reporterToken, map[string]string{"reason": "Oops"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("event owner views moderation queue", func(t *testing.T) {
+		pending := []*models.Comment{{ID: 1, EventID: eventID, UserID: authorID, Content: "Buy SPAM now", Status: models.CommentStatusPending}}
+
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("ListForModeration", mock.Anything, eventID, "reported", mock.Anything).
+			Return(pending, &query.PaginatedList{Success: true, Data: pending}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", commentsURL+"/moderation?status=reported", ownerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid queue status", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("GET", commentsURL+"/moderation?status=approved", ownerToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("other users cannot moderate", func(t *testing.T) {
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", commentsURL+"/moderation", reporterToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("admin approves comment", func(t *testing.T) {
+		commentID := 12
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Fine", Status: models.CommentStatusPending}
+
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+		mockCommentRepo.On("SetStatus", mock.Anything, commentID, models.CommentStatusApproved).Return(nil).Once()
+		mockReportRepo.On("ResolveByComment", mock.Anything, commentID, adminID).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", commentsURL+"/"+strconv.Itoa(commentID)+"/moderate", adminToken, map[string]string{"action": "approve"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Comment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, models.CommentStatusApproved, resp.Status)
+	})
+
+	t.Run("invalid moderation action", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", commentsURL+"/12/moderate", ownerToken, map[string]string{"action": "delete"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBasketCoupons tests applying and removing coupon codes on the basket
+func TestBasketCoupons(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	buyerID := 1
+	couponHolderID := 2
+	staleID := 3
+	buyerToken, _ := ts.GenerateToken(buyerID)
+	holderToken, _ := ts.GenerateToken(couponHolderID)
+	staleToken, _ := ts.GenerateToken(staleID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, buyerID).Return(&models.User{ID: buyerID, Email: "buyer@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, couponHolderID).Return(&models.User{ID: couponHolderID, Email: "holder@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, staleID).Return(&models.User{ID: staleID, Email: "stale@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, mock.Anything).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	save10 := &models.Coupon{ID: 1, Code: "SAVE10", Type: models.CouponTypePercentage, PercentOff: 10, Active: true}
+	bigSpender := &models.Coupon{ID: 2, Code: "BIG", Type: models.CouponTypeFixed, AmountOff: money.New(1000, "USD"),
+		MinSpend: money.New(10000, "USD"), Currency: "USD", Active: true}
+	oncePerUser := &models.Coupon{ID: 3, Code: "ONCE", Type: models.CouponTypePercentage, PercentOff: 5, MaxPerUser: 1, Active: true}
+	expired := time.Now().Add(-time.Hour)
+	ended := &models.Coupon{ID: 4, Code: "ENDED", Type: models.CouponTypePercentage, PercentOff: 5, EndsAt: &expired, Active: true}
+
+	mockCouponRepo := ts.Mocks.Coupons.(*mocks.CouponRepositoryMock)
+	mockCouponRepo.On("GetByCode", mock.Anything, "save10").Return(save10, nil)
+	mockCouponRepo.On("GetByCode", mock.Anything, "BIG").Return(bigSpender, nil)
+	mockCouponRepo.On("GetByCode", mock.Anything, "ONCE").Return(oncePerUser, nil)
+	mockCouponRepo.On("GetByCode", mock.Anything, "ENDED").Return(ended, nil)
+	mockCouponRepo.On("GetByCode", mock.Anything, "NOPE").Return(nil, appErrors.New(appErrors.ErrNotFound, "coupon NOPE not found"))
+	mockCouponRepo.On("CountUserRedemptions", mock.Anything, oncePerUser.ID, buyerID).Return(int64(1), nil)
+	mockCouponRepo.On("CountUserRedemptions", mock.Anything, mock.Anything, mock.Anything).Return(int64(0), nil)
+
+	mockBasketRepo := ts.Mocks.Baskets.(*mocks.BasketRepositoryMock)
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, buyerID).Return(&models.Basket{ID: 10, UserID: &buyerID, Status: models.BasketStatusActive, Items: []models.BasketItem{
+		{ID: 1, ProductID: 3, Quantity: 2, UnitPrice: money.New(1500, "USD"), Currency: "USD"},
+	}}, nil)
+	holderCouponID := save10.ID
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, couponHolderID).Return(&models.Basket{ID: 20, UserID: &couponHolderID, Status: models.BasketStatusActive,
+		CouponID: &holderCouponID, Coupon: save10, Items: []models.BasketItem{
+			{ID: 2, ProductID: 3, Quantity: 1, UnitPrice: money.New(1500, "USD"), Currency: "USD"},
+		}}, nil)
+
+	endedID := ended.ID
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, staleID).Return(&models.Basket{ID: 30, UserID: &staleID, Status: models.BasketStatusActive,
+		CouponID: &endedID, Coupon: ended, Items: []models.BasketItem{
+			{ID: 3, ProductID: 3, Quantity: 1, UnitPrice: money.New(1500, "USD"), Currency: "USD"},
+		}}, nil)
+
+	t.Run("unknown codes are not found", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/coupon", buyerToken, handlers.ApplyCouponRequest{Code: "NOPE"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("minimum spend is enforced", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/coupon", buyerToken, handlers.ApplyCouponRequest{Code: "BIG"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "minimum spend")
+	})
+
+	t.Run("per-user limits are enforced", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/coupon", buyerToken, handlers.ApplyCouponRequest{Code: "ONCE"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "already used")
+	})
+
+	t.Run("expired coupons are rejected", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/coupon", buyerToken, handlers.ApplyCouponRequest{Code: "ENDED"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("applying a coupon discounts the basket", func(t *testing.T) {
+		mockBasketRepo.On("SetCoupon", mock.Anything, 10, &save10.ID).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/coupon", buyerToken, handlers.ApplyCouponRequest{Code: "save10"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Totals)
+		assert.Equal(t, "SAVE10", resp.Totals.CouponCode)
+		assert.Equal(t, money.New(300, "USD"), resp.Totals.CouponDiscount)
+		assert.Equal(t, money.New(2700, "USD"), resp.Totals.Total)
+		assert.Empty(t, resp.CouponError)
+	})
+
+	t.Run("removing the coupon restores the full price", func(t *testing.T) {
+		mockBasketRepo.On("SetCoupon", mock.Anything, 20, (*int)(nil)).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/basket/coupon", holderToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Totals)
+		assert.Empty(t, resp.Totals.CouponCode)
+		assert.Equal(t, money.New(1500, "USD"), resp.Totals.Total)
+	})
+
+	t.Run("coupons that stop applying are reported on the basket", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/basket", staleToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp handlers.BasketResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Totals)
+		assert.Contains(t, resp.CouponError, "not active")
+		assert.Empty(t, resp.Totals.CouponCode)
+		assert.Equal(t, money.New(1500, "USD"), resp.Totals.Total)
+	})
+
+	mockBasketRepo.AssertExpectations(t)
+}
+
+// TestCouponAdmin tests coupon management
+func TestCouponAdmin(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	adminID := 1
+	userID := 2
+	adminToken, _ := ts.GenerateToken(adminID)
+	userToken, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "user@example.com"}, nil)
+
+	mockCouponRepo := ts.Mocks.Coupons.(*mocks.CouponRepositoryMock)
+
+	amountOff := money.New(500, "EUR")
+	minSpend := money.New(2500, "EUR")
+
+	t.Run("admins create coupons", func(t *testing.T) {
+		var created *models.Coupon
+		mockCouponRepo.On("Insert", mock.Anything, mock.Anything, []int{3}, []int(nil)).
+			Run(func(args mock.Arguments) {
+				created = args.Get(1).(*models.Coupon)
+				created.ID = 7
+			}).
+			Return(&models.Coupon{ID: 7}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/coupons", adminToken, handlers.CreateCouponRequest{
+			Code:       "spring-5",
+			Type:       models.CouponTypeFixed,
+			AmountOff:  &amountOff,
+			MinSpend:   &minSpend,
+			MaxPerUser: 1,
+			ProductIDs: []int{3},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		require.NotNil(t, created)
+		assert.Equal(t, "SPRING-5", created.Code)
+		assert.Equal(t, "EUR", created.Currency)
+		assert.Equal(t, amountOff, created.AmountOff)
+		assert.True(t, created.Active)
+	})
+
+	t.Run("coupons are validated", func(t *testing.T) {
+		usd := money.New(100, "USD")
+		invalid := []handlers.CreateCouponRequest{
+			{Code: "x", Type: models.CouponTypePercentage, PercentOff: 10},
+			{Code: "NOPERCENT", Type: models.CouponTypePercentage},
+			{Code: "BOTH", Type: models.CouponTypePercentage, PercentOff: 10, AmountOff: &amountOff},
+			{Code: "NOAMOUNT", Type: models.CouponTypeFixed},
+			{Code: "MIXED", Type: models.CouponTypeFixed, AmountOff: &amountOff, MinSpend: &usd},
+		}
+		for _, req := range invalid {
+			w := ts.createAuthenticatedRequest("POST", "/api/v1/coupons", adminToken, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, req.Code)
+		}
+	})
+
+	t.Run("only admins manage coupons", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/coupons", userToken, handlers.CreateCouponRequest{
+			Code: "MINE", Type: models.CouponTypePercentage, PercentOff: 50,
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = ts.createAuthenticatedRequest("DELETE", "/api/v1/coupons/7", userToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("admins deactivate coupons", func(t *testing.T) {
+		mockCouponRepo.On("Deactivate", mock.Anything, 7).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/coupons/7", adminToken, nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	mockCouponRepo.AssertExpectations(t)
+}
@@ -0,0 +1,363 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/imaging"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+// pngFile is enough of a PNG image for its content type to be sniffed
+var pngFile = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01")
+
+// uploadFile posts a file as the file field of a multipart form
+func uploadFile(ts *TestSuite, token, method, path, filename string, content []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", filename)
+	_, _ = part.Write(content)
+	_ = form.Close()
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	ts.Router.ServeHTTP(w, req)
+	return w
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// TestMediaUpload tests uploading files and downloading them through signed URLs
+func TestMediaUpload(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "media@example.com"}, nil)
+
+	mockMediaRepo := ts.Mocks.Media.(*mocks.MediaRepositoryMock)
+	hash := contentHash(pngFile)
+	key := "media/" + hash[:2] + "/" + hash + ".png"
+
+	var stored *models.Media
+	t.Run("stores a new file", func(t *testing.T) {
+		mockMediaRepo.On("GetByHash", mock.Anything, hash).Return(nil, nil).Once()
+		mockMediaRepo.On("Insert", mock.Anything, mock.MatchedBy(func(m *models.Media) bool {
+			return m.UserID == userID && m.Key == key && m.ContentType == "image/png" && m.Size == int64(len(pngFile)) && m.Filename == "photo.png"
+		})).Return(&models.Media{ID: 7, UserID: userID, Key: key, Hash: hash, ContentType: "image/png", Size: int64(len(pngFile)), URL: models.MediaURL(7)}, nil).Once()
+
+		w := uploadFile(ts, token, "POST", "/api/v1/media", "photo.png", pngFile)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var media models.Media
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &media))
+		assert.Equal(t, "/api/v1/media/7", media.URL)
+		assert.Equal(t, hash, media.Hash)
+		assert.NotContains(t, w.Body.String(), "media/"+hash[:2])
+		stored = &models.Media{ID: 7, UserID: userID, Key: key, Hash: hash, ContentType: "image/png", URL: media.URL}
+	})
+
+	t.Run("returns the stored file for the same content", func(t *testing.T) {
+		mockMediaRepo.On("GetByHash", mock.Anything, hash).Return(stored, nil).Once()
+
+		w := uploadFile(ts, token, "POST", "/api/v1/media", "copy.png", pngFile)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"url":"/api/v1/media/7"`)
+	})
+
+	t.Run("sniffs the content type", func(t *testing.T) {
+		w := uploadFile(ts, token, "POST", "/api/v1/media", "script.png", []byte("<html><script>alert(1)</script></html>"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("rejects files over the size limit", func(t *testing.T) {
+		ts.Handler.MediaMaxSize = 16
+		defer func() { ts.Handler.MediaMaxSize = 10 << 20 }()
+
+		w := uploadFile(ts, token, "POST", "/api/v1/media", "photo.png", pngFile)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("requires a file", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/media", token, map[string]string{"file": "photo.png"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("serves the file through a signed URL", func(t *testing.T) {
+		mockMediaRepo.On("Get", mock.Anything, 7).Return(stored, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/media/7", nil)
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+		location := w.Header().Get("Location")
+		assert.True(t, strings.HasPrefix(location, "/api/v1/media/files/"+key+"?"), location)
+
+		w = ts.createRequest("GET", location, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Equal(t, pngFile, w.Body.Bytes())
+
+		w = ts.createRequest("GET", strings.Replace(location, "signature=", "signature=0", 1), nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		w = ts.createRequest("GET", "/api/v1/media/files/"+key, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("returns not found for unknown files", func(t *testing.T) {
+		mockMediaRepo.On("Get", mock.Anything, 8).Return(nil, appErrors.New(appErrors.ErrNotFound, "media with ID 8 not found")).Once()
+
+		w := ts.createRequest("GET", "/api/v1/media/8", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestMediaLinks tests setting product images, category images and avatars to stored files
+func TestMediaLinks(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID, adminID := 1, 2
+	token, _ := ts.GenerateToken(userID)
+	adminToken, _ := ts.GenerateToken(adminID)
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "media@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+
+	mockMediaRepo := ts.Mocks.Media.(*mocks.MediaRepositoryMock)
+	hash := contentHash(pngFile)
+	stored := &models.Media{ID: 7, UserID: userID, Key: "media/" + hash[:2] + "/" + hash + ".png", Hash: hash, ContentType: "image/png", URL: models.MediaURL(7)}
+	mockMediaRepo.On("GetByHash", mock.Anything, hash).Return(stored, nil)
+	mockMediaRepo.On("Get", mock.Anything, 7).Return(stored, nil)
+	mockMediaRepo.On("Get", mock.Anything, 9).Return(nil, appErrors.New(appErrors.ErrNotFound, "media with ID 9 not found"))
+
+	t.Run("adds an uploaded image to a product", func(t *testing.T) {
+		mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+		mockProductRepo.On("Get", mock.Anything, 3).Return(&models.Product{ID: 3, UserID: userID, Name: "Lamp"}, nil).Once()
+		mockProductRepo.On("Update", mock.Anything, mock.MatchedBy(func(p *models.Product) bool {
+			return p.Image == "/api/v1/media/7" && len(p.Images) == 1 && p.Images[0] == "/api/v1/media/7"
+		})).Return(nil).Once()
+
+		w := uploadFile(ts, token, "POST", "/api/v1/products/3/images", "lamp.png", pngFile)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockProductRepo.AssertExpectations(t)
+	})
+
+	t.Run("only the owner uploads product images", func(t *testing.T) {
+		mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+		mockProductRepo.On("Get", mock.Anything, 4).Return(&models.Product{ID: 4, UserID: adminID, Name: "Desk"}, nil).Once()
+
+		w := uploadFile(ts, token, "POST", "/api/v1/products/4/images", "desk.png", pngFile)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("rejects links to files that are not stored", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", token, map[string]interface{}{
+			"name": "Lamp", "sku": "LAMP-1", "price": map[string]interface{}{"amount": 1000}, "stock": 1, "image": "/api/v1/media/9",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "No stored file with ID 9")
+
+		w = ts.createAuthenticatedRequest("POST", "/api/v1/categories", token, map[string]interface{}{
+			"name": "Lighting", "image": "/api/v1/media/9",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "No stored file with ID 9")
+	})
+
+	t.Run("sets a category image as an admin", func(t *testing.T) {
+		mockCategoryRepo := ts.Mocks.Categories.(*mocks.CategoryRepositoryMock)
+		mockCategoryRepo.On("Get", mock.Anything, 5).Return(&models.Category{ID: 5, Name: "Lighting"}, nil).Once()
+		mockCategoryRepo.On("Update", mock.Anything, mock.MatchedBy(func(c *models.Category) bool {
+			return c.ID == 5 && c.Image == "/api/v1/media/7"
+		})).Return(nil).Once()
+
+		w := uploadFile(ts, token, "PUT", "/api/v1/categories/5/image", "lighting.png", pngFile)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = uploadFile(ts, adminToken, "PUT", "/api/v1/categories/5/image", "lighting.png", pngFile)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		mockCategoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("sets the avatar", func(t *testing.T) {
+		mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+		mockProfileRepo.On("GetByUserID", mock.Anything, userID).Return(&models.Profile{ID: 1, UserID: userID}, nil).Once()
+		mockProfileRepo.On("UpdateByUserID", mock.Anything, userID, map[string]interface{}{"avatar_url": "/api/v1/media/7"}).Return(nil).Once()
+
+		w := uploadFile(ts, token, "PUT", "/api/v1/profile/avatar", "me.png", pngFile)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"avatarUrl":"/api/v1/media/7"`)
+		mockProfileRepo.AssertExpectations(t)
+	})
+}
+
+// TestMediaVariants tests making resized variants of uploaded images
+func TestMediaVariants(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+	models.SetImageVariants([]string{"thumbnail", "medium", "large"})
+	defer models.SetImageVariants(nil)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "media@example.com"}, nil)
+	mockMediaRepo := ts.Mocks.Media.(*mocks.MediaRepositoryMock)
+
+	// A 300x200 opaque photo
+	photo := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for i := range photo.Pix {
+		photo.Pix[i] = uint8(i)
+		if i%4 == 3 {
+			photo.Pix[i] = 0xff
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, photo))
+	content := encoded.Bytes()
+	hash := contentHash(content)
+	stored := &models.Media{ID: 7, UserID: userID, Key: "media/" + hash[:2] + "/" + hash + ".png", Hash: hash, ContentType: "image/png", Size: int64(len(content)), URL: models.MediaURL(7)}
+
+	// saved receives each variant the background job records
+	saved := make(chan models.MediaVariant, 3)
+	mockMediaRepo.On("SaveVariant", mock.Anything, mock.AnythingOfType("*models.MediaVariant")).
+		Run(func(args mock.Arguments) { saved <- *args.Get(1).(*models.MediaVariant) }).
+		Return(nil).Times(3)
+
+	variants := map[string]models.MediaVariant{}
+	t.Run("makes the configured sizes after upload", func(t *testing.T) {
+		mockMediaRepo.On("GetByHash", mock.Anything, hash).Return(nil, nil).Once()
+		mockMediaRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Media")).Return(stored, nil).Once()
+
+		w := uploadFile(ts, token, "POST", "/api/v1/media", "photo.png", content)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		for range 3 {
+			select {
+			case variant := <-saved:
+				variants[variant.Name] = variant
+			case <-time.After(5 * time.Second):
+				t.Fatal("the variants were not made")
+			}
+		}
+		thumbnail := variants["thumbnail"]
+		assert.Equal(t, 7, thumbnail.MediaID)
+		assert.Equal(t, 150, thumbnail.MaxSize)
+		assert.Equal(t, [2]int{150, 100}, [2]int{thumbnail.Width, thumbnail.Height})
+		assert.Equal(t, "image/jpeg", thumbnail.ContentType)
+		// Images are not enlarged
+		assert.Equal(t, [2]int{300, 200}, [2]int{variants["large"].Width, variants["large"].Height})
+	})
+
+	t.Run("redirects to a variant", func(t *testing.T) {
+		thumbnail := variants["thumbnail"]
+		mockMediaRepo.On("Get", mock.Anything, 7).Return(stored, nil).Once()
+		mockMediaRepo.On("GetVariant", mock.Anything, 7, "thumbnail").Return(&thumbnail, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/media/7/thumbnail", nil)
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+		w = ts.createRequest("GET", w.Header().Get("Location"), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+		decoded, err := jpeg.Decode(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 150, 100), decoded.Bounds())
+	})
+
+	t.Run("makes sizes configured since on demand", func(t *testing.T) {
+		ts.Handler.ImageSizes = append(ts.Handler.ImageSizes, imaging.Size{Name: "tiny", Max: 30})
+		ts.Handler.ImageFormat = imaging.FormatWebP
+		mockMediaRepo.On("Get", mock.Anything, 7).Return(stored, nil).Once()
+		// Looked up by the request, and again before the variant is made
+		mockMediaRepo.On("GetVariant", mock.Anything, 7, "tiny").Return(nil, nil).Twice()
+		mockMediaRepo.On("SaveVariant", mock.Anything, mock.MatchedBy(func(v *models.MediaVariant) bool {
+			return v.Name == "tiny" && v.Width == 30 && v.Height == 20 && v.ContentType == "image/webp"
+		})).Return(nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/media/7/tiny", nil)
+		require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+		w = ts.createRequest("GET", w.Header().Get("Location"), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+		decoded, err := webp.Decode(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 30, 20), decoded.Bounds())
+	})
+
+	t.Run("makes a variant once for concurrent requests", func(t *testing.T) {
+		const requests = 5
+		ts.Handler.ImageSizes = append(ts.Handler.ImageSizes, imaging.Size{Name: "small", Max: 60})
+		mockMediaRepo.On("Get", mock.Anything, 7).Return(stored, nil).Times(requests)
+
+		// Every request finds the variant missing, as does the one making it.
+		// Requests that come too late to wait for it find it made.
+		var lookups atomic.Int32
+		lookedUp := make(chan struct{})
+		mockMediaRepo.On("GetVariant", mock.Anything, 7, "small").
+			Run(func(mock.Arguments) {
+				if lookups.Add(1) == requests+1 {
+					close(lookedUp)
+				}
+			}).Return(nil, nil).Times(requests + 1)
+		small := &models.MediaVariant{MediaID: 7, Name: "small", MaxSize: 60, Key: "variants/small.jpg", ContentType: "image/jpeg"}
+		mockMediaRepo.On("GetVariant", mock.Anything, 7, "small").Return(small, nil).Maybe()
+		mockMediaRepo.On("SaveVariant", mock.Anything, mock.MatchedBy(func(v *models.MediaVariant) bool { return v.Name == "small" })).
+			Run(func(mock.Arguments) { <-lookedUp }).Return(nil).Once()
+
+		var wg sync.WaitGroup
+		codes := make([]int, requests)
+		for i := range requests {
+			wg.Go(func() { codes[i] = ts.createRequest("GET", "/api/v1/media/7/small", nil).Code })
+		}
+		wg.Wait()
+		for _, code := range codes {
+			assert.Equal(t, http.StatusFound, code)
+		}
+	})
+
+	t.Run("returns not found for unknown sizes", func(t *testing.T) {
+		w := ts.createRequest("GET", "/api/v1/media/7/huge", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("links products and profiles to the variants", func(t *testing.T) {
+		profile := &models.Profile{UserID: userID, AvatarURL: "/api/v1/media/7"}
+		require.NoError(t, profile.AfterFind(nil))
+		assert.Equal(t, map[string]string{
+			"thumbnail": "/api/v1/media/7/thumbnail",
+			"medium":    "/api/v1/media/7/medium",
+			"large":     "/api/v1/media/7/large",
+		}, profile.AvatarSrcset)
+
+		product := &models.Product{Image: "https://cdn.example.com/lamp.png"}
+		require.NoError(t, product.AfterFind(nil))
+		assert.Nil(t, product.ImageSrcset)
+	})
+
+	mockMediaRepo.AssertExpectations(t)
+}
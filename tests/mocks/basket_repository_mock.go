@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
 	"github.com/stretchr/testify/mock"
@@ -19,23 +20,31 @@ func (m *BasketRepositoryMock) GetActiveBasket(ctx context.Context, userID int)
 	return args.Get(0).(*models.Basket), args.Error(1)
 }
 
+func (m *BasketRepositoryMock) GetGuestBasket(ctx context.Context, basketID int) (*models.Basket, error) {
+	args := m.Called(ctx, basketID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Basket), args.Error(1)
+}
+
 func (m *BasketRepositoryMock) CreateBasket(ctx context.Context, basket *models.Basket) error {
 	args := m.Called(ctx, basket)
 	return args.Error(0)
 }
 
-func (m *BasketRepositoryMock) AddItem(ctx context.Context, basketID int, item *models.BasketItem) error {
-	args := m.Called(ctx, basketID, item)
+func (m *BasketRepositoryMock) AddItem(ctx context.Context, basketID int, item *models.BasketItem, reserveUntil time.Time) error {
+	args := m.Called(ctx, basketID, item, reserveUntil)
 	return args.Error(0)
 }
 
-func (m *BasketRepositoryMock) UpdateItemQuantity(ctx context.Context, itemID int, quantity int) error {
-	args := m.Called(ctx, itemID, quantity)
+func (m *BasketRepositoryMock) UpdateItemQuantity(ctx context.Context, basketID, itemID int, quantity int, reserveUntil time.Time) error {
+	args := m.Called(ctx, basketID, itemID, quantity, reserveUntil)
 	return args.Error(0)
 }
 
-func (m *BasketRepositoryMock) RemoveItem(ctx context.Context, itemID int) error {
-	args := m.Called(ctx, itemID)
+func (m *BasketRepositoryMock) RemoveItem(ctx context.Context, basketID, itemID int) error {
+	args := m.Called(ctx, basketID, itemID)
 	return args.Error(0)
 }
 
@@ -43,3 +52,18 @@ func (m *BasketRepositoryMock) ClearBasket(ctx context.Context, basketID int) er
 	args := m.Called(ctx, basketID)
 	return args.Error(0)
 }
+
+func (m *BasketRepositoryMock) SetCoupon(ctx context.Context, basketID int, couponID *int) error {
+	args := m.Called(ctx, basketID, couponID)
+	return args.Error(0)
+}
+
+func (m *BasketRepositoryMock) MergeGuestBasket(ctx context.Context, guestBasketID, userID int, reserveUntil time.Time) error {
+	args := m.Called(ctx, guestBasketID, userID, reserveUntil)
+	return args.Error(0)
+}
+
+func (m *BasketRepositoryMock) DeleteStaleGuestBaskets(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -42,3 +42,29 @@ func (m *CategoryRepositoryMock) GetBySlug(ctx context.Context, slug string) (*m
 	}
 	return args.Get(0).(*models.Category), args.Error(1)
 }
+
+func (m *CategoryRepositoryMock) Update(ctx context.Context, category *models.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *CategoryRepositoryMock) Tree(ctx context.Context) ([]models.Category, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Category), args.Error(1)
+}
+
+func (m *CategoryRepositoryMock) Move(ctx context.Context, id int, parentID *int) (*models.Category, error) {
+	args := m.Called(ctx, id, parentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *CategoryRepositoryMock) Delete(ctx context.Context, id int, strategy string, targetID *int) (int, error) {
+	args := m.Called(ctx, id, strategy, targetID)
+	return args.Int(0), args.Error(1)
+}
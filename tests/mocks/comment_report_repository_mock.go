@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type CommentReportRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *CommentReportRepositoryMock) Insert(ctx context.Context, report *models.CommentReport) (*models.CommentReport, error) {
+	args := m.Called(ctx, report)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CommentReport), args.Error(1)
+}
+
+func (m *CommentReportRepositoryMock) CountOpenByComment(ctx context.Context, commentID int) (int64, error) {
+	args := m.Called(ctx, commentID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *CommentReportRepositoryMock) ResolveByComment(ctx context.Context, commentID, resolverID int) error {
+	args := m.Called(ctx, commentID, resolverID)
+	return args.Error(0)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -27,6 +28,22 @@ func (m *CommentRepositoryMock) GetByEvent(ctx context.Context, eventID int) ([]
 	return args.Get(0).([]*models.Comment), args.Error(1)
 }
 
+func (m *CommentRepositoryMock) ListTopLevelByEvent(ctx context.Context, eventID int, params *query.QueryParams) ([]*models.Comment, *query.PaginatedList, error) {
+	args := m.Called(ctx, eventID, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.Comment), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
+
+func (m *CommentRepositoryMock) GetThread(ctx context.Context, id int) (*models.Comment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Comment), args.Error(1)
+}
+
 func (m *CommentRepositoryMock) Get(ctx context.Context, id int) (*models.Comment, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -35,6 +52,35 @@ func (m *CommentRepositoryMock) Get(ctx context.Context, id int) (*models.Commen
 	return args.Get(0).(*models.Comment), args.Error(1)
 }
 
+func (m *CommentRepositoryMock) ListForModeration(ctx context.Context, eventID int, status string, params *query.QueryParams) ([]*models.Comment, *query.PaginatedList, error) {
+	args := m.Called(ctx, eventID, status, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.Comment), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
+
+func (m *CommentRepositoryMock) SetStatus(ctx context.Context, id int, status string) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *CommentRepositoryMock) Update(ctx context.Context, id int, content string, status string, mentions []models.CommentMention, editorID int) (*models.Comment, error) {
+	args := m.Called(ctx, id, content, status, mentions, editorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Comment), args.Error(1)
+}
+
+func (m *CommentRepositoryMock) GetRevisions(ctx context.Context, commentID int) ([]*models.CommentRevision, error) {
+	args := m.Called(ctx, commentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CommentRevision), args.Error(1)
+}
+
 func (m *CommentRepositoryMock) Delete(ctx context.Context, id int) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
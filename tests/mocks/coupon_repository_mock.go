@@ -0,0 +1,55 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/stretchr/testify/mock"
+)
+
+type CouponRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *CouponRepositoryMock) Insert(ctx context.Context, coupon *models.Coupon, productIDs, categoryIDs []int) (*models.Coupon, error) {
+	args := m.Called(ctx, coupon, productIDs, categoryIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *CouponRepositoryMock) Get(ctx context.Context, id int) (*models.Coupon, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *CouponRepositoryMock) GetByCode(ctx context.Context, code string) (*models.Coupon, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *CouponRepositoryMock) List(ctx context.Context, params *query.QueryParams) ([]*models.Coupon, *query.PaginatedList, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.Coupon), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
+
+func (m *CouponRepositoryMock) Deactivate(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *CouponRepositoryMock) CountUserRedemptions(ctx context.Context, couponID, userID int) (int64, error) {
+	args := m.Called(ctx, couponID, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
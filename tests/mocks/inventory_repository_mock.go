@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/stretchr/testify/mock"
+)
+
+type InventoryRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *InventoryRepositoryMock) Adjust(ctx context.Context, adjustment *models.InventoryAdjustment) error {
+	args := m.Called(ctx, adjustment)
+	return args.Error(0)
+}
+
+func (m *InventoryRepositoryMock) Reconcile(ctx context.Context, productID int, actorID *int) ([]models.InventoryAdjustment, error) {
+	args := m.Called(ctx, productID, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.InventoryAdjustment), args.Error(1)
+}
+
+func (m *InventoryRepositoryMock) List(ctx context.Context, productID int, params *query.QueryParams) ([]*models.InventoryAdjustment, *query.PaginatedList, error) {
+	args := m.Called(ctx, productID, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.InventoryAdjustment), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
+
+func (m *InventoryRepositoryMock) ListByOrder(ctx context.Context, orderID int) ([]models.InventoryAdjustment, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.InventoryAdjustment), args.Error(1)
+}
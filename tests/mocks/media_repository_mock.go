@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MediaRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MediaRepositoryMock) Insert(ctx context.Context, media *models.Media) (*models.Media, error) {
+	args := m.Called(ctx, media)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Media), args.Error(1)
+}
+
+func (m *MediaRepositoryMock) Get(ctx context.Context, id int) (*models.Media, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Media), args.Error(1)
+}
+
+func (m *MediaRepositoryMock) GetByHash(ctx context.Context, hash string) (*models.Media, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Media), args.Error(1)
+}
+
+func (m *MediaRepositoryMock) GetVariant(ctx context.Context, mediaID int, name string) (*models.MediaVariant, error) {
+	args := m.Called(ctx, mediaID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MediaVariant), args.Error(1)
+}
+
+func (m *MediaRepositoryMock) SaveVariant(ctx context.Context, variant *models.MediaVariant) error {
+	args := m.Called(ctx, variant)
+	return args.Error(0)
+}
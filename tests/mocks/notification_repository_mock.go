@@ -0,0 +1,65 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/stretchr/testify/mock"
+)
+
+type NotificationRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *NotificationRepositoryMock) Insert(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
+	args := m.Called(ctx, notification)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Notification), args.Error(1)
+}
+
+func (m *NotificationRepositoryMock) ListByUser(ctx context.Context, userID int, unreadOnly bool, params *query.QueryParams) ([]*models.Notification, *query.PaginatedList, error) {
+	args := m.Called(ctx, userID, unreadOnly, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.Notification), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
+
+func (m *NotificationRepositoryMock) MarkRead(ctx context.Context, userID, id int) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *NotificationRepositoryMock) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *NotificationRepositoryMock) Mute(ctx context.Context, userID, eventID int) error {
+	args := m.Called(ctx, userID, eventID)
+	return args.Error(0)
+}
+
+func (m *NotificationRepositoryMock) Unmute(ctx context.Context, userID, eventID int) error {
+	args := m.Called(ctx, userID, eventID)
+	return args.Error(0)
+}
+
+func (m *NotificationRepositoryMock) MentionedUserIDs(ctx context.Context, commentID int) ([]int, error) {
+	args := m.Called(ctx, commentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *NotificationRepositoryMock) MutedUserIDs(ctx context.Context, eventID int, userIDs []int) ([]int, error) {
+	args := m.Called(ctx, eventID, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/pricing"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/stretchr/testify/mock"
+)
+
+type OrderRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *OrderRepositoryMock) Checkout(ctx context.Context, userID int, quote pricing.QuoteFunc) (*models.Order, error) {
+	args := m.Called(ctx, userID, quote)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) Get(ctx context.Context, id int) (*models.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) ListBySeller(ctx context.Context, sellerID int, params *query.QueryParams) ([]*models.Order, *query.PaginatedList, error) {
+	args := m.Called(ctx, sellerID, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.Order), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
+
+func (m *OrderRepositoryMock) Transition(ctx context.Context, id int, status string, actorID *int, note string) (*models.Order, error) {
+	args := m.Called(ctx, id, status, actorID, note)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Order), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) HasPurchased(ctx context.Context, userID, productID int) (bool, error) {
+	args := m.Called(ctx, userID, productID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *OrderRepositoryMock) ListByUser(ctx context.Context, userID int, params *query.QueryParams) ([]*models.Order, *query.PaginatedList, error) {
+	args := m.Called(ctx, userID, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.Order), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
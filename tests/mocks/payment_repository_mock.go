@@ -0,0 +1,60 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type PaymentRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *PaymentRepositoryMock) Insert(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	args := m.Called(ctx, payment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) GetByIntent(ctx context.Context, provider, intentID string) (*models.Payment, error) {
+	args := m.Called(ctx, provider, intentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) ListByOrder(ctx context.Context, orderID int) ([]*models.Payment, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Payment), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) ListPendingRefunds(ctx context.Context, before time.Time) ([]*models.Payment, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Payment), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) UpdateStatus(ctx context.Context, id int, status, errMsg string) error {
+	args := m.Called(ctx, id, status, errMsg)
+	return args.Error(0)
+}
+
+func (m *PaymentRepositoryMock) HasEvent(ctx context.Context, provider, eventID string) (bool, error) {
+	args := m.Called(ctx, provider, eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *PaymentRepositoryMock) RecordEvent(ctx context.Context, event *models.PaymentEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
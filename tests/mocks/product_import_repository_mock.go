@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type ProductImportRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ProductImportRepositoryMock) Insert(ctx context.Context, job *models.ProductImport) (*models.ProductImport, error) {
+	args := m.Called(ctx, job)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductImport), args.Error(1)
+}
+
+func (m *ProductImportRepositoryMock) Get(ctx context.Context, id int) (*models.ProductImport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductImport), args.Error(1)
+}
+
+func (m *ProductImportRepositoryMock) Update(ctx context.Context, job *models.ProductImport) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
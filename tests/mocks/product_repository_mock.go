@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/alireza-akbarzadeh/ginflow/internal/models"
 	"github.com/alireza-akbarzadeh/ginflow/internal/query"
@@ -39,6 +40,14 @@ func (m *ProductRepositoryMock) ListWithAdvancedPagination(ctx context.Context,
 	return args.Get(0).([]models.Product), args.Get(1).(*query.PaginatedList), args.Error(2)
 }
 
+func (m *ProductRepositoryMock) Facets(ctx context.Context, req *query.QueryParams) (*models.ProductFacets, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductFacets), args.Error(1)
+}
+
 func (m *ProductRepositoryMock) Get(ctx context.Context, id int) (*models.Product, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -52,6 +61,14 @@ func (m *ProductRepositoryMock) Update(ctx context.Context, product *models.Prod
 	return args.Error(0)
 }
 
+func (m *ProductRepositoryMock) UpsertBySKU(ctx context.Context, product *models.Product, categorySlugs []string) (*models.Product, error) {
+	args := m.Called(ctx, product, categorySlugs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
 func (m *ProductRepositoryMock) Delete(ctx context.Context, id int) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -81,6 +98,64 @@ func (m *ProductRepositoryMock) GetByCategory(ctx context.Context, categoryID in
 	return args.Get(0).([]models.Product), args.Error(1)
 }
 
+func (m *ProductRepositoryMock) AddViews(ctx context.Context, views []models.ProductView) error {
+	args := m.Called(ctx, views)
+	return args.Error(0)
+}
+
+func (m *ProductRepositoryMock) Trending(ctx context.Context, now time.Time, halfLife time.Duration, saleWeight, limit int) ([]models.Product, error) {
+	args := m.Called(ctx, now, halfLife, saleWeight, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) Related(ctx context.Context, product *models.Product, limit int) ([]models.Product, error) {
+	args := m.Called(ctx, product, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) PriceHistory(ctx context.Context, productID int) ([]models.ProductPriceChange, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductPriceChange), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) PriceSchedules(ctx context.Context, productID int) ([]models.ProductPriceSchedule, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductPriceSchedule), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) CreatePriceSchedule(ctx context.Context, schedule *models.ProductPriceSchedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *ProductRepositoryMock) CancelPriceSchedule(ctx context.Context, productID, scheduleID int, actorID *int) (*models.ProductPriceSchedule, error) {
+	args := m.Called(ctx, productID, scheduleID, actorID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductPriceSchedule), args.Error(1)
+}
+
+func (m *ProductRepositoryMock) ApplyPriceSchedules(ctx context.Context, now time.Time) ([]models.ProductRepricing, error) {
+	args := m.Called(ctx, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductRepricing), args.Error(1)
+}
+
 func (m *ProductRepositoryMock) GetByCategoryID(ctx context.Context, categoryID int) ([]*models.Product, error) {
 	args := m.Called(ctx, categoryID)
 	if args.Get(0) == nil {
@@ -8,9 +8,20 @@ package mocks
 // - attendee_repository_mock.go  - AttendeeRepositoryMock
 // - category_repository_mock.go  - CategoryRepositoryMock
 // - comment_repository_mock.go   - CommentRepositoryMock
+// - comment_report_repository_mock.go - CommentReportRepositoryMock
+// - notification_repository_mock.go   - NotificationRepositoryMock
 // - profile_repository_mock.go   - ProfileRepositoryMock
 // - product_repository_mock.go   - ProductRepositoryMock
 // - basket_repository_mock.go    - BasketRepositoryMock
+// - order_repository_mock.go     - OrderRepositoryMock
+// - payment_repository_mock.go   - PaymentRepositoryMock
+// - coupon_repository_mock.go    - CouponRepositoryMock
+// - reservation_repository_mock.go - ReservationRepositoryMock
+// - wishlist_repository_mock.go  - WishlistRepositoryMock
+// - review_repository_mock.go    - ReviewRepositoryMock
+// - product_import_repository_mock.go - ProductImportRepositoryMock
+// - media_repository_mock.go     - MediaRepositoryMock
+// - inventory_repository_mock.go - InventoryRepositoryMock
 //
 // All mocks implement their respective repository interfaces from
 // the internal/repository/interfaces package.
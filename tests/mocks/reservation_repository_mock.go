@@ -0,0 +1,17 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ReservationRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ReservationRepositoryMock) ReleaseExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
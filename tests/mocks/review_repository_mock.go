@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/stretchr/testify/mock"
+)
+
+type ReviewRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ReviewRepositoryMock) Insert(ctx context.Context, review *models.Review) (*models.Review, error) {
+	args := m.Called(ctx, review)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Review), args.Error(1)
+}
+
+func (m *ReviewRepositoryMock) Get(ctx context.Context, id int) (*models.Review, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Review), args.Error(1)
+}
+
+func (m *ReviewRepositoryMock) ListByProduct(ctx context.Context, productID int, params *query.QueryParams) ([]*models.Review, *query.PaginatedList, error) {
+	args := m.Called(ctx, productID, params)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]*models.Review), args.Get(1).(*query.PaginatedList), args.Error(2)
+}
+
+func (m *ReviewRepositoryMock) Update(ctx context.Context, review *models.Review) error {
+	args := m.Called(ctx, review)
+	return args.Error(0)
+}
+
+func (m *ReviewRepositoryMock) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
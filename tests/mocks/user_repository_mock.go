@@ -36,6 +36,14 @@ func (m *UserRepositoryMock) GetByEmail(ctx context.Context, email string) (*mod
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *UserRepositoryMock) FindByMentions(ctx context.Context, names []string, emails []string) ([]*models.User, error) {
+	args := m.Called(ctx, names, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
 func (m *UserRepositoryMock) GetById(ctx context.Context, id int) (*models.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
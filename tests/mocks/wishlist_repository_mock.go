@@ -0,0 +1,80 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type WishlistRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *WishlistRepositoryMock) Insert(ctx context.Context, wishlist *models.Wishlist) (*models.Wishlist, error) {
+	args := m.Called(ctx, wishlist)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wishlist), args.Error(1)
+}
+
+func (m *WishlistRepositoryMock) Get(ctx context.Context, id int) (*models.Wishlist, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wishlist), args.Error(1)
+}
+
+func (m *WishlistRepositoryMock) GetByShareToken(ctx context.Context, token string) (*models.Wishlist, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wishlist), args.Error(1)
+}
+
+func (m *WishlistRepositoryMock) ListByUser(ctx context.Context, userID int) ([]*models.Wishlist, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Wishlist), args.Error(1)
+}
+
+func (m *WishlistRepositoryMock) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *WishlistRepositoryMock) SetShareToken(ctx context.Context, id int, token *string) error {
+	args := m.Called(ctx, id, token)
+	return args.Error(0)
+}
+
+func (m *WishlistRepositoryMock) AddItem(ctx context.Context, wishlistID, productID int) error {
+	args := m.Called(ctx, wishlistID, productID)
+	return args.Error(0)
+}
+
+func (m *WishlistRepositoryMock) RemoveItem(ctx context.Context, wishlistID, productID int) error {
+	args := m.Called(ctx, wishlistID, productID)
+	return args.Error(0)
+}
+
+func (m *WishlistRepositoryMock) SaveForLater(ctx context.Context, userID, basketID, itemID int) (*models.Wishlist, error) {
+	args := m.Called(ctx, userID, basketID, itemID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Wishlist), args.Error(1)
+}
+
+func (m *WishlistRepositoryMock) WatcherIDs(ctx context.Context, productID int) ([]int, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/notifications"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordingSender records notification deliveries by user
+type recordingSender struct {
+	sent map[int][]notifications.Channel
+}
+
+func (s *recordingSender) Send(ctx context.Context, channel notifications.Channel, n *models.Notification) error {
+	s.sent[n.UserID] = append(s.sent[n.UserID], channel)
+	return nil
+}
+
+// TestCommentMentions tests mention resolution and mention notifications
+func TestCommentMentions(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	authorID := 1
+	aliceID := 2
+	bobID := 3
+	carolID := 4
+	authorToken, _ := ts.GenerateToken(authorID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, authorID).Return(&models.User{ID: authorID, Name: "Author", Email: "author@example.com"}, nil)
+
+	eventID := 1
+	event := &models.Event{ID: eventID, Name: "Mention Event", Description: "Event with mentions", Date: "2025-12-31", Location: "Mention Location"}
+
+	mockEventRepo := ts.Mocks.Events.(*mocks.EventRepositoryMock)
+	mockCommentRepo := ts.Mocks.Comments.(*mocks.CommentRepositoryMock)
+	mockNotificationRepo := ts.Mocks.Notifications.(*mocks.NotificationRepositoryMock)
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+
+	sender := &recordingSender{sent: make(map[int][]notifications.Channel)}
+	ts.Handler.Notifier = sender
+
+	t.Run("mentions are resolved and notified", func(t *testing.T) {
+		content := "Hi @alice, @bob@example.com and @carol"
+		users := []*models.User{
+			{ID: aliceID, Name: "Alice", Email: "alice@example.com"},
+			{ID: bobID, Name: "Bob", Email: "bob@example.com"},
+			{ID: carolID, Name: "Carol", Email: "carol@example.com"},
+		}
+
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockUserRepo.On("FindByMentions", mock.Anything, []string{"alice", "carol"}, []string{"bob@example.com"}).Return(users, nil).Once()
+		created := &models.Comment{}
+		mockCommentRepo.On("Insert", mock.Anything, mock.MatchedBy(func(c *models.Comment) bool {
+			return len(c.Mentions) == 3
+		})).Run(func(args mock.Arguments) {
+			c := args.Get(1).(*models.Comment)
+			c.ID = 1
+			*created = *c
+		}).Return(created, nil).Once()
+
+		// Carol muted the event; Bob only wants push notifications; Alice has no profile
+		mockNotificationRepo.On("MutedUserIDs", mock.Anything, eventID, []int{aliceID, bobID, carolID}).Return([]int{carolID}, nil).Once()
+		notification := &models.Notification{}
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.Type == models.NotificationTypeMention && *n.ActorID == authorID && *n.CommentID == 1
+		})).Run(func(args mock.Arguments) {
+			*notification = *args.Get(1).(*models.Notification)
+		}).Return(notification, nil).Twice()
+		mockProfileRepo.On("GetByUserID", mock.Anything, aliceID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found")).Once()
+		mockProfileRepo.On("GetByUserID", mock.Anything, bobID).Return(&models.Profile{UserID: bobID, PushNotifications: true}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/events/"+strconv.Itoa(eventID)+"/comments", authorToken, models.Comment{Content: content})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var resp models.Comment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		if assert.Len(t, resp.Mentions, 3) {
+			assert.Equal(t, models.CommentMention{UserID: aliceID, Start: 3, End: 9, Text: "@alice"}, resp.Mentions[0])
+		}
+
+		assert.ElementsMatch(t, []notifications.Channel{notifications.ChannelEmail, notifications.ChannelPush}, sender.sent[aliceID])
+		assert.Equal(t, []notifications.Channel{notifications.ChannelPush}, sender.sent[bobID])
+		assert.Empty(t, sender.sent[carolID])
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("re-approved comment notifies only users not notified before", func(t *testing.T) {
+		adminID := 9
+		adminToken, _ := ts.GenerateToken(adminID)
+		mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+
+		// Alice was notified before the comment was reported and held; Bob was
+		// mentioned by an edit made while it was held
+		commentID := 5
+		comment := &models.Comment{ID: commentID, EventID: eventID, UserID: authorID, Content: "Hi @alice and @bob", Status: models.CommentStatusPending,
+			Mentions: []models.CommentMention{{UserID: aliceID}, {UserID: bobID}}}
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockCommentRepo.On("Get", mock.Anything, commentID).Return(comment, nil).Once()
+		mockCommentRepo.On("SetStatus", mock.Anything, commentID, models.CommentStatusApproved).Return(nil).Once()
+		mockReportRepo := ts.Mocks.CommentReports.(*mocks.CommentReportRepositoryMock)
+		mockReportRepo.On("ResolveByComment", mock.Anything, commentID, adminID).Return(nil).Once()
+
+		mockNotificationRepo.On("MentionedUserIDs", mock.Anything, commentID).Return([]int{aliceID}, nil).Once()
+		mockNotificationRepo.On("MutedUserIDs", mock.Anything, eventID, []int{bobID}).Return([]int{}, nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == bobID && n.Type == models.NotificationTypeMention && *n.CommentID == commentID
+		})).Return(&models.Notification{ID: 3, UserID: bobID}, nil).Once()
+		mockProfileRepo.On("GetByUserID", mock.Anything, bobID).Return(&models.Profile{UserID: bobID, PushNotifications: true}, nil).Once()
+		delete(sender.sent, aliceID)
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/events/"+strconv.Itoa(eventID)+"/comments/"+strconv.Itoa(commentID)+"/moderate", adminToken, map[string]string{"action": "approve"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, sender.sent[aliceID])
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("mute event mentions", func(t *testing.T) {
+		mockEventRepo.On("Get", mock.Anything, eventID).Return(event, nil).Once()
+		mockNotificationRepo.On("Mute", mock.Anything, authorID, eventID).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/events/"+strconv.Itoa(eventID)+"/mentions/mute", authorToken, nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("unmute event mentions", func(t *testing.T) {
+		mockNotificationRepo.On("Unmute", mock.Anything, authorID, eventID).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/events/"+strconv.Itoa(eventID)+"/mentions/mute", authorToken, nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+// TestNotifications tests listing and reading notifications
+func TestNotifications(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "reader@example.com"}, nil)
+
+	mockNotificationRepo := ts.Mocks.Notifications.(*mocks.NotificationRepositoryMock)
+
+	t.Run("list unread notifications", func(t *testing.T) {
+		list := []*models.Notification{{ID: 1, UserID: userID, Type: models.NotificationTypeMention, Message: "Author mentioned you in a comment"}}
+
+		mockNotificationRepo.On("ListByUser", mock.Anything, userID, true, mock.Anything).
+			Return(list, &query.PaginatedList{Success: true, Data: list}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/notifications?unread=true", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("mark notification read", func(t *testing.T) {
+		mockNotificationRepo.On("MarkRead", mock.Anything, userID, 1).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/notifications/1/read", token, nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("mark another user's notification read", func(t *testing.T) {
+		mockNotificationRepo.On("MarkRead", mock.Anything, userID, 99).Return(appErrors.New(appErrors.ErrNotFound, "notification with ID 99 not found")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/notifications/99/read", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("mark all notifications read", func(t *testing.T) {
+		mockNotificationRepo.On("MarkAllRead", mock.Anything, userID).Return(int64(3), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/notifications/read-all", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]int64
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, int64(3), resp["updated"])
+	})
+}
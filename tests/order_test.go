@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestOrderCheckout tests basket checkout and order history
+func TestOrderCheckout(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	buyerID := 1
+	otherID := 2
+	buyerToken, _ := ts.GenerateToken(buyerID)
+	otherToken, _ := ts.GenerateToken(otherID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, buyerID).Return(&models.User{ID: buyerID, Email: "buyer@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, otherID).Return(&models.User{ID: otherID, Email: "other@example.com"}, nil)
+
+	mockOrderRepo := ts.Mocks.Orders.(*mocks.OrderRepositoryMock)
+	mockPaymentRepo := ts.Mocks.Payments.(*mocks.PaymentRepositoryMock)
+	mockInventoryRepo := ts.Mocks.Inventory.(*mocks.InventoryRepositoryMock)
+	mockNotificationRepo := ts.Mocks.Notifications.(*mocks.NotificationRepositoryMock)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, mock.Anything).
+		Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	basketID := 7
+	order := &models.Order{
+		ID:       1,
+		UserID:   buyerID,
+		BasketID: &basketID,
+		Status:   models.OrderStatusPending,
+		Total:    money.New(2500, "USD"),
+		Items: []models.OrderItem{
+			{ID: 1, OrderID: 1, ProductID: 3, ProductName: "Mug", Quantity: 2, UnitPrice: money.New(1000, "USD"), Subtotal: money.New(2000, "USD")},
+			{ID: 2, OrderID: 1, ProductID: 4, ProductName: "Sticker", Quantity: 1, UnitPrice: money.New(500, "USD"), Subtotal: money.New(500, "USD")},
+		},
+	}
+
+	t.Run("checkout places order", func(t *testing.T) {
+		mockOrderRepo.On("Checkout", mock.Anything, buyerID, mock.Anything).Return(order, nil).Once()
+		// The order took the mug to its low-stock threshold
+		mug := &models.Product{ID: 3, UserID: 9, Name: "Mug", Stock: 2, LowStockThreshold: 2}
+		mockInventoryRepo.On("ListByOrder", mock.Anything, order.ID).Return([]models.InventoryAdjustment{
+			{ID: 1, ProductID: 3, Product: mug, Delta: -2, Reason: models.InventoryReasonSale, StockAfter: 2, OrderID: &order.ID, LowStock: true},
+			{ID: 2, ProductID: 4, Product: &models.Product{ID: 4, UserID: 9, Name: "Sticker", Stock: 40}, Delta: -1, Reason: models.InventoryReasonSale, StockAfter: 40, OrderID: &order.ID},
+		}, nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == 9 && n.Type == models.NotificationTypeLowStock && *n.ProductID == 3 && n.Message == "Mug is low on stock: 2 left"
+		})).Return(&models.Notification{ID: 1, UserID: 9}, nil).Once()
+		mockPaymentRepo.On("Insert", mock.Anything, mock.MatchedBy(func(p *models.Payment) bool {
+			return p.OrderID == order.ID && p.Amount == money.New(2500, "USD") && p.Status == models.PaymentStatusPending && p.IntentID != ""
+		})).Return(&models.Payment{ID: 1, OrderID: order.ID, Provider: "fake", IntentID: "pi_fake_1_1", Amount: money.New(2500, "USD"), Currency: "USD", Status: models.PaymentStatusPending}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/checkout", buyerToken, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var resp models.Order
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, models.OrderStatusPending, resp.Status)
+		assert.Len(t, resp.Items, 2)
+		assert.Equal(t, money.New(2500, "USD"), resp.Total)
+		if assert.Len(t, resp.Payments, 1) {
+			assert.Equal(t, "pi_fake_1_1", resp.Payments[0].IntentID)
+			assert.NotEmpty(t, resp.Payments[0].ClientSecret)
+		}
+	})
+
+	t.Run("checkout with insufficient stock", func(t *testing.T) {
+		mockOrderRepo.On("Checkout", mock.Anything, otherID, mock.Anything).
+			Return(nil, appErrors.New(appErrors.ErrConflict, "insufficient stock for Mug").WithDetail("productId", 3)).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/checkout", otherToken, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("checkout requires authentication", func(t *testing.T) {
+		w := ts.createRequest("POST", "/api/v1/basket/checkout", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("list order history", func(t *testing.T) {
+		orders := []*models.Order{order}
+		mockOrderRepo.On("ListByUser", mock.Anything, buyerID, mock.Anything).
+			Return(orders, &query.PaginatedList{Success: true, Data: orders}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/orders", buyerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("buyer gets order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, order.ID).Return(order, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/orders/1", buyerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("other users cannot see order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, order.ID).Return(order, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/orders/1", otherToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestOrderStatusTransitions tests who may move an order between statuses
+func TestOrderStatusTransitions(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	buyerID := 1
+	sellerID := 2
+	strangerID := 3
+	adminID := 4
+	buyerToken, _ := ts.GenerateToken(buyerID)
+	sellerToken, _ := ts.GenerateToken(sellerID)
+	strangerToken, _ := ts.GenerateToken(strangerID)
+	adminToken, _ := ts.GenerateToken(adminID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, buyerID).Return(&models.User{ID: buyerID, Email: "buyer@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, sellerID).Return(&models.User{ID: sellerID, Email: "seller@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, strangerID).Return(&models.User{ID: strangerID, Email: "stranger@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+
+	mockOrderRepo := ts.Mocks.Orders.(*mocks.OrderRepositoryMock)
+	// The mug was in stock before orders returned theirs, so nobody is notified
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 3).Return(&models.Product{ID: 3, Name: "Mug", Price: money.New(1000, "USD"), Currency: "USD", Stock: 5, Available: 5}, nil)
+
+	orderID := 1
+	order := func(status string) *models.Order {
+		return &models.Order{
+			ID:     orderID,
+			UserID: buyerID,
+			Status: status,
+			Items:  []models.OrderItem{{ID: 1, OrderID: orderID, ProductID: 3, SellerID: sellerID, ProductName: "Mug", Quantity: 1, UnitPrice: money.New(1000, "USD"), Subtotal: money.New(1000, "USD")}},
+		}
+	}
+	statusURL := "/api/v1/orders/1/status"
+
+	t.Run("seller ships order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusProcessing), nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusShipped, &sellerID, "Tracking 123").
+			Return(order(models.OrderStatusShipped), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, sellerToken, map[string]string{"status": "shipped", "note": "Tracking 123"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Order
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, models.OrderStatusShipped, resp.Status)
+	})
+
+	t.Run("buyer cancels order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusPending), nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusCancelled, &buyerID, "").
+			Return(order(models.OrderStatusCancelled), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, buyerToken, map[string]string{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("buyer cannot ship order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusProcessing), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, buyerToken, map[string]string{"status": "shipped"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("seller cannot refund order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusDelivered), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, sellerToken, map[string]string{"status": "refunded"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("admin refunds order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusDelivered), nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusRefunded, &adminID, "").
+			Return(order(models.OrderStatusRefunded), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, adminToken, map[string]string{"status": "refunded"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid transition is a conflict", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusShipped), nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusCancelled, &sellerID, "").
+			Return(nil, appErrors.New(appErrors.ErrConflict, "order cannot move from shipped to cancelled")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, sellerToken, map[string]string{"status": "cancelled"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("unknown status is rejected", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", statusURL, adminToken, map[string]string{"status": "lost"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("strangers cannot see order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusPending), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, strangerToken, map[string]string{"status": "cancelled"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("seller lists orders", func(t *testing.T) {
+		orders := []*models.Order{order(models.OrderStatusPaid)}
+		mockOrderRepo.On("ListBySeller", mock.Anything, sellerID, mock.Anything).
+			Return(orders, &query.PaginatedList{Success: true, Data: orders}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/seller/orders", sellerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("seller views order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(order(models.OrderStatusPaid), nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/orders/1", sellerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	// An order with items of two sellers
+	sharedOrder := func(status string) *models.Order {
+		shared := order(status)
+		shared.Currency = "USD"
+		shared.Items = append(shared.Items, models.OrderItem{ID: 2, OrderID: orderID, ProductID: 5, SellerID: strangerID, ProductName: "Lamp", Quantity: 1, UnitPrice: money.New(4000, "USD"), Subtotal: money.New(4000, "USD")})
+		shared.Total = money.New(5000, "USD")
+		return shared
+	}
+
+	t.Run("seller cannot ship order with other sellers' items", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(sharedOrder(models.OrderStatusProcessing), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", statusURL, sellerToken, map[string]string{"status": "shipped"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("seller sees only own items", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(sharedOrder(models.OrderStatusPaid), nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/orders/1", sellerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Order
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		if assert.Len(t, resp.Items, 1) {
+			assert.Equal(t, "Mug", resp.Items[0].ProductName)
+		}
+		assert.Equal(t, int64(1000), resp.Total.Amount)
+	})
+
+	t.Run("buyer sees all items", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(sharedOrder(models.OrderStatusPaid), nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/orders/1", buyerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Order
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Items, 2)
+	})
+}
@@ -0,0 +1,302 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/payments"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// sendWebhook posts a raw webhook payload with its signature
+func (ts *TestSuite) sendWebhook(payload []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/v1/payments/webhook", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handlers.PaymentSignatureHeader, signature)
+
+	w := httptest.NewRecorder()
+	ts.Router.ServeHTTP(w, req)
+	return w
+}
+
+// TestPaymentWebhooks tests signed, idempotent payment webhooks
+func TestPaymentWebhooks(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+	provider := payments.NewFakeProvider("webhook-secret")
+	ts.Handler.Payments = provider
+
+	mockOrderRepo := ts.Mocks.Orders.(*mocks.OrderRepositoryMock)
+	mockPaymentRepo := ts.Mocks.Payments.(*mocks.PaymentRepositoryMock)
+
+	orderID := 5
+	payment := &models.Payment{ID: 9, OrderID: orderID, Provider: "fake", IntentID: "pi_fake_5_1", Amount: money.New(3000, "USD"), Currency: "USD", Status: models.PaymentStatusPending}
+	pendingOrder := &models.Order{ID: orderID, UserID: 1, Status: models.OrderStatusPending, Total: money.New(3000, "USD")}
+	paidOrder := &models.Order{ID: orderID, UserID: 1, Status: models.OrderStatusPaid, Total: money.New(3000, "USD")}
+	noActor := (*int)(nil)
+
+	t.Run("rejects invalid signature", func(t *testing.T) {
+		payload, _ := provider.Webhook(payments.WebhookEvent{ID: "evt_1", Type: payments.EventPaymentSucceeded, IntentID: payment.IntentID})
+
+		w := ts.sendWebhook(payload, "deadbeef")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("successful payment marks order paid", func(t *testing.T) {
+		payload, signature := provider.Webhook(payments.WebhookEvent{ID: "evt_2", Type: payments.EventPaymentSucceeded, IntentID: payment.IntentID, Amount: money.New(3000, "USD")})
+
+		mockPaymentRepo.On("HasEvent", mock.Anything, "fake", "evt_2").Return(false, nil).Once()
+		mockPaymentRepo.On("GetByIntent", mock.Anything, "fake", payment.IntentID).Return(payment, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, payment.ID, models.PaymentStatusSucceeded, "").Return(nil).Once()
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(pendingOrder, nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusPaid, noActor, mock.Anything).Return(paidOrder, nil).Once()
+		mockPaymentRepo.On("RecordEvent", mock.Anything, mock.MatchedBy(func(e *models.PaymentEvent) bool {
+			return e.EventID == "evt_2" && e.PaymentID != nil && *e.PaymentID == payment.ID
+		})).Return(nil).Once()
+
+		w := ts.sendWebhook(payload, signature)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]string
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "processed", resp["status"])
+	})
+
+	t.Run("redelivered event is ignored", func(t *testing.T) {
+		payload, signature := provider.Webhook(payments.WebhookEvent{ID: "evt_2", Type: payments.EventPaymentSucceeded, IntentID: payment.IntentID, Amount: money.New(3000, "USD")})
+
+		mockPaymentRepo.On("HasEvent", mock.Anything, "fake", "evt_2").Return(true, nil).Once()
+
+		w := ts.sendWebhook(payload, signature)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]string
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "duplicate", resp["status"])
+	})
+
+	t.Run("second success event for paid order is a no-op", func(t *testing.T) {
+		succeeded := *payment
+		succeeded.Status = models.PaymentStatusSucceeded
+		payload, signature := provider.Webhook(payments.WebhookEvent{ID: "evt_3", Type: payments.EventPaymentSucceeded, IntentID: payment.IntentID})
+
+		mockPaymentRepo.On("HasEvent", mock.Anything, "fake", "evt_3").Return(false, nil).Once()
+		mockPaymentRepo.On("GetByIntent", mock.Anything, "fake", payment.IntentID).Return(&succeeded, nil).Once()
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(paidOrder, nil).Once()
+		mockPaymentRepo.On("RecordEvent", mock.Anything, mock.Anything).Return(nil).Once()
+
+		w := ts.sendWebhook(payload, signature)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockOrderRepo.AssertNumberOfCalls(t, "Transition", 1)
+	})
+
+	t.Run("failed payment is recorded", func(t *testing.T) {
+		payload, signature := provider.Webhook(payments.WebhookEvent{ID: "evt_4", Type: payments.EventPaymentFailed, IntentID: payment.IntentID, Reason: "card declined"})
+
+		mockPaymentRepo.On("HasEvent", mock.Anything, "fake", "evt_4").Return(false, nil).Once()
+		mockPaymentRepo.On("GetByIntent", mock.Anything, "fake", payment.IntentID).Return(payment, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, payment.ID, models.PaymentStatusFailed, "card declined").Return(nil).Once()
+		mockPaymentRepo.On("RecordEvent", mock.Anything, mock.Anything).Return(nil).Once()
+
+		w := ts.sendWebhook(payload, signature)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	cancelledOrder := &models.Order{ID: orderID, UserID: 1, Status: models.OrderStatusCancelled, Total: money.New(3000, "USD")}
+
+	t.Run("authorization for cancelled order is cancelled, not captured", func(t *testing.T) {
+		authorized := *payment
+		authorized.IntentID = "pi_fake_5_7"
+		payload, signature := provider.Webhook(payments.WebhookEvent{ID: "evt_6", Type: payments.EventPaymentAuthorized, IntentID: authorized.IntentID})
+
+		mockPaymentRepo.On("HasEvent", mock.Anything, "fake", "evt_6").Return(false, nil).Once()
+		mockPaymentRepo.On("GetByIntent", mock.Anything, "fake", authorized.IntentID).Return(&authorized, nil).Once()
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(cancelledOrder, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, payment.ID, models.PaymentStatusCancelled, "order is cancelled").Return(nil).Once()
+		mockPaymentRepo.On("RecordEvent", mock.Anything, mock.Anything).Return(nil).Once()
+
+		w := ts.sendWebhook(payload, signature)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// The intent was cancelled, so it can no longer be captured
+		_, err := provider.Capture(t.Context(), authorized.IntentID)
+		assert.Error(t, err)
+	})
+
+	t.Run("payment captured for cancelled order is refunded", func(t *testing.T) {
+		payload, signature := provider.Webhook(payments.WebhookEvent{ID: "evt_7", Type: payments.EventPaymentSucceeded, IntentID: payment.IntentID})
+
+		mockPaymentRepo.On("HasEvent", mock.Anything, "fake", "evt_7").Return(false, nil).Once()
+		mockPaymentRepo.On("GetByIntent", mock.Anything, "fake", payment.IntentID).Return(payment, nil).Once()
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(cancelledOrder, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, payment.ID, models.PaymentStatusRefunded, "order is cancelled").Return(nil).Once()
+		mockPaymentRepo.On("RecordEvent", mock.Anything, mock.Anything).Return(nil).Once()
+
+		w := ts.sendWebhook(payload, signature)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockOrderRepo.AssertNumberOfCalls(t, "Transition", 1)
+	})
+
+	t.Run("unknown intent is acknowledged", func(t *testing.T) {
+		payload, signature := provider.Webhook(payments.WebhookEvent{ID: "evt_5", Type: payments.EventPaymentSucceeded, IntentID: "pi_unknown"})
+
+		mockPaymentRepo.On("HasEvent", mock.Anything, "fake", "evt_5").Return(false, nil).Once()
+		mockPaymentRepo.On("GetByIntent", mock.Anything, "fake", "pi_unknown").
+			Return(nil, appErrors.New(appErrors.ErrNotFound, "payment not found")).Once()
+
+		w := ts.sendWebhook(payload, signature)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	mockPaymentRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestOrderPayments tests payment retries and refunds
+func TestOrderPayments(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	buyerID := 1
+	otherID := 2
+	adminID := 3
+	buyerToken, _ := ts.GenerateToken(buyerID)
+	otherToken, _ := ts.GenerateToken(otherID)
+	adminToken, _ := ts.GenerateToken(adminID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, buyerID).Return(&models.User{ID: buyerID, Email: "buyer@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, otherID).Return(&models.User{ID: otherID, Email: "other@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+
+	mockOrderRepo := ts.Mocks.Orders.(*mocks.OrderRepositoryMock)
+	mockPaymentRepo := ts.Mocks.Payments.(*mocks.PaymentRepositoryMock)
+
+	orderID := 5
+	pendingOrder := &models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusPending, Total: money.New(3000, "USD")}
+
+	t.Run("buyer retries payment", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(pendingOrder, nil).Once()
+		mockPaymentRepo.On("Insert", mock.Anything, mock.MatchedBy(func(p *models.Payment) bool {
+			return p.OrderID == orderID && p.Amount == money.New(3000, "USD") && p.Currency == "USD"
+		})).Return(&models.Payment{ID: 2, OrderID: orderID, IntentID: "pi_fake_5_1", Status: models.PaymentStatusPending}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/5/pay", buyerToken, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var resp models.Payment
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "pi_fake_5_1_secret", resp.ClientSecret)
+	})
+
+	t.Run("other user cannot pay order", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).Return(pendingOrder, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/5/pay", otherToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("paid order cannot be paid again", func(t *testing.T) {
+		mockOrderRepo.On("Get", mock.Anything, orderID).
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusPaid}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/5/pay", buyerToken, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("refunding order refunds captured payments", func(t *testing.T) {
+		attempts := []models.Payment{
+			{ID: 1, OrderID: orderID, IntentID: "pi_fake_5_0", Amount: money.New(3000, "USD"), Status: models.PaymentStatusFailed},
+			{ID: 2, OrderID: orderID, IntentID: "pi_fake_5_1", Amount: money.New(3000, "USD"), Status: models.PaymentStatusSucceeded},
+		}
+		mockOrderRepo.On("Get", mock.Anything, orderID).
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusPaid, Payments: attempts}, nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusRefunded, &adminID, "").
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusRefunded, Payments: attempts}, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, 2, models.PaymentStatusRefundPending, "").Return(nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, 2, models.PaymentStatusRefunded, "").Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/5/status", adminToken, map[string]string{"status": "refunded"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Order
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		if assert.Len(t, resp.Payments, 2) {
+			assert.Equal(t, models.PaymentStatusRefunded, resp.Payments[1].Status)
+		}
+	})
+
+	t.Run("cancelling paid order refunds captured payments", func(t *testing.T) {
+		attempts := []models.Payment{
+			{ID: 3, OrderID: orderID, IntentID: "pi_fake_5_2", Amount: money.New(3000, "USD"), Status: models.PaymentStatusSucceeded},
+		}
+		mockOrderRepo.On("Get", mock.Anything, orderID).
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusPaid, Payments: attempts}, nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusCancelled, &buyerID, "").
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusCancelled, Payments: attempts}, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, 3, models.PaymentStatusRefundPending, "").Return(nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, 3, models.PaymentStatusRefunded, "").Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/5/status", buyerToken, map[string]string{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("failed transition refunds nothing", func(t *testing.T) {
+		attempts := []models.Payment{
+			{ID: 4, OrderID: orderID, IntentID: "pi_fake_5_3", Amount: money.New(3000, "USD"), Status: models.PaymentStatusSucceeded},
+		}
+		// The order was shipped since it was loaded
+		mockOrderRepo.On("Get", mock.Anything, orderID).
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusPaid, Payments: attempts}, nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusCancelled, &buyerID, "").
+			Return(nil, appErrors.New(appErrors.ErrConflict, "order cannot move from shipped to cancelled")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/5/status", buyerToken, map[string]string{"status": "cancelled"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		mockPaymentRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, 4, mock.Anything, mock.Anything)
+	})
+
+	t.Run("refund the provider fails stays pending", func(t *testing.T) {
+		// The fake provider rejects refunds of nothing
+		attempts := []models.Payment{
+			{ID: 5, OrderID: orderID, IntentID: "pi_fake_5_4", Amount: money.Zero("USD"), Status: models.PaymentStatusSucceeded},
+		}
+		mockOrderRepo.On("Get", mock.Anything, orderID).
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusPaid, Payments: attempts}, nil).Once()
+		mockOrderRepo.On("Transition", mock.Anything, orderID, models.OrderStatusRefunded, &adminID, "").
+			Return(&models.Order{ID: orderID, UserID: buyerID, Status: models.OrderStatusRefunded, Payments: attempts}, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, 5, models.PaymentStatusRefundPending, "").Return(nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, 5, models.PaymentStatusRefundPending, "refund amount must be positive").Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/5/status", adminToken, map[string]string{"status": "refunded"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.Order
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		if assert.Len(t, resp.Payments, 1) {
+			assert.Equal(t, models.PaymentStatusRefundPending, resp.Payments[0].Status)
+		}
+	})
+
+	t.Run("pending refunds are retried", func(t *testing.T) {
+		before := time.Now()
+		mockPaymentRepo.On("ListPendingRefunds", mock.Anything, before).Return([]*models.Payment{
+			{ID: 6, OrderID: orderID, IntentID: "pi_fake_5_5", Amount: money.New(3000, "USD"), Status: models.PaymentStatusRefundPending},
+		}, nil).Once()
+		mockPaymentRepo.On("UpdateStatus", mock.Anything, 6, models.PaymentStatusRefunded, "").Return(nil).Once()
+
+		assert.NoError(t, ts.Handler.RetryRefunds(context.Background(), before))
+	})
+
+	mockPaymentRepo.AssertExpectations(t)
+	mockOrderRepo.AssertExpectations(t)
+}
@@ -0,0 +1,264 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadProducts posts a product file to the import endpoint
+func uploadProducts(ts *TestSuite, token, path, contentType, file string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(file))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	ts.Router.ServeHTTP(w, req)
+	return w
+}
+
+// TestProductImport tests importing products from CSV and NDJSON files in the background
+func TestProductImport(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID, otherID := 1, 2
+	token, _ := ts.GenerateToken(userID)
+	otherToken, _ := ts.GenerateToken(otherID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "catalogue@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, otherID).Return(&models.User{ID: otherID, Email: "other@example.com"}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockImportRepo := ts.Mocks.ProductImports.(*mocks.ProductImportRepositoryMock)
+
+	// finished receives a copy of each job once it is done
+	finished := make(chan models.ProductImport, 1)
+	mockImportRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.ProductImport")).
+		Run(func(args mock.Arguments) {
+			job := args.Get(1).(*models.ProductImport)
+			if job.FinishedAt != nil {
+				finished <- *job
+			}
+		}).Return(nil)
+	expectImport := func(id int, format string) {
+		mockImportRepo.On("Insert", mock.Anything, mock.MatchedBy(func(job *models.ProductImport) bool {
+			return job.UserID == userID && job.Format == format && job.Status == models.ImportStatusPending
+		})).Return(&models.ProductImport{ID: id, UserID: userID, Format: format, Status: models.ImportStatusPending}, nil).Once()
+	}
+	awaitImport := func(t *testing.T) models.ProductImport {
+		select {
+		case job := <-finished:
+			return job
+		case <-time.After(5 * time.Second):
+			t.Fatal("the import did not finish")
+			return models.ProductImport{}
+		}
+	}
+
+	t.Run("imports CSV rows and reports the ones that fail", func(t *testing.T) {
+		expectImport(1, "csv")
+
+		var lamp *models.Product
+		mockProductRepo.On("UpsertBySKU", mock.Anything, mock.MatchedBy(func(p *models.Product) bool { return p.SKU == "LAMP-1" }), []string{"lighting", "home"}).
+			Run(func(args mock.Arguments) { lamp = args.Get(1).(*models.Product) }).
+			Return(nil, nil).Once()
+		mockProductRepo.On("UpsertBySKU", mock.Anything, mock.MatchedBy(func(p *models.Product) bool { return p.SKU == "CHAIR-1" }), []string(nil)).
+			Return(&models.Product{ID: 2, SKU: "CHAIR-1", Price: money.New(12000, "USD"), Currency: "USD", Stock: 2, Available: 2}, nil).Once()
+		mockProductRepo.On("UpsertBySKU", mock.Anything, mock.MatchedBy(func(p *models.Product) bool { return p.SKU == "DESK-1" }), []string{"offices"}).
+			Return(nil, appErrors.New(appErrors.ErrNotFound, `category "offices" not found`)).Once()
+		mockProductRepo.On("UpsertBySKU", mock.Anything, mock.MatchedBy(func(p *models.Product) bool { return p.SKU == "SHELF-1" }), []string(nil)).
+			Return(nil, errors.New("connection reset")).Once()
+
+		file := "sku,name,price,discount,stock,categories\n" +
+			"LAMP-1,Desk Lamp,19.99,5,4,lighting|home\n" +
+			"CHAIR-1,Oak Chair,120,,2,\n" +
+			"SOFA-1,Sofa,free,,1,\n" +
+			"DESK-1,Standing Desk,300,,1,offices\n" +
+			"STOOL-1,Stool,10\n" +
+			"SHELF-1,Shelf,40,,3,\n"
+
+		w := uploadProducts(ts, token, "/api/v1/products/import", "text/csv", file)
+		require.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"pending"`)
+
+		job := awaitImport(t)
+		assert.Equal(t, models.ImportStatusCompleted, job.Status)
+		assert.Equal(t, 6, job.Rows)
+		assert.Equal(t, 1, job.Created)
+		assert.Equal(t, 1, job.Updated)
+		assert.Equal(t, 4, job.Failed)
+		require.Len(t, job.Errors, 4)
+		assert.Equal(t, models.ImportRowError{Line: 4, SKU: "SOFA-1", Error: job.Errors[0].Error}, job.Errors[0])
+		assert.Contains(t, job.Errors[0].Error, "price")
+		assert.Equal(t, models.ImportRowError{Line: 5, SKU: "DESK-1", Error: `category "offices" not found`}, job.Errors[1])
+		assert.Equal(t, 6, job.Errors[2].Line)
+		assert.Equal(t, models.ImportRowError{Line: 7, SKU: "SHELF-1", Error: "failed to save the product"}, job.Errors[3])
+
+		require.NotNil(t, lamp)
+		assert.Equal(t, userID, lamp.UserID)
+		assert.Equal(t, "desk-lamp", lamp.Slug)
+		assert.Equal(t, money.New(1999, "USD"), lamp.Price)
+		assert.Equal(t, money.New(1499, "USD"), lamp.FinalPrice)
+		assert.Equal(t, 4, lamp.Stock)
+	})
+
+	t.Run("imports NDJSON", func(t *testing.T) {
+		expectImport(2, "ndjson")
+		mockProductRepo.On("UpsertBySKU", mock.Anything, mock.MatchedBy(func(p *models.Product) bool {
+			return p.SKU == "MUG-1" && p.Price == money.New(850, "EUR")
+		}), []string{"kitchen"}).Return(nil, nil).Once()
+
+		file := `{"sku":"MUG-1","name":"Mug","price":8.5,"currency":"EUR","stock":10,"categories":["kitchen"]}` + "\n"
+		w := uploadProducts(ts, token, "/api/v1/products/import?format=ndjson", "application/octet-stream", file)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		job := awaitImport(t)
+		assert.Equal(t, models.ImportStatusCompleted, job.Status)
+		assert.Equal(t, 1, job.Created)
+		assert.Empty(t, job.Errors)
+	})
+
+	t.Run("imports lowering a price notify wishlist watchers", func(t *testing.T) {
+		watcherID := 3
+		mockWishlistRepo := ts.Mocks.Wishlists.(*mocks.WishlistRepositoryMock)
+		mockWishlistRepo.On("WatcherIDs", mock.Anything, 4).Return([]int{watcherID}, nil).Once()
+		mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+		mockProfileRepo.On("GetByUserID", mock.Anything, watcherID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+		mockNotificationRepo := ts.Mocks.Notifications.(*mocks.NotificationRepositoryMock)
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == watcherID && n.Type == models.NotificationTypePriceDrop && *n.ProductID == 4 &&
+				n.Message == "Desk Lamp dropped in price from $19.99 to $14.99"
+		})).Return(&models.Notification{ID: 1, UserID: watcherID}, nil).Once()
+
+		expectImport(3, "csv")
+		// The repository sets the ID and availability of updated products
+		mockProductRepo.On("UpsertBySKU", mock.Anything, mock.MatchedBy(func(p *models.Product) bool { return p.SKU == "LAMP-1" }), []string(nil)).
+			Run(func(args mock.Arguments) {
+				product := args.Get(1).(*models.Product)
+				product.ID, product.Available = 4, product.Stock
+			}).
+			Return(&models.Product{ID: 4, SKU: "LAMP-1", Price: money.New(1999, "USD"), Currency: "USD", Stock: 4, Available: 4}, nil).Once()
+
+		w := uploadProducts(ts, token, "/api/v1/products/import", "text/csv", "sku,name,price,discount,stock\nLAMP-1,Desk Lamp,19.99,5,4\n")
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		job := awaitImport(t)
+		assert.Equal(t, 1, job.Updated)
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown formats and bad headers up front", func(t *testing.T) {
+		w := uploadProducts(ts, token, "/api/v1/products/import", "application/json", `[]`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = uploadProducts(ts, token, "/api/v1/products/import", "text/csv", "sku,title,price\n")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `unknown column \"title\"`)
+	})
+
+	t.Run("rejects files over the size limit", func(t *testing.T) {
+		ts.Handler.ProductImportMaxSize = 16
+		defer func() { ts.Handler.ProductImportMaxSize = 50 << 20 }()
+
+		w := uploadProducts(ts, token, "/api/v1/products/import", "text/csv", "sku,name,price\nLAMP-1,Desk Lamp,19.99\n")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("imports are visible to their owner only", func(t *testing.T) {
+		mockImportRepo.On("Get", mock.Anything, 1).Return(&models.ProductImport{ID: 1, UserID: userID, Status: models.ImportStatusRunning}, nil).Twice()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/products/imports/1", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"running"`)
+
+		w = ts.createAuthenticatedRequest("GET", "/api/v1/products/imports/1", otherToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	mockImportRepo.AssertExpectations(t)
+	mockProductRepo.AssertExpectations(t)
+}
+
+// TestProductExport tests streaming the filtered product list as CSV and NDJSON
+func TestProductExport(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "catalogue@example.com"}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	products := []models.Product{
+		{ID: 1, SKU: "LAMP-1", Name: "Desk Lamp", Slug: "desk-lamp", Price: money.New(1999, "USD"), Currency: "USD", Stock: 4,
+			Categories: []models.Category{{Slug: "lighting"}, {Slug: "home"}}},
+		{ID: 2, SKU: "CHAIR-1", Name: "Chair, oak", Slug: "chair-oak", Price: money.New(12000, "USD"), Currency: "USD", Stock: 2},
+	}
+
+	t.Run("streams every page as CSV", func(t *testing.T) {
+		page := func(n int) any {
+			return mock.MatchedBy(func(p *query.QueryParams) bool {
+				return p.Page == n && p.PageSize == 100 && !p.IncludeTotal && p.Search == "lamp"
+			})
+		}
+		full := make([]models.Product, 100)
+		for i := range full {
+			full[i] = products[0]
+		}
+		mockProductRepo.On("ListWithAdvancedPagination", mock.Anything, page(1)).Return(full, &query.PaginatedList{}, nil).Once()
+		mockProductRepo.On("ListWithAdvancedPagination", mock.Anything, page(2)).Return(products[1:], &query.PaginatedList{}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/products/export?search=lamp&page=7", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "products.csv")
+
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		require.Len(t, lines, 102)
+		assert.True(t, strings.HasPrefix(lines[0], "sku,name,slug,description,price"))
+		assert.Equal(t, "LAMP-1,Desk Lamp,desk-lamp,,19.99,,USD,4,,,,,,lighting|home,,,,", lines[1])
+		assert.Equal(t, `CHAIR-1,"Chair, oak",chair-oak,,120.00,,USD,2,,,,,,,,,,`, lines[101])
+	})
+
+	t.Run("streams NDJSON", func(t *testing.T) {
+		mockProductRepo.On("ListWithAdvancedPagination", mock.Anything, mock.Anything).Return(products, &query.PaginatedList{}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/products/export?format=ndjson", token, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"sku":"LAMP-1"`)
+		assert.Contains(t, lines[0], `"price":"19.99"`)
+		assert.Contains(t, lines[0], `"categories":["lighting","home"]`)
+	})
+
+	t.Run("reports failures before streaming", func(t *testing.T) {
+		mockProductRepo.On("ListWithAdvancedPagination", mock.Anything, mock.Anything).Return(nil, nil, errors.New("db down")).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/products/export", token, nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/products/export?format=xlsx", token, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockProductRepo.AssertExpectations(t)
+}
@@ -0,0 +1,527 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestProductPrices tests money prices on product creation
+func TestProductPrices(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	sellerID := 1
+	sellerToken, _ := ts.GenerateToken(sellerID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, sellerID).Return(&models.User{ID: sellerID, Email: "seller@example.com"}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+
+	product := func(price any) map[string]any {
+		return map[string]any{"name": "Coffee Mug", "sku": "MUG-1", "stock": 5, "price": price}
+	}
+
+	t.Run("price without currency uses the default", func(t *testing.T) {
+		var created models.Product
+		mockProductRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Product")).
+			Run(func(args mock.Arguments) { created = *args.Get(1).(*models.Product) }).
+			Return(&created, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, product(map[string]any{"amount": 1999}))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "USD", created.Currency)
+		assert.Equal(t, money.New(1999, "USD"), created.Price)
+		assert.Equal(t, money.Zero("USD"), created.Discount)
+		assert.Equal(t, money.New(1999, "USD"), created.FinalPrice)
+
+		var resp map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, map[string]any{"amount": 1999.0, "currency": "USD", "formatted": "$19.99"}, resp["price"])
+	})
+
+	t.Run("price in another currency", func(t *testing.T) {
+		var created models.Product
+		mockProductRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Product")).
+			Run(func(args mock.Arguments) { created = *args.Get(1).(*models.Product) }).
+			Return(&created, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, product(map[string]any{"amount": 1500, "currency": "jpy"}))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "JPY", created.Currency)
+		assert.Equal(t, "¥1500", created.Price.Format())
+	})
+
+	t.Run("final price is derived from price and discount", func(t *testing.T) {
+		var created models.Product
+		mockProductRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Product")).
+			Run(func(args mock.Arguments) { created = *args.Get(1).(*models.Product) }).
+			Return(&created, nil).Once()
+
+		body := product(map[string]any{"amount": 1999})
+		body["discount"] = map[string]any{"amount": 500}
+		body["finalPrice"] = map[string]any{"amount": 1}
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, body)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, money.New(1499, "USD"), created.FinalPrice)
+	})
+
+	t.Run("discount above price is rejected", func(t *testing.T) {
+		body := product(map[string]any{"amount": 1999})
+		body["discount"] = map[string]any{"amount": 2000}
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("float price is rejected", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, product(19.99))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("mixed currencies are rejected", func(t *testing.T) {
+		body := product(map[string]any{"amount": 1999, "currency": "USD"})
+		body["discount"] = map[string]any{"amount": 100, "currency": "EUR"}
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("zero price is rejected", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, product(map[string]any{"amount": 0}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// TestProductVariants tests products sold in variants
+func TestProductVariants(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	sellerID := 1
+	sellerToken, _ := ts.GenerateToken(sellerID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, sellerID).Return(&models.User{ID: sellerID, Email: "seller@example.com"}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+
+	tshirt := func() map[string]any {
+		return map[string]any{
+			"name": "T-Shirt", "sku": "TEE", "stock": 1,
+			"price":    map[string]any{"amount": 1500},
+			"discount": map[string]any{"amount": 200},
+			"options":  []map[string]any{{"name": "size", "values": []string{"S", "M"}}},
+			"variants": []map[string]any{
+				{"id": 7, "sku": "TEE-S", "options": map[string]string{"size": "S"}, "stock": 3},
+				{"sku": "TEE-M", "options": map[string]string{"size": "M"}, "stock": 4, "price": map[string]any{"amount": 1800}},
+			},
+		}
+	}
+
+	t.Run("variants are created with the product", func(t *testing.T) {
+		var created models.Product
+		mockProductRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Product")).
+			Run(func(args mock.Arguments) { created = *args.Get(1).(*models.Product) }).
+			Return(&created, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, tshirt())
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 7, created.Stock)
+		assert.Len(t, created.Variants, 2)
+		assert.Zero(t, created.Variants[0].ID)
+		assert.Equal(t, money.New(1800, "USD"), *created.Variants[1].Price)
+	})
+
+	t.Run("variants must match the options", func(t *testing.T) {
+		body := tshirt()
+		body["variants"] = []map[string]any{{"sku": "TEE-L", "options": map[string]string{"size": "L"}, "stock": 1}}
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `value \"L\" not offered`)
+	})
+
+	t.Run("variant prices cannot be below the discount", func(t *testing.T) {
+		body := tshirt()
+		body["variants"].([]map[string]any)[1]["price"] = map[string]any{"amount": 100}
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products", sellerToken, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("product detail filters variants by option", func(t *testing.T) {
+		mockProductRepo.On("Get", mock.Anything, 5).Return(&models.Product{
+			ID: 5, Name: "T-Shirt", Price: money.New(1500, "USD"), Currency: "USD",
+			Options: []models.ProductOption{{Name: "size", Values: []string{"S", "M"}}},
+			Variants: []models.ProductVariant{
+				{ID: 7, SKU: "TEE-S", Options: map[string]string{"size": "S"}},
+				{ID: 8, SKU: "TEE-M", Options: map[string]string{"size": "M"}},
+			},
+		}, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/5?option[size]=M", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var product models.Product
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+		if assert.Len(t, product.Variants, 1) {
+			assert.Equal(t, "TEE-M", product.Variants[0].SKU)
+		}
+	})
+}
+
+// TestProductFacets tests counting products by facet
+func TestProductFacets(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+
+	t.Run("facets take the list filters", func(t *testing.T) {
+		var params *query.QueryParams
+		facets := &models.ProductFacets{
+			Brands: []models.FacetCount{{Value: "Acme", Count: 3}},
+			Prices: []models.PriceBucket{models.NewPriceBucket(1)},
+		}
+		mockProductRepo.On("Facets", mock.Anything, mock.AnythingOfType("*query.QueryParams")).
+			Run(func(args mock.Arguments) { params = args.Get(1).(*query.QueryParams) }).
+			Return(facets, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/facets?search=mug&brand[in]=Acme&category[in]=2&category[in]=3", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "mug", params.Search)
+		assert.ElementsMatch(t, []query.Filter{
+			{Field: "brand", Operator: query.OpIn, Values: []interface{}{"Acme"}},
+			{Field: "category", Operator: query.OpIn, Values: []interface{}{"2", "3"}},
+		}, params.Filters)
+
+		var resp map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []any{map[string]any{"value": "Acme", "count": 3.0}}, resp["brands"])
+		assert.Equal(t, []any{map[string]any{"min": 1000.0, "max": 2500.0, "count": 0.0}}, resp["prices"])
+	})
+
+	t.Run("database errors", func(t *testing.T) {
+		mockProductRepo.On("Facets", mock.Anything, mock.Anything).
+			Return(nil, appErrors.New(appErrors.ErrDatabaseOperation, "failed to count product facets")).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/facets", nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+// TestProductViews tests counting product views
+func TestProductViews(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 4
+	token, _ := ts.GenerateToken(userID)
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "viewer@example.com"}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 1).Return(&models.Product{ID: 1, Name: "Desk Lamp"}, nil)
+	mockProductRepo.On("GetBySlug", mock.Anything, "desk-lamp").Return(&models.Product{ID: 1, Name: "Desk Lamp"}, nil)
+	mockProductRepo.On("Get", mock.Anything, 2).Return(nil, nil)
+
+	// Views by the same IP or user count once per window
+	assert.Equal(t, http.StatusOK, ts.createRequest("GET", "/api/v1/products/1", nil).Code)
+	assert.Equal(t, http.StatusOK, ts.createRequest("GET", "/api/v1/products/slug/desk-lamp", nil).Code)
+	assert.Equal(t, http.StatusOK, ts.createAuthenticatedRequest("GET", "/api/v1/products/1", token, nil).Code)
+	assert.Equal(t, http.StatusOK, ts.createAuthenticatedRequest("GET", "/api/v1/products/1", token, nil).Code)
+	// Missing products are not counted
+	assert.Equal(t, http.StatusNotFound, ts.createRequest("GET", "/api/v1/products/2", nil).Code)
+	assert.Equal(t, 2, ts.Handler.Views.Pending())
+
+	// Counted views are written in one batch
+	var flushed []models.ProductView
+	mockProductRepo.On("AddViews", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { flushed = args.Get(1).([]models.ProductView) }).
+		Return(nil).Once()
+	assert.NoError(t, ts.Handler.Views.Flush(context.Background(), mockProductRepo, time.Now()))
+	if assert.Len(t, flushed, 1) {
+		assert.Equal(t, 1, flushed[0].ProductID)
+		assert.Equal(t, 2, flushed[0].Views)
+	}
+	assert.Zero(t, ts.Handler.Views.Pending())
+}
+
+// TestTrendingAndRelatedProducts tests listing trending and related products
+func TestTrendingAndRelatedProducts(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+
+	t.Run("trending products", func(t *testing.T) {
+		mockProductRepo.On("Trending", mock.Anything, mock.AnythingOfType("time.Time"), ts.Handler.TrendingHalfLife, ts.Handler.TrendingSaleWeight, 50).
+			Return([]models.Product{{ID: 3, Name: "Desk Lamp"}}, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/trending?limit=500", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		if assert.Len(t, resp, 1) {
+			assert.Equal(t, 3.0, resp[0]["id"])
+		}
+	})
+
+	t.Run("related products", func(t *testing.T) {
+		product := &models.Product{ID: 1, Tags: []string{"lighting"}}
+		mockProductRepo.On("Get", mock.Anything, 1).Return(product, nil).Once()
+		mockProductRepo.On("Related", mock.Anything, product, 10).
+			Return([]models.Product{{ID: 2}, {ID: 5}}, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/1/related", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 2)
+	})
+
+	t.Run("related products of a missing product", func(t *testing.T) {
+		mockProductRepo.On("Get", mock.Anything, 9).Return(nil, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/9/related", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// TestProductPriceSchedules tests price history and scheduled price changes
+func TestProductPriceSchedules(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	sellerID, otherID := 1, 2
+	sellerToken, _ := ts.GenerateToken(sellerID)
+	otherToken, _ := ts.GenerateToken(otherID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, sellerID).Return(&models.User{ID: sellerID, Email: "seller@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, otherID).Return(&models.User{ID: otherID, Email: "other@example.com"}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	product := &models.Product{
+		ID:       1,
+		UserID:   sellerID,
+		Currency: "USD",
+		Price:    money.New(2000, "USD"),
+		Variants: []models.ProductVariant{{SKU: "MUG-S", Price: &money.Money{Amount: 1200, Currency: "USD"}}},
+	}
+	mockProductRepo.On("Get", mock.Anything, 1).Return(product, nil)
+
+	startsAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	endsAt := startsAt.Add(48 * time.Hour)
+
+	t.Run("price history", func(t *testing.T) {
+		mockProductRepo.On("PriceHistory", mock.Anything, 1).Return([]models.ProductPriceChange{
+			{ID: 2, ProductID: 1, Price: money.New(2000, "USD"), Reason: models.PriceChangeUpdated},
+			{ID: 1, ProductID: 1, Price: money.New(2500, "USD"), Reason: models.PriceChangeCreated},
+		}, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/1/prices", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		if assert.Len(t, resp, 2) {
+			assert.Equal(t, "updated", resp[0]["reason"])
+		}
+	})
+
+	t.Run("sale is scheduled in the product's currency", func(t *testing.T) {
+		var created models.ProductPriceSchedule
+		mockProductRepo.On("CreatePriceSchedule", mock.Anything, mock.AnythingOfType("*models.ProductPriceSchedule")).
+			Run(func(args mock.Arguments) { created = *args.Get(1).(*models.ProductPriceSchedule) }).
+			Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/price-schedules", sellerToken, map[string]any{
+			"price":    map[string]any{"amount": 2000},
+			"discount": map[string]any{"amount": 500},
+			"startsAt": startsAt,
+			"endsAt":   endsAt,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 1, created.ProductID)
+		assert.Equal(t, sellerID, created.CreatedByID)
+		assert.Equal(t, money.New(500, "USD"), created.Discount)
+		assert.Equal(t, "USD", created.Currency)
+		assert.True(t, created.StartsAt.Equal(startsAt))
+	})
+
+	t.Run("invalid schedules are rejected", func(t *testing.T) {
+		for name, body := range map[string]map[string]any{
+			"discount above price":         {"price": map[string]any{"amount": 2000}, "discount": map[string]any{"amount": 2500}, "startsAt": startsAt},
+			"discount above variant price": {"price": map[string]any{"amount": 2000}, "discount": map[string]any{"amount": 1500}, "startsAt": startsAt},
+			"other currency":               {"price": map[string]any{"amount": 2000, "currency": "EUR"}, "startsAt": startsAt},
+			"end before start":             {"price": map[string]any{"amount": 2000}, "startsAt": endsAt, "endsAt": startsAt},
+			"missing start":                {"price": map[string]any{"amount": 2000}},
+		} {
+			w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/price-schedules", sellerToken, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+
+	t.Run("overlapping schedule conflicts", func(t *testing.T) {
+		mockProductRepo.On("CreatePriceSchedule", mock.Anything, mock.AnythingOfType("*models.ProductPriceSchedule")).
+			Return(appErrors.New(appErrors.ErrConflict, "the schedule overlaps another pending schedule of the product")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/price-schedules", sellerToken, map[string]any{
+			"price": map[string]any{"amount": 1800}, "startsAt": startsAt,
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("only the owner manages schedules", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/products/1/price-schedules", otherToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("schedule is cancelled", func(t *testing.T) {
+		mockProductRepo.On("CancelPriceSchedule", mock.Anything, 1, 7, &sellerID).
+			Return(&models.ProductPriceSchedule{ID: 7, ProductID: 1, Status: models.PriceScheduleCancelled}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/products/1/price-schedules/7", sellerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "cancelled", resp["status"])
+	})
+}
+
+// TestProductInventory tests the inventory ledger and low-stock alerts
+func TestProductInventory(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	sellerID, otherID := 1, 2
+	sellerToken, _ := ts.GenerateToken(sellerID)
+	otherToken, _ := ts.GenerateToken(otherID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, sellerID).Return(&models.User{ID: sellerID, Email: "seller@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, otherID).Return(&models.User{ID: otherID, Email: "other@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, mock.Anything).
+		Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 1).
+		Return(&models.Product{ID: 1, UserID: sellerID, Name: "Mug", Stock: 6, Available: 6, LowStockThreshold: 5}, nil)
+
+	mockInventoryRepo := ts.Mocks.Inventory.(*mocks.InventoryRepositoryMock)
+	mockNotificationRepo := ts.Mocks.Notifications.(*mocks.NotificationRepositoryMock)
+
+	t.Run("ledger is listed", func(t *testing.T) {
+		adjustments := []*models.InventoryAdjustment{
+			{ID: 2, ProductID: 1, Delta: -2, Reason: models.InventoryReasonSale, StockAfter: 6},
+			{ID: 1, ProductID: 1, Delta: 8, Reason: models.InventoryReasonInitial, StockAfter: 8},
+		}
+		mockInventoryRepo.On("List", mock.Anything, 1, mock.Anything).
+			Return(adjustments, &query.PaginatedList{Data: adjustments}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/products/1/inventory", sellerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("restock is recorded", func(t *testing.T) {
+		mockInventoryRepo.On("Adjust", mock.Anything, mock.MatchedBy(func(a *models.InventoryAdjustment) bool {
+			return a.ProductID == 1 && a.Delta == 10 && a.Reason == models.InventoryReasonRestock && *a.ActorID == sellerID
+		})).Run(func(args mock.Arguments) {
+			adjustment := args.Get(1).(*models.InventoryAdjustment)
+			adjustment.ID, adjustment.StockAfter = 3, 16
+		}).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/inventory", sellerToken, map[string]any{
+			"delta": 10, "reason": "restock", "note": "Delivery from the supplier",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, float64(16), resp["stockAfter"])
+	})
+
+	t.Run("restock of a sold-out product notifies wishlist watchers", func(t *testing.T) {
+		watcherID := 3
+		soldOut := &models.Product{ID: 2, UserID: sellerID, Name: "Lamp", Price: money.New(1500, "USD"), Currency: "USD"}
+		restocked := *soldOut
+		restocked.Stock, restocked.Available = 4, 4
+		mockProductRepo.On("Get", mock.Anything, 2).Return(soldOut, nil).Once()
+		mockInventoryRepo.On("Adjust", mock.Anything, mock.MatchedBy(func(a *models.InventoryAdjustment) bool {
+			return a.ProductID == 2 && a.Delta == 4 && a.Reason == models.InventoryReasonReturn
+		})).Return(nil).Once()
+		mockProductRepo.On("Get", mock.Anything, 2).Return(&restocked, nil).Once()
+		ts.Mocks.Wishlists.(*mocks.WishlistRepositoryMock).On("WatcherIDs", mock.Anything, 2).Return([]int{watcherID}, nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == watcherID && n.Type == models.NotificationTypeBackInStock && *n.ProductID == 2
+		})).Return(&models.Notification{ID: 2, UserID: watcherID}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/2/inventory", sellerToken, map[string]any{
+			"delta": 4, "reason": "return",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("correction to low stock notifies the seller", func(t *testing.T) {
+		mockInventoryRepo.On("Adjust", mock.Anything, mock.AnythingOfType("*models.InventoryAdjustment")).
+			Run(func(args mock.Arguments) {
+				adjustment := args.Get(1).(*models.InventoryAdjustment)
+				adjustment.ID, adjustment.StockAfter, adjustment.LowStock = 4, 4, true
+			}).Return(nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == sellerID && n.Type == models.NotificationTypeLowStock && n.Message == "Mug is low on stock: 4 left"
+		})).Return(&models.Notification{ID: 1, UserID: sellerID}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/inventory", sellerToken, map[string]any{
+			"delta": -2, "reason": "correction", "note": "Broken in the warehouse",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid adjustments are rejected", func(t *testing.T) {
+		for name, body := range map[string]map[string]any{
+			"negative restock": {"delta": -3, "reason": "restock"},
+			"sale":             {"delta": -1, "reason": "sale"},
+			"missing delta":    {"reason": "correction"},
+		} {
+			w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/inventory", sellerToken, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+
+	t.Run("stock cannot go negative", func(t *testing.T) {
+		mockInventoryRepo.On("Adjust", mock.Anything, mock.AnythingOfType("*models.InventoryAdjustment")).
+			Return(appErrors.New(appErrors.ErrConflict, "only 6 in stock")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/inventory", sellerToken, map[string]any{
+			"delta": -7, "reason": "correction",
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("ledger is reconciled", func(t *testing.T) {
+		mockInventoryRepo.On("Reconcile", mock.Anything, 1, &sellerID).Return([]models.InventoryAdjustment{
+			{ID: 5, ProductID: 1, Delta: 6, Reason: models.InventoryReasonCorrection, StockAfter: 6, Note: "Reconciled with the stock"},
+		}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/inventory/reconcile", sellerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+	})
+
+	t.Run("only the owner manages inventory", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/1/inventory", otherToken, map[string]any{
+			"delta": 5, "reason": "restock",
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/query"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProductReviews tests creating, editing, deleting and listing product reviews
+func TestProductReviews(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	authorID, otherID, adminID := 1, 2, 3
+	authorToken, _ := ts.GenerateToken(authorID)
+	otherToken, _ := ts.GenerateToken(otherID)
+	adminToken, _ := ts.GenerateToken(adminID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, authorID).Return(&models.User{ID: authorID, Email: "author@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, otherID).Return(&models.User{ID: otherID, Email: "other@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, adminID).Return(&models.User{ID: adminID, Email: "admin@example.com", Role: models.RoleAdmin}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 5).Return(&models.Product{ID: 5, Name: "Lamp"}, nil)
+	mockProductRepo.On("Get", mock.Anything, 6).Return(nil, nil)
+
+	mockOrderRepo := ts.Mocks.Orders.(*mocks.OrderRepositoryMock)
+	mockOrderRepo.On("HasPurchased", mock.Anything, authorID, 5).Return(true, nil)
+	mockOrderRepo.On("HasPurchased", mock.Anything, otherID, 5).Return(false, nil)
+
+	mockReviewRepo := ts.Mocks.Reviews.(*mocks.ReviewRepositoryMock)
+	review := func() *models.Review {
+		return &models.Review{ID: 9, ProductID: 5, UserID: authorID, Rating: 4, Title: "Bright"}
+	}
+
+	t.Run("buyers' reviews are verified purchases", func(t *testing.T) {
+		var created *models.Review
+		mockReviewRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Review")).
+			Run(func(args mock.Arguments) { created = args.Get(1).(*models.Review) }).
+			Return(review(), nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/5/reviews", authorToken, map[string]any{"rating": 4, "title": "Bright"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		require.NotNil(t, created)
+		assert.Equal(t, authorID, created.UserID)
+		assert.Equal(t, 4, created.Rating)
+		assert.True(t, created.VerifiedPurchase)
+	})
+
+	t.Run("other users' reviews are not", func(t *testing.T) {
+		var created *models.Review
+		mockReviewRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Review")).
+			Run(func(args mock.Arguments) { created = args.Get(1).(*models.Review) }).
+			Return(&models.Review{ID: 10, ProductID: 5, UserID: otherID, Rating: 2}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/5/reviews", otherToken, map[string]any{"rating": 2})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		require.NotNil(t, created)
+		assert.False(t, created.VerifiedPurchase)
+	})
+
+	t.Run("reviews can be limited to verified purchasers", func(t *testing.T) {
+		ts.Handler.ReviewsVerifiedOnly = true
+		defer func() { ts.Handler.ReviewsVerifiedOnly = false }()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/5/reviews", otherToken, map[string]any{"rating": 2})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("a second review of a product is rejected", func(t *testing.T) {
+		mockReviewRepo.On("Insert", mock.Anything, mock.AnythingOfType("*models.Review")).
+			Return(nil, appErrors.New(appErrors.ErrAlreadyExists, "you have already reviewed this product")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/5/reviews", authorToken, map[string]any{"rating": 5})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("ratings outside 1 to 5 are rejected", func(t *testing.T) {
+		for _, rating := range []int{0, 6} {
+			w := ts.createAuthenticatedRequest("POST", "/api/v1/products/5/reviews", authorToken, map[string]any{"rating": rating})
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("reviews of missing products are not found", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/products/6/reviews", authorToken, map[string]any{"rating": 3})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("authors edit their reviews", func(t *testing.T) {
+		mockReviewRepo.On("Get", mock.Anything, 9).Return(review(), nil).Once()
+		mockReviewRepo.On("Update", mock.Anything, mock.MatchedBy(func(r *models.Review) bool {
+			return r.ID == 9 && r.Rating == 2 && r.Body == "Flickers"
+		})).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", "/api/v1/products/5/reviews/9", authorToken, map[string]any{"rating": 2, "body": "Flickers"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("other users cannot edit a review", func(t *testing.T) {
+		mockReviewRepo.On("Get", mock.Anything, 9).Return(review(), nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", "/api/v1/products/5/reviews/9", otherToken, map[string]any{"rating": 1})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("reviews of other products are not found", func(t *testing.T) {
+		mockReviewRepo.On("Get", mock.Anything, 9).Return(review(), nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/products/7/reviews/9", authorToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("admins delete any review", func(t *testing.T) {
+		mockReviewRepo.On("Get", mock.Anything, 9).Return(review(), nil).Once()
+		mockReviewRepo.On("Delete", mock.Anything, 9).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/products/5/reviews/9", adminToken, nil)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("reviews are listed with pagination", func(t *testing.T) {
+		mockReviewRepo.On("ListByProduct", mock.Anything, 5, mock.MatchedBy(func(p *query.QueryParams) bool {
+			return p.Page == 2 && p.PageSize == 10
+		})).Return([]*models.Review{review()}, &query.PaginatedList{Data: []*models.Review{review()}}, nil).Once()
+
+		w := ts.createRequest("GET", "/api/v1/products/5/reviews?page=2&page_size=10", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"title":"Bright"`)
+	})
+
+	mockReviewRepo.AssertExpectations(t)
+}
@@ -42,14 +42,25 @@ func SetupMockTestSuite(t *testing.T) *TestSuite {
 
 	// Create mocks
 	mockRepos := &repository.Models{
-		Users:      &mocks.UserRepositoryMock{},
-		Events:     &mocks.EventRepositoryMock{},
-		Attendees:  &mocks.AttendeeRepositoryMock{},
-		Categories: &mocks.CategoryRepositoryMock{},
-		Comments:   &mocks.CommentRepositoryMock{},
-		Profiles:   &mocks.ProfileRepositoryMock{},
-		Products:   &mocks.ProductRepositoryMock{},
-		Baskets:    &mocks.BasketRepositoryMock{},
+		Users:          &mocks.UserRepositoryMock{},
+		Events:         &mocks.EventRepositoryMock{},
+		Attendees:      &mocks.AttendeeRepositoryMock{},
+		Categories:     &mocks.CategoryRepositoryMock{},
+		Comments:       &mocks.CommentRepositoryMock{},
+		CommentReports: &mocks.CommentReportRepositoryMock{},
+		Notifications:  &mocks.NotificationRepositoryMock{},
+		Profiles:       &mocks.ProfileRepositoryMock{},
+		Products:       &mocks.ProductRepositoryMock{},
+		Baskets:        &mocks.BasketRepositoryMock{},
+		Orders:         &mocks.OrderRepositoryMock{},
+		Payments:       &mocks.PaymentRepositoryMock{},
+		Coupons:        &mocks.CouponRepositoryMock{},
+		Reservations:   &mocks.ReservationRepositoryMock{},
+		Wishlists:      &mocks.WishlistRepositoryMock{},
+		Reviews:        &mocks.ReviewRepositoryMock{},
+		ProductImports: &mocks.ProductImportRepositoryMock{},
+		Media:          &mocks.MediaRepositoryMock{},
+		Inventory:      &mocks.InventoryRepositoryMock{},
 	}
 
 	// JWT secret for testing
@@ -103,6 +114,11 @@ func SetupTestSuite(t *testing.T) *TestSuite {
 		&models.Attendee{},
 		&models.Category{},
 		&models.Comment{},
+		&models.CommentRevision{},
+		&models.CommentReport{},
+		&models.CommentMention{},
+		&models.Notification{},
+		&models.NotificationMute{},
 	)
 	require.NoError(t, err)
 
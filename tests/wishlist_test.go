@@ -0,0 +1,282 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alireza-akbarzadeh/ginflow/internal/api/handlers"
+	appErrors "github.com/alireza-akbarzadeh/ginflow/internal/errors"
+	"github.com/alireza-akbarzadeh/ginflow/internal/models"
+	"github.com/alireza-akbarzadeh/ginflow/internal/money"
+	"github.com/alireza-akbarzadeh/ginflow/tests/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWishlists tests wishlist management and sharing
+func TestWishlists(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	ownerID := 1
+	otherID := 2
+	ownerToken, _ := ts.GenerateToken(ownerID)
+	otherToken, _ := ts.GenerateToken(otherID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, ownerID).Return(&models.User{ID: ownerID, Email: "owner@example.com"}, nil)
+	mockUserRepo.On("Get", mock.Anything, otherID).Return(&models.User{ID: otherID, Email: "other@example.com"}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 3).Return(&models.Product{ID: 3, Name: "Lamp", Price: money.New(1500, "USD"), Currency: "USD"}, nil)
+
+	wishlist := &models.Wishlist{ID: 7, UserID: ownerID, Name: "Birthday", Items: []models.WishlistItem{}}
+	mockWishlistRepo := ts.Mocks.Wishlists.(*mocks.WishlistRepositoryMock)
+	mockWishlistRepo.On("Get", mock.Anything, 7).Return(wishlist, nil)
+
+	t.Run("users create named wishlists", func(t *testing.T) {
+		mockWishlistRepo.On("Insert", mock.Anything, mock.MatchedBy(func(w *models.Wishlist) bool {
+			return w.UserID == ownerID && w.Name == "Birthday"
+		})).Return(wishlist, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/wishlists", ownerToken, handlers.CreateWishlistRequest{Name: " Birthday "})
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("duplicate names are rejected", func(t *testing.T) {
+		mockWishlistRepo.On("Insert", mock.Anything, mock.Anything).
+			Return(nil, appErrors.New(appErrors.ErrAlreadyExists, `wishlist "Birthday" already exists`)).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/wishlists", ownerToken, handlers.CreateWishlistRequest{Name: "Birthday"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("products are added to the owner's wishlist", func(t *testing.T) {
+		mockWishlistRepo.On("AddItem", mock.Anything, 7, 3).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/wishlists/7/items", ownerToken, handlers.AddWishlistItemRequest{ProductID: 3})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("other users' wishlists are not found", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("GET", "/api/v1/wishlists/7", otherToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = ts.createAuthenticatedRequest("POST", "/api/v1/wishlists/7/items", otherToken, handlers.AddWishlistItemRequest{ProductID: 3})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("shared wishlists are public", func(t *testing.T) {
+		var token string
+		mockWishlistRepo.On("SetShareToken", mock.Anything, 7, mock.AnythingOfType("*string")).
+			Run(func(args mock.Arguments) { token = *args.Get(2).(*string) }).
+			Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/wishlists/7/share", ownerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotEmpty(t, token)
+
+		var shared models.Wishlist
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shared))
+		assert.Equal(t, token, *shared.ShareToken)
+
+		mockWishlistRepo.On("GetByShareToken", mock.Anything, token).Return(wishlist, nil).Once()
+		w = ts.createRequest("GET", "/api/v1/wishlists/shared/"+token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		mockWishlistRepo.On("GetByShareToken", mock.Anything, "revoked").Return(nil, appErrors.New(appErrors.ErrNotFound, "wishlist not found")).Once()
+		w = ts.createRequest("GET", "/api/v1/wishlists/shared/revoked", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	mockWishlistRepo.AssertExpectations(t)
+}
+
+// TestWishlistBasketMoves tests moving products between wishlists and the basket
+func TestWishlistBasketMoves(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	userID := 1
+	token, _ := ts.GenerateToken(userID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, userID).Return(&models.User{ID: userID, Email: "buyer@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, userID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockProductRepo.On("Get", mock.Anything, 3).Return(&models.Product{ID: 3, Name: "Lamp", Price: money.New(1500, "USD"), Currency: "USD", Stock: 5, Available: 5}, nil)
+
+	mockBasketRepo := ts.Mocks.Baskets.(*mocks.BasketRepositoryMock)
+	mockBasketRepo.On("GetActiveBasket", mock.Anything, userID).Return(&models.Basket{ID: 10, UserID: &userID, Status: models.BasketStatusActive}, nil)
+
+	mockWishlistRepo := ts.Mocks.Wishlists.(*mocks.WishlistRepositoryMock)
+	mockWishlistRepo.On("Get", mock.Anything, 7).Return(&models.Wishlist{ID: 7, UserID: userID, Name: "Birthday", Items: []models.WishlistItem{
+		{ID: 1, WishlistID: 7, ProductID: 3},
+	}}, nil)
+
+	t.Run("wishlist products move to the basket", func(t *testing.T) {
+		mockBasketRepo.On("AddItem", mock.Anything, 10, mock.MatchedBy(func(item *models.BasketItem) bool {
+			return item.ProductID == 3 && item.Quantity == 2 && item.UnitPrice == money.New(1500, "USD")
+		}), mock.Anything).Return(nil).Once()
+		mockWishlistRepo.On("RemoveItem", mock.Anything, 7, 3).Return(nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/wishlists/7/items/3/move-to-basket", token, handlers.MoveToBasketRequest{Quantity: 2})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("products beyond available stock stay on the wishlist", func(t *testing.T) {
+		mockBasketRepo.On("AddItem", mock.Anything, 10, mock.Anything, mock.Anything).
+			Return(appErrors.New(appErrors.ErrConflict, "only 0 of Lamp available")).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/wishlists/7/items/3/move-to-basket", token, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("products not on the wishlist are not found", func(t *testing.T) {
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/wishlists/7/items/4/move-to-basket", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("basket items are saved for later", func(t *testing.T) {
+		mockWishlistRepo.On("SaveForLater", mock.Anything, userID, 10, 5).
+			Return(&models.Wishlist{ID: 8, UserID: userID, Name: models.SaveForLaterWishlist}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/basket/items/5/save-for-later", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("guests cannot save for later", func(t *testing.T) {
+		w := ts.createRequest("POST", "/api/v1/basket/items/5/save-for-later", nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	mockBasketRepo.AssertExpectations(t)
+	mockWishlistRepo.AssertExpectations(t)
+}
+
+// TestWishlistNotifications tests notifying watchers of price drops, scheduled
+// ones included, and restocks
+func TestWishlistNotifications(t *testing.T) {
+	ts := SetupMockTestSuite(t)
+
+	sellerID := 1
+	watcherID := 2
+	sellerToken, _ := ts.GenerateToken(sellerID)
+
+	mockUserRepo := ts.Mocks.Users.(*mocks.UserRepositoryMock)
+	mockUserRepo.On("Get", mock.Anything, sellerID).Return(&models.User{ID: sellerID, Email: "seller@example.com"}, nil)
+
+	mockProfileRepo := ts.Mocks.Profiles.(*mocks.ProfileRepositoryMock)
+	mockProfileRepo.On("GetByUserID", mock.Anything, watcherID).Return(nil, appErrors.New(appErrors.ErrNotFound, "profile not found"))
+
+	mockWishlistRepo := ts.Mocks.Wishlists.(*mocks.WishlistRepositoryMock)
+	mockWishlistRepo.On("WatcherIDs", mock.Anything, 3).Return([]int{watcherID}, nil)
+
+	mockProductRepo := ts.Mocks.Products.(*mocks.ProductRepositoryMock)
+	mockNotificationRepo := ts.Mocks.Notifications.(*mocks.NotificationRepositoryMock)
+
+	lamp := func(stock int) *models.Product {
+		return &models.Product{ID: 3, UserID: sellerID, Name: "Lamp", SKU: "LAMP-1", Price: money.New(1500, "USD"), FinalPrice: money.New(1500, "USD"), Currency: "USD", Stock: stock, Available: stock}
+	}
+	update := func(stock int, price int64) map[string]any {
+		return map[string]any{"name": "Lamp", "sku": "LAMP-1", "stock": stock, "price": map[string]any{"amount": price}}
+	}
+	// The repository recomputes availability on update
+	mockProductRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Product")).
+		Run(func(args mock.Arguments) {
+			product := args.Get(1).(*models.Product)
+			product.Available = product.Stock
+		}).Return(nil)
+
+	t.Run("price drops notify watchers", func(t *testing.T) {
+		mockProductRepo.On("Get", mock.Anything, 3).Return(lamp(5), nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == watcherID && n.Type == models.NotificationTypePriceDrop && *n.ProductID == 3 &&
+				n.Message == "Lamp dropped in price from $15.00 to $12.00"
+		})).Return(&models.Notification{ID: 1, UserID: watcherID}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", "/api/v1/products/3", sellerToken, update(5, 1200))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("restocks notify watchers", func(t *testing.T) {
+		mockProductRepo.On("Get", mock.Anything, 3).Return(lamp(0), nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == watcherID && n.Type == models.NotificationTypeBackInStock
+		})).Return(&models.Notification{ID: 2, UserID: watcherID}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", "/api/v1/products/3", sellerToken, update(4, 1500))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("other changes notify nobody", func(t *testing.T) {
+		mockProductRepo.On("Get", mock.Anything, 3).Return(lamp(5), nil).Once()
+
+		w := ts.createAuthenticatedRequest("PUT", "/api/v1/products/3", sellerToken, update(6, 1800))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("scheduled price drops notify watchers", func(t *testing.T) {
+		now := time.Now()
+		onSale := lamp(5)
+		onSale.Discount = money.New(300, "USD")
+		pricier := &models.Product{ID: 4, Name: "Desk", Price: money.New(9000, "USD"), Currency: "USD"}
+		mockProductRepo.On("ApplyPriceSchedules", mock.Anything, now).Return([]models.ProductRepricing{
+			{Product: *onSale, PreviousFinalPrice: money.New(1500, "USD")},
+			{Product: *pricier, PreviousFinalPrice: money.New(8000, "USD")},
+		}, nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == watcherID && n.Type == models.NotificationTypePriceDrop && *n.ProductID == 3 &&
+				n.Message == "Lamp dropped in price from $15.00 to $12.00"
+		})).Return(&models.Notification{ID: 3, UserID: watcherID}, nil).Once()
+
+		assert.NoError(t, ts.Handler.ApplyPriceSchedules(context.Background(), now))
+	})
+
+	t.Run("cancelling a price rise under way notifies watchers", func(t *testing.T) {
+		restored := lamp(5)
+		restored.Price = money.New(1200, "USD")
+		mockProductRepo.On("Get", mock.Anything, 3).Return(lamp(5), nil).Once()
+		mockProductRepo.On("CancelPriceSchedule", mock.Anything, 3, 9, &sellerID).Return(&models.ProductPriceSchedule{
+			ID: 9, ProductID: 3, Status: models.PriceScheduleCancelled, PreviousPrice: &money.Money{Amount: 1200, Currency: "USD"},
+		}, nil).Once()
+		mockProductRepo.On("Get", mock.Anything, 3).Return(restored, nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == watcherID && n.Type == models.NotificationTypePriceDrop &&
+				n.Message == "Lamp dropped in price from $15.00 to $12.00"
+		})).Return(&models.Notification{ID: 4, UserID: watcherID}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("DELETE", "/api/v1/products/3/price-schedules/9", sellerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("cancelled orders returning stock notify watchers", func(t *testing.T) {
+		buyerID := 5
+		buyerToken, _ := ts.GenerateToken(buyerID)
+		mockUserRepo.On("Get", mock.Anything, buyerID).Return(&models.User{ID: buyerID, Email: "buyer@example.com"}, nil)
+
+		mockOrderRepo := ts.Mocks.Orders.(*mocks.OrderRepositoryMock)
+		order := &models.Order{ID: 7, UserID: buyerID, Status: models.OrderStatusPending,
+			Items: []models.OrderItem{{ID: 1, OrderID: 7, ProductID: 3, SellerID: sellerID, ProductName: "Lamp", Quantity: 2, UnitPrice: money.New(1500, "USD")}}}
+		mockOrderRepo.On("Get", mock.Anything, 7).Return(order, nil).Once()
+		cancelled := *order
+		cancelled.Status = models.OrderStatusCancelled
+		mockOrderRepo.On("Transition", mock.Anything, 7, models.OrderStatusCancelled, &buyerID, "").Return(&cancelled, nil).Once()
+		// The two lamps returned were the only ones available
+		mockProductRepo.On("Get", mock.Anything, 3).Return(lamp(2), nil).Once()
+		mockNotificationRepo.On("Insert", mock.Anything, mock.MatchedBy(func(n *models.Notification) bool {
+			return n.UserID == watcherID && n.Type == models.NotificationTypeBackInStock && *n.ProductID == 3
+		})).Return(&models.Notification{ID: 5, UserID: watcherID}, nil).Once()
+
+		w := ts.createAuthenticatedRequest("POST", "/api/v1/orders/7/status", buyerToken, map[string]string{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	mockNotificationRepo.AssertExpectations(t)
+	mockProductRepo.AssertExpectations(t)
+}